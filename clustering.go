@@ -119,17 +119,12 @@ func (km *Kmeans) Assign(vectors []float32) ([]int64, error) {
 	assignments := make([]int64, n)
 
 	// Create a flat index with centroids to perform assignment
-	idx, err := NewIndexFlatL2(km.d)
+	idx, err := km.ToFlatIndex()
 	if err != nil {
-		return nil, fmt.Errorf("faiss: failed to create index for assignment: %w", err)
+		return nil, err
 	}
 	defer idx.Close()
 
-	// Add centroids to index
-	if err := idx.Add(km.centroids); err != nil {
-		return nil, fmt.Errorf("faiss: failed to add centroids: %w", err)
-	}
-
 	// Search for nearest centroid for each vector
 	_, labels, err := idx.Search(vectors, 1)
 	if err != nil {
@@ -140,5 +135,32 @@ func (km *Kmeans) Assign(vectors []float32) ([]int64, error) {
 	return assignments, nil
 }
 
+// ToFlatIndex returns an L2 flat index holding the centroids
+//
+// Label i of the index is centroid i, so Search with k=1 gives the same
+// nearest-centroid assignment as Assign, along with the squared distances.
+// Keeping the index avoids rebuilding it on every Assign call. The caller
+// owns the returned index and must Close it.
+//
+// Example:
+//   centroidIndex, _ := kmeans.ToFlatIndex()
+//   defer centroidIndex.Close()
+//   distances, clusters, _ := centroidIndex.Search(points, 1)
+func (km *Kmeans) ToFlatIndex() (*IndexFlat, error) {
+	if !km.isTrained {
+		return nil, fmt.Errorf("faiss: must train before building the centroid index")
+	}
+
+	idx, err := NewIndexFlatL2(km.d)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to create centroid index: %w", err)
+	}
+	if err := idx.Add(km.centroids); err != nil {
+		idx.Close()
+		return nil, fmt.Errorf("faiss: failed to add centroids: %w", err)
+	}
+	return idx, nil
+}
+
 // NOTE: Full Clustering API (faiss.Clustering) not exposed due to C binding complexity.
 // Use Kmeans which provides the core functionality via faiss_kmeans_clustering.
@@ -0,0 +1,187 @@
+//go:build gpu
+// +build gpu
+
+package faiss
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// GpuKmeans performs k-means clustering on a GPU
+//
+// It runs the same algorithm as Kmeans, with FAISS's default clustering
+// parameters and seed, but assigns points to centroids with a GPU flat
+// index, which is where k-means spends nearly all of its time. Centroids
+// match the CPU Kmeans up to floating point differences.
+//
+// Python equivalent: faiss.Kmeans(d, k, gpu=True)
+//
+// Example:
+//   res, _ := faiss.NewStandardGpuResources()
+//   defer res.Close()
+//
+//   kmeans, _ := faiss.NewGpuKmeans(res, 128, 4096, 0)
+//   defer kmeans.Close()
+//   kmeans.Train(trainingVectors)
+//   clusters, _ := kmeans.Assign(points)
+type GpuKmeans struct {
+	resources *StandardGpuResources
+	deviceID  int
+	d         int       // dimension
+	k         int       // number of clusters
+	centroids []float32 // cluster centroids (k * d)
+	isTrained bool      // training status
+
+	// index holds the centroids on the GPU for Assign
+	index *GpuIndexFlat
+}
+
+// NewGpuKmeans creates a new k-means clustering object that runs on a GPU
+//
+// Parameters:
+//   - res: GPU resources
+//   - d: dimension of vectors
+//   - k: number of clusters
+//   - device: GPU device ID
+func NewGpuKmeans(res *StandardGpuResources, d, k, device int) (*GpuKmeans, error) {
+	if res == nil {
+		return nil, fmt.Errorf("GPU resources cannot be nil")
+	}
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("faiss: k must be positive")
+	}
+
+	km := &GpuKmeans{
+		resources: res,
+		deviceID:  device,
+		d:         d,
+		k:         k,
+	}
+
+	runtime.SetFinalizer(km, func(km *GpuKmeans) {
+		km.Close()
+	})
+
+	return km, nil
+}
+
+// D returns the dimension
+func (km *GpuKmeans) D() int {
+	return km.d
+}
+
+// K returns the number of clusters
+func (km *GpuKmeans) K() int {
+	return km.k
+}
+
+// Device returns the GPU device ID
+func (km *GpuKmeans) Device() int {
+	return km.deviceID
+}
+
+// Train performs k-means clustering on the training vectors
+//
+// Parameters:
+//   - vectors: training vectors (n vectors of dimension d)
+//
+// The training vectors stay in host memory; FAISS streams them to the
+// device while assigning. Training again replaces the previous centroids.
+func (km *GpuKmeans) Train(vectors []float32) error {
+	if len(vectors) == 0 {
+		return fmt.Errorf("faiss: cannot train on empty vectors")
+	}
+	if len(vectors)%km.d != 0 {
+		return ErrInvalidVectors
+	}
+
+	n := len(vectors) / km.d
+	if n < km.k {
+		return fmt.Errorf("faiss: need at least %d training vectors for %d clusters, got %d", km.k, km.k, n)
+	}
+
+	index, err := NewGpuIndexFlatL2(km.resources, km.d, km.deviceID)
+	if err != nil {
+		return fmt.Errorf("faiss: failed to create GPU assignment index: %w", err)
+	}
+
+	centroids, err := faissClusteringTrain(km.d, km.k, vectors, n, index.ptr)
+	if err != nil {
+		index.Close()
+		return fmt.Errorf("faiss: GPU k-means clustering failed: %w", err)
+	}
+	// FAISS leaves the final centroids in the assignment index
+	index.ntotal = int64(km.k)
+
+	if km.index != nil {
+		km.index.Close()
+	}
+	km.index = index
+	km.centroids = centroids
+	km.isTrained = true
+	return nil
+}
+
+// Centroids returns the cluster centroids (k * d floats)
+// Must call Train() first
+func (km *GpuKmeans) Centroids() []float32 {
+	if !km.isTrained {
+		return nil
+	}
+	return km.centroids
+}
+
+// GetCentroids is an alias of Centroids
+func (km *GpuKmeans) GetCentroids() []float32 {
+	return km.Centroids()
+}
+
+// IsTrained returns whether the clustering has been trained
+func (km *GpuKmeans) IsTrained() bool {
+	return km.isTrained
+}
+
+// Assign assigns vectors to their nearest cluster using L2 distance
+//
+// The search runs on the GPU against the centroids kept from Train.
+//
+// Parameters:
+//   - vectors: vectors to assign (n vectors of dimension d)
+//
+// Returns: cluster assignments (n integers, each in range [0, k))
+func (km *GpuKmeans) Assign(vectors []float32) ([]int64, error) {
+	if !km.isTrained {
+		return nil, fmt.Errorf("faiss: must train before assigning")
+	}
+	if km.index == nil {
+		return nil, fmt.Errorf("faiss: GpuKmeans is closed")
+	}
+	if len(vectors) == 0 {
+		return []int64{}, nil
+	}
+	if len(vectors)%km.d != 0 {
+		return nil, ErrInvalidVectors
+	}
+
+	_, labels, err := km.index.Search(vectors, 1)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: assignment search failed: %w", err)
+	}
+	return labels, nil
+}
+
+// Close frees the GPU index holding the centroids
+//
+// Centroids stay available after Close, but Assign no longer works. The GPU
+// resources are owned by the caller and are not released.
+func (km *GpuKmeans) Close() error {
+	if km.index != nil {
+		km.index.Close()
+		km.index = nil
+	}
+	return nil
+}
@@ -195,6 +195,48 @@ func TestKmeans_Assign_Empty(t *testing.T) {
 	}
 }
 
+func TestKmeans_ToFlatIndex(t *testing.T) {
+	d := 16
+	k := 8
+	vectors := generateVectors(1000, d)
+
+	km, _ := NewKmeans(d, k)
+	if _, err := km.ToFlatIndex(); err == nil {
+		t.Error("ToFlatIndex() before training should return error")
+	}
+	if err := km.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+
+	index, err := km.ToFlatIndex()
+	if err != nil {
+		t.Fatalf("ToFlatIndex() failed: %v", err)
+	}
+	defer index.Close()
+
+	if index.Ntotal() != int64(k) {
+		t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), k)
+	}
+
+	points := vectors[:50*d]
+	assignments, err := km.Assign(points)
+	if err != nil {
+		t.Fatalf("Assign() failed: %v", err)
+	}
+	distances, labels, err := index.Search(points, 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for i := range assignments {
+		if labels[i] != assignments[i] {
+			t.Errorf("point %d: Search label = %d, want Assign label %d", i, labels[i], assignments[i])
+		}
+		if distances[i] < 0 {
+			t.Errorf("point %d: distance = %v, want >= 0", i, distances[i])
+		}
+	}
+}
+
 // ========================================
 // Clustering Quality Tests
 // ========================================
@@ -1,6 +1,7 @@
 package faiss
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -180,6 +181,60 @@ func TestIndexPreTransformDimensionMismatch(t *testing.T) {
 	}
 }
 
+func TestIndexPreTransform_CloseOrder(t *testing.T) {
+	dIn, dOut := 32, 8
+	vectors := generateVectors(200, dIn)
+
+	build := func() (*PCAMatrix, *IndexFlat, *IndexPreTransform) {
+		pca, _ := NewPCAMatrix(dIn, dOut)
+		base, _ := NewIndexFlatL2(dOut)
+		index, err := NewIndexPreTransform(pca, base)
+		if err != nil {
+			t.Fatalf("NewIndexPreTransform failed: %v", err)
+		}
+		if err := index.Train(vectors); err != nil {
+			t.Fatalf("Train failed: %v", err)
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		return pca, base, index
+	}
+
+	// Documented order: composite first, then its parts, each closed twice
+	pca, base, index := build()
+	for i := 0; i < 2; i++ {
+		if err := index.Close(); err != nil {
+			t.Errorf("IndexPreTransform.Close() #%d failed: %v", i+1, err)
+		}
+	}
+	// The base index is still usable after the composite is gone
+	if _, _, err := base.Search(vectors[:dOut], 1); err != nil {
+		t.Errorf("base Search after composite Close failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		base.Close()
+		pca.Close()
+	}
+
+	// Reverse order: parts first, the composite refuses to touch them
+	pca, base, index = build()
+	base.Close()
+	if _, _, err := index.Search(vectors[:dIn], 1); !errors.Is(err, ErrNullPointer) {
+		t.Errorf("Search with closed base error = %v, want ErrNullPointer", err)
+	}
+	if err := index.Reset(); !errors.Is(err, ErrNullPointer) {
+		t.Errorf("Reset with closed base error = %v, want ErrNullPointer", err)
+	}
+	pca.Close()
+	if index.IsTrained() {
+		t.Error("IsTrained() with closed parts = true, want false")
+	}
+	if err := index.Close(); err != nil {
+		t.Errorf("Close after parts were closed failed: %v", err)
+	}
+}
+
 // ========================================
 // IndexShards Tests
 // ========================================
@@ -276,3 +331,197 @@ func TestIndexShardsNoShards(t *testing.T) {
 		t.Error("Expected error when adding to empty shards")
 	}
 }
+
+// ========================================
+// FederatedSearch Tests
+// ========================================
+
+func TestFederatedSearch(t *testing.T) {
+	d := 32
+	nb := 400
+	k := 10
+
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(5, d)
+
+	whole, err := NewIndexFlatL2(d)
+	if err != nil {
+		t.Fatalf("NewIndexFlatL2() failed: %v", err)
+	}
+	defer whole.Close()
+	if err := whole.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	half1, _ := NewIndexFlatL2(d)
+	defer half1.Close()
+	half2, _ := NewIndexFlatL2(d)
+	defer half2.Close()
+	if err := half1.Add(vectors[:nb/2*d]); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := half2.Add(vectors[nb/2*d:]); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	wantDist, wantLabels, err := whole.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	gotDist, gotLabels, err := FederatedSearch([]Index{half1, half2}, queries, k)
+	if err != nil {
+		t.Fatalf("FederatedSearch() failed: %v", err)
+	}
+
+	for i := range wantLabels {
+		if gotLabels[i] != wantLabels[i] {
+			t.Errorf("labels[%d] = %d, want %d", i, gotLabels[i], wantLabels[i])
+		}
+		if !almostEqual(gotDist[i], wantDist[i], 1e-4) {
+			t.Errorf("distances[%d] = %v, want %v", i, gotDist[i], wantDist[i])
+		}
+	}
+}
+
+func TestFederatedSearchWithOffsets(t *testing.T) {
+	d := 8
+
+	index1, _ := NewIndexFlatIP(d)
+	defer index1.Close()
+	index2, _ := NewIndexFlatIP(d)
+	defer index2.Close()
+
+	v1 := make([]float32, d)
+	v1[0] = 1
+	v2 := make([]float32, d)
+	v2[0] = 2
+	index1.Add(v1)
+	index2.Add(v2)
+
+	// k larger than the total forces padding
+	distances, labels, err := FederatedSearchWithOffsets([]Index{index1, index2}, []int64{1000, 2000}, v1, 3)
+	if err != nil {
+		t.Fatalf("FederatedSearchWithOffsets() failed: %v", err)
+	}
+
+	wantLabels := []int64{2000, 1000, -1}
+	for i, want := range wantLabels {
+		if labels[i] != want {
+			t.Errorf("labels[%d] = %d, want %d", i, labels[i], want)
+		}
+	}
+	if distances[0] != 2 || distances[1] != 1 {
+		t.Errorf("distances = %v, want [2 1 ...]", distances)
+	}
+}
+
+func TestFederatedSearchInvalid(t *testing.T) {
+	a, _ := NewIndexFlatL2(8)
+	defer a.Close()
+	b, _ := NewIndexFlatL2(16)
+	defer b.Close()
+	c, _ := NewIndexFlatIP(8)
+	defer c.Close()
+
+	query := make([]float32, 8)
+	if _, _, err := FederatedSearch(nil, query, 1); err == nil {
+		t.Error("Expected error for no indexes")
+	}
+	if _, _, err := FederatedSearch([]Index{a, b}, query, 1); err == nil {
+		t.Error("Expected error for mismatched dimensions")
+	}
+	if _, _, err := FederatedSearch([]Index{a, c}, query, 1); err == nil {
+		t.Error("Expected error for mismatched metrics")
+	}
+	if _, _, err := FederatedSearchWithOffsets([]Index{a}, []int64{0, 1}, query, 1); err == nil {
+		t.Error("Expected error for wrong number of offsets")
+	}
+}
+
+// ========================================
+// WeightedEnsemble Tests
+// ========================================
+
+func TestWeightedEnsemble(t *testing.T) {
+	text, _ := NewIndexFlatL2(2)
+	defer text.Close()
+	image, _ := NewIndexFlatL2(2)
+	defer image.Close()
+
+	// Squared distances from the query (origin in both spaces):
+	//   item 0: text 0, image 4
+	//   item 1: text 4, image 0
+	//   item 2: text 1, image 1
+	ensemble, err := NewWeightedEnsemble([]Index{text, image}, []float32{0.5, 0.5})
+	if err != nil {
+		t.Fatalf("NewWeightedEnsemble() failed: %v", err)
+	}
+	defer ensemble.Close()
+
+	if ensemble.D() != 4 {
+		t.Errorf("D() = %d, want 4", ensemble.D())
+	}
+
+	vectors := []float32{
+		0, 0, 2, 0,
+		2, 0, 0, 0,
+		1, 0, 1, 0,
+	}
+	if err := ensemble.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if ensemble.Ntotal() != 3 || text.Ntotal() != 3 || image.Ntotal() != 3 {
+		t.Fatalf("Ntotal() = %d/%d/%d, want 3", ensemble.Ntotal(), text.Ntotal(), image.Ntotal())
+	}
+
+	query := []float32{0, 0, 0, 0}
+	_, textLabels, _ := text.Search(query[:2], 1)
+	_, imageLabels, _ := image.Search(query[2:], 1)
+	if textLabels[0] != 0 || imageLabels[0] != 1 {
+		t.Fatalf("single-space top-1 = %d/%d, want 0/1", textLabels[0], imageLabels[0])
+	}
+
+	distances, labels, err := ensemble.Search(query, 3)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if labels[0] != 2 {
+		t.Errorf("fused top-1 = %d, want 2", labels[0])
+	}
+	if !almostEqual(distances[0], 1, 1e-5) || !almostEqual(distances[1], 2, 1e-5) {
+		t.Errorf("fused distances = %v, want [1 2 2]", distances)
+	}
+
+	// All weight on one space reproduces that space's ranking
+	textOnly, _ := NewWeightedEnsemble([]Index{text, image}, []float32{1, 0})
+	_, labels, err = textOnly.Search(query, 3)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	want := []int64{0, 2, 1}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("text-only labels[%d] = %d, want %d", i, labels[i], want[i])
+		}
+	}
+}
+
+func TestWeightedEnsembleInvalid(t *testing.T) {
+	a, _ := NewIndexFlatL2(4)
+	defer a.Close()
+	b, _ := NewIndexFlatIP(4)
+	defer b.Close()
+
+	if _, err := NewWeightedEnsemble(nil, nil); err == nil {
+		t.Error("Expected error for no indexes")
+	}
+	if _, err := NewWeightedEnsemble([]Index{a}, []float32{1, 2}); err == nil {
+		t.Error("Expected error for mismatched weights")
+	}
+	if _, err := NewWeightedEnsemble([]Index{a, b}, []float32{1, 1}); err == nil {
+		t.Error("Expected error for mismatched metrics")
+	}
+	if _, err := NewWeightedEnsemble([]Index{a}, []float32{-1}); err == nil {
+		t.Error("Expected error for negative weight")
+	}
+}
@@ -1,9 +1,13 @@
 package faiss
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -92,6 +96,160 @@ func TestRangeSearch_EmptyQuery(t *testing.T) {
 	}
 }
 
+func TestRangeSearch_MultiQueryAccessors(t *testing.T) {
+	d := 2
+	index, err := NewIndexFlatL2(d)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	defer index.Close()
+
+	// Points on the x axis at 0, 1, 2, ..., 9
+	vectors := make([]float32, 10*d)
+	for i := 0; i < 10; i++ {
+		vectors[i*d] = float32(i)
+	}
+	index.Add(vectors)
+
+	// Query 0 near x=0, query 1 far away (no results), query 2 near x=9
+	queries := []float32{
+		0, 0,
+		100, 100,
+		9, 0,
+	}
+	result, err := index.RangeSearch(queries, 4.5)
+	if err != nil {
+		t.Fatalf("RangeSearch failed: %v", err)
+	}
+
+	if len(result.Lims) != result.Nq+1 || result.Lims[0] != 0 {
+		t.Fatalf("Lims = %v, want %d offsets starting at 0", result.Lims, result.Nq+1)
+	}
+	wantCounts := []int{3, 0, 3}
+	for q, want := range wantCounts {
+		if got := result.NumResults(q); got != want {
+			t.Errorf("NumResults(%d) = %d, want %d", q, got, want)
+		}
+	}
+
+	allLabels := result.AllLabels()
+	allDistances := result.AllDistances()
+	if len(allLabels) != result.TotalResults() || len(allDistances) != result.TotalResults() {
+		t.Fatalf("flattened lengths = %d/%d, want %d", len(allLabels), len(allDistances), result.TotalResults())
+	}
+
+	// Per-query and flattened accessors agree
+	for q := 0; q < result.Nq; q++ {
+		labels, distances := result.GetResults(q)
+		start, end := result.Lims[q], result.Lims[q+1]
+		for j := range labels {
+			if labels[j] != allLabels[start+int64(j)] || distances[j] != allDistances[start+int64(j)] {
+				t.Errorf("query %d result %d: GetResults = (%d, %v), flattened = (%d, %v)",
+					q, j, labels[j], distances[j], allLabels[start+int64(j)], allDistances[start+int64(j)])
+			}
+		}
+		if int64(len(labels)) != end-start {
+			t.Errorf("query %d: GetResults returned %d labels, Lims span %d", q, len(labels), end-start)
+		}
+		for _, label := range labels {
+			if q == 0 && label > 2 || q == 2 && label < 7 {
+				t.Errorf("query %d: unexpected label %d", q, label)
+			}
+		}
+	}
+
+	// The flattened copies are independent of the result
+	allLabels[0] = -42
+	if result.Labels[0] == -42 {
+		t.Error("AllLabels() should return a copy")
+	}
+}
+
+// TestRangeSearch_NoLeak checks that repeated range searches release the C
+// result objects and that Go memory stays bounded
+func TestRangeSearch_NoLeak(t *testing.T) {
+	d := 8
+	index, _ := NewIndexFlatL2(d)
+	defer index.Close()
+	index.Add(generateVectors(1000, d))
+	queries := generateVectors(4, d)
+
+	// Warm up so that one-time allocations don't count as growth
+	if _, err := index.RangeSearch(queries, 1.0); err != nil {
+		t.Fatalf("RangeSearch failed: %v", err)
+	}
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	for i := 0; i < 10000; i++ {
+		result, err := index.RangeSearch(queries, 1.0)
+		if err != nil {
+			t.Fatalf("RangeSearch %d failed: %v", i, err)
+		}
+		if result.TotalResults() == 0 {
+			t.Fatal("expected results within the radius")
+		}
+	}
+
+	if live := atomic.LoadInt64(&liveRangeSearchResults); live != 0 {
+		t.Errorf("%d C range search results still allocated, want 0", live)
+	}
+
+	runtime.GC()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	growth := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+	t.Logf("heap growth after 10000 range searches: %d bytes", growth)
+	if growth > 16<<20 {
+		t.Errorf("heap grew by %d bytes, want < 16MB", growth)
+	}
+}
+
+func TestRangeSearchCount(t *testing.T) {
+	d := 8
+	vectors := generateVectors(1000, d)
+	queries := generateVectors(4, d)
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+
+	generic := mustCreateGenericIndex(t, d, "IDMap,Flat").(*GenericIndex)
+	defer generic.Close()
+	generic.Add(vectors)
+
+	type counter interface {
+		RangeSearch([]float32, float32) (*RangeSearchResult, error)
+		RangeSearchCount([]float32, float32) (int, error)
+	}
+	for name, index := range map[string]counter{"Flat": flat, "Generic": generic} {
+		for _, radius := range []float32{0, 0.5, 1.0, 100} {
+			result, err := index.RangeSearch(queries, radius)
+			if err != nil {
+				t.Fatalf("%s: RangeSearch failed: %v", name, err)
+			}
+			count, err := index.RangeSearchCount(queries, radius)
+			if err != nil {
+				t.Fatalf("%s: RangeSearchCount failed: %v", name, err)
+			}
+			if count != result.TotalResults() {
+				t.Errorf("%s: RangeSearchCount(radius=%v) = %d, want %d", name, radius, count, result.TotalResults())
+			}
+		}
+	}
+
+	if count, err := flat.RangeSearchCount(nil, 1.0); err != nil || count != 0 {
+		t.Errorf("RangeSearchCount(nil) = %d, %v, want 0, nil", count, err)
+	}
+	if _, err := flat.RangeSearchCount(queries[:d-1], 1.0); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("RangeSearchCount(ragged) error = %v, want ErrDimensionMismatch", err)
+	}
+	if live := atomic.LoadInt64(&liveRangeSearchResults); live != 0 {
+		t.Errorf("%d C range search results still allocated, want 0", live)
+	}
+}
+
 // ========================================
 // Performance Batch Tests
 // ========================================
@@ -145,6 +303,62 @@ func TestSearchBatch_EmptyQueries_Coverage(t *testing.T) {
 	}
 }
 
+func TestSearchBatchCallback(t *testing.T) {
+	d, k := 4, 3
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+	index.Add(generateVectors(50, d))
+
+	// More queries than one chunk, so the last chunk is partial
+	nq := searchBatchSize + 37
+	queries := generateVectors(nq, d)
+	wantDist, wantLabels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	next := 0
+	err = SearchBatchCallback(index, queries, k, func(q int, distances []float32, labels []int64) error {
+		if q != next {
+			return fmt.Errorf("callback for query %d, want %d", q, next)
+		}
+		next++
+		if len(distances) != k || len(labels) != k {
+			return fmt.Errorf("query %d: got %d distances and %d labels, want %d", q, len(distances), len(labels), k)
+		}
+		for j := 0; j < k; j++ {
+			if distances[j] != wantDist[q*k+j] || labels[j] != wantLabels[q*k+j] {
+				return fmt.Errorf("query %d result %d = (%v, %d), want (%v, %d)", q, j, distances[j], labels[j], wantDist[q*k+j], wantLabels[q*k+j])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SearchBatchCallback failed: %v", err)
+	}
+	if next != nq {
+		t.Errorf("callback invoked %d times, want %d", next, nq)
+	}
+
+	// An error from the callback stops the search
+	stop := errors.New("stop")
+	calls := 0
+	err = SearchBatchCallback(index, queries, k, func(int, []float32, []int64) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("SearchBatchCallback() = %v after %d calls, want stop after 1", err, calls)
+	}
+
+	if err := SearchBatchCallback(index, queries[:d+1], k, func(int, []float32, []int64) error { return nil }); err == nil {
+		t.Error("Expected error for queries not a multiple of d")
+	}
+	if err := SearchBatchCallback(index, queries, 0, func(int, []float32, []int64) error { return nil }); err == nil {
+		t.Error("Expected error for k = 0")
+	}
+}
+
 func TestAddBatch_Coverage(t *testing.T) {
 	d := 8
 	index, err := NewIndexFlatL2(d)
@@ -180,6 +394,183 @@ func TestAddBatch_EmptyVectors_Coverage(t *testing.T) {
 	}
 }
 
+// TestConcurrentAdd is meant to be run with -race
+func TestConcurrentAdd(t *testing.T) {
+	d := 8
+	workers, batches, batchSize := 8, 25, 10
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+
+	data := make([][]float32, workers)
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*batches)
+	for w := range data {
+		data[w] = generateVectors(batches*batchSize, d)
+		wg.Add(1)
+		go func(vectors []float32) {
+			defer wg.Done()
+			for b := 0; b < batches; b++ {
+				errs <- ConcurrentAdd(index, vectors[b*batchSize*d:(b+1)*batchSize*d])
+			}
+		}(data[w])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ConcurrentAdd failed: %v", err)
+		}
+	}
+
+	if want := int64(workers * batches * batchSize); index.Ntotal() != want {
+		t.Fatalf("Ntotal() = %d, want %d", index.Ntotal(), want)
+	}
+
+	// Every vector made it in intact
+	for w, vectors := range data {
+		distances, _, err := index.Search(vectors, 1)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		for i, dist := range distances {
+			if dist > 1e-4 {
+				t.Fatalf("worker %d vector %d: nearest distance %v, want 0", w, i, dist)
+			}
+		}
+	}
+
+	addLocks.Lock()
+	defer addLocks.Unlock()
+	if len(addLocks.m) != 0 {
+		t.Errorf("%d add locks left after ConcurrentAdd returned, want 0", len(addLocks.m))
+	}
+}
+
+func TestSearchChannel(t *testing.T) {
+	d := 8
+	k := 3
+	nq := 100
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+	vectors := generateVectors(500, d)
+	index.Add(vectors)
+
+	// Buffered so that queued queries get micro-batched
+	in := make(chan []float32, 32)
+	go func() {
+		defer close(in)
+		for i := 0; i < nq; i++ {
+			in <- vectors[i*d : (i+1)*d]
+		}
+	}()
+
+	i := 0
+	for r := range SearchChannel(index, in, k) {
+		if r.Err != nil {
+			t.Fatalf("result %d: unexpected error %v", i, r.Err)
+		}
+		if r.Nq != 1 || r.K != k || len(r.Labels) != k || len(r.Distances) != k {
+			t.Fatalf("result %d: Nq=%d K=%d with %d labels, want one query of %d", i, r.Nq, r.K, len(r.Labels), k)
+		}
+		// Every query is a stored vector, so it must come back as its own nearest neighbor
+		if r.Labels[0] != int64(i) {
+			t.Errorf("result %d: nearest = %d, want %d (out of order?)", i, r.Labels[0], i)
+		}
+		i++
+	}
+	if i != nq {
+		t.Fatalf("got %d results, want %d", i, nq)
+	}
+
+	// A bad query fails alone and the stream goes on
+	in = make(chan []float32, 3)
+	in <- vectors[:d]
+	in <- vectors[:d-1]
+	in <- vectors[d : 2*d]
+	close(in)
+	var results []StreamResult
+	for r := range SearchChannel(index, in, k) {
+		results = append(results, r)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if results[0].Err != nil || results[2].Err != nil || results[2].Labels[0] != 1 {
+		t.Errorf("valid queries around a bad one: %+v, %+v", results[0], results[2])
+	}
+	if !errors.Is(results[1].Err, ErrDimensionMismatch) {
+		t.Errorf("bad query error = %v, want ErrDimensionMismatch", results[1].Err)
+	}
+}
+
+func TestAddBatchWithIDs_Coverage(t *testing.T) {
+	d := 8
+	n := 100500
+	chunkSize := 10000
+
+	base, err := NewIndexFlatL2(d)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	defer base.Close()
+	index, err := NewIndexIDMap(base)
+	if err != nil {
+		t.Fatalf("Failed to create IDMap: %v", err)
+	}
+	defer index.Close()
+
+	vectors := generateVectors(n, d)
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = 1000000 + int64(i)*3
+	}
+
+	if err := AddBatchWithIDs(index, vectors, ids, chunkSize); err != nil {
+		t.Fatalf("AddBatchWithIDs failed: %v", err)
+	}
+	if index.Ntotal() != int64(n) {
+		t.Fatalf("Expected %d vectors, got %d", n, index.Ntotal())
+	}
+
+	// Probe both sides of every chunk boundary plus the final partial chunk
+	var probes []int
+	for c := chunkSize; c < n; c += chunkSize {
+		probes = append(probes, c-1, c)
+	}
+	probes = append(probes, 0, n-1)
+
+	queries := make([]float32, 0, len(probes)*d)
+	for _, p := range probes {
+		queries = append(queries, vectors[p*d:(p+1)*d]...)
+	}
+	_, labels, err := index.Search(queries, 1)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	for i, p := range probes {
+		if labels[i] != ids[p] {
+			t.Errorf("vector %d: got ID %d, want %d", p, labels[i], ids[p])
+		}
+	}
+}
+
+func TestAddBatchWithIDs_Invalid_Coverage(t *testing.T) {
+	base, _ := NewIndexFlatL2(4)
+	defer base.Close()
+	index, _ := NewIndexIDMap(base)
+	defer index.Close()
+
+	if err := AddBatchWithIDs(index, make([]float32, 8), []int64{1}, 1); err == nil {
+		t.Error("Expected error for mismatched ID count")
+	}
+	if err := AddBatchWithIDs(index, make([]float32, 7), []int64{1, 2}, 1); err == nil {
+		t.Error("Expected error for bad vector length")
+	}
+	if err := AddBatchWithIDs(index, nil, nil, 0); err != nil {
+		t.Errorf("AddBatchWithIDs with empty input failed: %v", err)
+	}
+}
+
 // ========================================
 // Composite Index Additional Tests
 // ========================================
@@ -395,10 +786,14 @@ func TestIndexFactoryFromFile_Coverage(t *testing.T) {
 	}
 	f.Close()
 
-	// Try to load (this tests the function, even if format isn't perfect)
-	_, err = IndexFactoryFromFile(d, "Flat", MetricL2, tmpVecFile)
-	// The function may fail due to format, but we've exercised the code path
-	_ = err // We just want coverage, not correctness here
+	index, err := IndexFactoryFromFile(d, "Flat", MetricL2, tmpVecFile)
+	if err != nil {
+		t.Fatalf("IndexFactoryFromFile() failed: %v", err)
+	}
+	defer index.Close()
+	if index.Ntotal() != int64(n) {
+		t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), n)
+	}
 }
 
 // ========================================
@@ -2009,6 +2404,73 @@ func TestNewIndexIVFPQ_Coverage(t *testing.T) {
 	}
 }
 
+func TestNewIndex2Layer(t *testing.T) {
+	d, nlist1, nlist2 := 16, 8, 64
+	index, err := NewIndex2Layer(d, nlist1, nlist2, 4, 4)
+	if err != nil {
+		t.Fatalf("NewIndex2Layer failed: %v", err)
+	}
+	defer index.Close()
+
+	if nlist, err := faissIndexIVFGetNlist(index.(*GenericIndex).ptr); err != nil || nlist != nlist2 {
+		t.Errorf("nlist = %d, %v, want %d", nlist, err, nlist2)
+	}
+
+	if err := index.Train(generateVectors(100, d)); !errors.Is(err, ErrTrainingTooSmall) {
+		t.Errorf("Train(100 vectors) error = %v, want ErrTrainingTooSmall", err)
+	}
+	if err := index.Train(generateVectors(30*nlist2, d)); err != nil {
+		t.Fatalf("Train failed: %v", err)
+	}
+
+	vectors := generateVectors(2000, d)
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if index.Ntotal() != 2000 {
+		t.Errorf("Ntotal() = %d, want 2000", index.Ntotal())
+	}
+	if err := index.SetNprobe(16); err != nil {
+		t.Fatalf("SetNprobe failed: %v", err)
+	}
+
+	// Each vector should usually come back among its own nearest neighbors
+	nq, k := 100, 10
+	_, labels, err := index.Search(vectors[:nq*d], k)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	found := 0
+	for q := 0; q < nq; q++ {
+		for _, id := range labels[q*k : (q+1)*k] {
+			if id < -1 || id >= 2000 {
+				t.Fatalf("query %d: label %d out of range", q, id)
+			}
+			if id == int64(q) {
+				found++
+			}
+		}
+	}
+	t.Logf("self-recall@%d: %d/%d", k, found, nq)
+	if found < nq/2 {
+		t.Errorf("self-recall@%d = %d/%d, want at least half", k, found, nq)
+	}
+
+	invalid := []struct{ d, nlist1, nlist2, M, nbits int }{
+		{0, 8, 64, 4, 4},
+		{16, 0, 64, 4, 4},
+		{16, 128, 64, 4, 4},
+		{16, 8, 64, 5, 4},
+		{16, 8, 64, 4, 0},
+	}
+	for _, p := range invalid {
+		if index, err := NewIndex2Layer(p.d, p.nlist1, p.nlist2, p.M, p.nbits); err == nil {
+			index.Close()
+			t.Errorf("NewIndex2Layer(%v) succeeded, want error", p)
+		}
+	}
+}
+
 // ========================================
 // VectorTransform is_trained Test
 // ========================================
@@ -116,17 +116,16 @@ func innerProductSearch() {
 	query := generateNormalizedVectors(1, dimension)
 	k := 5
 
-	scores, labels, err := index.Search(query, k)
+	// SearchCosine clamps the scores to [-1, 1] to absorb floating point error
+	similarities, labels, err := faiss.SearchCosine(index, query, k)
 	if err != nil {
 		log.Fatalf("Search failed: %v", err)
 	}
 
 	fmt.Printf("\nTop %d most similar vectors (cosine similarity):\n", k)
 	for i := 0; i < k; i++ {
-		// Clamp to [-1, 1] to handle floating point precision
-		similarity := math.Max(-1, math.Min(1, float64(scores[i])))
 		fmt.Printf("  %d. ID=%d, Cosine Similarity=%.4f\n",
-			i+1, labels[i], similarity)
+			i+1, labels[i], similarities[i])
 	}
 }
 
@@ -142,15 +142,11 @@ func tuneNprobe() {
 
 	fmt.Printf("Index: %d vectors, %d clusters\n\n", index.Ntotal(), nlist)
 
-	// Get ground truth with exhaustive flat search
-	flatIndex, _ := faiss.NewIndexFlatL2(dimension)
-	defer flatIndex.Close()
-	flatIndex.Add(vectors)
-
 	query := generateRandomVectors(1, dimension)
 	k := 10
 
-	gtDist, gtLabels, _ := flatIndex.Search(query, k)
+	// Get ground truth with exhaustive flat search
+	gtLabels, gtDist, _ := faiss.ComputeGroundTruth(vectors, query, dimension, k, faiss.MetricL2)
 	gtSet := make(map[int64]bool)
 	for _, id := range gtLabels {
 		gtSet[id] = true
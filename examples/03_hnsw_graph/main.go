@@ -116,18 +116,12 @@ func compareMValues() {
 	vectors := generateRandomVectors(numVectors, dimension)
 	queries := generateRandomVectors(100, dimension)
 
-	// Ground truth from exact search
-	flatIndex, _ := faiss.NewIndexFlatL2(dimension)
-	defer flatIndex.Close()
-	flatIndex.Add(vectors)
-
-	// Calculate ground truth for all queries
+	// Ground truth from exact search for all queries
+	gtLabels, _, _ := faiss.ComputeGroundTruth(vectors, queries, dimension, k, faiss.MetricL2)
 	groundTruth := make([]map[int64]bool, 100)
 	for q := 0; q < 100; q++ {
-		query := queries[q*dimension : (q+1)*dimension]
-		_, gtLabels, _ := flatIndex.Search(query, k)
 		groundTruth[q] = make(map[int64]bool)
-		for _, id := range gtLabels {
+		for _, id := range gtLabels[q*k : (q+1)*k] {
 			groundTruth[q][id] = true
 		}
 	}
@@ -126,10 +126,7 @@ func compareCompression() {
 	query := generateRandomVectors(1, dimension)
 
 	// Ground truth from exact search
-	flatIndex, _ := faiss.NewIndexFlatL2(dimension)
-	defer flatIndex.Close()
-	flatIndex.Add(vectors)
-	_, gtLabels, _ := flatIndex.Search(query, k)
+	gtLabels, _, _ := faiss.ComputeGroundTruth(vectors, query, dimension, k, faiss.MetricL2)
 
 	gtSet := make(map[int64]bool)
 	for _, id := range gtLabels {
@@ -212,10 +209,7 @@ func scalarQuantization() {
 	query := generateRandomVectors(1, dimension)
 
 	// Ground truth
-	flatIndex, _ := faiss.NewIndexFlatL2(dimension)
-	defer flatIndex.Close()
-	flatIndex.Add(vectors)
-	_, gtLabels, _ := flatIndex.Search(query, k)
+	gtLabels, _, _ := faiss.ComputeGroundTruth(vectors, query, dimension, k, faiss.MetricL2)
 
 	gtSet := make(map[int64]bool)
 	for _, id := range gtLabels {
@@ -332,10 +326,7 @@ func ivfPQ() {
 	fmt.Printf("  Added %d vectors in %v\n", index.Ntotal(), time.Since(start))
 
 	// Ground truth for recall calculation
-	flatIndex, _ := faiss.NewIndexFlatL2(dimension)
-	defer flatIndex.Close()
-	flatIndex.Add(vectors)
-	_, gtLabels, _ := flatIndex.Search(query, k)
+	gtLabels, _, _ := faiss.ComputeGroundTruth(vectors, query, dimension, k, faiss.MetricL2)
 
 	gtSet := make(map[int64]bool)
 	for _, id := range gtLabels {
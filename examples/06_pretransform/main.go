@@ -106,20 +106,13 @@ func pcaExample() {
 	fmt.Printf("  Top 5 results: IDs=%v\n", labels)
 	fmt.Printf("  Distances: %.4f\n", distances)
 
-	// Demonstrate reverse transform (approximate reconstruction)
+	// Measure reconstruction error (apply + reverse transform) over all vectors
 	fmt.Println("\nReverse transform (reconstruction):")
-	reconstructed, err := pca.ReverseTransform(reduced[:dOut]) // first vector
+	mse, err := faiss.EstimateReconstructionError(pca, vectors)
 	if err != nil {
 		fmt.Printf("  Reverse transform error: %v\n", err)
 	} else {
-		// Calculate reconstruction error
-		var mse float64
-		for i := 0; i < dIn; i++ {
-			diff := float64(vectors[i] - reconstructed[i])
-			mse += diff * diff
-		}
-		mse /= float64(dIn)
-		fmt.Printf("  MSE of first vector: %.6f\n", mse)
+		fmt.Printf("  Mean MSE per vector: %.6f\n", mse)
 		fmt.Printf("  (Lower MSE = better reconstruction)\n")
 	}
 }
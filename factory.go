@@ -1,9 +1,16 @@
 package faiss
 
 import (
+	"bufio"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Index type constants for factory descriptions
@@ -37,6 +44,9 @@ const (
 //   - "Flat"              -> Exact search (IndexFlatL2 or IndexFlatIP)
 //   - "LSH"               -> Locality-sensitive hashing
 //   - "PQn"               -> Product quantization (n = number of bytes)
+//   - "PQmxb"             -> PQ with m subquantizers of b bits (1-16),
+//     e.g. "PQ16x4" (8 bytes per vector) or "PQ8x6"
+//   - "PQmx4fs"           -> PQ FastScan (4 bits only)
 //   - "SQn"               -> Scalar quantization (n = 4, 6, or 8 bits)
 //
 // IVF (Inverted File) indexes:
@@ -49,18 +59,29 @@ const (
 //   - "HNSW32,Flat"      -> HNSW graph with flat refinement
 //
 // Pre-transform indexes:
+//   - "L2norm,..."       -> Normalize vectors to unit length first
 //   - "PCAn,..."         -> Apply PCA to reduce to n dimensions first
-//   - "OPQn,..."         -> Apply Optimized Product Quantization
+//   - "OPQn,..."         -> Apply Optimized Product Quantization (n must equal
+//     the M of the PQ that follows, e.g. "OPQ16,IVF100,PQ16")
 //   - "RRn,..."          -> Apply Random Rotation
 //
 // Refinement:
 //   - "...,Refine(Flat)" -> Two-stage search with refinement
 //
+// The metric applies to the whole index, e.g. "HNSW32" with
+// MetricInnerProduct builds an inner product HNSW graph. MetricCosine builds
+// an inner product index behind an "L2norm" pre-transform, so stored vectors
+// and queries are normalized automatically and the distances returned by
+// Search are cosine similarities.
+//
 // Examples:
 //
 //	// Create HNSW index (fast, accurate approximate search)
 //	index, _ := IndexFactory(128, "HNSW32", MetricL2)
 //
+//	// Create cosine HNSW index (no need to normalize the inputs)
+//	index, _ := IndexFactory(128, "HNSW32", MetricCosine)
+//
 //	// Create IVF+PQ index (compressed, scalable)
 //	index, _ := IndexFactory(128, "IVF100,PQ8", MetricL2)
 //
@@ -79,6 +100,22 @@ func IndexFactory(d int, description string, metric MetricType) (Index, error) {
 		return nil, fmt.Errorf("faiss: empty index description")
 	}
 
+	// Registered handlers take precedence over the FAISS factory
+	if prefix, handler := lookupFactoryHandler(description); handler != nil {
+		return handler(d, strings.TrimPrefix(description, prefix), metric)
+	}
+
+	if err := validateOPQDescription(d, description); err != nil {
+		return nil, err
+	}
+	if err := validatePQDescription(description); err != nil {
+		return nil, err
+	}
+
+	if metric == MetricCosine {
+		description, metric = cosineDescription(description), MetricInnerProduct
+	}
+
 	// Use the actual FAISS index_factory C function!
 	// This supports ALL index types, not just the ones we manually parse.
 	ptr, err := faissIndexFactory(d, description, int(metric))
@@ -90,21 +127,511 @@ func IndexFactory(d int, description string, metric MetricType) (Index, error) {
 	return newGenericIndex(ptr, d, metric, description), nil
 }
 
+// cosineDescription prefixes description with an L2 normalization
+// pre-transform, so that inner product on the stored and query vectors is
+// their cosine similarity
+func cosineDescription(description string) string {
+	if strings.HasPrefix(description, "L2norm,") {
+		return description
+	}
+	return "L2norm," + description
+}
+
+// validateOPQDescription checks an "OPQ{M}[_{dOut}],...,PQ{M}" chain
+//
+// FAISS accepts an OPQ rotation trained for a different number of
+// subquantizers than the PQ that follows it, which silently wastes the
+// rotation, and reports a bare error code when the dimensions don't divide.
+// d may be 0 to check only what does not depend on the input dimension.
+func validateOPQDescription(d int, description string) error {
+	parts := strings.Split(description, ",")
+	opqM, dOut, ok := parseOPQComponent(strings.TrimSpace(parts[0]))
+	if !ok {
+		return nil
+	}
+	if dOut == 0 {
+		dOut = d
+	}
+	if dOut > 0 && dOut%opqM != 0 {
+		return fmt.Errorf("faiss: OPQ M=%d must divide the OPQ output dimension %d", opqM, dOut)
+	}
+
+	for _, part := range parts[1:] {
+		pqM, _, ok := parsePQ(strings.TrimSpace(part))
+		if !ok {
+			continue
+		}
+		if pqM != opqM {
+			return fmt.Errorf("faiss: OPQ M=%d must equal PQ M=%d", opqM, pqM)
+		}
+	}
+	return nil
+}
+
+// parseOPQComponent parses "OPQ{M}" or "OPQ{M}_{dOut}"; dOut is 0 if absent
+func parseOPQComponent(part string) (m, dOut int, ok bool) {
+	if !strings.HasPrefix(part, "OPQ") {
+		return 0, 0, false
+	}
+	mStr, dOutStr, hasDOut := strings.Cut(strings.TrimPrefix(part, "OPQ"), "_")
+	m, err := strconv.Atoi(mStr)
+	if err != nil || m <= 0 {
+		return 0, 0, false
+	}
+	if hasDOut {
+		if dOut, err = strconv.Atoi(dOutStr); err != nil || dOut <= 0 {
+			return 0, 0, false
+		}
+	}
+	return m, dOut, true
+}
+
+// validatePQDescription checks every "PQ{M}[x{nbits}]" component
+//
+// FAISS trains 2^nbits centroids per subquantizer and supports 1 to 16 bits,
+// the same range as NewIndexPQ; 4 bits halves the code size of the default 8
+// and is the only width FastScan ("PQ{M}x4fs") implements.
+func validatePQDescription(description string) error {
+	for _, part := range strings.Split(description, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, IndexTypePQ) {
+			continue
+		}
+		_, nbits, ok := parsePQ(part)
+		if !ok {
+			return fmt.Errorf("faiss: invalid PQ component %q (want PQ{M} or PQ{M}x{nbits})", part)
+		}
+		if nbits > 16 {
+			return fmt.Errorf("faiss: PQ nbits must be between 1 and 16, got %d in %q", nbits, part)
+		}
+		if strings.Contains(part, "fs") && nbits != 4 {
+			return fmt.Errorf("faiss: PQ FastScan requires nbits=4, got %d in %q", nbits, part)
+		}
+	}
+	return nil
+}
+
+// parsePQ returns the number of subquantizers and bits per subquantizer of a
+// "PQ{M}[x{nbits}]..." component (e.g. "PQ16", "PQ16x4", "PQ16x4fs"); nbits
+// defaults to 8
+func parsePQ(part string) (m, nbits int, ok bool) {
+	if !strings.HasPrefix(part, IndexTypePQ) {
+		return 0, 0, false
+	}
+	rest := strings.TrimPrefix(part, IndexTypePQ)
+	m, rest = leadingInt(rest)
+	if m <= 0 {
+		return 0, 0, false
+	}
+	nbits = 8
+	if strings.HasPrefix(rest, "x") {
+		if nbits, _ = leadingInt(rest[1:]); nbits <= 0 {
+			return 0, 0, false
+		}
+	}
+	return m, nbits, true
+}
+
+// leadingInt parses the decimal digits at the start of s, returning -1 if
+// there are none
+func leadingInt(s string) (int, string) {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	v, err := strconv.Atoi(s[:n])
+	if err != nil {
+		return -1, s
+	}
+	return v, s[n:]
+}
+
+// FactoryHandler builds an index for a custom factory description
+//
+// args is the part of the description following the registered prefix, so a
+// handler registered for "MYINDEX" receives "42" for "MYINDEX42".
+type FactoryHandler func(d int, args string, metric MetricType) (Index, error)
+
+var (
+	factoryHandlersMu sync.RWMutex
+	factoryHandlers   = map[string]FactoryHandler{}
+)
+
+// RegisterFactoryHandler makes IndexFactory dispatch descriptions starting
+// with prefix to fn
+//
+// Descriptions that match no registered prefix are passed to the FAISS
+// index_factory as before. When several prefixes match, the longest one wins.
+// RegisterFactoryHandler panics if prefix is empty, fn is nil, or prefix is
+// already registered, like database/sql.Register.
+//
+// Example:
+//
+//	faiss.RegisterFactoryHandler("MYINDEX", func(d int, args string, metric faiss.MetricType) (faiss.Index, error) {
+//	    m, err := strconv.Atoi(args)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return faiss.NewIndexHNSWFlat(d, m, metric)
+//	})
+//	index, _ := faiss.IndexFactory(128, "MYINDEX42", faiss.MetricL2)
+func RegisterFactoryHandler(prefix string, fn FactoryHandler) {
+	if prefix == "" {
+		panic("faiss: RegisterFactoryHandler prefix is empty")
+	}
+	if fn == nil {
+		panic("faiss: RegisterFactoryHandler handler is nil")
+	}
+
+	factoryHandlersMu.Lock()
+	defer factoryHandlersMu.Unlock()
+	if _, dup := factoryHandlers[prefix]; dup {
+		panic("faiss: RegisterFactoryHandler called twice for prefix " + prefix)
+	}
+	factoryHandlers[prefix] = fn
+}
+
+// UnregisterFactoryHandler removes the handler registered for prefix, if any
+func UnregisterFactoryHandler(prefix string) {
+	factoryHandlersMu.Lock()
+	defer factoryHandlersMu.Unlock()
+	delete(factoryHandlers, prefix)
+}
+
+// lookupFactoryHandler returns the handler with the longest prefix matching
+// description, or nil if there is none
+func lookupFactoryHandler(description string) (string, FactoryHandler) {
+	factoryHandlersMu.RLock()
+	defer factoryHandlersMu.RUnlock()
+
+	var bestPrefix string
+	var best FactoryHandler
+	for prefix, fn := range factoryHandlers {
+		if strings.HasPrefix(description, prefix) && len(prefix) > len(bestPrefix) {
+			bestPrefix, best = prefix, fn
+		}
+	}
+	return bestPrefix, best
+}
+
+// FactoryTrainSize is the maximum number of vectors IndexFactoryFromFile
+// reads from the start of the file to train indexes that need training (and
+// Transcode takes from the source index)
+const FactoryTrainSize = 100000
+
+// ProgressFunc is called as a long-running operation advances, with the
+// number of items processed so far out of total
+type ProgressFunc func(done, total int64)
+
 // IndexFactoryFromFile creates an index from a factory description and immediately
 // loads vectors from a file.
 //
 // This is a convenience function that combines IndexFactory + Train + Add.
+// The file must be in .fvecs format: each vector is stored as its dimension
+// (little-endian int32) followed by that many little-endian float32 values.
+// Every vector must have dimension d.
+//
+// If the index requires training, it is trained on the first
+// FactoryTrainSize vectors of the file. All vectors are then added in
+// batches of DefaultAddBatchSize, so only one batch is held in memory.
+//
+// Example:
+//
+//	index, err := faiss.IndexFactoryFromFile(128, "IVF1024,Flat", faiss.MetricL2, "base.fvecs")
 func IndexFactoryFromFile(d int, description string, metric MetricType, vectorFile string) (Index, error) {
+	return IndexFactoryFromFileWithProgress(d, description, metric, vectorFile, nil)
+}
+
+// IndexFactoryFromFileWithProgress is IndexFactoryFromFile with a callback
+// that is invoked after every batch is added, with the number of vectors
+// added so far and the total number of vectors in the file. A nil progress
+// is allowed.
+//
+// Example:
+//
+//	index, err := faiss.IndexFactoryFromFileWithProgress(128, "HNSW32", faiss.MetricL2, "base.fvecs",
+//	    func(done, total int64) {
+//	        log.Printf("added %d/%d vectors", done, total)
+//	    })
+func IndexFactoryFromFileWithProgress(d int, description string, metric MetricType, vectorFile string, progress ProgressFunc) (Index, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+
+	f, err := os.Open(vectorFile)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to open vector file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to stat vector file: %w", err)
+	}
+
+	// Every record has the same size, so the vector count is known upfront
+	// and a truncated file is detected before any work is done
+	recordSize := int64(4 + 4*d)
+	if info.Size()%recordSize != 0 {
+		return nil, fmt.Errorf("faiss: %s is not a valid .fvecs file for d=%d: size %d is not a multiple of the %d-byte record size",
+			vectorFile, d, info.Size(), recordSize)
+	}
+	total := info.Size() / recordSize
+	if total == 0 {
+		return nil, fmt.Errorf("faiss: %s contains no vectors", vectorFile)
+	}
+
 	index, err := IndexFactory(d, description, metric)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Implement vector file loading if needed
-	// For now, just return the index
+	if err := loadFvecsIntoIndex(index, f, d, total, progress); err != nil {
+		index.Close()
+		return nil, err
+	}
+
 	return index, nil
 }
 
+// loadFvecsIntoIndex trains the index if needed and adds every vector of f
+func loadFvecsIntoIndex(index Index, f *os.File, d int, total int64, progress ProgressFunc) error {
+	if !index.IsTrained() {
+		nTrain := total
+		if nTrain > FactoryTrainSize {
+			nTrain = FactoryTrainSize
+		}
+
+		training := make([]float32, nTrain*int64(d))
+		if err := readFvecs(bufio.NewReader(f), d, 0, training); err != nil {
+			return err
+		}
+		if err := index.Train(training); err != nil {
+			return fmt.Errorf("faiss: training failed: %w", err)
+		}
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("faiss: failed to rewind vector file: %w", err)
+		}
+	}
+
+	batchSize := total
+	if batchSize > DefaultAddBatchSize {
+		batchSize = DefaultAddBatchSize
+	}
+
+	r := bufio.NewReader(f)
+	batch := make([]float32, batchSize*int64(d))
+	for added := int64(0); added < total; {
+		n := total - added
+		if n > batchSize {
+			n = batchSize
+		}
+
+		chunk := batch[:n*int64(d)]
+		if err := readFvecs(r, d, added, chunk); err != nil {
+			return err
+		}
+		if err := index.Add(chunk); err != nil {
+			return fmt.Errorf("faiss: failed to add vectors %d-%d: %w", added, added+n, err)
+		}
+
+		added += n
+		if progress != nil {
+			progress(added, total)
+		}
+	}
+
+	return nil
+}
+
+// Transcode builds a new index of type targetFactory holding the vectors of
+// src, without going back to the original data
+//
+// Every vector is reconstructed from src, so a lossy source (PQ, SQ) passes
+// on its quantization error. The target is built by IndexFactory with the
+// metric of src, trained on the first FactoryTrainSize vectors if needed,
+// and filled with all of them. IDs are preserved when src is an IDMap
+// (NewIndexIDMap or an "IDMap," factory index): the target gets an "IDMap,"
+// prefix unless its description has one or it is an IVF index, which
+// stores IDs itself. src must support reconstruction; IVF factory indexes
+// get their direct map enabled. src is left unchanged otherwise.
+//
+// Example:
+//
+//	// Prototype with Flat, productionize with IVFPQ
+//	ivfpq, err := faiss.Transcode(flat, "IVF1024,PQ16")
+func Transcode(src Index, targetFactory string) (Index, error) {
+	if src == nil {
+		return nil, fmt.Errorf("faiss: source index cannot be nil")
+	}
+	if isClosed(src) {
+		return nil, ErrNullPointer
+	}
+
+	// IDMap vectors are read from the wrapped FAISS index, in id_map order
+	var ids []int64
+	vectorSource := src
+	if ptr, ok := indexPointer(src); ok && faissIndexIsIDMap(ptr) {
+		ids = faissIndexIDMapIDs(ptr)
+		vectorSource = &GenericIndex{ptr: faissIndexIDMapSubIndex(ptr), d: src.D(), metric: src.MetricType(), ntotal: int64(len(ids))}
+	}
+
+	if err := ensureGenericDirectMap(vectorSource); err != nil {
+		return nil, err
+	}
+	vectors, err := reconstructAll(vectorSource)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: source vectors cannot be reconstructed: %w", err)
+	}
+
+	d := src.D()
+	target, err := IndexFactory(d, targetFactory, src.MetricType())
+	if err != nil {
+		return nil, err
+	}
+	if ids != nil && !strings.HasPrefix(targetFactory, "IDMap") {
+		if ptr, ok := indexPointer(target); !ok || !storesIDs(ptr) {
+			target.Close()
+			if target, err = IndexFactory(d, "IDMap,"+targetFactory, src.MetricType()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !target.IsTrained() {
+		if len(vectors) == 0 {
+			target.Close()
+			return nil, fmt.Errorf("faiss: cannot train %q on an empty source index", targetFactory)
+		}
+		nTrain := min(int64(len(vectors)/d), FactoryTrainSize)
+		if err := target.Train(vectors[:nTrain*int64(d)]); err != nil {
+			target.Close()
+			return nil, fmt.Errorf("faiss: training failed: %w", err)
+		}
+	}
+
+	if ids != nil {
+		err = target.(interface {
+			AddWithIDs(vectors []float32, ids []int64) error
+		}).AddWithIDs(vectors, ids)
+	} else {
+		err = target.Add(vectors)
+	}
+	if err != nil {
+		target.Close()
+		return nil, fmt.Errorf("faiss: failed to add vectors: %w", err)
+	}
+	return target, nil
+}
+
+// storesIDs reports whether an index keeps caller-provided IDs: IDMap and
+// IVF indexes
+func storesIDs(ptr uintptr) bool {
+	if faissIndexIsIDMap(ptr) {
+		return true
+	}
+	_, err := faissIndexIVFGetNlist(ptr)
+	return err == nil
+}
+
+// AddFromReader streams .fvecs records from r into index until EOF
+//
+// Vectors are added in batches of DefaultAddBatchSize, so memory use is
+// bounded however long the stream is (e.g. os.Stdin fed by another process).
+// Every record must have dimension d, which must match the index. The index
+// must already be trained.
+//
+// added is the number of vectors added to the index, also when an error
+// stops the stream part way: a bad record fails only its own batch, and
+// everything before that batch stays in the index.
+//
+// Example:
+//
+//	added, err := faiss.AddFromReader(index, os.Stdin, 768)
+func AddFromReader(index Index, r io.Reader, d int) (added int64, err error) {
+	if index == nil {
+		return 0, fmt.Errorf("faiss: index cannot be nil")
+	}
+	if d <= 0 {
+		return 0, ErrInvalidDimension
+	}
+	if d != index.D() {
+		return 0, fmt.Errorf("faiss: stream dimension %d does not match index dimension %d: %w", d, index.D(), ErrDimensionMismatch)
+	}
+
+	br := bufio.NewReader(r)
+	record := make([]byte, 4+4*d)
+	batch := make([]float32, 0, DefaultAddBatchSize*d)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n := int64(len(batch) / d)
+		if err := index.Add(batch); err != nil {
+			return fmt.Errorf("faiss: failed to add vectors %d-%d: %w", added, added+n, err)
+		}
+		added += n
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		n := len(batch)
+		batch = batch[:n+d]
+		if err := readFvecsRecord(br, record, batch[n:], added+int64(n/d)); err == io.EOF {
+			batch = batch[:n]
+			break
+		} else if err != nil {
+			return added, err
+		}
+
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return added, err
+			}
+		}
+	}
+	return added, flush()
+}
+
+// readFvecs reads len(dst)/d .fvecs records into dst, checking that every
+// record has dimension d. first is the index of the first record, used in
+// error messages.
+func readFvecs(r io.Reader, d int, first int64, dst []float32) error {
+	record := make([]byte, 4+4*d)
+	for i := 0; i < len(dst)/d; i++ {
+		err := readFvecsRecord(r, record, dst[i*d:(i+1)*d], first+int64(i))
+		if err == io.EOF {
+			return fmt.Errorf("faiss: failed to read vector %d: %w", first+int64(i), err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFvecsRecord reads the .fvecs record numbered i into row, checking that
+// its dimension is len(row). record is scratch space of 4+4*len(row) bytes.
+// It returns io.EOF unwrapped if the stream ends cleanly before the record.
+func readFvecsRecord(r io.Reader, record []byte, row []float32, i int64) error {
+	if _, err := io.ReadFull(r, record); err == io.EOF {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("faiss: failed to read vector %d: %w", i, err)
+	}
+
+	dim := int32(binary.LittleEndian.Uint32(record))
+	if int(dim) != len(row) {
+		return fmt.Errorf("faiss: vector %d has dimension %d, want %d: %w", i, dim, len(row), ErrDimensionMismatch)
+	}
+	for j := range row {
+		row[j] = math.Float32frombits(binary.LittleEndian.Uint32(record[4+4*j:]))
+	}
+	return nil
+}
+
 // ParseIndexDescription parses an index factory description and returns its components.
 // This is useful for understanding what a factory string will create.
 //
@@ -206,9 +733,10 @@ func parseHNSWComponent(first string, result map[string]interface{}) {
 
 func parsePQComponent(first string, result map[string]interface{}) {
 	result["type"] = IndexTypePQ
-	nbytesStr := strings.TrimPrefix(first, IndexTypePQ)
-	if nbytes, err := strconv.Atoi(nbytesStr); err == nil {
-		result["nbytes"] = nbytes
+	if m, nbits, ok := parsePQ(first); ok {
+		result["M"] = m
+		result["nbits"] = nbits
+		result["nbytes"] = (m*nbits + 7) / 8
 	}
 	result["training_required"] = true
 }
@@ -257,15 +785,44 @@ func parseRequirements(requirements map[string]interface{}) indexRecommendation
 	return rec
 }
 
-func calculateNlist(n int64) int {
-	nlist := int(n / 1000)
-	if nlist > 65536 {
-		nlist = 65536
+// SuggestNlist returns a number of IVF lists for numVectors vectors, the
+// value RecommendIndex puts in its IVF descriptions
+//
+// It is numVectors/1000, clamped to [100, 65536]. Train requires 30 training
+// vectors per list (see TrainingSizeError), so with the minimum of 100 lists
+// it needs at least 3000.
+//
+// Example:
+//
+//	nlist := faiss.SuggestNlist(int64(len(vectors) / d)) // 1000 for 1M vectors
+//	index, _ := faiss.NewIndexIVFFlat(quantizer, d, nlist, faiss.MetricL2)
+func SuggestNlist(numVectors int64) int {
+	return int(min(max(numVectors/1000, 100), 65536))
+}
+
+// SuggestNprobe returns a number of lists to probe for an IVF index with
+// nlist lists to reach roughly targetRecall
+//
+// Recall grows with the fraction of lists probed, and clustered data needs
+// about sqrt(nlist) probes for a balanced setting. The suggestion is
+// sqrt(nlist)/4 * r/(1-r) for r = targetRecall: about sqrt(nlist)/4 probes
+// at r = 0.5 and 2.25*sqrt(nlist) at 0.9. It is clamped to [1, nlist], and a
+// targetRecall of 1 or more probes every list (exact search). Measure the
+// actual recall on a sample (see VerifyRecall) before relying on it.
+//
+// Example:
+//
+//	index.SetNprobe(faiss.SuggestNprobe(nlist, 0.9))
+func SuggestNprobe(nlist int, targetRecall float64) int {
+	if nlist <= 1 {
+		return 1
 	}
-	if nlist < 100 {
-		nlist = 100
+	if targetRecall >= 1 {
+		return nlist
 	}
-	return nlist
+	r := max(targetRecall, 0)
+	nprobe := math.Round(math.Sqrt(float64(nlist)) / 4 * r / (1 - r))
+	return int(min(max(nprobe, 1), float64(nlist)))
 }
 
 func recommendSmallDataset(rec indexRecommendation) string {
@@ -285,7 +842,7 @@ func recommendMediumDataset(n int64, rec indexRecommendation) string {
 	case "accurate":
 		return "HNSW64"
 	default:
-		nlist := calculateNlist(n)
+		nlist := SuggestNlist(n)
 		if rec.memoryPref == "low" {
 			return fmt.Sprintf("IVF%d,PQ8", nlist)
 		}
@@ -294,7 +851,7 @@ func recommendMediumDataset(n int64, rec indexRecommendation) string {
 }
 
 func recommendLargeDataset(n int64, d int, rec indexRecommendation) string {
-	nlist := calculateNlist(n)
+	nlist := SuggestNlist(n)
 
 	switch rec.memoryPref {
 	case "low":
@@ -314,7 +871,7 @@ func recommendLargeDataset(n int64, d int, rec indexRecommendation) string {
 }
 
 func recommendVeryLargeDataset(n int64, d int) string {
-	nlist := calculateNlist(n)
+	nlist := SuggestNlist(n)
 
 	if d >= 256 {
 		return fmt.Sprintf("OPQ16,IVF%d,PQ16", nlist)
@@ -386,5 +943,103 @@ func ValidateIndexDescription(description string) error {
 		}
 	}
 
-	return nil
+	if err := validatePQDescription(description); err != nil {
+		return err
+	}
+	return validateOPQDescription(0, description)
+}
+
+// GridResult is the evaluation of one factory description by GridSearch
+type GridResult struct {
+	FactoryString string
+	Recall        float64       // recall@k against the ground truth
+	BuildTime     time.Duration // time to create, train and add
+	SearchLatency time.Duration // mean search time per query
+	MemoryBytes   int64         // serialized index size
+	Err           error         // set if the config could not be built or searched
+}
+
+// GridSearch builds, evaluates and frees one index per factory description
+// to help choose an index type for a dataset
+//
+// Every config is created with MetricL2, trained on trainData when needed,
+// filled with trainData, and searched with queries; trainData and queries
+// share the dimension implied by len(queries)/nq. gtLabels holds the true k
+// nearest neighbors of each query among trainData (nq*k IDs), e.g. from
+// KNN or a flat index. MemoryBytes is the serialized index size, a close
+// estimate of what FAISS keeps in memory.
+//
+// Results are returned in config order. A config that fails to build or
+// search gets its error in Err and zero measurements, so one bad entry does
+// not abort the grid.
+//
+// Example:
+//
+//	_, gt, _ := faiss.KNN(base, queries, d, 10, faiss.MetricL2)
+//	results := faiss.GridSearch(base, queries, gt, 10, []string{"HNSW32", "IVF1024,SQ8", "IVF4096,PQ32"})
+//	for _, r := range results {
+//	    fmt.Printf("%-14s recall=%.3f build=%v latency=%v mem=%d\n",
+//	        r.FactoryString, r.Recall, r.BuildTime, r.SearchLatency, r.MemoryBytes)
+//	}
+func GridSearch(trainData, queries []float32, gtLabels []int64, k int, configs []string) []GridResult {
+	results := make([]GridResult, len(configs))
+	for i, config := range configs {
+		results[i] = evaluateConfig(trainData, queries, gtLabels, k, config)
+	}
+	return results
+}
+
+// evaluateConfig runs one GridSearch entry
+func evaluateConfig(trainData, queries []float32, gtLabels []int64, k int, config string) GridResult {
+	result := GridResult{FactoryString: config}
+	if k <= 0 {
+		result.Err = ErrInvalidK
+		return result
+	}
+	nq := len(gtLabels) / k
+	if nq == 0 || len(gtLabels) != nq*k || len(queries)%nq != 0 || len(queries) == 0 {
+		result.Err = fmt.Errorf("faiss: %d query values do not match %d ground truth labels for k=%d: %w",
+			len(queries), len(gtLabels), k, ErrDimensionMismatch)
+		return result
+	}
+	d := len(queries) / nq
+
+	start := time.Now()
+	index, err := IndexFactory(d, config, MetricL2)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer index.Close()
+	if !index.IsTrained() {
+		if err := index.Train(trainData); err != nil {
+			result.Err = fmt.Errorf("faiss: training %s failed: %w", config, err)
+			return result
+		}
+	}
+	if err := index.Add(trainData); err != nil {
+		result.Err = fmt.Errorf("faiss: adding to %s failed: %w", config, err)
+		return result
+	}
+	buildTime := time.Since(start)
+
+	start = time.Now()
+	_, labels, err := index.Search(queries, k)
+	if err != nil {
+		result.Err = fmt.Errorf("faiss: searching %s failed: %w", config, err)
+		return result
+	}
+	searchTime := time.Since(start)
+
+	data, err := SerializeIndex(index)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Recall = ComputeRecall(gtLabels, labels, nq, k, k)
+	result.BuildTime = buildTime
+	result.SearchLatency = searchTime / time.Duration(nq)
+	result.MemoryBytes = int64(len(data))
+	return result
 }
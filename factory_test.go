@@ -1,6 +1,16 @@
 package faiss
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -216,6 +226,66 @@ func TestIndexFactory_PQ(t *testing.T) {
 	t.Logf("✅ PQ8 index test PASSED!")
 }
 
+func TestIndexFactory_PQNbits(t *testing.T) {
+	d := 32
+	tests := []struct {
+		desc     string
+		m, nbits int
+	}{
+		{"PQ16x4", 16, 4},
+		{"PQ8x6", 8, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			index, err := IndexFactory(d, tt.desc, MetricL2)
+			if err != nil {
+				t.Fatalf("IndexFactory(%q) failed: %v", tt.desc, err)
+			}
+			defer index.Close()
+
+			vectors := generateVectors(39<<tt.nbits, d)
+			if err := index.Train(vectors); err != nil {
+				t.Fatalf("Train() failed: %v", err)
+			}
+			if err := index.Add(vectors[:10*d]); err != nil {
+				t.Fatalf("Add() failed: %v", err)
+			}
+
+			ptr, _ := indexPointer(index)
+			m, nbits, err := faissIndexPQParams(ptr)
+			if err != nil {
+				t.Fatalf("faissIndexPQParams() failed: %v", err)
+			}
+			if m != tt.m || nbits != tt.nbits {
+				t.Errorf("M, nbits = %d, %d, want %d, %d", m, nbits, tt.m, tt.nbits)
+			}
+			centroids, err := faissIndexPQCentroidsSize(ptr)
+			if err != nil {
+				t.Fatalf("faissIndexPQCentroidsSize() failed: %v", err)
+			}
+			if want := int64(d << tt.nbits); centroids != want {
+				t.Errorf("codebook size = %d floats, want %d (d * 2^nbits)", centroids, want)
+			}
+
+			info := ParseIndexDescription(tt.desc)
+			if info["M"] != tt.m || info["nbits"] != tt.nbits || info["nbytes"] != tt.m*tt.nbits/8 {
+				t.Errorf("ParseIndexDescription(%q) = %v", tt.desc, info)
+			}
+		})
+	}
+
+	for _, desc := range []string{"PQ8x17", "PQ16x8fs", "PQx4", "IVF16,PQ8x20"} {
+		if index, err := IndexFactory(d, desc, MetricL2); err == nil {
+			index.Close()
+			t.Errorf("IndexFactory(%q) should return error", desc)
+		}
+		if err := ValidateIndexDescription(desc); err == nil {
+			t.Errorf("ValidateIndexDescription(%q) should return error", desc)
+		}
+	}
+}
+
 // TestIndexFactory_LSH tests LSH index creation
 func TestIndexFactory_LSH(t *testing.T) {
 	d := 128
@@ -297,6 +367,55 @@ func TestIndexFactory_WithTransform(t *testing.T) {
 	t.Logf("   Reduced from %d to %d dims, trained and searched successfully", d, dReduced)
 }
 
+func TestIndexFactory_OPQ(t *testing.T) {
+	d := 32
+	vectors := generateVectors(3000, d)
+
+	// OPQ output dimension 16 feeds an 8-subquantizer PQ
+	for _, desc := range []string{"OPQ8,IVF16,PQ8x4", "OPQ8_16,PQ8x4"} {
+		index, err := IndexFactory(d, desc, MetricL2)
+		if err != nil {
+			t.Fatalf("IndexFactory(%q) failed: %v", desc, err)
+		}
+		if index.D() != d {
+			t.Errorf("%s: D() = %d, want %d", desc, index.D(), d)
+		}
+		if err := index.Train(vectors); err != nil {
+			t.Fatalf("%s: Train() failed: %v", desc, err)
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("%s: Add() failed: %v", desc, err)
+		}
+		if _, labels, err := index.Search(vectors[:d], 5); err != nil || labels[0] < 0 {
+			t.Errorf("%s: Search() = %v, %v", desc, labels, err)
+		}
+		index.Close()
+	}
+
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{"OPQ8,IVF16,PQ16", "OPQ M=8 must equal PQ M=16"},
+		{"OPQ16,PQ8x4", "OPQ M=16 must equal PQ M=8"},
+		{"OPQ8_20,PQ8", "OPQ M=8 must divide the OPQ output dimension 20"},
+		{"OPQ5,IVF16,PQ5", "OPQ M=5 must divide the OPQ output dimension 32"},
+	}
+	for _, tt := range tests {
+		_, err := IndexFactory(d, tt.desc, MetricL2)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("IndexFactory(%q) error = %v, want %q", tt.desc, err, tt.want)
+		}
+	}
+
+	if err := ValidateIndexDescription("OPQ8,IVF16,PQ16"); err == nil {
+		t.Error("ValidateIndexDescription() with mismatched OPQ/PQ M should return error")
+	}
+	if err := ValidateIndexDescription("OPQ16,IVF100,PQ16"); err != nil {
+		t.Errorf("ValidateIndexDescription(matching OPQ/PQ M) failed: %v", err)
+	}
+}
+
 // TestIndexFactory_InvalidDescription tests error handling
 func TestIndexFactory_InvalidDescription(t *testing.T) {
 	tests := []struct {
@@ -452,6 +571,103 @@ func TestRecommendIndex(t *testing.T) {
 	}
 }
 
+func TestSuggestNlist(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want int
+	}{
+		{-1, 100},
+		{0, 100},
+		{10000, 100},
+		{100000, 100},
+		{500000, 500},
+		{1000000, 1000},
+		{10000000, 10000},
+		{100000000, 65536},
+		{1 << 40, 65536},
+	}
+	for _, tt := range tests {
+		if got := SuggestNlist(tt.n); got != tt.want {
+			t.Errorf("SuggestNlist(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+
+	// RecommendIndex uses the same value
+	if desc := RecommendIndex(500000, 64, MetricL2, nil); !strings.Contains(desc, fmt.Sprintf("IVF%d,", SuggestNlist(500000))) {
+		t.Errorf("RecommendIndex(500000) = %q, want nlist %d", desc, SuggestNlist(500000))
+	}
+}
+
+func TestRecommendIndex_Nlist(t *testing.T) {
+	tests := []struct {
+		n    int64
+		d    int
+		want string
+	}{
+		{500000, 64, "IVF500,Flat"},
+		{2000000, 64, "IVF2000,PQ8"},
+		{2000000, 256, "PCA128,IVF2000,PQ8"},
+		{50000000, 512, "OPQ16,IVF50000,PQ16"},
+	}
+	for _, tt := range tests {
+		if got := RecommendIndex(tt.n, tt.d, MetricL2, nil); got != tt.want {
+			t.Errorf("RecommendIndex(%d, %d) = %q, want %q", tt.n, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestNprobe(t *testing.T) {
+	nlist := 1024
+	prev := 0
+	for _, recall := range []float64{0.5, 0.8, 0.9, 0.95, 0.99} {
+		nprobe := SuggestNprobe(nlist, recall)
+		if nprobe < 1 || nprobe > nlist {
+			t.Errorf("SuggestNprobe(%d, %v) = %d, want in [1, %d]", nlist, recall, nprobe, nlist)
+		}
+		if nprobe < prev {
+			t.Errorf("SuggestNprobe(%d, %v) = %d, want at least %d (higher recall)", nlist, recall, nprobe, prev)
+		}
+		prev = nprobe
+	}
+	if got := SuggestNprobe(nlist, 0.9); got != 72 {
+		t.Errorf("SuggestNprobe(1024, 0.9) = %d, want 72 (2.25*sqrt(nlist))", got)
+	}
+
+	if got := SuggestNprobe(nlist, 1); got != nlist {
+		t.Errorf("SuggestNprobe(%d, 1) = %d, want %d", nlist, got, nlist)
+	}
+	if got := SuggestNprobe(16, 1-1e-15); got != 16 {
+		t.Errorf("SuggestNprobe(16, 1-1e-15) = %d, want 16 (clamped)", got)
+	}
+	for _, recall := range []float64{0, -1} {
+		if got := SuggestNprobe(nlist, recall); got != 1 {
+			t.Errorf("SuggestNprobe(%d, %v) = %d, want 1", nlist, recall, got)
+		}
+	}
+	if got := SuggestNprobe(0, 0.9); got != 1 {
+		t.Errorf("SuggestNprobe(0, 0.9) = %d, want 1", got)
+	}
+
+	// A suggestion for a real index reaches a sensible recall
+	d, nb := 16, 5000
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(50, d)
+	nlist = SuggestNlist(int64(nb))
+	index, err := IndexFactory(d, fmt.Sprintf("IVF%d,Flat", nlist), MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer index.Close()
+	index.Train(vectors)
+	index.Add(vectors)
+	index.SetNprobe(SuggestNprobe(nlist, 0.9))
+	_, gt, _ := KNN(vectors, queries, d, 10, MetricL2)
+	_, labels, _ := index.Search(queries, 10)
+	if recall := ComputeRecall(gt, labels, 50, 10, 10); recall < 0.8 {
+		t.Errorf("recall with SuggestNprobe(%d, 0.9) = %.3f, want >= 0.8", nlist, recall)
+	}
+}
+
 // TestIndexFactory_AllTypes is a comprehensive test of various index types
 func TestIndexFactory_AllTypes(t *testing.T) {
 	d := 128
@@ -517,3 +733,425 @@ func TestIndexFactory_AllTypes(t *testing.T) {
 		})
 	}
 }
+
+// writeFvecsFile writes vectors to path in .fvecs format
+func writeFvecsFile(t *testing.T, path string, vectors []float32, d int) {
+	t.Helper()
+	if err := os.WriteFile(path, encodeFvecs(vectors, d), 0644); err != nil {
+		t.Fatalf("failed to write fvecs file: %v", err)
+	}
+}
+
+// encodeFvecs returns vectors in .fvecs format
+func encodeFvecs(vectors []float32, d int) []byte {
+	buf := make([]byte, 0, len(vectors)/d*(4+4*d))
+	for i := 0; i < len(vectors)/d; i++ {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(d))
+		for _, v := range vectors[i*d : (i+1)*d] {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+	}
+	return buf
+}
+
+// TestIndexFactoryFromFile tests loading an fvecs file into trained and
+// untrained index types
+func TestIndexFactoryFromFile(t *testing.T) {
+	d := 16
+	n := 2000
+	vectors := generateVectors(n, d)
+	path := filepath.Join(t.TempDir(), "base.fvecs")
+	writeFvecsFile(t, path, vectors, d)
+
+	for _, desc := range []string{"Flat", "IVF16,Flat"} {
+		t.Run(desc, func(t *testing.T) {
+			var calls int
+			var lastDone, lastTotal int64
+			index, err := IndexFactoryFromFileWithProgress(d, desc, MetricL2, path, func(done, total int64) {
+				calls++
+				lastDone, lastTotal = done, total
+			})
+			if err != nil {
+				t.Fatalf("IndexFactoryFromFileWithProgress() failed: %v", err)
+			}
+			defer index.Close()
+
+			if index.Ntotal() != int64(n) {
+				t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), n)
+			}
+			if calls == 0 || lastDone != int64(n) || lastTotal != int64(n) {
+				t.Errorf("progress: calls = %d, last = (%d, %d), want final (%d, %d)",
+					calls, lastDone, lastTotal, n, n)
+			}
+
+			index.SetNprobe(16)
+			distances, labels, err := index.Search(vectors[:10*d], 1)
+			if err != nil {
+				t.Fatalf("Search() failed: %v", err)
+			}
+			for i := 0; i < 10; i++ {
+				if labels[i] != int64(i) || distances[i] > 1e-5 {
+					t.Errorf("Search(vector %d) = (%d, %v), want (%d, 0)", i, labels[i], distances[i], i)
+				}
+			}
+		})
+	}
+}
+
+// TestIndexFactoryFromFile_Invalid tests that malformed files are rejected
+func TestIndexFactoryFromFile_Invalid(t *testing.T) {
+	d := 4
+	dir := t.TempDir()
+	vectors := generateVectors(3, d)
+
+	good := filepath.Join(dir, "good.fvecs")
+	writeFvecsFile(t, good, vectors, d)
+	data, _ := os.ReadFile(good)
+
+	truncated := filepath.Join(dir, "truncated.fvecs")
+	os.WriteFile(truncated, data[:len(data)-2], 0644)
+
+	// Same file size, but the second record claims a different dimension
+	wrongDim := filepath.Join(dir, "wrongdim.fvecs")
+	corrupted := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(corrupted[4+4*d:], uint32(d+1))
+	os.WriteFile(wrongDim, corrupted, 0644)
+
+	empty := filepath.Join(dir, "empty.fvecs")
+	os.WriteFile(empty, nil, 0644)
+
+	tests := []struct {
+		name string
+		d    int
+		path string
+	}{
+		{"missing file", d, filepath.Join(dir, "missing.fvecs")},
+		{"truncated", d, truncated},
+		{"wrong record dimension", d, wrongDim},
+		{"dimension mismatch", d * 2, good},
+		{"empty", d, empty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := IndexFactoryFromFile(tt.d, "Flat", MetricL2, tt.path)
+			if err == nil {
+				index.Close()
+				t.Error("IndexFactoryFromFile() should return error")
+			}
+		})
+	}
+}
+
+// TestRegisterFactoryHandler tests dispatching custom descriptions to a
+// registered handler
+func TestRegisterFactoryHandler(t *testing.T) {
+	var gotArgs string
+	RegisterFactoryHandler("MYINDEX", func(d int, args string, metric MetricType) (Index, error) {
+		gotArgs = args
+		M, err := strconv.Atoi(args)
+		if err != nil {
+			return nil, err
+		}
+		return NewIndexHNSWFlat(d, M, metric)
+	})
+	defer UnregisterFactoryHandler("MYINDEX")
+
+	// A longer prefix takes precedence
+	RegisterFactoryHandler("MYINDEXFLAT", func(d int, args string, metric MetricType) (Index, error) {
+		return NewIndexFlat(d, metric)
+	})
+	defer UnregisterFactoryHandler("MYINDEXFLAT")
+
+	index, err := IndexFactory(16, "MYINDEX42", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory(MYINDEX42) failed: %v", err)
+	}
+	defer index.Close()
+	if gotArgs != "42" {
+		t.Errorf("handler args = %q, want %q", gotArgs, "42")
+	}
+	if params := IndexParams(index); params["efConstruction"] == nil {
+		t.Errorf("IndexParams() = %v, want an HNSW index", params)
+	}
+
+	vectors := generateVectors(100, 16)
+	index.Add(vectors)
+	_, labels, err := index.Search(vectors[:16], 1)
+	if err != nil || labels[0] != 0 {
+		t.Errorf("Search() = (%v, %v), want nearest 0", labels, err)
+	}
+
+	flat, err := IndexFactory(16, "MYINDEXFLAT", MetricInnerProduct)
+	if err != nil {
+		t.Fatalf("IndexFactory(MYINDEXFLAT) failed: %v", err)
+	}
+	defer flat.Close()
+	if _, ok := flat.(*IndexFlat); !ok || flat.MetricType() != MetricInnerProduct {
+		t.Errorf("IndexFactory(MYINDEXFLAT) = %T (%s), want *IndexFlat (IP)", flat, flat.MetricType())
+	}
+
+	// Handler errors are returned, and built-in descriptions still work
+	if _, err := IndexFactory(16, "MYINDEXabc", MetricL2); err == nil {
+		t.Error("IndexFactory() should return the handler error")
+	}
+	builtin, err := IndexFactory(16, "Flat", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory(Flat) failed: %v", err)
+	}
+	builtin.Close()
+
+	UnregisterFactoryHandler("MYINDEXFLAT")
+	if _, err := IndexFactory(16, "MYINDEXFLAT", MetricL2); err == nil {
+		t.Error("IndexFactory() after unregistering should not use the removed handler")
+	}
+}
+
+// TestRegisterFactoryHandler_Invalid tests that invalid registrations panic
+func TestRegisterFactoryHandler_Invalid(t *testing.T) {
+	handler := func(d int, args string, metric MetricType) (Index, error) {
+		return NewIndexFlat(d, metric)
+	}
+	RegisterFactoryHandler("DUP", handler)
+	defer UnregisterFactoryHandler("DUP")
+
+	tests := []struct {
+		name   string
+		prefix string
+		fn     FactoryHandler
+	}{
+		{"empty prefix", "", handler},
+		{"nil handler", "NILHANDLER", nil},
+		{"duplicate prefix", "DUP", handler},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterFactoryHandler() should panic")
+				}
+			}()
+			RegisterFactoryHandler(tt.prefix, tt.fn)
+		})
+	}
+}
+
+// ========================================
+// Transcode Tests
+// ========================================
+
+func TestTranscode(t *testing.T) {
+	d := 16
+	nb := 2000
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(20, d)
+	k := 5
+
+	flat := mustCreateIndexFlatL2(t, d)
+	defer flat.Close()
+	flat.Add(vectors)
+	wantDist, wantLabels, _ := flat.Search(queries, k)
+
+	ivf, err := Transcode(flat, "IVF16,Flat")
+	if err != nil {
+		t.Fatalf("Transcode() failed: %v", err)
+	}
+	defer ivf.Close()
+	if ivf.Ntotal() != int64(nb) || ivf.MetricType() != MetricL2 {
+		t.Errorf("Ntotal() = %d, metric = %v, want %d, L2", ivf.Ntotal(), ivf.MetricType(), nb)
+	}
+
+	// Probing every list makes IVF search exhaustive
+	ivf.SetNprobe(16)
+	distances, labels, err := ivf.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for i := range labels {
+		if labels[i] != wantLabels[i] || math.Abs(float64(distances[i]-wantDist[i])) > 1e-3 {
+			t.Errorf("result %d = (%d, %v), want (%d, %v)", i, labels[i], distances[i], wantLabels[i], wantDist[i])
+			break
+		}
+	}
+	if flat.Ntotal() != int64(nb) {
+		t.Errorf("source Ntotal() = %d, want %d", flat.Ntotal(), nb)
+	}
+
+	// Transcoding back from IVF needs its direct map
+	back, err := Transcode(ivf, "Flat")
+	if err != nil {
+		t.Fatalf("Transcode(IVF) failed: %v", err)
+	}
+	defer back.Close()
+	_, labels, _ = back.Search(queries, k)
+	for i := range labels {
+		if labels[i] != wantLabels[i] {
+			t.Errorf("round trip label %d = %d, want %d", i, labels[i], wantLabels[i])
+			break
+		}
+	}
+}
+
+func TestTranscode_PreservesIDs(t *testing.T) {
+	d := 8
+	nb := 500
+	vectors := generateVectors(nb, d)
+	ids := make([]int64, nb)
+	for i := range ids {
+		ids[i] = int64(1000 + 3*i)
+	}
+
+	base, _ := NewIndexFlatL2(d)
+	defer base.Close()
+	idmap, err := NewIndexIDMap(base)
+	if err != nil {
+		t.Fatalf("NewIndexIDMap() failed: %v", err)
+	}
+	defer idmap.Close()
+	idmap.AddWithIDs(vectors, ids)
+
+	generic, _ := IndexFactory(d, "IDMap,Flat", MetricL2)
+	defer generic.Close()
+	generic.(*GenericIndex).AddWithIDs(vectors, ids)
+
+	for _, src := range []Index{idmap, generic} {
+		// Flat gets an IDMap prefix; IVF stores the IDs itself
+		for _, desc := range []string{"Flat", "HNSW16", "IVF8,Flat"} {
+			target, err := Transcode(src, desc)
+			if err != nil {
+				t.Fatalf("%T to %s: Transcode() failed: %v", src, desc, err)
+			}
+			target.SetNprobe(8)
+			for _, i := range []int{0, 123, nb - 1} {
+				_, labels, _ := target.Search(vectors[i*d:(i+1)*d], 1)
+				if labels[0] != ids[i] {
+					t.Errorf("%T to %s: Search(vector %d) = %d, want %d", src, desc, i, labels[0], ids[i])
+				}
+			}
+			target.Close()
+		}
+	}
+}
+
+func TestTranscode_Invalid(t *testing.T) {
+	if _, err := Transcode(nil, "Flat"); err == nil {
+		t.Error("Expected error for nil source")
+	}
+
+	flat := mustCreateIndexFlatL2(t, 8)
+	if _, err := Transcode(flat, "Bogus42"); err == nil {
+		t.Error("Expected error for invalid description")
+	}
+	if _, err := Transcode(flat, "IVF4,Flat"); err == nil {
+		t.Error("Expected error for training on an empty source")
+	}
+	flat.Close()
+	if _, err := Transcode(flat, "Flat"); err == nil {
+		t.Error("Expected error for closed source")
+	}
+}
+
+// ========================================
+// AddFromReader Tests
+// ========================================
+
+func TestAddFromReader(t *testing.T) {
+	d := 8
+	n := 1234
+	vectors := generateVectors(n, d)
+
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+
+	added, err := AddFromReader(index, bytes.NewReader(encodeFvecs(vectors, d)), d)
+	if err != nil {
+		t.Fatalf("AddFromReader() failed: %v", err)
+	}
+	if added != int64(n) || index.Ntotal() != int64(n) {
+		t.Errorf("AddFromReader() added %d, Ntotal() = %d, want %d", added, index.Ntotal(), n)
+	}
+	_, labels, _ := index.Search(vectors[(n-1)*d:], 1)
+	if labels[0] != int64(n-1) {
+		t.Errorf("Search(last vector) = %d, want %d", labels[0], n-1)
+	}
+
+	// An empty stream adds nothing
+	if added, err := AddFromReader(index, bytes.NewReader(nil), d); err != nil || added != 0 {
+		t.Errorf("AddFromReader(empty) = %d, %v, want 0, nil", added, err)
+	}
+}
+
+func TestAddFromReader_Invalid(t *testing.T) {
+	d := 4
+	data := encodeFvecs(generateVectors(3, d), d)
+
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+
+	if _, err := AddFromReader(nil, bytes.NewReader(data), d); err == nil {
+		t.Error("Expected error for nil index")
+	}
+	if _, err := AddFromReader(index, bytes.NewReader(data), 8); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("AddFromReader() with wrong d error = %v, want ErrDimensionMismatch", err)
+	}
+
+	if _, err := AddFromReader(index, bytes.NewReader(data[:len(data)-3]), d); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("AddFromReader() on truncated stream error = %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	bad := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(bad[2*(4+4*d):], 5)
+	if _, err := AddFromReader(index, bytes.NewReader(bad), d); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("AddFromReader() with bad record error = %v, want ErrDimensionMismatch", err)
+	}
+	if index.Ntotal() != 0 {
+		t.Errorf("Ntotal() = %d after failed streams, want 0", index.Ntotal())
+	}
+}
+
+// ========================================
+// GridSearch Tests
+// ========================================
+
+func TestGridSearch(t *testing.T) {
+	d := 16
+	nb := 2000
+	nq := 20
+	k := 10
+	base := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+	_, gt, err := KNN(base, queries, d, k, MetricL2)
+	if err != nil {
+		t.Fatalf("KNN() failed: %v", err)
+	}
+
+	configs := []string{"Flat", "IVF16,Flat", "NotAnIndex"}
+	results := GridSearch(base, queries, gt, k, configs)
+	if len(results) != len(configs) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(configs))
+	}
+
+	for i, r := range results[:2] {
+		t.Logf("%s: recall=%.3f build=%v latency=%v mem=%d", r.FactoryString, r.Recall, r.BuildTime, r.SearchLatency, r.MemoryBytes)
+		if r.Err != nil {
+			t.Errorf("%s: Err = %v", configs[i], r.Err)
+			continue
+		}
+		if r.FactoryString != configs[i] {
+			t.Errorf("FactoryString = %q, want %q", r.FactoryString, configs[i])
+		}
+		if r.Recall <= 0 || r.BuildTime <= 0 || r.SearchLatency <= 0 {
+			t.Errorf("%s: measurements not populated: %+v", configs[i], r)
+		}
+		if r.MemoryBytes < int64(nb*d*4) {
+			t.Errorf("%s: MemoryBytes = %d, want >= %d", configs[i], r.MemoryBytes, nb*d*4)
+		}
+	}
+	if results[0].Recall != 1 {
+		t.Errorf("Flat recall = %v, want 1", results[0].Recall)
+	}
+	if results[2].Err == nil {
+		t.Error("invalid config: Err = nil, want an error")
+	}
+}
@@ -58,7 +58,9 @@
 //  - IndexHNSW: Best recall/speed tradeoff, excellent for production
 //  - IndexPQ: 8-32x compression, great for memory-constrained scenarios
 //  - IndexIVFPQ: Combines speed and compression, best overall balance
-//  - IndexOnDisk: For billion-scale datasets that don't fit in RAM
+//  - IndexIVFFlatOnDisk / IndexIVFPQOnDisk: For billion-scale datasets that
+//    don't fit in RAM; the inverted lists are memory-mapped from a file and the
+//    index can be reopened with OpenIndexIVFPQOnDisk
 //  - GPU indexes: 10-100x faster search with CUDA acceleration
 //
 // # Build Modes
@@ -122,11 +124,14 @@
 //
 // # Metrics
 //
-// FAISS supports two distance metrics:
+// FAISS supports two main distance metrics:
 //
 //   - MetricL2: Euclidean (L2) distance - lower is more similar
 //   - MetricInnerProduct: Inner product - higher is more similar
 //
+// Flat indexes additionally support MetricCanberra and MetricBrayCurtis
+// (lower is more similar), which are common for abundance/count data.
+//
 // For cosine similarity, normalize vectors and use MetricInnerProduct:
 //
 //	normalized := normalize(vectors) // Divide by L2 norm
@@ -152,6 +157,12 @@
 //	    indexes[i].Add(vectors) // Same data in each
 //	}
 //
+// Option 3 - ConcurrentAdd for parallel ingestion (adds only, no searches
+// until ingestion is done):
+//
+//	go faiss.ConcurrentAdd(index, batchA)
+//	go faiss.ConcurrentAdd(index, batchB)
+//
 // # Memory Management
 //
 // Always call Close() to free C++ resources:
@@ -237,11 +248,15 @@ var (
 	// ErrInvalidDimension is returned when dimension is invalid
 	ErrInvalidDimension = errors.New("faiss: invalid dimension (must be > 0)")
 	// ErrInvalidVectors is returned when vector data is invalid
-	ErrInvalidVectors = errors.New("faiss: invalid vectors (length must be multiple of dimension)")
+	// It matches ErrDimensionMismatch under errors.Is.
+	ErrInvalidVectors error = &kindError{"faiss: invalid vectors (length must be multiple of dimension)", ErrDimensionMismatch}
 	// ErrIndexNotTrained is returned when operation requires trained index
-	ErrIndexNotTrained = errors.New("faiss: index not trained")
+	//
+	// Deprecated: use ErrNotTrained, which is the same error value.
+	ErrIndexNotTrained = ErrNotTrained
 	// ErrNullPointer is returned when C pointer is null
-	ErrNullPointer = errors.New("faiss: null pointer")
+	// Indexes only hold a null pointer once closed, so it matches ErrIndexClosed under errors.Is.
+	ErrNullPointer error = &kindError{"faiss: null pointer", ErrIndexClosed}
 )
 
 // MetricType defines the distance metric used by an index
@@ -252,6 +267,17 @@ const (
 	MetricInnerProduct MetricType = 0
 	// MetricL2 uses L2 (Euclidean) distance (lower is more similar)
 	MetricL2 MetricType = 1
+	// MetricCanberra uses Canberra distance: sum |x_i - y_i| / (|x_i| + |y_i|)
+	// (lower is more similar). Only supported by flat indexes.
+	MetricCanberra MetricType = 20
+	// MetricBrayCurtis uses Bray-Curtis dissimilarity: sum |x_i - y_i| / sum |x_i + y_i|
+	// (lower is more similar). Only supported by flat indexes.
+	MetricBrayCurtis MetricType = 21
+	// MetricCosine uses cosine similarity (higher is more similar). FAISS has
+	// no cosine metric: IndexFactory builds an inner product index behind an
+	// L2 normalization pre-transform, so the index reports MetricInnerProduct.
+	// Only supported by IndexFactory.
+	MetricCosine MetricType = 100
 )
 
 // String returns the string representation of the metric type
@@ -261,6 +287,12 @@ func (m MetricType) String() string {
 		return "InnerProduct"
 	case MetricL2:
 		return "L2"
+	case MetricCanberra:
+		return "Canberra"
+	case MetricBrayCurtis:
+		return "BrayCurtis"
+	case MetricCosine:
+		return "Cosine"
 	default:
 		return fmt.Sprintf("MetricType(%d)", m)
 	}
@@ -275,6 +307,10 @@ type IndexFlat struct {
 	metric    MetricType  // metric type
 	ntotal    int64       // number of vectors
 	isTrained bool        // always true for flat indexes
+	mmapped   bool        // vectors are mapped from a file (NewIndexFlatMmap)
+	normGuard bool        // Add/Search reject vectors that are not unit-norm
+
+	shared bool // owned by a SharedQuantizer
 }
 
 // Ensure IndexFlat implements Index
@@ -286,7 +322,8 @@ var _ Index = (*IndexFlat)(nil)
 //
 // Parameters:
 //   - d: dimension of vectors
-//   - metric: distance metric (MetricL2 or MetricInnerProduct)
+//   - metric: distance metric (MetricL2, MetricInnerProduct, MetricCanberra
+//     or MetricBrayCurtis)
 //
 // Example:
 //
@@ -301,11 +338,42 @@ func NewIndexFlat(d int, metric MetricType) (*IndexFlat, error) {
 		return NewIndexFlatL2(d)
 	case MetricInnerProduct:
 		return NewIndexFlatIP(d)
+	case MetricCanberra, MetricBrayCurtis:
+		return newIndexFlatWithMetric(d, metric)
 	default:
 		return nil, fmt.Errorf("faiss: unsupported metric type %d for flat index", metric)
 	}
 }
 
+// newIndexFlatWithMetric creates a flat index for metrics without a
+// dedicated FAISS constructor (computed by FAISS's extra-metrics kernels)
+func newIndexFlatWithMetric(d int, metric MetricType) (*IndexFlat, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+
+	ptr, err := faissIndexFlatNew(d, int(metric))
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to create IndexFlat (%s): %w", metric, err)
+	}
+
+	idx := &IndexFlat{
+		ptr:       ptr,
+		d:         d,
+		metric:    metric,
+		ntotal:    0,
+		isTrained: true,
+	}
+
+	runtime.SetFinalizer(idx, func(i *IndexFlat) {
+		if i.ptr != 0 {
+			_ = i.Close()
+		}
+	})
+
+	return idx, nil
+}
+
 // NewIndexFlatL2 creates a new flat index using L2 distance
 func NewIndexFlatL2(d int) (*IndexFlat, error) {
 	if d <= 0 {
@@ -394,10 +462,19 @@ func (idx *IndexFlat) Train(vectors []float32) error {
 }
 
 // Add adds vectors to the index
+//
+// A quantizer owned by a SharedQuantizer cannot be added to, since that would
+// add inverted lists the indexes using it do not have.
 func (idx *IndexFlat) Add(vectors []float32) error {
 	if idx.ptr == 0 {
 		return ErrNullPointer
 	}
+	if idx.mmapped {
+		return ErrReadOnly
+	}
+	if idx.shared {
+		return errSharedQuantizerInUse
+	}
 
 	if len(vectors) == 0 {
 		return nil // nothing to add
@@ -407,6 +484,13 @@ func (idx *IndexFlat) Add(vectors []float32) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+	if err := validateNormalized(idx.normGuard, vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
 
 	timer := StartTimer()
@@ -435,10 +519,17 @@ func (idx *IndexFlat) Search(queries []float32, k int) (distances []float32, ind
 		return nil, nil, ErrInvalidVectors
 	}
 
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
 	nq := len(queries) / idx.d
 
 	if k <= 0 {
-		return nil, nil, errors.New("faiss: k must be positive")
+		return nil, nil, ErrInvalidK
 	}
 
 	distances = make([]float32, nq*k)
@@ -460,11 +551,46 @@ func (idx *IndexFlat) Search(queries []float32, k int) (distances []float32, ind
 	return distances, indices, nil
 }
 
+// SetRequireNormalized makes Add and Search reject vectors whose L2 norm is
+// not ~1.0 (inner product indexes only)
+//
+// Cosine search on an inner product index silently returns wrong results
+// for un-normalized input; with the guard on, the offending vector is named
+// in an error wrapping ErrNotNormalized instead. The check costs a pass over
+// the input, so it is meant for development. Off by default.
+//
+// Example:
+//
+//	index, _ := faiss.NewIndexFlatIP(768)
+//	index.SetRequireNormalized(true)
+//	err := index.Add(embeddings) // fails unless NormalizeL2 was applied
+func (idx *IndexFlat) SetRequireNormalized(require bool) error {
+	if err := checkRequireNormalized(require, idx.metric); err != nil {
+		return err
+	}
+	idx.normGuard = require
+	return nil
+}
+
+// RequireNormalized reports whether Add and Search reject un-normalized vectors
+func (idx *IndexFlat) RequireNormalized() bool {
+	return idx.normGuard
+}
+
 // Reset removes all vectors from the index
+//
+// A quantizer owned by a SharedQuantizer cannot be reset, since the indexes
+// using it would be left without centroids.
 func (idx *IndexFlat) Reset() error {
 	if idx.ptr == 0 {
 		return ErrNullPointer
 	}
+	if idx.mmapped {
+		return ErrReadOnly
+	}
+	if idx.shared {
+		return errSharedQuantizerInUse
+	}
 
 	timer := StartTimer()
 	if err := faissIndexReset(idx.ptr); err != nil {
@@ -476,6 +602,81 @@ func (idx *IndexFlat) Reset() error {
 	return nil
 }
 
+// Compact releases the unused capacity of the index storage
+//
+// FAISS keeps flat vectors in a growable buffer that never shrinks: Reset
+// keeps its capacity and incremental adds leave up to half of it unused.
+// After many add/reset cycles Compact shrinks the buffer to the vectors it
+// holds. The index is compacted in place, so wrappers and composite indexes
+// holding it keep working, and search results are unchanged. The allocation
+// itself is still made by FAISS, so there is no control over huge pages or
+// NUMA placement.
+//
+// Shrinking temporarily needs about twice the index memory. A memory-mapped
+// index returns ErrReadOnly, and a quantizer owned by a SharedQuantizer
+// cannot be compacted.
+func (idx *IndexFlat) Compact() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if idx.mmapped {
+		return ErrReadOnly
+	}
+	if idx.shared {
+		return errSharedQuantizerInUse
+	}
+
+	if err := faissIndexFlatShrinkToFit(idx.ptr); err != nil {
+		return fmt.Errorf("faiss: compaction failed: %w", err)
+	}
+	return nil
+}
+
+// Capacity returns the number of vectors the index storage holds without
+// reallocating (see Reserve and Compact)
+func (idx *IndexFlat) Capacity() int64 {
+	if idx.ptr == 0 {
+		return 0
+	}
+	capacity, err := faissIndexFlatCapacity(idx.ptr)
+	if err != nil {
+		return 0
+	}
+	return capacity
+}
+
+// Reserve grows the index storage to hold at least n vectors, so that adding
+// up to n vectors does not reallocate it
+//
+// The stored vectors are unchanged. Capacity survives Reset, which makes
+// Reserve most useful before the first fill of a periodically rebuilt index.
+// Compact releases it. A memory-mapped index returns ErrReadOnly, and a
+// quantizer owned by a SharedQuantizer cannot be resized.
+//
+// Reserve does nothing if the index already holds n vectors or more.
+func (idx *IndexFlat) Reserve(n int64) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if idx.mmapped {
+		return ErrReadOnly
+	}
+	if idx.shared {
+		return errSharedQuantizerInUse
+	}
+	if n < 0 {
+		return fmt.Errorf("faiss: reserve size must be non-negative, got %d", n)
+	}
+	if n <= idx.ntotal {
+		return nil
+	}
+
+	if err := faissIndexFlatReserve(idx.ptr, n); err != nil {
+		return fmt.Errorf("faiss: reserve failed: %w", err)
+	}
+	return nil
+}
+
 // SetNprobe is not supported for flat indexes (not an IVF index)
 func (idx *IndexFlat) SetNprobe(nprobe int) error {
 	return fmt.Errorf("faiss: SetNprobe not supported for IndexFlat (not an IVF index)")
@@ -491,6 +692,9 @@ func (idx *IndexFlat) Close() error {
 	if idx.ptr == 0 {
 		return nil // already closed
 	}
+	if idx.shared {
+		return errSharedQuantizerInUse
+	}
 
 	err := faissIndexFree(idx.ptr)
 	idx.ptr = 0
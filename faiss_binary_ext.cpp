@@ -0,0 +1,74 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * Binary index searches that the FAISS C API does not expose.
+ *
+ * Compiled against the FAISS headers in third_party/faiss, so the stored
+ * codes are reached through the real class definitions and dynamic_cast.
+ */
+
+#include <cstdint>
+#include <cstring>
+
+#include <faiss/IndexBinaryFlat.h>
+#include <faiss/utils/Heap.h>
+
+namespace {
+
+int hamming(const uint8_t* a, const uint8_t* b, size_t code_size) {
+    int dis = 0;
+    size_t i = 0;
+    for (; i + 8 <= code_size; i += 8) {
+        uint64_t x, y;
+        memcpy(&x, a + i, 8);
+        memcpy(&y, b + i, 8);
+        dis += __builtin_popcountll(x ^ y);
+    }
+    for (; i < code_size; i++) {
+        dis += __builtin_popcount(a[i] ^ b[i]);
+    }
+    return dis;
+}
+
+} // namespace
+
+extern "C" {
+
+// Searches one query against an IndexBinaryFlat in a single pass over its
+// codes: the k nearest codes go to distances and labels, sorted like
+// IndexBinary::search, and the number of codes at Hamming distance at most
+// radius goes to within. Returns -1 if index is not an IndexBinaryFlat.
+int faiss_go_IndexBinaryFlat_search_hybrid(
+        void* index,
+        const uint8_t* query,
+        int64_t k,
+        int radius,
+        int32_t* distances,
+        int64_t* labels,
+        int64_t* within) {
+    faiss::IndexBinaryFlat* flat = dynamic_cast<faiss::IndexBinaryFlat*>(
+            static_cast<faiss::IndexBinary*>(index));
+    if (!flat) {
+        return -1;
+    }
+    using C = faiss::CMax<int32_t, int64_t>;
+
+    const uint8_t* codes = flat->xb.data();
+    faiss::heap_heapify<C>(k, distances, labels);
+    int64_t count = 0;
+    for (faiss::idx_t i = 0; i < flat->ntotal; i++) {
+        int32_t dis = hamming(query, codes + i * flat->code_size, flat->code_size);
+        if (dis <= radius) {
+            count++;
+        }
+        if (C::cmp(distances[0], dis)) {
+            faiss::heap_replace_top<C>(k, distances, labels, dis, i);
+        }
+    }
+    faiss::heap_reorder<C>(k, distances, labels);
+    *within = count;
+    return 0;
+}
+
+} // extern "C"
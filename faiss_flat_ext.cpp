@@ -0,0 +1,115 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * Storage management for flat indexes that the FAISS C API does not expose.
+ *
+ * Compiled against the FAISS headers in third_party/faiss, so the code
+ * buffer is reached through the real class definitions and dynamic_cast.
+ */
+
+#include <cstdint>
+#include <memory>
+
+#include <faiss/IndexFlat.h>
+#include <faiss/IndexFlatCodes.h>
+#include <faiss/impl/mapped_io.h>
+#include <faiss/index_io.h>
+
+namespace {
+
+faiss::IndexFlatCodes* as_flat_codes(void* index) {
+    return dynamic_cast<faiss::IndexFlatCodes*>(
+            static_cast<faiss::Index*>(index));
+}
+
+} // namespace
+
+extern "C" {
+
+// Releases the unused capacity of the code buffer in place. Returns -1 if
+// index is not an IndexFlatCodes, or -2 if its codes are a memory-mapped view.
+int faiss_go_IndexFlatCodes_shrink_to_fit(void* index) {
+    faiss::IndexFlatCodes* flat = as_flat_codes(index);
+    if (!flat) {
+        return -1;
+    }
+    faiss::MaybeOwnedVector<uint8_t>& codes = flat->codes;
+    if (!codes.is_owned) {
+        return -2;
+    }
+    try {
+        codes.owned_data.shrink_to_fit();
+    } catch (...) {
+        return -3;
+    }
+    codes.c_ptr = codes.owned_data.data();
+    codes.c_size = codes.owned_data.size();
+    return 0;
+}
+
+// Grows the capacity of the code buffer to at least n vectors, as
+// std::vector::reserve does; the stored codes are unchanged. Returns -1 if
+// index is not an IndexFlatCodes, -2 if its codes are a memory-mapped view,
+// or -3 if the allocation fails.
+int faiss_go_IndexFlatCodes_reserve(void* index, int64_t n) {
+    faiss::IndexFlatCodes* flat = as_flat_codes(index);
+    if (!flat) {
+        return -1;
+    }
+    faiss::MaybeOwnedVector<uint8_t>& codes = flat->codes;
+    if (!codes.is_owned) {
+        return -2;
+    }
+    try {
+        codes.owned_data.reserve(static_cast<size_t>(n) * flat->code_size);
+    } catch (...) {
+        return -3;
+    }
+    codes.c_ptr = codes.owned_data.data();
+    return 0;
+}
+
+// Returns the number of vectors the code buffer holds without reallocating,
+// or -1 if index is not an IndexFlatCodes.
+int64_t faiss_go_IndexFlatCodes_capacity(void* index) {
+    faiss::IndexFlatCodes* flat = as_flat_codes(index);
+    if (!flat || flat->code_size == 0) {
+        return -1;
+    }
+    const faiss::MaybeOwnedVector<uint8_t>& codes = flat->codes;
+    size_t bytes = codes.is_owned ? codes.owned_data.capacity() : codes.size();
+    return static_cast<int64_t>(bytes / flat->code_size);
+}
+
+// Writes an IndexFlat holding the raw little-endian float32 vectors of
+// raw_fname to fname with faiss::write_index. The vectors are memory-mapped
+// rather than read, so memory use does not grow with the file; a null
+// raw_fname writes an empty index. Returns -2 if the file does not hold a
+// whole number of vectors, or -3 if mapping or writing fails.
+int faiss_go_write_flat_index_from_raw(
+        const char* raw_fname,
+        int d,
+        int metric,
+        const char* fname) {
+    try {
+        faiss::IndexFlat index(d, static_cast<faiss::MetricType>(metric));
+        if (raw_fname) {
+            auto mapping =
+                    std::make_shared<faiss::MmappedFileMappingOwner>(raw_fname);
+            size_t bytes = mapping->size();
+            if (bytes % index.code_size != 0) {
+                return -2;
+            }
+            index.codes = faiss::MaybeOwnedVector<uint8_t>::create_view(
+                    mapping->data(), bytes, mapping);
+            index.ntotal = static_cast<faiss::idx_t>(bytes / index.code_size);
+        }
+        faiss::write_index(&index, fname);
+    } catch (...) {
+        return -3;
+    }
+    return 0;
+}
+
+} // extern "C"
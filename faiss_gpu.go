@@ -30,6 +30,13 @@ extern int faiss_index_cpu_to_gpu(FaissStandardGpuResources res, int device, Fai
 extern int faiss_index_gpu_to_cpu(FaissGpuIndex gpu_index, FaissIndex* p_cpu_index);
 extern int faiss_index_cpu_to_all_gpus(FaissStandardGpuResources res, FaissIndex cpu_index, FaissGpuIndex* p_gpu_index);
 
+// GPU cloner options (used to request float16 storage)
+typedef void* FaissGpuClonerOptions;
+extern int faiss_GpuClonerOptions_new(FaissGpuClonerOptions* p_options);
+extern void faiss_GpuClonerOptions_free(FaissGpuClonerOptions options);
+extern void faiss_GpuClonerOptions_set_useFloat16(FaissGpuClonerOptions options, int use_float16);
+extern int faiss_index_cpu_to_gpu_with_options(FaissStandardGpuResources res, int device, FaissIndex cpu_index, FaissGpuClonerOptions options, FaissGpuIndex* p_gpu_index);
+
 // GPU utility functions
 extern int faiss_get_num_gpus(int* num_gpus);
 
@@ -121,6 +128,31 @@ func faiss_index_cpu_to_gpu(res uintptr, device int, cpu_index uintptr, p_gpu_in
 	return nil
 }
 
+func faiss_index_cpu_to_gpu_with_float16(res uintptr, device int, cpu_index uintptr, use_float16 bool, p_gpu_index *uintptr) error {
+	var opts C.FaissGpuClonerOptions
+	if ret := C.faiss_GpuClonerOptions_new(&opts); ret != 0 {
+		return fmt.Errorf("failed to create GPU cloner options")
+	}
+	defer C.faiss_GpuClonerOptions_free(opts)
+
+	flag := C.int(0)
+	if use_float16 {
+		flag = 1
+	}
+	C.faiss_GpuClonerOptions_set_useFloat16(opts, flag)
+
+	var gpu_idx C.FaissGpuIndex
+	r := C.FaissStandardGpuResources(unsafe.Pointer(res))
+	cpu_idx := C.FaissIndex(unsafe.Pointer(cpu_index))
+
+	ret := C.faiss_index_cpu_to_gpu_with_options(r, C.int(device), cpu_idx, opts, &gpu_idx)
+	if ret != 0 {
+		return fmt.Errorf("failed to transfer index to GPU")
+	}
+	*p_gpu_index = uintptr(unsafe.Pointer(gpu_idx))
+	return nil
+}
+
 func faiss_index_gpu_to_cpu(gpu_index uintptr, p_cpu_index *uintptr) error {
 	var cpu_idx C.FaissIndex
 	gpu_idx := C.FaissGpuIndex(unsafe.Pointer(gpu_index))
@@ -0,0 +1,187 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * IndexHNSW fields and HNSW search parameters that the FAISS C API does not
+ * expose.
+ *
+ * Compiled against the FAISS headers in third_party/faiss, so the graph is
+ * reached through the real class definitions and dynamic_cast rather than
+ * assumed offsets.
+ */
+
+#include <new>
+
+#include <faiss/IndexHNSW.h>
+
+#include <algorithm>
+#include <cstdint>
+
+namespace {
+
+faiss::IndexHNSW* as_hnsw(void* index) {
+    return dynamic_cast<faiss::IndexHNSW*>(static_cast<faiss::Index*>(index));
+}
+
+} // namespace
+
+extern "C" {
+
+// Returns M, the number of neighbors per node on the levels above 0, or -1
+// if index is not an IndexHNSW.
+int faiss_go_IndexHNSW_M(void* index) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    return hnsw->hnsw.nb_neighbors(1);
+}
+
+// Returns the number of layers node belongs to (its top layer + 1), -1 if
+// index is not an IndexHNSW, or -2 if node is out of range.
+int faiss_go_IndexHNSW_node_levels(void* index, int64_t node) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    const std::vector<int>& levels = hnsw->hnsw.levels;
+    if (node < 0 || node >= static_cast<int64_t>(levels.size())) {
+        return -2;
+    }
+    return levels[node];
+}
+
+// Copies up to cap neighbors of node on layer level into out, stopping at the
+// first unused slot. Returns the number of neighbors, which may exceed cap,
+// -1 if index is not an IndexHNSW, or -2 if node does not have that layer.
+int faiss_go_IndexHNSW_neighbors(
+        void* index,
+        int64_t node,
+        int level,
+        int64_t* out,
+        int cap) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    const faiss::HNSW& graph = hnsw->hnsw;
+    if (node < 0 || node >= static_cast<int64_t>(graph.levels.size()) ||
+        level < 0 || level >= graph.levels[node]) {
+        return -2;
+    }
+
+    size_t begin, end;
+    graph.neighbor_range(node, level, &begin, &end);
+    int n = 0;
+    for (size_t i = begin; i < end && graph.neighbors[i] >= 0; i++, n++) {
+        if (n < cap) {
+            out[n] = graph.neighbors[i];
+        }
+    }
+    return n;
+}
+
+// Reads the node where searches start and the top layer of the graph, both
+// -1 for an empty graph. Returns -1 if index is not an IndexHNSW.
+int faiss_go_IndexHNSW_entry_point(
+        void* index,
+        int64_t* entry_point,
+        int* max_level) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    *entry_point = hnsw->hnsw.entry_point;
+    *max_level = hnsw->hnsw.max_level;
+    return 0;
+}
+
+// Reports the sizes of the arrays filled by faiss_go_IndexHNSW_graph_copy.
+// Returns -1 if index is not an IndexHNSW.
+int faiss_go_IndexHNSW_graph_sizes(
+        void* index,
+        int64_t* nlayers,
+        int64_t* nnodes,
+        int64_t* nslots) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    *nlayers = hnsw->hnsw.cum_nneighbor_per_level.size();
+    *nnodes = hnsw->hnsw.levels.size();
+    *nslots = hnsw->hnsw.neighbors.size();
+    return 0;
+}
+
+// Copies the link structure of the graph. offsets holds nnodes + 1 entries.
+// Returns -1 if index is not an IndexHNSW, or -2 if the graph is inconsistent.
+int faiss_go_IndexHNSW_graph_copy(
+        void* index,
+        int32_t* cum_neighbors,
+        int32_t* levels,
+        uint64_t* offsets,
+        int32_t* neighbors,
+        int32_t* entry_point,
+        int32_t* max_level) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    const faiss::HNSW& graph = hnsw->hnsw;
+    if (graph.offsets.size() != graph.levels.size() + 1) {
+        return -2;
+    }
+    std::copy(
+            graph.cum_nneighbor_per_level.begin(),
+            graph.cum_nneighbor_per_level.end(),
+            cum_neighbors);
+    std::copy(graph.levels.begin(), graph.levels.end(), levels);
+    std::copy(graph.offsets.begin(), graph.offsets.end(), offsets);
+    std::copy(
+            graph.neighbors.data(),
+            graph.neighbors.data() + graph.neighbors.size(),
+            neighbors);
+    *entry_point = graph.entry_point;
+    *max_level = graph.max_level;
+    return 0;
+}
+
+// Reads search_bounded_queue, the candidate queue mode used by searches that
+// do not pass SearchParametersHNSW. Returns 1 for a bounded queue, 0 for an
+// unbounded one, or -1 if index is not an IndexHNSW.
+int faiss_go_IndexHNSW_search_bounded_queue(void* index) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    return hnsw->hnsw.search_bounded_queue ? 1 : 0;
+}
+
+// Sets search_bounded_queue. Returns -1 if index is not an IndexHNSW.
+int faiss_go_IndexHNSW_set_search_bounded_queue(void* index, int bounded) {
+    faiss::IndexHNSW* hnsw = as_hnsw(index);
+    if (!hnsw) {
+        return -1;
+    }
+    hnsw->hnsw.search_bounded_queue = bounded != 0;
+    return 0;
+}
+
+// Allocates SearchParametersHNSW, which the C API does not expose, for
+// faiss_Index_search_with_params. Returns NULL on allocation failure.
+void* faiss_go_SearchParametersHNSW_new(int efSearch, int bounded_queue) {
+    faiss::SearchParametersHNSW* params =
+            new (std::nothrow) faiss::SearchParametersHNSW();
+    if (!params) {
+        return nullptr;
+    }
+    params->efSearch = efSearch;
+    params->bounded_queue = bounded_queue != 0;
+    return static_cast<faiss::SearchParameters*>(params);
+}
+
+void faiss_go_SearchParameters_free(void* params) {
+    delete static_cast<faiss::SearchParameters*>(params);
+}
+
+} // extern "C"
@@ -0,0 +1,51 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * Runtime class name of a FAISS index.
+ *
+ * faiss::Index is polymorphic, so its dynamic type is read through RTTI.
+ * Compiled against the FAISS headers in third_party/faiss. The name is
+ * demangled and the "faiss::" namespace stripped, e.g. "IndexIVFPQ".
+ */
+
+#include <cstdlib>
+#include <cstring>
+#include <cxxabi.h>
+#include <typeinfo>
+
+#include <faiss/Index.h>
+
+extern "C" {
+
+// Writes the class name into buf (NUL-terminated, truncated to len - 1).
+// Returns the full name length, or -1 on failure.
+int faiss_go_Index_class_name(const void* index, char* buf, int len) {
+    if (!index || !buf || len <= 0) {
+        return -1;
+    }
+
+    const char* mangled;
+    try {
+        mangled = typeid(*static_cast<const faiss::Index*>(index)).name();
+    } catch (...) {
+        return -1;
+    }
+
+    int status = 0;
+    char* demangled = abi::__cxa_demangle(mangled, nullptr, nullptr, &status);
+    const char* name = (status == 0 && demangled) ? demangled : mangled;
+    if (std::strncmp(name, "faiss::", 7) == 0) {
+        name += 7;
+    }
+
+    int n = static_cast<int>(std::strlen(name));
+    int copied = n < len - 1 ? n : len - 1;
+    std::memcpy(buf, name, copied);
+    buf[copied] = '\0';
+
+    std::free(demangled);
+    return n;
+}
+
+} // extern "C"
@@ -0,0 +1,195 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * IndexIVF fields that the FAISS C API does not expose.
+ *
+ * Compiled against the FAISS headers in third_party/faiss, so fields are
+ * reached through the real class definitions and dynamic_cast rather than
+ * assumed offsets.
+ */
+
+#include <faiss/IndexIDMap.h>
+#include <faiss/IndexIVFPQ.h>
+#include <faiss/IndexPQ.h>
+
+namespace {
+
+// Returns the IndexIVF that index is, or wraps in an IndexIDMap, or nullptr.
+// If idmap is non-null it receives the wrapping IndexIDMap, if any.
+faiss::IndexIVF* ivf_target(void* index, faiss::IndexIDMap** idmap) {
+    faiss::Index* idx = static_cast<faiss::Index*>(index);
+    faiss::IndexIDMap* wrapper = dynamic_cast<faiss::IndexIDMap*>(idx);
+    if (wrapper) {
+        idx = wrapper->index;
+    }
+    if (idmap) {
+        *idmap = wrapper;
+    }
+    return dynamic_cast<faiss::IndexIVF*>(idx);
+}
+
+} // namespace
+
+extern "C" {
+
+// Returns the IndexIVF that index is or wraps in an IndexIDMap, or nullptr.
+void* faiss_go_Index_ivf(void* index) {
+    return static_cast<faiss::Index*>(ivf_target(index, nullptr));
+}
+
+// Sets parallel_mode (see faiss::IndexIVF::parallel_mode). Returns -1 if
+// index is not an IndexIVF.
+int faiss_go_IndexIVF_set_parallel_mode(void* index, int parallel_mode) {
+    faiss::IndexIVF* ivf = ivf_target(index, nullptr);
+    if (!ivf) {
+        return -1;
+    }
+    ivf->parallel_mode = parallel_mode;
+    return 0;
+}
+
+// Returns parallel_mode, or -1 if index is not an IndexIVF.
+int faiss_go_IndexIVF_parallel_mode(void* index) {
+    faiss::IndexIVF* ivf = ivf_target(index, nullptr);
+    if (!ivf) {
+        return -1;
+    }
+    return ivf->parallel_mode;
+}
+
+// Sets the k-means iterations and restarts Index::train uses: those of the
+// coarse quantizer of an IndexIVF (see faiss::Level1Quantizer::cp) and of
+// the product quantizer of an IndexIVFPQ or IndexPQ (see
+// faiss::ProductQuantizer::cp). Other clustering parameters, such as the
+// spherical k-means FAISS selects for inner-product IVF indexes, are kept.
+// Returns -1 if index is none of these.
+int faiss_go_Index_set_clustering_params(void* index, int niter, int nredo) {
+    faiss::Index* idx = static_cast<faiss::Index*>(index);
+    faiss::ProductQuantizer* pq = nullptr;
+    faiss::IndexIVF* ivf = ivf_target(index, nullptr);
+    if (ivf) {
+        ivf->cp.niter = niter;
+        ivf->cp.nredo = nredo;
+        if (auto* ivfpq = dynamic_cast<faiss::IndexIVFPQ*>(ivf)) {
+            pq = &ivfpq->pq;
+        }
+    } else if (auto* flat = dynamic_cast<faiss::IndexPQ*>(idx)) {
+        pq = &flat->pq;
+    } else {
+        return -1;
+    }
+    if (pq) {
+        pq->cp.niter = niter;
+        pq->cp.nredo = nredo;
+    }
+    return 0;
+}
+
+// Returns the DirectMap type of an IVF index (or one wrapped in an
+// IndexIDMap): 0 for none, 1 for an array, 2 for a hashtable, or -1 if
+// index is not an IndexIVF.
+int faiss_go_IndexIVF_direct_map_type(void* index) {
+    faiss::IndexIVF* ivf = ivf_target(index, nullptr);
+    if (!ivf) {
+        return -1;
+    }
+    return static_cast<int>(ivf->direct_map.type);
+}
+
+// Copies every stored vector of an IVF index, list by list, with the ID it
+// was added with. Vectors are decoded with reconstruct_from_offset, so they
+// are exact for IVFFlat and approximate for compressed encodings. If index
+// is an IndexIDMap, its id_map is applied to the IVF's internal IDs. x may
+// be NULL to copy only the IDs.
+//
+// Returns -1 if index is not (a wrapped) IndexIVF, -2 if n is not the
+// number of stored vectors, or -3 if the encoding cannot be decoded.
+int faiss_go_IndexIVF_stored_vectors(
+        void* index,
+        int64_t n,
+        int64_t* ids,
+        float* x) {
+    faiss::IndexIDMap* idmap = nullptr;
+    faiss::IndexIVF* ivf = ivf_target(index, &idmap);
+    if (!ivf) {
+        return -1;
+    }
+    if (n != ivf->ntotal || (idmap && idmap->id_map.size() != (size_t)n)) {
+        return -2;
+    }
+    try {
+        int64_t i = 0;
+        for (size_t list_no = 0; list_no < ivf->nlist; list_no++) {
+            size_t size = ivf->invlists->list_size(list_no);
+            faiss::InvertedLists::ScopedIds list_ids(ivf->invlists, list_no);
+            for (size_t offset = 0; offset < size; offset++, i++) {
+                if (i >= n) {
+                    return -2;
+                }
+                faiss::idx_t id = list_ids[offset];
+                ids[i] = idmap ? idmap->id_map[id] : id;
+                if (x) {
+                    ivf->reconstruct_from_offset(
+                            list_no, offset, x + i * ivf->d);
+                }
+            }
+        }
+        if (i != n) {
+            return -2;
+        }
+    } catch (...) {
+        return -3;
+    }
+    return 0;
+}
+
+
+// Creates an IndexIVFPQ around an existing coarse quantizer, which the new
+// index does not own. The C API constructor has no metric argument. Returns
+// -1 if FAISS rejects the parameters.
+int faiss_go_IndexIVFPQ_new_with_metric(
+        void** p_index,
+        void* quantizer,
+        int64_t d,
+        int64_t nlist,
+        int64_t M,
+        int64_t nbits,
+        int metric) {
+    try {
+        *p_index = static_cast<faiss::Index*>(new faiss::IndexIVFPQ(
+                static_cast<faiss::Index*>(quantizer),
+                d,
+                nlist,
+                M,
+                nbits,
+                static_cast<faiss::MetricType>(metric)));
+    } catch (...) {
+        return -1;
+    }
+    return 0;
+}
+
+// Returns 1 if the IVFPQ index encodes residuals, 0 if it encodes raw
+// vectors, or -1 if index is not an IndexIVFPQ.
+int faiss_go_IndexIVFPQ_by_residual(void* index) {
+    faiss::IndexIVFPQ* ivfpq =
+            dynamic_cast<faiss::IndexIVFPQ*>(static_cast<faiss::Index*>(index));
+    if (!ivfpq) {
+        return -1;
+    }
+    return ivfpq->by_residual ? 1 : 0;
+}
+
+// Sets by_residual in place. Returns -1 if index is not an IndexIVFPQ.
+int faiss_go_IndexIVFPQ_set_by_residual(void* index, int by_residual) {
+    faiss::IndexIVFPQ* ivfpq =
+            dynamic_cast<faiss::IndexIVFPQ*>(static_cast<faiss::Index*>(index));
+    if (!ivfpq) {
+        return -1;
+    }
+    ivfpq->by_residual = by_residual != 0;
+    return 0;
+}
+
+} // extern "C"
@@ -24,6 +24,11 @@ package faiss
 // CGO linking is provided entirely by the faiss-go-bindings module (blank import below)
 // No LDFLAGS needed here - all flags come from the bindings module
 
+// The C++ shims (faiss_*_ext.cpp) are compiled against the FAISS headers in
+// third_party/faiss, which match the version of the bundled libraries
+#cgo CXXFLAGS: -std=c++17 -I${SRCDIR}/third_party/faiss
+
+#include <stdio.h>
 #include <stdlib.h>
 #include <stdint.h>
 
@@ -49,13 +54,65 @@ typedef void* FaissIndexShards;
 // ==== Flat Index Functions ====
 extern int faiss_IndexFlatL2_new_with(FaissIndex* p_index, int64_t d);
 extern int faiss_IndexFlatIP_new_with(FaissIndex* p_index, int64_t d);
+extern int faiss_IndexFlat_new_with(FaissIndex* p_index, int64_t d, int metric);
+extern void faiss_IndexFlat_xb(FaissIndex index, float** p_xb, size_t* p_size);
 
 // ==== IVF Index Functions ====
 extern FaissIndexIVF* faiss_IndexIVF_cast(FaissIndex index);
 extern int faiss_IndexIVFFlat_new_with_metric(FaissIndexIVFFlat** p_index, FaissIndex quantizer, size_t d, size_t nlist, int metric_type);
 extern void faiss_IndexIVF_set_nprobe(FaissIndexIVF* index, size_t nprobe);
 extern size_t faiss_IndexIVF_nprobe(FaissIndexIVF* index);  // Note: getter has no "get_" prefix
+extern size_t faiss_IndexIVF_nlist(FaissIndexIVF* index);
 extern void faiss_IndexIVF_set_own_fields(FaissIndexIVF* index, int own_fields);
+extern int faiss_IndexIVF_make_direct_map(FaissIndexIVF* index, int new_maintain_direct_map);
+extern FaissIndex* faiss_IndexIVF_quantizer(const FaissIndexIVF* index);
+extern int faiss_IndexIVF_copy_subset_to(const FaissIndexIVF* index, FaissIndexIVF* other, int subset_type, int64_t a1, int64_t a2);
+
+// ==== Clone Functions ====
+extern int faiss_clone_index(const FaissIndex* index, FaissIndex** p_out);
+
+// ==== ParameterSpace Functions ====
+// Used for IVF fields that have no dedicated C setter (e.g. max_codes)
+typedef void* FaissParameterSpace;
+extern int faiss_ParameterSpace_new(FaissParameterSpace** space);
+extern void faiss_ParameterSpace_free(FaissParameterSpace* space);
+extern int faiss_ParameterSpace_set_index_parameter(const FaissParameterSpace* space, FaissIndex* index, const char* name, double value);
+
+// ==== IndexIVF Fields and IVFPQ Construction (faiss_ivf_ext.cpp) ====
+extern int faiss_go_IndexIVFPQ_new_with_metric(void** p_index, void* quantizer, int64_t d, int64_t nlist, int64_t M, int64_t nbits, int metric);
+extern int faiss_go_IndexIVFPQ_by_residual(void* index);
+extern int faiss_go_IndexIVFPQ_set_by_residual(void* index, int by_residual);
+extern void* faiss_go_Index_ivf(void* index);
+extern int faiss_go_IndexIVF_set_parallel_mode(void* index, int parallel_mode);
+extern int faiss_go_IndexIVF_parallel_mode(void* index);
+extern int faiss_go_IndexIVF_direct_map_type(void* index);
+extern int faiss_go_Index_set_clustering_params(void* index, int niter, int nredo);
+extern int faiss_go_IndexIVF_stored_vectors(void* index, int64_t n, int64_t* ids, float* x);
+
+// ==== Flat Index Storage (faiss_flat_ext.cpp) ====
+extern int faiss_go_IndexFlatCodes_shrink_to_fit(void* index);
+extern int64_t faiss_go_IndexFlatCodes_capacity(void* index);
+extern int faiss_go_IndexFlatCodes_reserve(void* index, int64_t n);
+extern int faiss_go_write_flat_index_from_raw(const char* raw_fname, int d, int metric, const char* fname);
+
+// ==== On-Disk Inverted Lists (faiss_ondisk_ext.cpp) ====
+extern int faiss_go_IndexIVF_use_ondisk_lists(void* index, const char* filename);
+extern int faiss_go_IndexIVF_ondisk_filename(void* index, char* buf, int len);
+extern int faiss_go_IndexIVF_ondisk_sync(void* index);
+
+// ==== Product Quantizer Parameters (faiss_pq_ext.cpp) ====
+extern int faiss_go_Index_pq_params(void* index, int* M, int* nbits);
+extern int faiss_go_Index_pq_centroids_size(void* index, int64_t* size);
+
+// ==== HNSW Graph Parameters (faiss_hnsw_ext.cpp) ====
+extern int faiss_go_IndexHNSW_M(void* index);
+extern int faiss_go_IndexHNSW_node_levels(void* index, int64_t node);
+extern int faiss_go_IndexHNSW_neighbors(void* index, int64_t node, int level, int64_t* out, int cap);
+extern int faiss_go_IndexHNSW_entry_point(void* index, int64_t* entry_point, int* max_level);
+extern int faiss_go_IndexHNSW_graph_sizes(void* index, int64_t* nlayers, int64_t* nnodes, int64_t* nslots);
+extern int faiss_go_IndexHNSW_graph_copy(void* index, int32_t* cum_neighbors, int32_t* levels, uint64_t* offsets, int32_t* neighbors, int32_t* entry_point, int32_t* max_level);
+extern int faiss_go_IndexHNSW_search_bounded_queue(void* index);
+extern int faiss_go_IndexHNSW_set_search_bounded_queue(void* index, int bounded);
 
 // ==== Scalar Quantizer Index Functions ====
 extern int faiss_IndexScalarQuantizer_new_with(FaissIndex* p_index, int64_t d, int qtype, int metric_type);
@@ -71,6 +128,12 @@ extern int faiss_IndexLSH_new_with_options(FaissIndex** p_index, int64_t d, int
 // ==== ID Map Functions ====
 extern int faiss_IndexIDMap_new(FaissIndex* p_index, FaissIndex base_index);
 extern void faiss_IndexIDMap_set_own_fields(FaissIndex index, int own_fields);
+extern FaissIndex faiss_IndexIDMap_cast(FaissIndex index);
+extern FaissIndex faiss_IndexIDMap2_cast(FaissIndex index);
+extern void faiss_IndexIDMap_id_map(FaissIndex index, int64_t** p_id_map, size_t* p_size);
+extern FaissIndex faiss_IndexIDMap_sub_index(FaissIndex index);
+// Runtime class name (faiss_index_type.cpp)
+extern int faiss_go_Index_class_name(const void* index, char* buf, int len);
 extern int faiss_IndexIDMap_add_with_ids(FaissIndex index, int64_t n, const float* x, const int64_t* ids);
 // extern int faiss_IndexIDMap_remove_ids(FaissIndex index, const int64_t* ids, int64_t n_ids, int64_t* n_removed); // NOT AVAILABLE
 
@@ -101,10 +164,16 @@ extern int faiss_Index_assign(FaissIndex* index, int64_t n, const float* x, int6
 extern int faiss_Index_reconstruct(FaissIndex index, int64_t key, float* recons);
 extern int faiss_Index_reconstruct_n(FaissIndex index, int64_t i0, int64_t ni, float* recons);
 extern int faiss_Index_reset(FaissIndex index);
+// ID selectors, used to remove a contiguous range of vectors
+typedef void* FaissIDSelector;
+extern int faiss_IDSelectorRange_new(FaissIDSelector* p_sel, int64_t imin, int64_t imax);
+extern void faiss_IDSelector_free(FaissIDSelector sel);
+extern int faiss_Index_remove_ids(FaissIndex index, const FaissIDSelector sel, size_t* n_removed);
 extern void faiss_Index_free(FaissIndex index);
 extern int64_t faiss_Index_ntotal(FaissIndex index);
 extern int faiss_Index_is_trained(FaissIndex index);
 extern int faiss_Index_d(FaissIndex index);
+extern int faiss_Index_metric_type(FaissIndex index);
 
 // ==== Index Factory ====
 extern int faiss_index_factory(FaissIndex* p_index, int d, const char* description, int metric_type);
@@ -115,6 +184,36 @@ extern int faiss_write_index_fname(const FaissIndex* idx, const char* fname);
 // Note: Using faiss_read_index_fname for file path-based reads (faiss_read_index takes FILE*)
 extern int faiss_read_index_fname(const char* fname, int io_flags, FaissIndex** p_out);
 
+
+// ==== In-Memory Serialization ====
+// FILE*-based I/O over memory streams (POSIX open_memstream/fmemopen)
+extern int faiss_write_index(const FaissIndex* idx, FILE* f);
+extern int faiss_read_index(FILE* f, int io_flags, FaissIndex** p_out);
+
+static int faiss_go_serialize_index(FaissIndex idx, char** data, size_t* size) {
+	FILE* f = open_memstream(data, size);
+	if (f == NULL) {
+		return -1;
+	}
+	int ret = faiss_write_index((const FaissIndex*)idx, f);
+	fclose(f);
+	if (ret != 0) {
+		free(*data);
+		*data = NULL;
+	}
+	return ret;
+}
+
+static int faiss_go_deserialize_index(void* data, size_t size, FaissIndex** p_out) {
+	FILE* f = fmemopen(data, size, "rb");
+	if (f == NULL) {
+		return -1;
+	}
+	int ret = faiss_read_index(f, 0, p_out);
+	fclose(f);
+	return ret;
+}
+
 // ==== Binary Index Functions ====
 // Note: Only IndexBinaryFlat_new is available via our extension
 // Other binary index constructors have ABI compatibility issues
@@ -128,12 +227,34 @@ extern int faiss_IndexBinary_is_trained(FaissIndexBinary index, int* is_trained)
 extern int faiss_IndexBinaryIVF_set_nprobe(FaissIndexBinary index, int64_t nprobe);
 extern void faiss_IndexBinary_free(FaissIndexBinary index);
 
+// ==== Binary Hybrid Search (faiss_binary_ext.cpp) ====
+extern int faiss_go_IndexBinaryFlat_search_hybrid(void* index, const uint8_t* query, int64_t k, int radius, int32_t* distances, int64_t* labels, int64_t* within);
+
+// ==== IVF Search Statistics ====
+typedef struct FaissIndexIVFStats {
+    size_t nq;
+    size_t nlist;
+    size_t ndis;
+    size_t nheap_updates;
+    double quantization_time;
+    double search_time;
+} FaissIndexIVFStats;
+extern void faiss_IndexIVFStats_reset(FaissIndexIVFStats* stats);
+extern FaissIndexIVFStats* faiss_get_indexIVF_stats();
+
 // ==== HNSW Property Accessors (from our extension) ====
 extern int faiss_IndexHNSW_set_efConstruction(FaissIndex index, int ef);
 extern int faiss_IndexHNSW_set_efSearch(FaissIndex index, int ef);
 extern int faiss_IndexHNSW_get_efConstruction(FaissIndex index, int* ef);
 extern int faiss_IndexHNSW_get_efSearch(FaissIndex index, int* ef);
 
+// ==== Search Parameters (HNSW parameters from faiss_hnsw_ext.cpp) ====
+extern int faiss_Index_search_with_params(FaissIndex index, int64_t n, const float* x, int64_t k, const void* params, float* distances, int64_t* labels);
+extern void* faiss_go_SearchParametersHNSW_new(int efSearch, int bounded_queue);
+extern void faiss_go_SearchParameters_free(void* params);
+extern int faiss_SearchParametersIVF_new_with(void** p_sp, void* sel, size_t nprobe, size_t max_codes);
+extern void faiss_SearchParametersIVF_free(void* params);
+
 // ==== Index Assign (from our extension - works reliably) ====
 extern int faiss_Index_assign_ext(FaissIndex index, int64_t n, const float* x, int64_t* labels, int64_t k);
 
@@ -148,14 +269,36 @@ extern int faiss_VectorTransform_is_trained_ext(FaissVectorTransform vt, int* tr
 extern int faiss_VectorTransform_apply_noalloc_ext(FaissVectorTransform vt, int64_t n, const float* x, float* xt);
 extern int faiss_VectorTransform_reverse_transform_ext(FaissVectorTransform vt, int64_t n, const float* xt, float* x);
 extern void faiss_VectorTransform_free(FaissVectorTransform vt);
+extern int faiss_VectorTransform_d_in(FaissVectorTransform vt);
+extern int faiss_VectorTransform_d_out(FaissVectorTransform vt);
+extern int faiss_VectorTransform_is_trained(FaissVectorTransform vt);
+extern int faiss_read_VectorTransform_fname(const char* fname, FaissVectorTransform* p_out);
+// Writer from faiss_transform_io.cpp (the C API has no write counterpart)
+extern int faiss_go_write_VectorTransform_fname(const void* vt, const char* fname);
 
 // ==== Clustering Functions ====
 typedef void* FaissClustering;
 extern int faiss_Clustering_new(FaissClustering* p_clustering, int d, int k);
 extern int faiss_Clustering_train(FaissClustering clustering, int64_t n, const float* x, FaissIndex index);
 // Note: faiss_Clustering_centroids returns pointer and size, not accepting pre-allocated buffer
-extern void faiss_Clustering_centroids(FaissClustering* clustering, float** centroids, size_t* size);
+extern void faiss_Clustering_centroids(FaissClustering clustering, float** centroids, size_t* size);
 extern void faiss_Clustering_free(FaissClustering clustering);
+// Mirrors FaissClusteringParameters from Clustering_c.h
+typedef struct FaissClusteringParameters {
+    int niter;
+    int nredo;
+    int verbose;
+    int spherical;
+    int int_centroids;
+    int update_index;
+    int frozen_centroids;
+    int min_points_per_centroid;
+    int max_points_per_centroid;
+    int seed;
+    size_t decode_block_size;
+} FaissClusteringParameters;
+extern void faiss_ClusteringParameters_init(FaissClusteringParameters* params);
+extern int faiss_Clustering_new_with_params(FaissClustering* p_clustering, int d, int k, const FaissClusteringParameters* cp);
 // Note: faiss_kmeans_clustering also returns quantization error
 extern int faiss_kmeans_clustering(size_t d, size_t n, size_t k, const float* x, float* centroids, float* q_error);
 
@@ -165,6 +308,8 @@ extern void faiss_IndexRefineFlat_set_k_factor(FaissIndexRefineFlat index, float
 extern void faiss_IndexRefineFlat_set_own_fields(FaissIndex index, int own_fields);
 extern int faiss_IndexPreTransform_new_with_transform(FaissIndexPreTransform** p_index, FaissVectorTransform* ltrans, FaissIndex* index);
 extern void faiss_IndexPreTransform_set_own_fields(FaissIndex index, int own_fields);
+extern FaissIndex faiss_IndexPreTransform_cast(FaissIndex index);
+extern FaissIndex faiss_IndexPreTransform_index(FaissIndex index);
 extern int faiss_IndexShards_new(FaissIndexShards** p_index, int64_t d);
 extern int faiss_IndexShards_add_shard(FaissIndexShards* index, FaissIndex* shard);
 // extern void faiss_IndexShards_set_own_indices(FaissIndex index, int own_indices); // NOT AVAILABLE
@@ -187,6 +332,8 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	_ "github.com/NerdMeNot/faiss-go-bindings" // Links FAISS static libraries
@@ -218,6 +365,32 @@ func faissIndexFlatIPNew(d int) (uintptr, error) {
 	return uintptr(unsafe.Pointer(idx)), nil
 }
 
+// faissIndexFlatNew creates a new IndexFlat with an arbitrary FAISS metric
+func faissIndexFlatNew(d int, metric int) (uintptr, error) {
+	var idx C.FaissIndex
+	ret := C.faiss_IndexFlat_new_with(&idx, C.int64_t(d), C.int(metric))
+	if ret != 0 {
+		return 0, fmt.Errorf("FAISS error code: %d", ret)
+	}
+	if idx == nil {
+		return 0, errors.New("null index pointer")
+	}
+	return uintptr(unsafe.Pointer(idx)), nil
+}
+
+// faissIndexFlatXb returns the flat index's vector storage as a slice
+// aliasing the C buffer, valid until the next add or removal
+func faissIndexFlatXb(ptr uintptr) []float32 {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	var xb *C.float
+	var size C.size_t
+	C.faiss_IndexFlat_xb(idx, &xb, &size)
+	if xb == nil || size == 0 {
+		return nil
+	}
+	return unsafe.Slice((*float32)(unsafe.Pointer(xb)), int(size))
+}
+
 // faissIndexAdd adds vectors to an index
 func faissIndexAdd(ptr uintptr, vectors []float32, n int) error {
 	idx := C.FaissIndex(unsafe.Pointer(ptr))
@@ -243,6 +416,71 @@ func faissIndexSearch(ptr uintptr, queries []float32, nq, k int, distances []flo
 	return nil
 }
 
+// faissIndexHNSWSearchWithQueue searches an HNSW index with an explicit
+// efSearch and bounded/unbounded candidate queue
+func faissIndexHNSWSearchWithQueue(ptr uintptr, queries []float32, nq, k, efSearch int, boundedQueue bool, distances []float32, indices []int64) error {
+	bounded := 0
+	if boundedQueue {
+		bounded = 1
+	}
+	params := C.faiss_go_SearchParametersHNSW_new(C.int(efSearch), C.int(bounded))
+	if params == nil {
+		return errors.New("failed to allocate search parameters")
+	}
+	defer C.faiss_go_SearchParameters_free(params)
+
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	queryPtr := (*C.float)(unsafe.Pointer(&queries[0]))
+	distPtr := (*C.float)(unsafe.Pointer(&distances[0]))
+	idxPtr := (*C.int64_t)(unsafe.Pointer(&indices[0]))
+
+	ret := C.faiss_Index_search_with_params(idx, C.int64_t(nq), queryPtr, C.int64_t(k), params, distPtr, idxPtr)
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+// faissIndexIVFSearchWithNprobe searches an IVF index with an explicit
+// nprobe, leaving the index's own nprobe unchanged
+func faissIndexIVFSearchWithNprobe(ptr uintptr, queries []float32, nq, k, nprobe int, distances []float32, indices []int64) error {
+	var params unsafe.Pointer
+	ret := C.faiss_SearchParametersIVF_new_with(&params, nil, C.size_t(nprobe), 0)
+	if ret != 0 {
+		return fmt.Errorf("failed to allocate search parameters: FAISS error code: %d", ret)
+	}
+	defer C.faiss_SearchParametersIVF_free(params)
+
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	queryPtr := (*C.float)(unsafe.Pointer(&queries[0]))
+	distPtr := (*C.float)(unsafe.Pointer(&distances[0]))
+	idxPtr := (*C.int64_t)(unsafe.Pointer(&indices[0]))
+
+	ret = C.faiss_Index_search_with_params(idx, C.int64_t(nq), queryPtr, C.int64_t(k), params, distPtr, idxPtr)
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+// faissGetIndexIVFStats reads the global faiss::indexIVF_stats counters
+func faissGetIndexIVFStats() SearchStats {
+	stats := C.faiss_get_indexIVF_stats()
+	return SearchStats{
+		NQ:               int64(stats.nq),
+		NList:            int64(stats.nlist),
+		NDis:             int64(stats.ndis),
+		NHeapUpdates:     int64(stats.nheap_updates),
+		QuantizationTime: time.Duration(float64(stats.quantization_time) * float64(time.Millisecond)),
+		SearchTime:       time.Duration(float64(stats.search_time) * float64(time.Millisecond)),
+	}
+}
+
+// faissResetIndexIVFStats zeroes the global faiss::indexIVF_stats counters
+func faissResetIndexIVFStats() {
+	C.faiss_IndexIVFStats_reset(C.faiss_get_indexIVF_stats())
+}
+
 // faissIndexReset resets an index
 func faissIndexReset(ptr uintptr) error {
 	idx := C.FaissIndex(unsafe.Pointer(ptr))
@@ -335,6 +573,309 @@ func faissIndexIVFGetNprobe(ptr uintptr) (int, error) {
 	return int(nprobe), nil
 }
 
+// faissIndexIVFSetParallelMode sets parallel_mode on an IVF index
+func faissIndexIVFSetParallelMode(ptr uintptr, mode int) error {
+	if C.faiss_go_IndexIVF_set_parallel_mode(unsafe.Pointer(ptr), C.int(mode)) != 0 {
+		return fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+	return nil
+}
+
+// faissIndexIVFGetParallelMode returns parallel_mode of an IVF index
+func faissIndexIVFGetParallelMode(ptr uintptr) (int, error) {
+	mode := C.faiss_go_IndexIVF_parallel_mode(unsafe.Pointer(ptr))
+	if mode < 0 {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+	return int(mode), nil
+}
+
+// faissIndexIVFFlatNewEmptyLike creates an empty IndexIVFFlat sharing the
+// trained coarse quantizer of src. The quantizer is cloned and owned by the
+// new index, so the result is trained but contains no vectors.
+func faissIndexIVFFlatNewEmptyLike(src uintptr, d, nlist, metric int) (uintptr, error) {
+	ivf := C.faiss_IndexIVF_cast(C.FaissIndex(unsafe.Pointer(src)))
+	if ivf == nil {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+
+	var quantizer *C.FaissIndex
+	ret := C.faiss_clone_index(C.faiss_IndexIVF_quantizer(ivf), &quantizer)
+	if ret != 0 {
+		return 0, fmt.Errorf("failed to clone quantizer: FAISS error code %d", ret)
+	}
+
+	var idx *C.FaissIndexIVFFlat
+	ret = C.faiss_IndexIVFFlat_new_with_metric(&idx, C.FaissIndex(unsafe.Pointer(quantizer)), C.size_t(d), C.size_t(nlist), C.int(metric))
+	if ret != 0 {
+		C.faiss_Index_free(C.FaissIndex(unsafe.Pointer(quantizer)))
+		return 0, fmt.Errorf("failed to create IndexIVFFlat: FAISS error code %d", ret)
+	}
+
+	// Free the cloned quantizer together with the new index
+	C.faiss_IndexIVF_set_own_fields((*C.FaissIndexIVF)(unsafe.Pointer(idx)), 1)
+	return uintptr(unsafe.Pointer(idx)), nil
+}
+
+// faissIndexIVFFlatNewWithQuantizer creates an IndexIVFFlat around an
+// existing coarse quantizer. The quantizer is not owned by the new index and
+// must outlive it.
+func faissIndexIVFFlatNewWithQuantizer(quantizer uintptr, d, nlist, metric int) (uintptr, error) {
+	var idx *C.FaissIndexIVFFlat
+	ret := C.faiss_IndexIVFFlat_new_with_metric(&idx, C.FaissIndex(unsafe.Pointer(quantizer)), C.size_t(d), C.size_t(nlist), C.int(metric))
+	if ret != 0 {
+		return 0, fmt.Errorf("failed to create IndexIVFFlat: FAISS error code %d", ret)
+	}
+	return uintptr(unsafe.Pointer(idx)), nil
+}
+
+// faissIndexIVFPQNewWithQuantizer creates an IndexIVFPQ around an existing
+// coarse quantizer. The quantizer is not owned by the new index and must
+// outlive it.
+func faissIndexIVFPQNewWithQuantizer(quantizer uintptr, d, nlist, M, nbits, metric int) (uintptr, error) {
+	var idx unsafe.Pointer
+	ret := C.faiss_go_IndexIVFPQ_new_with_metric(&idx, unsafe.Pointer(quantizer), C.int64_t(d), C.int64_t(nlist), C.int64_t(M), C.int64_t(nbits), C.int(metric))
+	if ret != 0 {
+		return 0, fmt.Errorf("failed to create IndexIVFPQ: FAISS error code %d", ret)
+	}
+	return uintptr(idx), nil
+}
+
+func faissIndexIVFCopySubsetTo(src, dst uintptr, subsetType int, a1, a2 int64) error {
+	srcIVF := C.faiss_IndexIVF_cast(C.FaissIndex(unsafe.Pointer(src)))
+	dstIVF := C.faiss_IndexIVF_cast(C.FaissIndex(unsafe.Pointer(dst)))
+	if srcIVF == nil || dstIVF == nil {
+		return fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+
+	ret := C.faiss_IndexIVF_copy_subset_to(srcIVF, dstIVF, C.int(subsetType), C.int64_t(a1), C.int64_t(a2))
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+func faissSetIndexParameter(ptr uintptr, name string, value float64) error {
+	var space *C.FaissParameterSpace
+	if ret := C.faiss_ParameterSpace_new(&space); ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	defer C.faiss_ParameterSpace_free(space)
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	ret := C.faiss_ParameterSpace_set_index_parameter(space, (*C.FaissIndex)(unsafe.Pointer(ptr)), cName, C.double(value))
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+func faissIndexIVFGetNlist(ptr uintptr) (int, error) {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+
+	// Downcast to IndexIVF
+	ivf := C.faiss_IndexIVF_cast(idx)
+	if ivf == nil {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+
+	return int(C.faiss_IndexIVF_nlist(ivf)), nil
+}
+
+// faissIndexIVFQuantizer returns the coarse quantizer of an IVF index
+// The quantizer is owned by the IVF index and must not be freed.
+func faissIndexIVFQuantizer(ptr uintptr) (uintptr, error) {
+	ivf := C.faiss_IndexIVF_cast(C.FaissIndex(unsafe.Pointer(ptr)))
+	if ivf == nil {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+
+	quantizer := C.faiss_IndexIVF_quantizer(ivf)
+	if quantizer == nil {
+		return 0, errors.New("null quantizer pointer")
+	}
+	return uintptr(unsafe.Pointer(quantizer)), nil
+}
+
+// faissIndexIVFQuantizerMetric returns the metric of an IVF index's coarse quantizer
+func faissIndexIVFQuantizerMetric(ptr uintptr) (MetricType, error) {
+	ivf := C.faiss_IndexIVF_cast(C.FaissIndex(unsafe.Pointer(ptr)))
+	if ivf == nil {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+
+	quantizer := C.faiss_IndexIVF_quantizer(ivf)
+	if quantizer == nil {
+		return 0, errors.New("null quantizer pointer")
+	}
+	return MetricType(C.faiss_Index_metric_type(C.FaissIndex(unsafe.Pointer(quantizer)))), nil
+}
+
+// faissIndexSetClusteringParams sets the k-means iterations and restarts
+// Index::train uses for the coarse quantizer of an IVF index and the
+// codebooks of a PQ or IVFPQ index, looking through IndexPreTransform and
+// IndexIDMap wrappers. The other clustering parameters are left as
+// they are.
+func faissIndexSetClusteringParams(ptr uintptr, niter, nredo int) error {
+	if C.faiss_go_Index_set_clustering_params(unsafe.Pointer(hnswTarget(ptr)), C.int(niter), C.int(nredo)) != 0 {
+		return fmt.Errorf("faiss: not an IVF, PQ or IVFPQ index")
+	}
+	return nil
+}
+
+// faissClusteringTrain runs k-means with FAISS's default clustering
+// parameters, the same ones faiss_kmeans_clustering uses, assigning points
+// with the given index (any faiss::Index, CPU or GPU). It returns a copy of
+// the k*d centroids; the index is left holding them.
+func faissClusteringTrain(d, k int, vectors []float32, n int, index uintptr) ([]float32, error) {
+	var clustering C.FaissClustering
+	ret := C.faiss_Clustering_new(&clustering, C.int(d), C.int(k))
+	if ret != 0 {
+		return nil, fmt.Errorf("FAISS error code: %d", ret)
+	}
+	defer C.faiss_Clustering_free(clustering)
+
+	ret = C.faiss_Clustering_train(clustering, C.int64_t(n), (*C.float)(unsafe.Pointer(&vectors[0])), C.FaissIndex(unsafe.Pointer(index)))
+	if ret != 0 {
+		return nil, fmt.Errorf("FAISS error code: %d", ret)
+	}
+
+	var cPtr *C.float
+	var size C.size_t
+	C.faiss_Clustering_centroids(clustering, &cPtr, &size)
+	if cPtr == nil || int(size) != k*d {
+		return nil, fmt.Errorf("clustering returned %d centroid values, want %d", int(size), k*d)
+	}
+	centroids := make([]float32, k*d)
+	copy(centroids, unsafe.Slice((*float32)(unsafe.Pointer(cPtr)), k*d))
+	return centroids, nil
+}
+
+// faissIndexIVFPQByResidual reads by_residual from an IVFPQ index
+func faissIndexIVFPQByResidual(ptr uintptr) (bool, error) {
+	ret := C.faiss_go_IndexIVFPQ_by_residual(unsafe.Pointer(ptr))
+	if ret < 0 {
+		return false, fmt.Errorf("faiss: not an IVFPQ index")
+	}
+	return ret == 1, nil
+}
+
+// faissIndexIVFPQSetByResidual sets by_residual on an IVFPQ index in place
+func faissIndexIVFPQSetByResidual(ptr uintptr, enable bool) error {
+	flag := C.int(0)
+	if enable {
+		flag = 1
+	}
+	if C.faiss_go_IndexIVFPQ_set_by_residual(unsafe.Pointer(ptr), flag) != 0 {
+		return fmt.Errorf("faiss: not an IVFPQ index")
+	}
+	return nil
+}
+
+// faissIndexIVFTarget returns the IVF index that ptr is or wraps in an
+// IndexIDMap
+func faissIndexIVFTarget(ptr uintptr) (uintptr, error) {
+	ivf := C.faiss_go_Index_ivf(unsafe.Pointer(ptr))
+	if ivf == nil {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+	return uintptr(ivf), nil
+}
+
+// faissIndexIVFDirectMapType returns the direct map type of an IVF index
+// (0 none, 1 array, 2 hashtable), looking through an IndexIDMap wrapper
+func faissIndexIVFDirectMapType(ptr uintptr) (int, error) {
+	ret := C.faiss_go_IndexIVF_direct_map_type(unsafe.Pointer(ptr))
+	if ret < 0 {
+		return 0, fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+	return int(ret), nil
+}
+
+// faissIndexIVFStoredVectors copies the n vectors stored in an IVF index
+// (or an IndexIDMap over one) together with their IDs, in inverted list
+// order
+func faissIndexIVFStoredVectors(ptr uintptr, n int64, d int) (ids []int64, vectors []float32, err error) {
+	ids = make([]int64, n)
+	vectors = make([]float32, n*int64(d))
+	if n == 0 {
+		return ids, vectors, nil
+	}
+	ret := C.faiss_go_IndexIVF_stored_vectors(unsafe.Pointer(ptr), C.int64_t(n),
+		(*C.int64_t)(unsafe.Pointer(&ids[0])), (*C.float)(unsafe.Pointer(&vectors[0])))
+	switch ret {
+	case 0:
+		return ids, vectors, nil
+	case -1:
+		return nil, nil, fmt.Errorf("index is not an IVF index (downcast failed)")
+	case -2:
+		return nil, nil, fmt.Errorf("index does not hold %d vectors", n)
+	default:
+		return nil, nil, fmt.Errorf("stored vectors cannot be decoded")
+	}
+}
+
+// faissIndexIVFStoredIDs copies the IDs of the n vectors stored in an IVF
+// index (or an IndexIDMap over one), in inverted list order
+func faissIndexIVFStoredIDs(ptr uintptr, n int64) ([]int64, error) {
+	ids := make([]int64, n)
+	if n == 0 {
+		return ids, nil
+	}
+	ret := C.faiss_go_IndexIVF_stored_vectors(unsafe.Pointer(ptr), C.int64_t(n),
+		(*C.int64_t)(unsafe.Pointer(&ids[0])), nil)
+	switch ret {
+	case 0:
+		return ids, nil
+	case -1:
+		return nil, fmt.Errorf("index is not an IVF index (downcast failed)")
+	default:
+		return nil, fmt.Errorf("index does not hold %d vectors", n)
+	}
+}
+
+// faissIndexPQParams reads M and nbits of the product quantizer of an
+// IndexPQ or IndexIVFPQ, looking through IndexPreTransform wrappers
+func faissIndexPQParams(ptr uintptr) (M, nbits int, err error) {
+	var cM, cNbits C.int
+	if C.faiss_go_Index_pq_params(unsafe.Pointer(hnswTarget(ptr)), &cM, &cNbits) != 0 {
+		return 0, 0, fmt.Errorf("faiss: not a PQ or IVFPQ index")
+	}
+	return int(cM), int(cNbits), nil
+}
+
+// faissIndexPQCentroidsSize reads the number of floats in the centroid table
+// of an IndexPQ or IndexIVFPQ, looking through IndexPreTransform wrappers
+func faissIndexPQCentroidsSize(ptr uintptr) (int64, error) {
+	var size C.int64_t
+	if C.faiss_go_Index_pq_centroids_size(unsafe.Pointer(hnswTarget(ptr)), &size) != 0 {
+		return 0, fmt.Errorf("faiss: not a PQ or IVFPQ index")
+	}
+	return int64(size), nil
+}
+
+func faissIndexIVFMakeDirectMap(ptr uintptr, enable bool) error {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+
+	// Downcast to IndexIVF
+	ivf := C.faiss_IndexIVF_cast(idx)
+	if ivf == nil {
+		return fmt.Errorf("index is not an IVF index (downcast failed)")
+	}
+
+	maintain := C.int(0)
+	if enable {
+		maintain = 1
+	}
+	ret := C.faiss_IndexIVF_make_direct_map(ivf, maintain)
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
 // ==== ID Map Functions ====
 
 func faissIndexIDMapNew(basePtr uintptr) (uintptr, error) {
@@ -357,6 +898,159 @@ func faiss_IndexIDMap_set_own_fields(ptr uintptr, own int) {
 	C.faiss_IndexIDMap_set_own_fields(idx, C.int(own))
 }
 
+// faissIndexIsIDMap reports whether the index is an IndexIDMap or IndexIDMap2,
+// which require caller-provided IDs on add
+func faissIndexIsIDMap(ptr uintptr) bool {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	return C.faiss_IndexIDMap_cast(idx) != nil || C.faiss_IndexIDMap2_cast(idx) != nil
+}
+
+// faissIndexIDMapIDs returns a copy of the IDMap's id_map: the ID of each
+// vector of its sub-index, in storage order
+func faissIndexIDMapIDs(ptr uintptr) []int64 {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	var idMap *C.int64_t
+	var size C.size_t
+	C.faiss_IndexIDMap_id_map(idx, &idMap, &size)
+	ids := make([]int64, int(size))
+	if idMap != nil && size > 0 {
+		copy(ids, unsafe.Slice((*int64)(unsafe.Pointer(idMap)), int(size)))
+	}
+	return ids
+}
+
+// faissIndexIDMapSubIndex returns the index wrapped by an IDMap
+func faissIndexIDMapSubIndex(ptr uintptr) uintptr {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	return uintptr(unsafe.Pointer(C.faiss_IndexIDMap_sub_index(idx)))
+}
+
+// faissIndexClassName returns the FAISS class of the index object, e.g.
+// "IndexIVFPQ", read from its runtime type
+func faissIndexClassName(ptr uintptr) (string, error) {
+	if ptr == 0 {
+		return "", errors.New("null index pointer")
+	}
+	buf := make([]byte, 128)
+	n := C.faiss_go_Index_class_name(unsafe.Pointer(ptr), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+	if n < 0 {
+		return "", errors.New("failed to read index class name")
+	}
+	if int(n) >= len(buf) {
+		buf = make([]byte, int(n)+1)
+		C.faiss_go_Index_class_name(unsafe.Pointer(ptr), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+	}
+	return string(buf[:n]), nil
+}
+
+// faissIndexFlatShrinkToFit releases the unused capacity of a flat index's
+// code buffer in place
+func faissIndexFlatShrinkToFit(ptr uintptr) error {
+	switch C.faiss_go_IndexFlatCodes_shrink_to_fit(unsafe.Pointer(ptr)) {
+	case 0:
+		return nil
+	case -1:
+		return errors.New("index is not a flat index")
+	case -2:
+		return errors.New("index storage is memory-mapped")
+	default:
+		return errors.New("failed to reallocate index storage")
+	}
+}
+
+// faissIndexFlatReserve grows a flat index's code buffer to hold at least n
+// vectors
+func faissIndexFlatReserve(ptr uintptr, n int64) error {
+	switch C.faiss_go_IndexFlatCodes_reserve(unsafe.Pointer(ptr), C.int64_t(n)) {
+	case 0:
+		return nil
+	case -1:
+		return errors.New("index is not a flat index")
+	case -2:
+		return errors.New("index storage is memory-mapped")
+	default:
+		return errors.New("failed to allocate index storage")
+	}
+}
+
+// faissWriteFlatIndexFromRaw writes an IndexFlat holding the raw float32
+// vectors of rawFilename, which FAISS maps rather than reads, to filename.
+// An empty rawFilename writes an empty index.
+func faissWriteFlatIndexFromRaw(rawFilename string, d int, metric MetricType, filename string) error {
+	var cRaw *C.char
+	if rawFilename != "" {
+		cRaw = C.CString(rawFilename)
+		defer C.free(unsafe.Pointer(cRaw))
+	}
+	cFilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cFilename))
+
+	switch C.faiss_go_write_flat_index_from_raw(cRaw, C.int(d), C.int(metric), cFilename) {
+	case 0:
+		return nil
+	case -2:
+		return fmt.Errorf("%s does not hold whole %d-dimensional vectors", rawFilename, d)
+	default:
+		return fmt.Errorf("failed to write %s", filename)
+	}
+}
+
+// faissIndexFlatCapacity returns the number of vectors a flat index's code
+// buffer holds without reallocating
+func faissIndexFlatCapacity(ptr uintptr) (int64, error) {
+	capacity := C.faiss_go_IndexFlatCodes_capacity(unsafe.Pointer(ptr))
+	if capacity < 0 {
+		return 0, errors.New("index is not a flat index")
+	}
+	return int64(capacity), nil
+}
+
+// faissIndexIVFUseOnDiskLists moves the inverted lists of an empty IVF index
+// to an on-disk file
+func faissIndexIVFUseOnDiskLists(ptr uintptr, filename string) error {
+	cFilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cFilename))
+
+	switch C.faiss_go_IndexIVF_use_ondisk_lists(unsafe.Pointer(ptr), cFilename) {
+	case 0:
+		return nil
+	case -1:
+		return errors.New("index is not an IVF index")
+	case -2:
+		return errors.New("index already holds vectors")
+	default:
+		return fmt.Errorf("failed to create on-disk inverted lists in %s", filename)
+	}
+}
+
+// faissIndexIVFOnDiskFilename returns the file holding the on-disk inverted
+// lists of an IVF index
+func faissIndexIVFOnDiskFilename(ptr uintptr) (string, error) {
+	buf := make([]byte, 256)
+	n := C.faiss_go_IndexIVF_ondisk_filename(unsafe.Pointer(ptr), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+	if n < 0 {
+		return "", errors.New("index has no on-disk inverted lists")
+	}
+	if int(n) >= len(buf) {
+		buf = make([]byte, int(n)+1)
+		C.faiss_go_IndexIVF_ondisk_filename(unsafe.Pointer(ptr), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+	}
+	return string(buf[:n]), nil
+}
+
+// faissIndexIVFOnDiskSync writes the mapped on-disk inverted lists back to
+// their file
+func faissIndexIVFOnDiskSync(ptr uintptr) error {
+	switch C.faiss_go_IndexIVF_ondisk_sync(unsafe.Pointer(ptr)) {
+	case 0:
+		return nil
+	case -1:
+		return errors.New("index has no on-disk inverted lists")
+	default:
+		return errors.New("msync of the inverted lists failed")
+	}
+}
+
 func faissIndexAddWithIDs(ptr uintptr, vectors []float32, ids []int64, n int) error {
 	idx := C.FaissIndex(unsafe.Pointer(ptr))
 	vecPtr := (*C.float)(unsafe.Pointer(&vectors[0]))
@@ -422,6 +1116,48 @@ func faissWriteIndex(ptr uintptr, filename string) error {
 	return nil
 }
 
+// faissSerializeIndex serializes an index into a Go-owned byte slice
+func faissSerializeIndex(ptr uintptr) ([]byte, error) {
+	if ptr == 0 {
+		return nil, errors.New("null index pointer")
+	}
+
+	var data *C.char
+	var size C.size_t
+	ret := C.faiss_go_serialize_index(C.FaissIndex(unsafe.Pointer(ptr)), &data, &size)
+	if ret != 0 {
+		return nil, fmt.Errorf("FAISS error code: %d", ret)
+	}
+	defer C.free(unsafe.Pointer(data))
+
+	// C.GoBytes takes a C.int length, which overflows for indexes of 2 GiB
+	// and more
+	out := make([]byte, int(size))
+	copy(out, unsafe.Slice((*byte)(unsafe.Pointer(data)), int(size)))
+	return out, nil
+}
+
+// faissDeserializeIndex creates an index from bytes produced by faissSerializeIndex
+func faissDeserializeIndex(data []byte) (uintptr, error) {
+	if len(data) == 0 {
+		return 0, errors.New("empty index data")
+	}
+
+	cData := C.CBytes(data)
+	defer C.free(cData)
+
+	var idx *C.FaissIndex
+	ret := C.faiss_go_deserialize_index(cData, C.size_t(len(data)), &idx)
+	if ret != 0 {
+		return 0, fmt.Errorf("FAISS error code: %d", ret)
+	}
+	if idx == nil {
+		return 0, errors.New("null index pointer")
+	}
+
+	return uintptr(unsafe.Pointer(idx)), nil
+}
+
 // ==== Index Factory ====
 
 // faissIndexFactory creates an index from a factory description string
@@ -444,7 +1180,14 @@ func faissIndexFactory(d int, description string, metric int) (uintptr, error) {
 
 // ==== Range Search Functions ====
 
-func faissIndexRangeSearch(ptr uintptr, queries []float32, nq int, radius float32) (uintptr, []int64, []int64, []float32, error) {
+// liveRangeSearchResults counts C RangeSearchResult objects that have been
+// allocated but not yet freed; tests use it to detect leaks
+var liveRangeSearchResults int64
+
+// faissIndexRangeSearch runs a range search and copies the results into Go
+// slices. The C result is always freed before returning, so callers own
+// plain Go memory and have nothing to release.
+func faissIndexRangeSearch(ptr uintptr, queries []float32, nq int, radius float32) (lims, labels []int64, distances []float32, err error) {
 	idx := C.FaissIndex(unsafe.Pointer(ptr))
 	queryPtr := (*C.float)(unsafe.Pointer(&queries[0]))
 
@@ -452,56 +1195,76 @@ func faissIndexRangeSearch(ptr uintptr, queries []float32, nq int, radius float3
 	var resultPtr C.FaissRangeSearchResult
 	ret := C.faiss_RangeSearchResult_new(&resultPtr, C.int64_t(nq))
 	if ret != 0 {
-		return 0, nil, nil, nil, fmt.Errorf("faiss_RangeSearchResult_new failed with code %d", ret)
+		return nil, nil, nil, fmt.Errorf("faiss_RangeSearchResult_new failed with code %d", ret)
 	}
+	atomic.AddInt64(&liveRangeSearchResults, 1)
+	defer func() {
+		C.faiss_RangeSearchResult_free(resultPtr)
+		atomic.AddInt64(&liveRangeSearchResults, -1)
+	}()
 
 	// Step 2: Perform the range search with pre-allocated result
 	ret = C.faiss_Index_range_search(idx, C.int64_t(nq), queryPtr, C.float(radius), resultPtr)
 	if ret != 0 {
-		C.faiss_RangeSearchResult_free(resultPtr)
-		return 0, nil, nil, nil, fmt.Errorf("range_search failed with code %d", ret)
+		return nil, nil, nil, fmt.Errorf("range_search failed with code %d", ret)
 	}
 
 	// Step 3: Get results from the RangeSearchResult
 	var cLims, cLabels *C.int64_t
 	var cDistances *C.float
 
-	ret2 := C.faiss_RangeSearchResult_get(resultPtr, &cLims, &cLabels, &cDistances)
-	if ret2 != 0 {
-		C.faiss_RangeSearchResult_free(resultPtr)
-		return 0, nil, nil, nil, fmt.Errorf("RangeSearchResult_get failed with code %d", ret2)
+	ret = C.faiss_RangeSearchResult_get(resultPtr, &cLims, &cLabels, &cDistances)
+	if ret != 0 {
+		return nil, nil, nil, fmt.Errorf("RangeSearchResult_get failed with code %d", ret)
 	}
 
-	// Convert lims (nq+1 elements)
-	lims := make([]int64, nq+1)
-	cLimsSlice := (*[1 << 30]C.int64_t)(unsafe.Pointer(cLims))[:nq+1:nq+1]
-	for i := 0; i <= nq; i++ {
-		lims[i] = int64(cLimsSlice[i])
-	}
+	// Copy lims (nq+1 elements); C.int64_t and int64 share a layout
+	lims = make([]int64, nq+1)
+	copy(lims, unsafe.Slice((*int64)(unsafe.Pointer(cLims)), nq+1))
 
-	// Total number of results
+	// Copy labels and distances, however many results there are
 	nTotal := int(lims[nq])
-	if nTotal == 0 {
-		return uintptr(unsafe.Pointer(resultPtr)), lims, []int64{}, []float32{}, nil
+	labels = make([]int64, nTotal)
+	distances = make([]float32, nTotal)
+	if nTotal > 0 {
+		copy(labels, unsafe.Slice((*int64)(unsafe.Pointer(cLabels)), nTotal))
+		copy(distances, unsafe.Slice((*float32)(unsafe.Pointer(cDistances)), nTotal))
 	}
 
-	// Convert labels and distances
-	labels := make([]int64, nTotal)
-	distances := make([]float32, nTotal)
+	return lims, labels, distances, nil
+}
 
-	cLabelsSlice := (*[1 << 30]C.int64_t)(unsafe.Pointer(cLabels))[:nTotal:nTotal]
-	cDistancesSlice := (*[1 << 30]C.float)(unsafe.Pointer(cDistances))[:nTotal:nTotal]
+// faissIndexRangeSearchCount runs a range search and returns only the total
+// number of results, read from lims[nq]. Labels and distances are left in
+// the C result and freed with it.
+func faissIndexRangeSearchCount(ptr uintptr, queries []float32, nq int, radius float32) (int64, error) {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	queryPtr := (*C.float)(unsafe.Pointer(&queries[0]))
 
-	for i := 0; i < nTotal; i++ {
-		labels[i] = int64(cLabelsSlice[i])
-		distances[i] = float32(cDistancesSlice[i])
+	var resultPtr C.FaissRangeSearchResult
+	ret := C.faiss_RangeSearchResult_new(&resultPtr, C.int64_t(nq))
+	if ret != 0 {
+		return 0, fmt.Errorf("faiss_RangeSearchResult_new failed with code %d", ret)
 	}
+	atomic.AddInt64(&liveRangeSearchResults, 1)
+	defer func() {
+		C.faiss_RangeSearchResult_free(resultPtr)
+		atomic.AddInt64(&liveRangeSearchResults, -1)
+	}()
 
-	return uintptr(unsafe.Pointer(resultPtr)), lims, labels, distances, nil
-}
+	ret = C.faiss_Index_range_search(idx, C.int64_t(nq), queryPtr, C.float(radius), resultPtr)
+	if ret != 0 {
+		return 0, fmt.Errorf("range_search failed with code %d", ret)
+	}
 
-func faissRangeSearchResultFree(ptr uintptr) {
-	C.faiss_RangeSearchResult_free(C.FaissRangeSearchResult(unsafe.Pointer(ptr)))
+	var cLims, cLabels *C.int64_t
+	var cDistances *C.float
+	ret = C.faiss_RangeSearchResult_get(resultPtr, &cLims, &cLabels, &cDistances)
+	if ret != 0 {
+		return 0, fmt.Errorf("RangeSearchResult_get failed with code %d", ret)
+	}
+
+	return unsafe.Slice((*int64)(unsafe.Pointer(cLims)), nq+1)[nq], nil
 }
 
 // ==== Reconstruction Functions ====
@@ -527,9 +1290,58 @@ func faissIndexReconstructN(ptr uintptr, i0, ni int64, recons []float32) error {
 }
 
 // ==== Binary Index Functions ====
-// NOTE: Binary index support is experimental (see LIMITATIONS.md).
-// The binary index type is not currently exposed in the public Go API.
-// If you need binary indexes, use the IndexFactory with binary descriptions.
+// Back IndexBinaryFlat (index_binary.go); the binary factory and the other
+// binary index types are not available.
+
+func faissIndexBinaryFlatNew(d int) (uintptr, error) {
+	var idx C.FaissIndexBinary
+	ret := C.faiss_IndexBinaryFlat_new(&idx, C.int64_t(d))
+	if ret != 0 || idx == nil {
+		return 0, fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return uintptr(unsafe.Pointer(idx)), nil
+}
+
+func faissIndexBinaryAdd(ptr uintptr, codes []uint8, n int) error {
+	ret := C.faiss_IndexBinary_add(C.FaissIndexBinary(unsafe.Pointer(ptr)), C.int64_t(n), (*C.uint8_t)(unsafe.Pointer(&codes[0])))
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+func faissIndexBinarySearch(ptr uintptr, queries []uint8, nq, k int, distances []int32, labels []int64) error {
+	ret := C.faiss_IndexBinary_search(C.FaissIndexBinary(unsafe.Pointer(ptr)), C.int64_t(nq), (*C.uint8_t)(unsafe.Pointer(&queries[0])),
+		C.int64_t(k), (*C.int32_t)(unsafe.Pointer(&distances[0])), (*C.int64_t)(unsafe.Pointer(&labels[0])))
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+// faissIndexBinaryFlatSearchHybrid finds the k nearest codes of one query
+// and counts the codes within radius, in one pass over the index
+func faissIndexBinaryFlatSearchHybrid(ptr uintptr, query []uint8, k, radius int, distances []int32, labels []int64) (int64, error) {
+	var within C.int64_t
+	ret := C.faiss_go_IndexBinaryFlat_search_hybrid(unsafe.Pointer(ptr), (*C.uint8_t)(unsafe.Pointer(&query[0])), C.int64_t(k), C.int(radius),
+		(*C.int32_t)(unsafe.Pointer(&distances[0])), (*C.int64_t)(unsafe.Pointer(&labels[0])), &within)
+	if ret != 0 {
+		return 0, fmt.Errorf("faiss: not an IndexBinaryFlat")
+	}
+	return int64(within), nil
+}
+
+func faissIndexBinaryReset(ptr uintptr) error {
+	ret := C.faiss_IndexBinary_reset(C.FaissIndexBinary(unsafe.Pointer(ptr)))
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+func faissIndexBinaryFree(ptr uintptr) {
+	C.faiss_IndexBinary_free(C.FaissIndexBinary(unsafe.Pointer(ptr)))
+}
 
 // ==== Generic Index Functions (for composite indexes) ====
 
@@ -710,6 +1522,42 @@ func faiss_VectorTransform_free(transform uintptr) {
 	C.faiss_VectorTransform_free(t)
 }
 
+// faissWriteVectorTransform saves a transform with faiss::write_VectorTransform
+func faissWriteVectorTransform(transform uintptr, filename string) error {
+	if transform == 0 {
+		return errors.New("null transform pointer")
+	}
+	cFilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cFilename))
+
+	ret := C.faiss_go_write_VectorTransform_fname(unsafe.Pointer(transform), cFilename)
+	if ret != 0 {
+		return fmt.Errorf("FAISS error code: %d", ret)
+	}
+	return nil
+}
+
+// faissReadVectorTransform loads a transform and returns its pointer,
+// dimensions and training status
+func faissReadVectorTransform(filename string) (ptr uintptr, dIn, dOut int, isTrained bool, err error) {
+	cFilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cFilename))
+
+	var transform C.FaissVectorTransform
+	ret := C.faiss_read_VectorTransform_fname(cFilename, &transform)
+	if ret != 0 {
+		return 0, 0, 0, false, fmt.Errorf("FAISS error code: %d", ret)
+	}
+	if transform == nil {
+		return 0, 0, 0, false, errors.New("null transform pointer")
+	}
+
+	dIn = int(C.faiss_VectorTransform_d_in(transform))
+	dOut = int(C.faiss_VectorTransform_d_out(transform))
+	isTrained = C.faiss_VectorTransform_is_trained(transform) != 0
+	return uintptr(unsafe.Pointer(transform)), dIn, dOut, isTrained, nil
+}
+
 // ==== LSH Index Wrapper Functions ====
 
 func faiss_IndexLSH_new(p_index *uintptr, d, nbits int64, rotate_data, train_thresholds bool) int {
@@ -787,9 +1635,23 @@ func faiss_kmeans_clustering(d, n, k int, x []float32, centroids []float32) erro
 // Low-level Clustering API removed as it's not used.
 
 // ==== HNSW Property Accessors ====
+// The accessors look through IndexPreTransform wrappers (e.g. "L2norm,HNSW32")
+// to the HNSW index inside.
 
-func faissIndexHNSWSetEfSearch(ptr uintptr, ef int) error {
+// hnswTarget returns the innermost index below any IndexPreTransform wrappers
+func hnswTarget(ptr uintptr) uintptr {
 	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	for {
+		pt := C.faiss_IndexPreTransform_cast(idx)
+		if pt == nil {
+			return uintptr(unsafe.Pointer(idx))
+		}
+		idx = C.faiss_IndexPreTransform_index(pt)
+	}
+}
+
+func faissIndexHNSWSetEfSearch(ptr uintptr, ef int) error {
+	idx := C.FaissIndex(unsafe.Pointer(hnswTarget(ptr)))
 	ret := C.faiss_IndexHNSW_set_efSearch(idx, C.int(ef))
 	if ret != 0 {
 		return fmt.Errorf("failed to set efSearch: error code %d", ret)
@@ -797,5 +1659,126 @@ func faissIndexHNSWSetEfSearch(ptr uintptr, ef int) error {
 	return nil
 }
 
-// Note: Byte-level serialization functions removed due to ABI compatibility issues.
-// Use persistence.go (WriteIndexToFile, ReadIndexFromFile) for serialization.
+func faissIndexHNSWGetEfSearch(ptr uintptr) (int, error) {
+	idx := C.FaissIndex(unsafe.Pointer(hnswTarget(ptr)))
+	var ef C.int
+	ret := C.faiss_IndexHNSW_get_efSearch(idx, &ef)
+	if ret != 0 {
+		return 0, fmt.Errorf("failed to get efSearch: error code %d", ret)
+	}
+	return int(ef), nil
+}
+
+// faissIndexHNSWGetM reads M, the number of neighbors per node above level 0
+func faissIndexHNSWGetM(ptr uintptr) (int, error) {
+	M := C.faiss_go_IndexHNSW_M(unsafe.Pointer(hnswTarget(ptr)))
+	if M < 0 {
+		return 0, fmt.Errorf("faiss: not an HNSW index")
+	}
+	return int(M), nil
+}
+
+// errNotHNSW is returned by the graph accessors for indexes that are not HNSW
+var errNotHNSW = errors.New("faiss: not an HNSW index")
+
+// faissIndexHNSWSearchBoundedQueue reads the queue mode used by plain searches
+func faissIndexHNSWSearchBoundedQueue(ptr uintptr) (bool, error) {
+	bounded := C.faiss_go_IndexHNSW_search_bounded_queue(unsafe.Pointer(hnswTarget(ptr)))
+	if bounded < 0 {
+		return false, errNotHNSW
+	}
+	return bounded == 1, nil
+}
+
+// faissIndexHNSWSetSearchBoundedQueue sets the queue mode used by plain searches
+func faissIndexHNSWSetSearchBoundedQueue(ptr uintptr, bounded bool) error {
+	flag := 0
+	if bounded {
+		flag = 1
+	}
+	if C.faiss_go_IndexHNSW_set_search_bounded_queue(unsafe.Pointer(hnswTarget(ptr)), C.int(flag)) != 0 {
+		return errNotHNSW
+	}
+	return nil
+}
+
+// faissIndexHNSWNodeLevel returns the top layer of node
+func faissIndexHNSWNodeLevel(ptr uintptr, node int64) (int, error) {
+	levels := C.faiss_go_IndexHNSW_node_levels(unsafe.Pointer(hnswTarget(ptr)), C.int64_t(node))
+	switch {
+	case levels == -1:
+		return 0, errNotHNSW
+	case levels < 0:
+		return 0, fmt.Errorf("faiss: node %d out of range", node)
+	}
+	return int(levels) - 1, nil
+}
+
+// faissIndexHNSWNeighbors returns the neighbors of node on layer level
+func faissIndexHNSWNeighbors(ptr uintptr, node int64, level int) ([]int64, error) {
+	target := unsafe.Pointer(hnswTarget(ptr))
+	out := make([]int64, 64)
+	for {
+		n := C.faiss_go_IndexHNSW_neighbors(target, C.int64_t(node), C.int(level),
+			(*C.int64_t)(unsafe.Pointer(&out[0])), C.int(len(out)))
+		switch {
+		case n == -1:
+			return nil, errNotHNSW
+		case n < 0:
+			return nil, fmt.Errorf("faiss: node %d has no layer %d", node, level)
+		case int(n) <= len(out):
+			return out[:n], nil
+		}
+		out = make([]int64, int(n))
+	}
+}
+
+// faissIndexHNSWEntryPoint returns the entry point and top layer of the graph
+func faissIndexHNSWEntryPoint(ptr uintptr) (int64, int, error) {
+	var entry C.int64_t
+	var maxLevel C.int
+	if C.faiss_go_IndexHNSW_entry_point(unsafe.Pointer(hnswTarget(ptr)), &entry, &maxLevel) != 0 {
+		return 0, 0, errNotHNSW
+	}
+	return int64(entry), int(maxLevel), nil
+}
+
+// faissIndexHNSWGraph copies the link structure of an HNSW index
+func faissIndexHNSWGraph(ptr uintptr) (*HNSWGraph, error) {
+	target := unsafe.Pointer(hnswTarget(ptr))
+	var nlayers, nnodes, nslots C.int64_t
+	if C.faiss_go_IndexHNSW_graph_sizes(target, &nlayers, &nnodes, &nslots) != 0 {
+		return nil, errNotHNSW
+	}
+
+	// Spare slots keep the first element addressable for empty graphs
+	graph := &HNSWGraph{
+		cumNeighbors: make([]int32, nlayers, nlayers+1),
+		levels:       make([]int32, nnodes, nnodes+1),
+		offsets:      make([]uint64, nnodes+1),
+		neighbors:    make([]int32, nslots, nslots+1),
+	}
+	var entry, maxLevel C.int32_t
+	ret := C.faiss_go_IndexHNSW_graph_copy(target,
+		(*C.int32_t)(unsafe.Pointer(&graph.cumNeighbors[:1][0])),
+		(*C.int32_t)(unsafe.Pointer(&graph.levels[:1][0])),
+		(*C.uint64_t)(unsafe.Pointer(&graph.offsets[0])),
+		(*C.int32_t)(unsafe.Pointer(&graph.neighbors[:1][0])),
+		&entry, &maxLevel)
+	if ret != 0 {
+		return nil, fmt.Errorf("faiss: inconsistent HNSW graph")
+	}
+	graph.entryPoint = int32(entry)
+	graph.maxLevel = int32(maxLevel)
+	return graph, nil
+}
+
+func faissIndexHNSWGetEfConstruction(ptr uintptr) (int, error) {
+	idx := C.FaissIndex(unsafe.Pointer(hnswTarget(ptr)))
+	var ef C.int
+	ret := C.faiss_IndexHNSW_get_efConstruction(idx, &ef)
+	if ret != 0 {
+		return 0, fmt.Errorf("failed to get efConstruction: error code %d", ret)
+	}
+	return int(ef), nil
+}
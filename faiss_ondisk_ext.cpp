@@ -0,0 +1,87 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * On-disk inverted lists for IVF indexes.
+ *
+ * The FAISS C API can read and write indexes whose inverted lists live in an
+ * OnDiskInvertedLists file, but cannot create one. Compiled against the
+ * FAISS headers in third_party/faiss.
+ */
+
+#include <sys/mman.h>
+
+#include <cstring>
+#include <string>
+
+#include <faiss/IndexIVF.h>
+#include <faiss/invlists/OnDiskInvertedLists.h>
+
+namespace {
+
+faiss::OnDiskInvertedLists* ondisk_lists(void* index) {
+    faiss::IndexIVF* ivf =
+            dynamic_cast<faiss::IndexIVF*>(static_cast<faiss::Index*>(index));
+    if (!ivf) {
+        return nullptr;
+    }
+    return dynamic_cast<faiss::OnDiskInvertedLists*>(ivf->invlists);
+}
+
+} // namespace
+
+extern "C" {
+
+// Replaces the inverted lists of an empty IVF index with on-disk lists
+// stored in filename, which is created on the first add. Returns -1 if index
+// is not an IndexIVF, -2 if it already holds vectors, or -3 on failure.
+int faiss_go_IndexIVF_use_ondisk_lists(void* index, const char* filename) {
+    faiss::IndexIVF* ivf =
+            dynamic_cast<faiss::IndexIVF*>(static_cast<faiss::Index*>(index));
+    if (!ivf) {
+        return -1;
+    }
+    if (ivf->ntotal != 0) {
+        return -2;
+    }
+    try {
+        ivf->replace_invlists(
+                new faiss::OnDiskInvertedLists(
+                        ivf->nlist, ivf->code_size, filename),
+                true);
+    } catch (...) {
+        return -3;
+    }
+    return 0;
+}
+
+// Writes the path of the on-disk inverted lists file into buf
+// (NUL-terminated, truncated to len - 1). Returns the full path length, or
+// -1 if index has no on-disk inverted lists.
+int faiss_go_IndexIVF_ondisk_filename(void* index, char* buf, int len) {
+    faiss::OnDiskInvertedLists* od = ondisk_lists(index);
+    if (!od || !buf || len <= 0) {
+        return -1;
+    }
+    const std::string& name = od->filename;
+    int n = static_cast<int>(name.size());
+    int copied = n < len - 1 ? n : len - 1;
+    std::memcpy(buf, name.data(), copied);
+    buf[copied] = '\0';
+    return n;
+}
+
+// Writes the mapped inverted lists back to their file with msync. Returns
+// -1 if index has no on-disk inverted lists, or -2 if msync fails.
+int faiss_go_IndexIVF_ondisk_sync(void* index) {
+    faiss::OnDiskInvertedLists* od = ondisk_lists(index);
+    if (!od) {
+        return -1;
+    }
+    if (od->ptr && od->totsize > 0 && msync(od->ptr, od->totsize, MS_SYNC) != 0) {
+        return -2;
+    }
+    return 0;
+}
+
+} // extern "C"
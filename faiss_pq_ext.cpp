@@ -0,0 +1,57 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * Product quantizer parameters that the FAISS C API does not expose.
+ *
+ * Compiled against the FAISS headers in third_party/faiss, so the quantizer
+ * is reached through the real class definitions and dynamic_cast.
+ */
+
+#include <faiss/IndexIVFPQ.h>
+#include <faiss/IndexPQ.h>
+
+#include <cstdint>
+
+namespace {
+
+const faiss::ProductQuantizer* pq_of(void* index) {
+    faiss::Index* idx = static_cast<faiss::Index*>(index);
+    if (auto* flat = dynamic_cast<faiss::IndexPQ*>(idx)) {
+        return &flat->pq;
+    }
+    if (auto* ivf = dynamic_cast<faiss::IndexIVFPQ*>(idx)) {
+        return &ivf->pq;
+    }
+    return nullptr;
+}
+
+} // namespace
+
+extern "C" {
+
+// Reads M and nbits of the product quantizer of an IndexPQ or IndexIVFPQ.
+// Returns -1 if index has neither.
+int faiss_go_Index_pq_params(void* index, int* M, int* nbits) {
+    const faiss::ProductQuantizer* pq = pq_of(index);
+    if (!pq) {
+        return -1;
+    }
+    *M = static_cast<int>(pq->M);
+    *nbits = static_cast<int>(pq->nbits);
+    return 0;
+}
+
+// Reads the number of floats in the centroid table of the product quantizer
+// of an IndexPQ or IndexIVFPQ (M * 2^nbits * dsub once trained). Returns -1
+// if index has neither.
+int faiss_go_Index_pq_centroids_size(void* index, int64_t* size) {
+    const faiss::ProductQuantizer* pq = pq_of(index);
+    if (!pq) {
+        return -1;
+    }
+    *size = static_cast<int64_t>(pq->centroids.size());
+    return 0;
+}
+
+} // extern "C"
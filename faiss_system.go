@@ -186,6 +186,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"unsafe"
 )
 
@@ -410,9 +411,6 @@ func faissWriteIndex(ptr uintptr, filename string) error {
 	return nil
 }
 
-// Note: Byte-level serialization functions removed due to ABI compatibility issues.
-// Use persistence.go (WriteIndexToFile, ReadIndexFromFile) for serialization.
-
 // ==== Kmeans Functions ====
 
 func faissKmeansNew(d, k int) (uintptr, error) {
@@ -521,59 +519,76 @@ func faissIndexIVFPQNew(quantizerPtr uintptr, d, nlist, M, nbits int) (uintptr,
 
 // ==== Range Search Functions ====
 
-func faissIndexRangeSearch(ptr uintptr, queries []float32, nq int, radius float32) (uintptr, []int64, []int64, []float32, error) {
+// liveRangeSearchResults counts C RangeSearchResult objects that have been
+// allocated but not yet freed; tests use it to detect leaks
+var liveRangeSearchResults int64
+
+// faissIndexRangeSearch runs a range search and copies the results into Go
+// slices, freeing the C result before returning
+func faissIndexRangeSearch(ptr uintptr, queries []float32, nq int, radius float32) (lims, labels []int64, distances []float32, err error) {
 	idx := C.FaissIndex(unsafe.Pointer(ptr))
 	queryPtr := (*C.float)(unsafe.Pointer(&queries[0]))
 
 	var resultPtr unsafe.Pointer
 	ret := C.faiss_Index_range_search(idx, C.int64_t(nq), queryPtr, C.float(radius), &resultPtr)
 	if ret != 0 {
-		return 0, nil, nil, nil, fmt.Errorf("FAISS error code: %d", ret)
+		return nil, nil, nil, fmt.Errorf("FAISS error code: %d", ret)
 	}
+	atomic.AddInt64(&liveRangeSearchResults, 1)
+	defer func() {
+		C.faiss_RangeSearchResult_free(resultPtr)
+		atomic.AddInt64(&liveRangeSearchResults, -1)
+	}()
 
 	// Get result arrays
 	var cLims, cLabels *C.int64_t
 	var cDistances *C.float
 
-	ret2 := C.faiss_RangeSearchResult_get(resultPtr, &cLims, &cLabels, &cDistances)
-	if ret2 != 0 {
-		C.faiss_RangeSearchResult_free(resultPtr)
-		return 0, nil, nil, nil, fmt.Errorf("FAISS error code: %d", ret2)
+	ret = C.faiss_RangeSearchResult_get(resultPtr, &cLims, &cLabels, &cDistances)
+	if ret != 0 {
+		return nil, nil, nil, fmt.Errorf("FAISS error code: %d", ret)
 	}
 
-	// Convert to Go slices (we need to copy the data)
-	lims := (*[1 << 30]C.int64_t)(unsafe.Pointer(cLims))[:nq+1:nq+1]
-	totalResults := int(lims[nq])
+	// Copy to Go slices; C.int64_t and int64 share a layout
+	lims = make([]int64, nq+1)
+	copy(lims, unsafe.Slice((*int64)(unsafe.Pointer(cLims)), nq+1))
 
-	goLims := make([]int64, nq+1)
-	for i := 0; i <= nq; i++ {
-		goLims[i] = int64(lims[i])
+	nTotal := int(lims[nq])
+	labels = make([]int64, nTotal)
+	distances = make([]float32, nTotal)
+	if nTotal > 0 {
+		copy(labels, unsafe.Slice((*int64)(unsafe.Pointer(cLabels)), nTotal))
+		copy(distances, unsafe.Slice((*float32)(unsafe.Pointer(cDistances)), nTotal))
 	}
 
-	var goLabels []int64
-	var goDistances []float32
-
-	if totalResults > 0 {
-		labels := (*[1 << 30]C.int64_t)(unsafe.Pointer(cLabels))[:totalResults:totalResults]
-		distances := (*[1 << 30]C.float)(unsafe.Pointer(cDistances))[:totalResults:totalResults]
+	return lims, labels, distances, nil
+}
 
-		goLabels = make([]int64, totalResults)
-		goDistances = make([]float32, totalResults)
+func faissIndexRangeSearchCount(ptr uintptr, queries []float32, nq int, radius float32) (int64, error) {
+	idx := C.FaissIndex(unsafe.Pointer(ptr))
+	queryPtr := (*C.float)(unsafe.Pointer(&queries[0]))
 
-		for i := 0; i < totalResults; i++ {
-			goLabels[i] = int64(labels[i])
-			goDistances[i] = float32(distances[i])
-		}
-	} else {
-		goLabels = []int64{}
-		goDistances = []float32{}
+	var resultPtr unsafe.Pointer
+	ret := C.faiss_Index_range_search(idx, C.int64_t(nq), queryPtr, C.float(radius), &resultPtr)
+	if ret != 0 {
+		return 0, fmt.Errorf("FAISS error code: %d", ret)
 	}
+	atomic.AddInt64(&liveRangeSearchResults, 1)
+	defer func() {
+		C.faiss_RangeSearchResult_free(resultPtr)
+		atomic.AddInt64(&liveRangeSearchResults, -1)
+	}()
 
-	return uintptr(resultPtr), goLims, goLabels, goDistances, nil
-}
+	// Only lims[nq] is read; labels and distances are never copied
+	var cLims, cLabels *C.int64_t
+	var cDistances *C.float
+
+	ret = C.faiss_RangeSearchResult_get(resultPtr, &cLims, &cLabels, &cDistances)
+	if ret != 0 {
+		return 0, fmt.Errorf("FAISS error code: %d", ret)
+	}
 
-func faissRangeSearchResultFree(ptr uintptr) {
-	C.faiss_RangeSearchResult_free(unsafe.Pointer(ptr))
+	return unsafe.Slice((*int64)(unsafe.Pointer(cLims)), nq+1)[nq], nil
 }
 
 // ==== Reconstruction Functions ====
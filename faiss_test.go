@@ -382,3 +382,309 @@ func TestIndexFactoryFlatSearchDistances(t *testing.T) {
 		t.Log("IndexFactory Flat works correctly - distances are non-zero")
 	}
 }
+
+func TestIndexFlat_ExtraMetrics(t *testing.T) {
+	d := 3
+	vectors := []float32{
+		1, 2, 3, // Vec 0
+		3, 2, 1, // Vec 1
+		1, 2, 2, // Vec 2
+	}
+	query := vectors[:3]
+
+	tests := []struct {
+		metric  MetricType
+		name    string
+		wantIDs []int64
+		want    []float32
+	}{
+		// Canberra: sum |x-y| / (|x|+|y|)
+		//   Vec 1: 2/4 + 0/4 + 2/4 = 1.0
+		//   Vec 2: 0/2 + 0/4 + 1/5 = 0.2
+		{MetricCanberra, "Canberra", []int64{0, 2, 1}, []float32{0, 0.2, 1.0}},
+		// Bray-Curtis: sum |x-y| / sum |x+y|
+		//   Vec 1: (2+0+2) / (4+4+4) = 1/3
+		//   Vec 2: (0+0+1) / (2+4+5) = 1/11
+		{MetricBrayCurtis, "BrayCurtis", []int64{0, 2, 1}, []float32{0, 1.0 / 11, 1.0 / 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := NewIndexFlat(d, tt.metric)
+			if err != nil {
+				t.Fatalf("NewIndexFlat(%s) failed: %v", tt.metric, err)
+			}
+			defer index.Close()
+
+			if index.MetricType() != tt.metric {
+				t.Errorf("MetricType() = %v, want %v", index.MetricType(), tt.metric)
+			}
+			if tt.metric.String() != tt.name {
+				t.Errorf("String() = %q, want %q", tt.metric.String(), tt.name)
+			}
+
+			if err := index.Add(vectors); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+
+			distances, ids, err := index.Search(query, 3)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+
+			for i := range tt.wantIDs {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("ids[%d] = %d, want %d", i, ids[i], tt.wantIDs[i])
+				}
+				if !almostEqual(distances[i], tt.want[i], 1e-5) {
+					t.Errorf("distances[%d] = %v, want %v", i, distances[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// fragmentedFlatIndex builds an IndexFlatL2 whose storage went through
+// several large add/reset cycles before being filled incrementally
+func fragmentedFlatIndex(tb testing.TB, d, n int) (*IndexFlat, []float32) {
+	tb.Helper()
+	index, err := NewIndexFlatL2(d)
+	if err != nil {
+		tb.Fatalf("Failed to create index: %v", err)
+	}
+
+	big := make([]float32, d*n*4)
+	for i := range big {
+		big[i] = rand.Float32()
+	}
+	for cycle := 0; cycle < 5; cycle++ {
+		_ = index.Add(big)
+		_ = index.Reset()
+	}
+
+	vectors := big[:d*n]
+	for i := 0; i < n; i += 100 {
+		_ = index.Add(vectors[i*d : min(i+100, n)*d])
+	}
+	return index, vectors
+}
+
+func TestIndexFlat_Compact(t *testing.T) {
+	d := 32
+	n := 2000
+	k := 5
+
+	index, vectors := fragmentedFlatIndex(t, d, n)
+	defer index.Close()
+
+	queries := vectors[:10*d]
+	wantDist, wantLabels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if got := index.Capacity(); got <= int64(n) {
+		t.Fatalf("Capacity() before Compact = %d, want more than %d", got, n)
+	}
+	if err := index.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if index.Ntotal() != int64(n) {
+		t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), n)
+	}
+	if got := index.Capacity(); got != int64(n) {
+		t.Errorf("Capacity() after Compact = %d, want %d", got, n)
+	}
+
+	gotDist, gotLabels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search after Compact failed: %v", err)
+	}
+	for i := range wantLabels {
+		if gotLabels[i] != wantLabels[i] || gotDist[i] != wantDist[i] {
+			t.Fatalf("result %d = (%d, %v), want (%d, %v)", i, gotLabels[i], gotDist[i], wantLabels[i], wantDist[i])
+		}
+	}
+
+	// The compacted index keeps working normally
+	if err := index.Add(vectors[:d]); err != nil {
+		t.Errorf("Add after Compact failed: %v", err)
+	}
+
+	empty, _ := NewIndexFlatIP(d)
+	defer empty.Close()
+	if err := empty.Compact(); err != nil {
+		t.Errorf("Compact on empty index failed: %v", err)
+	}
+	if empty.MetricType() != MetricInnerProduct {
+		t.Errorf("MetricType() = %v, want %v", empty.MetricType(), MetricInnerProduct)
+	}
+
+	empty.Close()
+	if err := empty.Compact(); err == nil {
+		t.Error("Compact on closed index should return error")
+	}
+}
+
+func TestIndexFlat_CompactWrapped(t *testing.T) {
+	d := 16
+	n := 500
+	vectors := generateVectors(n, d)
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(1000 + i)
+	}
+
+	// Wrappers hold the base pointer, which Compact must keep valid
+	base, _ := NewIndexFlatL2(d)
+	defer base.Close()
+	idmap, err := NewIndexIDMap(base)
+	if err != nil {
+		t.Fatalf("NewIndexIDMap() failed: %v", err)
+	}
+	defer idmap.Close()
+	for cycle := 0; cycle < 3; cycle++ {
+		idmap.AddWithIDs(vectors, ids)
+		idmap.Reset()
+	}
+	idmap.AddWithIDs(vectors[:n/2*d], ids[:n/2])
+
+	if err := base.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if got := base.Capacity(); got != int64(n/2) {
+		t.Errorf("Capacity() after Compact = %d, want %d", got, n/2)
+	}
+
+	if err := idmap.AddWithIDs(vectors[n/2*d:], ids[n/2:]); err != nil {
+		t.Fatalf("AddWithIDs() after Compact failed: %v", err)
+	}
+	_, labels, err := idmap.Search(vectors, 1)
+	if err != nil {
+		t.Fatalf("Search() after Compact failed: %v", err)
+	}
+	for i, label := range labels {
+		if label != ids[i] {
+			t.Fatalf("Search(vector %d) = %d, want %d", i, label, ids[i])
+		}
+	}
+}
+
+func BenchmarkIndexFlat_Compact(b *testing.B) {
+	d := 128
+	n := 20000
+	k := 10
+
+	for _, compact := range []bool{false, true} {
+		name := "fragmented"
+		if compact {
+			name = "compacted"
+		}
+		b.Run(name, func(b *testing.B) {
+			index, vectors := fragmentedFlatIndex(b, d, n)
+			defer index.Close()
+			if compact {
+				if err := index.Compact(); err != nil {
+					b.Fatalf("Compact failed: %v", err)
+				}
+			}
+			query := vectors[:d]
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _, _ = index.Search(query, k)
+			}
+		})
+	}
+}
+
+func TestIndexFlat_Reserve(t *testing.T) {
+	d := 16
+	vectors := generateVectors(300, d)
+
+	index, err := NewIndexFlatL2(d)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	defer index.Close()
+	index.Add(vectors[:100*d])
+
+	if err := index.Reserve(1000); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if index.Ntotal() != 100 {
+		t.Errorf("Ntotal() after Reserve = %d, want 100", index.Ntotal())
+	}
+	if got := index.Capacity(); got < 1000 {
+		t.Errorf("Capacity() after Reserve(1000) = %d, want >= 1000", got)
+	}
+
+	// Stored vectors are untouched and new ones keep their IDs
+	if err := index.Add(vectors[100*d:]); err != nil {
+		t.Fatalf("Add after Reserve failed: %v", err)
+	}
+	for _, id := range []int64{0, 99, 100, 299} {
+		got, err := index.Reconstruct(id)
+		if err != nil {
+			t.Fatalf("Reconstruct(%d) failed: %v", id, err)
+		}
+		for j := range got {
+			if got[j] != vectors[int(id)*d+j] {
+				t.Fatalf("vector %d = %v, want %v", id, got, vectors[int(id)*d:int(id+1)*d])
+			}
+		}
+	}
+
+	// Smaller than the current size is a no-op
+	if err := index.Reserve(10); err != nil {
+		t.Errorf("Reserve(10) failed: %v", err)
+	}
+	if index.Ntotal() != 300 {
+		t.Errorf("Ntotal() = %d, want 300", index.Ntotal())
+	}
+
+	if err := index.Reserve(-1); err == nil {
+		t.Error("Reserve(-1) should return error")
+	}
+	index.Close()
+	if err := index.Reserve(10); err == nil {
+		t.Error("Reserve on closed index should return error")
+	}
+}
+
+// BenchmarkIndexFlat_Reserve times filling a fresh index in small batches,
+// with and without reserving its final size first. The reallocations happen
+// inside FAISS, so they show up in ns/op rather than in -benchmem counts.
+func BenchmarkIndexFlat_Reserve(b *testing.B) {
+	d := 128
+	n := 50000
+	batch := 100
+	vectors := generateVectors(n, d)
+
+	for _, reserve := range []bool{false, true} {
+		name := "growing"
+		if reserve {
+			name = "reserved"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				index, _ := NewIndexFlatL2(d)
+				if reserve {
+					if err := index.Reserve(int64(n)); err != nil {
+						b.Fatalf("Reserve failed: %v", err)
+					}
+				}
+				b.StartTimer()
+
+				for j := 0; j < n; j += batch {
+					_ = index.Add(vectors[j*d : (j+batch)*d])
+				}
+
+				b.StopTimer()
+				index.Close()
+				b.StartTimer()
+			}
+		})
+	}
+}
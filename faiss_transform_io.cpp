@@ -0,0 +1,26 @@
+//go:build !faiss_use_system
+// +build !faiss_use_system
+
+/**
+ * VectorTransform serialization that the FAISS C API does not expose.
+ *
+ * The C API only provides faiss_read_VectorTransform_fname, so the writer
+ * calls faiss::write_VectorTransform from libfaiss directly, declared by the
+ * FAISS headers in third_party/faiss.
+ */
+
+#include <faiss/index_io.h>
+
+extern "C" {
+
+int faiss_go_write_VectorTransform_fname(const void* vt, const char* fname) {
+    try {
+        faiss::write_VectorTransform(
+                static_cast<const faiss::VectorTransform*>(vt), fname);
+    } catch (...) {
+        return -1;
+    }
+    return 0;
+}
+
+} // extern "C"
@@ -6,6 +6,7 @@ package faiss
 import (
 	"fmt"
 	"runtime"
+	"sync"
 )
 
 // StandardGpuResources manages GPU memory and resources for FAISS
@@ -88,6 +89,155 @@ func (res *StandardGpuResources) Close() error {
 	return nil
 }
 
+// ========================================
+// GPU Resource Pool
+// ========================================
+
+// GpuResourcePool hands out StandardGpuResources for short-lived GPU indexes
+//
+// Creating StandardGpuResources is expensive (it allocates CUDA temp memory
+// and streams), so a service should not create one per request. A single
+// StandardGpuResources is not safe for concurrent use either: its temp
+// memory and stream are shared by every index built on it, so concurrent
+// searches through it serialize at best. The pool owns numStreams
+// independent resources, each with its own stream and temp memory, and
+// gives each one to at most one goroutine at a time.
+//
+// Thread safety: all GpuResourcePool methods are safe for concurrent use.
+// A resource obtained from Acquire (or passed to Do) belongs exclusively to
+// the caller until Release, and GPU indexes created on it must be closed
+// before it is released. Do not Close pooled resources directly.
+//
+// Example:
+//   pool, _ := faiss.NewGpuResourcePool(4)
+//   defer pool.Close()
+//
+//   err := pool.Do(func(res *faiss.StandardGpuResources) error {
+//       gpuIndex, err := faiss.IndexCpuToGpu(res, 0, cpuIndex)
+//       if err != nil {
+//           return err
+//       }
+//       defer gpuIndex.Close()
+//       distances, labels, err = gpuIndex.Search(queries, k)
+//       return err
+//   })
+type GpuResourcePool struct {
+	resources []*StandardGpuResources
+	free      chan *StandardGpuResources
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewGpuResourcePool creates a pool of numStreams GPU resources
+func NewGpuResourcePool(numStreams int) (*GpuResourcePool, error) {
+	if numStreams <= 0 {
+		return nil, fmt.Errorf("faiss: numStreams must be positive, got %d", numStreams)
+	}
+
+	pool := &GpuResourcePool{
+		resources: make([]*StandardGpuResources, 0, numStreams),
+		free:      make(chan *StandardGpuResources, numStreams),
+	}
+	for i := 0; i < numStreams; i++ {
+		res, err := NewStandardGpuResources()
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.resources = append(pool.resources, res)
+		pool.free <- res
+	}
+
+	return pool, nil
+}
+
+// Size returns the number of resources in the pool
+func (p *GpuResourcePool) Size() int {
+	return len(p.resources)
+}
+
+// SetTempMemory sets the temporary GPU memory of every pooled resource
+// Call it before handing out resources.
+func (p *GpuResourcePool) SetTempMemory(bytes int64) error {
+	for _, res := range p.resources {
+		if err := res.SetTempMemory(bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Acquire blocks until a resource is free and returns it for exclusive use
+// The resource must be returned with Release.
+func (p *GpuResourcePool) Acquire() (*StandardGpuResources, error) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, fmt.Errorf("faiss: GPU resource pool is closed")
+	}
+
+	res, ok := <-p.free
+	if !ok {
+		return nil, fmt.Errorf("faiss: GPU resource pool is closed")
+	}
+	// Close may have run while this call was waiting; the resource is then
+	// no longer pooled and is freed here
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		res.Close()
+		return nil, fmt.Errorf("faiss: GPU resource pool is closed")
+	}
+	return res, nil
+}
+
+// Release returns a resource obtained from Acquire to the pool
+// After Close, the resource is freed instead.
+func (p *GpuResourcePool) Release(res *StandardGpuResources) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		res.Close()
+		return
+	}
+	p.free <- res
+}
+
+// Do runs fn with a resource from the pool and releases it afterwards
+func (p *GpuResourcePool) Do(fn func(res *StandardGpuResources) error) error {
+	res, err := p.Acquire()
+	if err != nil {
+		return err
+	}
+	defer p.Release(res)
+	return fn(res)
+}
+
+// Close frees the idle pooled resources and makes Acquire fail
+// Resources still held by callers stay valid and are freed when they are
+// released.
+func (p *GpuResourcePool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+drain:
+	for {
+		select {
+		case res := <-p.free:
+			res.Close()
+		default:
+			break drain
+		}
+	}
+	close(p.free)
+	return nil
+}
+
 // ========================================
 // GPU Configuration Options
 // ========================================
@@ -352,7 +502,11 @@ func (idx *GpuIndex) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -371,7 +525,11 @@ func (idx *GpuIndex) Search(queries []float32, k int) (distances []float32, indi
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -396,6 +554,11 @@ func (idx *GpuIndex) SetEfSearch(efSearch int) error {
 	return fmt.Errorf("faiss: SetEfSearch not supported for generic GpuIndex")
 }
 
+// CanReconstruct returns false: reconstruction is not exposed for GPU indexes
+func (idx *GpuIndex) CanReconstruct() bool {
+	return false
+}
+
 // Reset removes all vectors
 func (idx *GpuIndex) Reset() error {
 	ret := faiss_Index_reset(idx.ptr)
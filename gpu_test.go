@@ -4,9 +4,20 @@
 package faiss
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
 )
 
+// Compile-time checks that every GPU index type implements Index
+var (
+	_ Index = (*GpuIndex)(nil)
+	_ Index = (*GpuIndexFlat)(nil)
+	_ Index = (*GpuIndexIVFFlat)(nil)
+)
+
 // ========================================
 // StandardGpuResources Tests
 // ========================================
@@ -77,6 +88,100 @@ func TestStandardGpuResources_Close(t *testing.T) {
 	}
 }
 
+// ========================================
+// GpuResourcePool Tests
+// ========================================
+
+func TestNewGpuResourcePool_Invalid(t *testing.T) {
+	if _, err := NewGpuResourcePool(0); err == nil {
+		t.Error("NewGpuResourcePool(0) should return error")
+	}
+}
+
+func TestGpuResourcePool_CloseWhileHeld(t *testing.T) {
+	pool, err := NewGpuResourcePool(2)
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	held, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+
+	pool.Close()
+	if held.ptr == 0 {
+		t.Fatal("Close() freed a resource still held by a caller")
+	}
+	if _, err := pool.Acquire(); err == nil {
+		t.Error("Acquire() after Close should return error")
+	}
+	pool.Release(held)
+	if held.ptr != 0 {
+		t.Error("Release() after Close should free the resource")
+	}
+}
+
+func TestGpuResourcePool_ConcurrentSearch(t *testing.T) {
+	pool, err := NewGpuResourcePool(2)
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	defer pool.Close()
+
+	if pool.Size() != 2 {
+		t.Errorf("Size() = %d, want 2", pool.Size())
+	}
+
+	d := 32
+	nb := 1000
+	k := 5
+	vectors := generateVectors(nb, d)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs <- pool.Do(func(res *StandardGpuResources) error {
+				idx, err := NewGpuIndexFlatL2(res, d, 0)
+				if err != nil {
+					return err
+				}
+				defer idx.Close()
+				if err := idx.Add(vectors); err != nil {
+					return err
+				}
+
+				// Each vector is its own nearest neighbor
+				query := vectors[w*d : (w+1)*d]
+				_, labels, err := idx.Search(query, k)
+				if err != nil {
+					return err
+				}
+				if labels[0] != int64(w) {
+					return fmt.Errorf("worker %d: nearest = %d, want %d", w, labels[0], w)
+				}
+				return nil
+			})
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	pool.Close()
+	if _, err := pool.Acquire(); err == nil {
+		t.Error("Acquire() after Close should return error")
+	}
+}
+
 // ========================================
 // GpuClonerOptions Tests
 // ========================================
@@ -308,6 +413,106 @@ func TestGpuIndexFlat_Reset(t *testing.T) {
 	}
 }
 
+func TestGpuIndexFlat_Float16Recall(t *testing.T) {
+	res, err := NewStandardGpuResources()
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	defer res.Close()
+
+	d := 64
+	nb := 10000
+	nq := 100
+	k := 10
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+
+	fp32, err := NewGpuIndexFlatL2Config(res, d, 0, GpuFlatConfig{})
+	if err != nil {
+		t.Fatalf("NewGpuIndexFlatL2Config(fp32) failed: %v", err)
+	}
+	defer fp32.Close()
+
+	fp16, err := NewGpuIndexFlatL2Config(res, d, 0, GpuFlatConfig{UseFloat16: true})
+	if err != nil {
+		t.Fatalf("NewGpuIndexFlatL2Config(fp16) failed: %v", err)
+	}
+	defer fp16.Close()
+
+	if fp32.UseFloat16() || !fp16.UseFloat16() {
+		t.Errorf("UseFloat16() = %v/%v, want false/true", fp32.UseFloat16(), fp16.UseFloat16())
+	}
+
+	if err := fp32.Add(vectors); err != nil {
+		t.Fatalf("Add(fp32) failed: %v", err)
+	}
+	if err := fp16.Add(vectors); err != nil {
+		t.Fatalf("Add(fp16) failed: %v", err)
+	}
+	if fp16.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", fp16.Ntotal(), nb)
+	}
+
+	_, want, err := fp32.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search(fp32) failed: %v", err)
+	}
+	_, got, err := fp16.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search(fp16) failed: %v", err)
+	}
+
+	if recall := ComputeRecall(want, got, nq, k, k); recall < 0.95 {
+		t.Errorf("fp16 recall@%d vs fp32 = %.3f, want >= 0.95", k, recall)
+	}
+}
+
+func TestGpuIndexFlat_AddChunked(t *testing.T) {
+	res, err := NewStandardGpuResources()
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	defer res.Close()
+
+	d := 64
+	nb := 20000
+	index, err := NewGpuIndexFlatL2(res, d, 0)
+	if err != nil {
+		t.Fatalf("NewGpuIndexFlatL2() failed: %v", err)
+	}
+	defer index.Close()
+
+	if got, want := index.AddChunkSize(), gpuAddChunkBytes/(4*d); got != want {
+		t.Errorf("AddChunkSize() = %d, want %d", got, want)
+	}
+	if err := index.SetAddChunkSize(0); err == nil {
+		t.Error("SetAddChunkSize(0) should return error")
+	}
+
+	// A chunk size that does not divide nb exercises the short last chunk
+	if err := index.SetAddChunkSize(3000); err != nil {
+		t.Fatalf("SetAddChunkSize() failed: %v", err)
+	}
+	vectors := generateVectors(nb, d)
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if index.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), nb)
+	}
+
+	// Vectors from every chunk must be stored in order
+	for _, i := range []int{0, 2999, 3000, 12345, nb - 1} {
+		_, labels, err := index.Search(vectors[i*d:(i+1)*d], 1)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		if labels[0] != int64(i) {
+			t.Errorf("nearest to vector %d = %d, want %d", i, labels[0], i)
+		}
+	}
+}
+
 // ========================================
 // IndexCpuToGpu Tests
 // ========================================
@@ -516,6 +721,200 @@ func TestGpuIndexIVFFlat_SetNprobe_Invalid(t *testing.T) {
 	}
 }
 
+func TestGpuIndexIVFFlat_CanReconstruct(t *testing.T) {
+	res, err := NewStandardGpuResources()
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	defer res.Close()
+
+	d := 64
+	quantizer, _ := NewGpuIndexFlatL2(res, d, 0)
+	defer quantizer.Close()
+
+	idx, err := NewGpuIndexIVFFlat(res, quantizer, d, 10, 0, MetricL2)
+	if err != nil {
+		t.Fatalf("NewGpuIndexIVFFlat() failed: %v", err)
+	}
+	defer idx.Close()
+
+	if idx.CanReconstruct() {
+		t.Error("GpuIndexIVFFlat.CanReconstruct() = true, want false")
+	}
+
+	_, err = idx.Reconstruct(0)
+	if !errors.Is(err, ErrNotSupportedOnGPU) {
+		t.Errorf("Reconstruct() error = %v, want ErrNotSupportedOnGPU", err)
+	}
+
+	cpu, _ := NewIndexFlatL2(d)
+	defer cpu.Close()
+
+	if !cpu.CanReconstruct() {
+		t.Error("IndexFlat.CanReconstruct() = false, want true")
+	}
+}
+
+func TestGpuIndex_CPUMirror(t *testing.T) {
+	res, err := NewStandardGpuResources()
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	defer res.Close()
+
+	d := 32
+	nb := 2000
+	k := 5
+	vectors := generateVectors(nb, d)
+	queries := vectors[:10*d]
+
+	flat, _ := NewGpuIndexFlatL2(res, d, 0)
+	defer flat.Close()
+	if _, _, _, err := flat.SearchAndReconstruct(queries, k); !errors.Is(err, ErrNotSupportedOnGPU) {
+		t.Errorf("SearchAndReconstruct() without mirror error = %v, want ErrNotSupportedOnGPU", err)
+	}
+	if err := flat.EnableCPUMirror(); err != nil {
+		t.Fatalf("EnableCPUMirror() failed: %v", err)
+	}
+	// Small chunks make sure every chunk is mirrored
+	flat.SetAddChunkSize(300)
+	flat.Add(vectors)
+
+	quantizer, _ := NewGpuIndexFlatL2(res, d, 0)
+	defer quantizer.Close()
+	ivf, _ := NewGpuIndexIVFFlat(res, quantizer, d, 16, 0, MetricL2)
+	defer ivf.Close()
+	if err := ivf.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := ivf.EnableCPUMirror(); err != nil {
+		t.Fatalf("EnableCPUMirror() failed: %v", err)
+	}
+	ivf.Add(vectors)
+	ivf.SetNprobe(16)
+	if !ivf.CanReconstruct() {
+		t.Error("CanReconstruct() with mirror = false, want true")
+	}
+
+	for name, index := range map[string]interface {
+		SearchAndReconstruct([]float32, int) ([]float32, []int64, []float32, error)
+	}{"flat": flat, "ivf": ivf} {
+		_, labels, recons, err := index.SearchAndReconstruct(queries, k)
+		if err != nil {
+			t.Fatalf("%s: SearchAndReconstruct() failed: %v", name, err)
+		}
+		if len(recons) != len(labels)*d {
+			t.Fatalf("%s: len(recons) = %d, want %d", name, len(recons), len(labels)*d)
+		}
+		for i, label := range labels {
+			want := vectors[label*int64(d) : (label+1)*int64(d)]
+			for j, v := range recons[i*d : (i+1)*d] {
+				if v != want[j] {
+					t.Fatalf("%s: result %d (label %d) component %d = %v, want %v", name, i, label, j, v, want[j])
+				}
+			}
+		}
+	}
+
+	vec, err := ivf.Reconstruct(42)
+	if err != nil || vec[0] != vectors[42*d] {
+		t.Errorf("Reconstruct(42) = %v, %v, want vector 42", vec[:1], err)
+	}
+	if _, err := ivf.Reconstruct(-1); err == nil {
+		t.Error("Reconstruct(-1) should return error")
+	}
+	if _, err := ivf.ReconstructBatch([]int64{0, -1}); err == nil {
+		t.Error("ReconstructBatch() with a negative key should return error")
+	}
+
+	// The mirror can't be enabled once vectors are on the device
+	other, _ := NewGpuIndexFlatL2(res, d, 0)
+	defer other.Close()
+	other.Add(vectors[:d])
+	if err := other.EnableCPUMirror(); err == nil {
+		t.Error("EnableCPUMirror() on a non-empty index should return error")
+	}
+}
+
+// ========================================
+// GpuKmeans Tests
+// ========================================
+
+func TestGpuKmeans_MatchesCPU(t *testing.T) {
+	res, err := NewStandardGpuResources()
+	if err != nil {
+		t.Skipf("GPU not available: %v", err)
+	}
+	defer res.Close()
+
+	d, k, perCluster := 16, 8, 300
+
+	// Well-separated blobs, so both runs converge to the blob means
+	noise := generateVectors(k*perCluster, d)
+	vectors := make([]float32, len(noise))
+	for i := range vectors {
+		cluster := i / d / perCluster
+		vectors[i] = float32(cluster*10) + noise[i]
+		if i%d == cluster%d {
+			vectors[i] += 50
+		}
+	}
+
+	cpu, _ := NewKmeans(d, k)
+	if err := cpu.Train(vectors); err != nil {
+		t.Fatalf("Kmeans.Train() failed: %v", err)
+	}
+
+	gpu, err := NewGpuKmeans(res, d, k, 0)
+	if err != nil {
+		t.Fatalf("NewGpuKmeans() failed: %v", err)
+	}
+	defer gpu.Close()
+
+	if _, err := gpu.Assign(vectors[:d]); err == nil {
+		t.Error("Assign() before Train() should return error")
+	}
+	if err := gpu.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if !gpu.IsTrained() {
+		t.Error("IsTrained() = false after training")
+	}
+
+	// Every GPU centroid has a CPU counterpart within tolerance
+	gpuCentroids := gpu.GetCentroids()
+	if len(gpuCentroids) != k*d {
+		t.Fatalf("len(GetCentroids()) = %d, want %d", len(gpuCentroids), k*d)
+	}
+	cpuCentroids := cpu.Centroids()
+	for i := 0; i < k; i++ {
+		best := float32(math.MaxFloat32)
+		for j := 0; j < k; j++ {
+			dist, _ := L2Distance(gpuCentroids[i*d:(i+1)*d], cpuCentroids[j*d:(j+1)*d])
+			best = min(best, dist)
+		}
+		if best > 0.1 {
+			t.Errorf("GPU centroid %d is %v from the nearest CPU centroid, want <= 0.1", i, best)
+		}
+	}
+
+	// Points of a blob share one cluster, and it matches the CPU assignment
+	gpuLabels, err := gpu.Assign(vectors)
+	if err != nil {
+		t.Fatalf("Assign() failed: %v", err)
+	}
+	cpuLabels, _ := cpu.Assign(vectors)
+	for i := range gpuLabels {
+		if gpuLabels[i] != gpuLabels[i/perCluster*perCluster] {
+			t.Fatalf("point %d assigned to %d, want its blob's cluster %d", i, gpuLabels[i], gpuLabels[i/perCluster*perCluster])
+		}
+		g, c := gpuLabels[i], cpuLabels[i]
+		if dist, _ := L2Distance(gpuCentroids[g*int64(d):(g+1)*int64(d)], cpuCentroids[c*int64(d):(c+1)*int64(d)]); dist > 0.1 {
+			t.Fatalf("point %d: GPU and CPU cluster centroids are %v apart", i, dist)
+		}
+	}
+}
+
 // ========================================
 // GpuIndex Interface Compliance Tests
 // ========================================
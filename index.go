@@ -4,7 +4,11 @@ package faiss
 // matching Python FAISS completeness
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
 )
 
 var (
@@ -16,10 +20,103 @@ var (
 	ErrInvalidK = errors.New("faiss: k must be positive")
 	// ErrInvalidRadius is returned when radius is invalid
 	ErrInvalidRadius = errors.New("faiss: invalid radius")
+	// ErrNotSupportedOnGPU is returned when an operation is not available for GPU indexes
+	ErrNotSupportedOnGPU = errors.New("faiss: operation not supported on GPU")
+	// ErrIDOverflow is returned when an ID does not fit in int32
+	ErrIDOverflow = errors.New("faiss: ID overflows int32")
+	// ErrDimensionMismatch is returned when vector data does not match the index dimension
+	ErrDimensionMismatch = errors.New("faiss: dimension mismatch")
+	// ErrMetricMismatch is returned when an index does not use the requested metric
+	ErrMetricMismatch = errors.New("faiss: metric mismatch")
+	// ErrIndexClosed is returned when an operation is called on a closed index
+	ErrIndexClosed = errors.New("faiss: index is closed")
+	// ErrTrainingTooSmall is returned when there are too few training vectors
+	ErrTrainingTooSmall = errors.New("faiss: insufficient training data")
+	// ErrReadOnly is returned when modifying an index whose storage is read-only
+	ErrReadOnly = errors.New("faiss: index is read-only")
+	// ErrCorruptIndex is returned by SelfTest when an index does not find its own vectors
+	ErrCorruptIndex = errors.New("faiss: index failed self-test")
+	// ErrNotNormalized is returned when an index that requires unit-norm vectors gets another vector
+	ErrNotNormalized = errors.New("faiss: vector is not L2-normalized")
+	// ErrHeaderNotWritten is returned by WriteIndexWithHeader when the index file was written but its sidecar header was not
+	ErrHeaderNotWritten = errors.New("faiss: index header not written")
 )
 
+// kindError is a sentinel error with its own message that also matches a
+// broader sentinel under errors.Is, e.g. ErrInvalidVectors is an
+// ErrDimensionMismatch.
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Is(target error) bool { return target == e.kind }
+
+// TrainingSizeError is returned by Train when fewer vectors are given than
+// the index needs. It matches ErrTrainingTooSmall under errors.Is.
+//
+// The minimums are:
+//   - IVF coarse quantizers: 30*nlist vectors
+//   - product quantizers (PQ, IVFPQ): 39*2^nbits vectors, the FAISS k-means
+//     minimum for each sub-quantizer codebook
+//   - scalar quantizers: one vector (IVFSQ also needs the IVF minimum)
+type TrainingSizeError struct {
+	Provided int // training vectors passed to Train
+	Required int // minimum number of training vectors
+}
+
+func (e *TrainingSizeError) Error() string {
+	return fmt.Sprintf("%v (have %d, need at least %d)", ErrTrainingTooSmall, e.Provided, e.Required)
+}
+
+func (e *TrainingSizeError) Unwrap() error { return ErrTrainingTooSmall }
+
+// checkTrainingSize returns a *TrainingSizeError if n < required
+func checkTrainingSize(n, required int) error {
+	if n < required {
+		return &TrainingSizeError{Provided: n, Required: required}
+	}
+	return nil
+}
+
+// RecommendedTrainingSize returns the number of training vectors below which
+// FAISS k-means warns that it has too few points: 39 per centroid of the
+// largest codebook, i.e. 39*max(nlist, 2^nbits). It returns 0 for indexes
+// without k-means training.
+func RecommendedTrainingSize(index Index) int {
+	ptr, ok := indexPointer(index)
+	if !ok || ptr == 0 {
+		return 0
+	}
+	centroids := 0
+	if nlist, err := faissIndexIVFGetNlist(ptr); err == nil {
+		centroids = nlist
+	}
+	if _, nbits, err := faissIndexPQParams(ptr); err == nil && nbits < 31 {
+		centroids = max(centroids, 1<<nbits)
+	}
+	return 39 * centroids
+}
+
 // Index is the base interface for all FAISS indexes
 // This matches the Python FAISS Index API
+//
+// Every concrete index type in this package implements the full method set
+// below, so generic code (routers, shards, ensembles) can hold any of them
+// as an Index. This covers IndexFlat, IndexIVFFlat, IndexIDMap, IndexLSH,
+// IndexScalarQuantizer, IndexIVFScalarQuantizer, IndexRowwiseMinMax, the
+// composite indexes, GenericIndex (which backs HNSW, PQ and IVFPQ indexes
+// built by the factory) and, in GPU builds, GpuIndex, GpuIndexFlat and
+// GpuIndexIVFFlat. Parameter setters that do not apply to an index type
+// return an error rather than being left out of the interface.
+//
+// Search returns the IDs the vectors were added with. Add assigns sequential
+// IDs starting at Ntotal(), so an index filled only through Add returns
+// insertion positions. Indexes that accept custom IDs (IndexIDMap, IVF
+// indexes, and factory indexes built with "IDMap,...") return the IDs given
+// to AddWithIDs. Slots without a result are reported as -1.
 type Index interface {
 	// Basic properties
 	D() int                // Dimension of vectors
@@ -30,10 +127,10 @@ type Index interface {
 	// Training (required for some index types like IVF, PQ)
 	Train(vectors []float32) error
 
-	// Adding vectors
+	// Adding vectors (IDs are assigned sequentially from Ntotal())
 	Add(vectors []float32) error
 
-	// Searching
+	// Searching (returns the IDs assigned at add time, -1 for missing results)
 	Search(queries []float32, k int) (distances []float32, indices []int64, err error)
 
 	// Parameter setters (index-specific, may return error if not supported)
@@ -87,14 +184,56 @@ type IndexWithAssign interface {
 	Assign(vectors []float32) ([]int64, error)
 }
 
+// indexPointer returns the C pointer wrapped by a CPU index type.
+// ok is false for index types that are not known here.
+func indexPointer(index Index) (ptr uintptr, ok bool) {
+	switch idx := index.(type) {
+	case *IndexFlat:
+		return idx.ptr, true
+	case *IndexIVFFlat:
+		return idx.ptr, true
+	case *IndexLSH:
+		return idx.ptr, true
+	case *IndexScalarQuantizer:
+		return idx.ptr, true
+	case *IndexIVFScalarQuantizer:
+		return idx.ptr, true
+	case *IndexIDMap:
+		return idx.ptr, true
+	case *IndexRefine:
+		return idx.ptr, true
+	case *IndexPreTransform:
+		return idx.ptr, true
+	case *IndexShards:
+		return idx.ptr, true
+	case *GenericIndex:
+		return idx.ptr, true
+	case *IndexIVFFlatOnDisk:
+		return idx.ptr, true
+	case *IndexIVFPQOnDisk:
+		return idx.ptr, true
+	case *CheckpointingIndex:
+		return indexPointer(idx.base)
+	}
+	return 0, false
+}
+
+// isClosed reports whether a known index type has already been closed.
+// Composite indexes use it to refuse operations once a sub-index they
+// reference has been freed, instead of crashing inside FAISS.
+func isClosed(index Index) bool {
+	ptr, ok := indexPointer(index)
+	return ok && ptr == 0
+}
+
 // RangeSearchResult is defined in range_search.go to avoid duplication
 
 // SearchResult is a structured search result
 type SearchResult struct {
 	Distances []float32
 	Labels    []int64
-	Nq        int  // Number of queries
-	K         int  // Number of neighbors per query
+	Nq        int // Number of queries
+	K         int // Number of neighbors per query
 }
 
 // NewSearchResult creates a SearchResult from raw arrays
@@ -119,3 +258,374 @@ func (sr *SearchResult) GetNeighbors(i int) (distances []float32, labels []int64
 	end := start + sr.K
 	return sr.Distances[start:end], sr.Labels[start:end]
 }
+
+// Neighbor is a single search hit: a vector ID and its distance to the query
+type Neighbor struct {
+	ID       int64
+	Distance float32
+}
+
+// Neighbors returns the results grouped per query
+//
+// The outer slice has one entry per query and each inner slice holds the K
+// neighbors in ranking order. Missing results (fewer than K vectors in the
+// index) keep FAISS's ID of -1.
+func (sr *SearchResult) Neighbors() [][]Neighbor {
+	results := make([][]Neighbor, sr.Nq)
+	for i := range results {
+		neighbors := make([]Neighbor, sr.K)
+		for j := range neighbors {
+			neighbors[j].Distance, neighbors[j].ID = sr.Get(i, j)
+		}
+		results[i] = neighbors
+	}
+	return results
+}
+
+// SearchResults searches the index and returns the results grouped per query
+//
+// This avoids manual i*k+j indexing into the flat arrays returned by Search.
+// Use Search directly on performance-critical paths.
+//
+// Example:
+//   results, _ := faiss.SearchResults(index, queries, 10)
+//   for q, neighbors := range results {
+//       for _, n := range neighbors {
+//           fmt.Printf("query %d: id=%d dist=%f\n", q, n.ID, n.Distance)
+//       }
+//   }
+func SearchResults(index Index, queries []float32, k int) ([][]Neighbor, error) {
+	if k <= 0 {
+		return nil, ErrInvalidK
+	}
+
+	distances, labels, err := index.Search(queries, k)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSearchResult(distances, labels, len(labels)/k, k).Neighbors(), nil
+}
+
+// Nearest searches the index with a single query and returns its k nearest
+// neighbors in ranking order
+//
+// query must hold exactly index.D() floats. As with Neighbors, missing
+// results (fewer than k vectors in the index) keep FAISS's ID of -1.
+//
+// Example:
+//   neighbors, _ := faiss.Nearest(index, query, 5)
+//   for _, n := range neighbors {
+//       fmt.Printf("id=%d dist=%f\n", n.ID, n.Distance)
+//   }
+func Nearest(index Index, query []float32, k int) ([]Neighbor, error) {
+	if k <= 0 {
+		return nil, ErrInvalidK
+	}
+	if d := index.D(); len(query) != d {
+		return nil, fmt.Errorf("faiss: query length %d does not match index dimension %d: %w", len(query), d, ErrDimensionMismatch)
+	}
+
+	distances, labels, err := index.Search(query, k)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSearchResult(distances, labels, 1, k).Neighbors()[0], nil
+}
+
+// SearchCounted searches the index and also returns how many real results
+// each query got
+//
+// FAISS pads a query's results with label -1 when fewer than k vectors
+// qualify, e.g. when the index holds fewer than k vectors. counts[i] is the
+// number of labels other than -1 for query i, so callers can slice
+// result.GetNeighbors(i) to counts[i] instead of scanning for the padding.
+//
+// Example:
+//   result, counts, _ := faiss.SearchCounted(index, queries, 10)
+//   for q := 0; q < result.Nq; q++ {
+//       _, labels := result.GetNeighbors(q)
+//       process(labels[:counts[q]])
+//   }
+func SearchCounted(index Index, queries []float32, k int) (result SearchResult, counts []int, err error) {
+	if k <= 0 {
+		return SearchResult{}, nil, ErrInvalidK
+	}
+
+	distances, labels, err := index.Search(queries, k)
+	if err != nil {
+		return SearchResult{}, nil, err
+	}
+
+	result = *NewSearchResult(distances, labels, len(labels)/k, k)
+	counts = make([]int, result.Nq)
+	for i, label := range labels {
+		if label != -1 {
+			counts[i/k]++
+		}
+	}
+	return result, counts, nil
+}
+
+// RankedResult is a search result ordered best-first whatever the metric
+type RankedResult struct {
+	SearchResult
+
+	// Higher reports whether larger values in Distances are better matches:
+	// true for similarity scores (inner product, cosine), false for
+	// distances (L2 and the other metrics). Results are ordered best-first
+	// either way, so only code that compares or thresholds values needs it.
+	Higher bool
+}
+
+// SearchRanked searches the index and returns each query's results
+// best-first, with RankedResult.Higher telling which direction is better
+//
+// L2 indexes return ascending distances and inner product indexes
+// descending scores; rendering code can rely on the order and Higher
+// instead of branching on the metric. Padding results (label -1) come last.
+//
+// Example:
+//   ranked, _ := faiss.SearchRanked(index, query, 10)
+//   best := ranked.Distances[0]
+//   if ranked.Higher { ... } // similarity score, not a distance
+func SearchRanked(index Index, queries []float32, k int) (RankedResult, error) {
+	if index == nil {
+		return RankedResult{}, fmt.Errorf("faiss: index cannot be nil")
+	}
+	if k <= 0 {
+		return RankedResult{}, ErrInvalidK
+	}
+
+	distances, labels, err := index.Search(queries, k)
+	if err != nil {
+		return RankedResult{}, err
+	}
+
+	metric := index.MetricType()
+	result := RankedResult{
+		SearchResult: *NewSearchResult(distances, labels, len(labels)/k, k),
+		Higher:       metric == MetricInnerProduct || metric == MetricCosine,
+	}
+	for q := 0; q < result.Nq; q++ {
+		sortBestFirst(distances[q*k:(q+1)*k], labels[q*k:(q+1)*k], result.Higher)
+	}
+	return result, nil
+}
+
+// sortBestFirst orders one query's results best-first in place, keeping
+// padding (label -1) last. FAISS results are already in that order, so the
+// sort only does work for wrappers that merge results.
+func sortBestFirst(distances []float32, labels []int64, higher bool) {
+	less := func(i, j int) bool {
+		if (labels[i] == -1) != (labels[j] == -1) {
+			return labels[j] == -1
+		}
+		if higher {
+			return distances[i] > distances[j]
+		}
+		return distances[i] < distances[j]
+	}
+	sorted := true
+	for i := 1; i < len(labels) && sorted; i++ {
+		sorted = !less(i, i-1)
+	}
+	if sorted {
+		return
+	}
+	sort.Stable(rankedRow{distances, labels, less})
+}
+
+// rankedRow sorts one query's distances and labels together
+type rankedRow struct {
+	distances []float32
+	labels    []int64
+	less      func(i, j int) bool
+}
+
+func (r rankedRow) Len() int           { return len(r.labels) }
+func (r rankedRow) Less(i, j int) bool { return r.less(i, j) }
+func (r rankedRow) Swap(i, j int) {
+	r.distances[i], r.distances[j] = r.distances[j], r.distances[i]
+	r.labels[i], r.labels[j] = r.labels[j], r.labels[i]
+}
+
+// FlattenQueries packs per-query vectors (e.g. decoded from JSON) into the
+// flat layout Search expects
+//
+// Every query must have exactly expectedDim components; a ragged input
+// returns an error naming the offending query instead of silently shifting
+// every following vector.
+//
+// Example:
+//   queries, err := faiss.FlattenQueries(request.Vectors, index.D())
+//   distances, labels, _ := index.Search(queries, k)
+//   results, err := faiss.UnflattenResults(distances, labels, len(request.Vectors), k)
+func FlattenQueries(queries [][]float32, expectedDim int) ([]float32, error) {
+	if expectedDim <= 0 {
+		return nil, ErrInvalidDimension
+	}
+
+	flat := make([]float32, 0, len(queries)*expectedDim)
+	for i, q := range queries {
+		if len(q) != expectedDim {
+			return nil, fmt.Errorf("faiss: query %d has length %d, want %d: %w", i, len(q), expectedDim, ErrDimensionMismatch)
+		}
+		flat = append(flat, q...)
+	}
+	return flat, nil
+}
+
+// UnflattenResults groups the flat Search output per query
+//
+// distances and labels must hold nq*k entries, as returned by Search for nq
+// queries; a negative nq or k or shorter slices return an error, as
+// FlattenQueries does for ragged input. See SearchResult.Neighbors.
+func UnflattenResults(distances []float32, labels []int64, nq, k int) ([][]Neighbor, error) {
+	if nq < 0 || k < 0 {
+		return nil, fmt.Errorf("faiss: nq (%d) and k (%d) must be non-negative", nq, k)
+	}
+	if len(distances) < nq*k || len(labels) < nq*k {
+		return nil, fmt.Errorf("faiss: %d distances and %d labels do not cover %d queries of k=%d", len(distances), len(labels), nq, k)
+	}
+	return NewSearchResult(distances, labels, nq, k).Neighbors(), nil
+}
+
+// MergeTopK merges several result sets for the same queries into one top-k
+//
+// Every result must cover the same number of queries, e.g. one search per
+// query variant in query expansion or one per index in an ensemble. For each
+// query the hits of all results are pooled, repeated IDs keep their smallest
+// distance, and the k smallest distances are returned in ascending order
+// (ties broken by ID). Empty slots (label -1) are ignored; queries with fewer
+// than k distinct hits are padded with label -1 and distance
+// math.MaxFloat32, as FAISS pads L2 results.
+//
+// Distances are compared as "smaller is better", so merging inner product
+// results requires negating their distances first.
+//
+// MergeTopK returns an error if the results disagree on the number of
+// queries.
+//
+// Example:
+//   original := faiss.NewSearchResult(d1, l1, nq, k)
+//   augmented := faiss.NewSearchResult(d2, l2, nq, k)
+//   merged, err := faiss.MergeTopK([]faiss.SearchResult{*original, *augmented}, k)
+func MergeTopK(results []SearchResult, k int) (SearchResult, error) {
+	nq := 0
+	if len(results) > 0 {
+		nq = results[0].Nq
+	}
+	for i, r := range results {
+		if r.Nq != nq {
+			return SearchResult{}, fmt.Errorf("faiss: MergeTopK result %d has %d queries, want %d", i, r.Nq, nq)
+		}
+	}
+	if k < 0 {
+		k = 0
+	}
+
+	merged := SearchResult{
+		Distances: make([]float32, nq*k),
+		Labels:    make([]int64, nq*k),
+		Nq:        nq,
+		K:         k,
+	}
+	best := make(map[int64]float32)
+	for q := 0; q < nq; q++ {
+		for id := range best {
+			delete(best, id)
+		}
+		for _, r := range results {
+			distances, labels := r.GetNeighbors(q)
+			for j, id := range labels {
+				if id < 0 {
+					continue
+				}
+				if d, ok := best[id]; !ok || distances[j] < d {
+					best[id] = distances[j]
+				}
+			}
+		}
+
+		hits := make([]Neighbor, 0, len(best))
+		for id, d := range best {
+			hits = append(hits, Neighbor{ID: id, Distance: d})
+		}
+		sort.Slice(hits, func(a, b int) bool {
+			if hits[a].Distance != hits[b].Distance {
+				return hits[a].Distance < hits[b].Distance
+			}
+			return hits[a].ID < hits[b].ID
+		})
+
+		for j := 0; j < k; j++ {
+			idx := q*k + j
+			if j < len(hits) {
+				merged.Distances[idx], merged.Labels[idx] = hits[j].Distance, hits[j].ID
+			} else {
+				merged.Distances[idx], merged.Labels[idx] = math.MaxFloat32, -1
+			}
+		}
+	}
+	return merged, nil
+}
+
+// resultEntrySize is the encoded size of one result: int64 label + float32 distance
+const resultEntrySize = 12
+
+// EncodeResults packs search results into a compact binary buffer for
+// transfer between processes
+//
+// The layout is a little-endian uint64 entry count followed by one
+// (int64 label, float32 distance) pair per entry, 8 + 12*n bytes in total.
+// Slots without a result (label -1) are encoded as is. Shape information
+// (nq, k) is not included; pass it alongside if the receiver needs it.
+// EncodeResults panics if distances and labels differ in length.
+//
+// Example:
+//   distances, labels, _ := index.Search(queries, k)
+//   payload := faiss.EncodeResults(distances, labels)
+//   // ... on the other side:
+//   distances, labels, err := faiss.DecodeResults(payload)
+func EncodeResults(distances []float32, labels []int64) []byte {
+	if len(distances) != len(labels) {
+		panic(fmt.Sprintf("faiss: EncodeResults: %d distances but %d labels", len(distances), len(labels)))
+	}
+
+	buf := make([]byte, 8+resultEntrySize*len(labels))
+	binary.LittleEndian.PutUint64(buf, uint64(len(labels)))
+	off := 8
+	for i, label := range labels {
+		binary.LittleEndian.PutUint64(buf[off:], uint64(label))
+		binary.LittleEndian.PutUint32(buf[off+8:], math.Float32bits(distances[i]))
+		off += resultEntrySize
+	}
+	return buf
+}
+
+// DecodeResults unpacks a buffer produced by EncodeResults
+//
+// It returns an error if the buffer is truncated or its length does not
+// match the entry count in its header.
+func DecodeResults(data []byte) (distances []float32, labels []int64, err error) {
+	if len(data) < 8 {
+		return nil, nil, fmt.Errorf("faiss: encoded results too short (%d bytes)", len(data))
+	}
+	n := binary.LittleEndian.Uint64(data)
+	if n > uint64(len(data)-8)/resultEntrySize || len(data) != 8+resultEntrySize*int(n) {
+		return nil, nil, fmt.Errorf("faiss: encoded results have %d bytes, want %d for %d entries",
+			len(data), 8+resultEntrySize*n, n)
+	}
+
+	distances = make([]float32, n)
+	labels = make([]int64, n)
+	off := 8
+	for i := range labels {
+		labels[i] = int64(binary.LittleEndian.Uint64(data[off:]))
+		distances[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[off+8:]))
+		off += resultEntrySize
+	}
+	return distances, labels, nil
+}
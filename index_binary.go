@@ -0,0 +1,193 @@
+package faiss
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// IndexBinaryFlat performs exhaustive Hamming-distance search on packed
+// binary codes
+//
+// Vectors are d bits long and packed 8 per byte, least significant bit
+// first, as produced by BinarizeVectors or Fvec2Bvec; a vector takes d/8
+// bytes. Distances are Hamming distances. IndexBinaryFlat works on bytes
+// rather than float32, so it does not implement Index.
+//
+// Python equivalent: faiss.IndexBinaryFlat
+//
+// Example:
+//   codes, _ := faiss.BinarizeVectors(vectors, 256, 0)
+//   index, _ := faiss.NewIndexBinaryFlat(256)
+//   index.Add(codes)
+//   distances, labels, _ := index.Search(codes[:32], 10)
+type IndexBinaryFlat struct {
+	ptr    uintptr // C pointer to FaissIndexBinary
+	d      int     // dimension in bits
+	ntotal int64   // number of vectors
+}
+
+// NewIndexBinaryFlat creates a binary flat index for d-bit vectors
+//
+// d must be a positive multiple of 8.
+func NewIndexBinaryFlat(d int) (*IndexBinaryFlat, error) {
+	if d <= 0 || d%8 != 0 {
+		return nil, fmt.Errorf("faiss: binary dimension %d must be a positive multiple of 8: %w", d, ErrInvalidDimension)
+	}
+
+	ptr, err := faissIndexBinaryFlatNew(d)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to create IndexBinaryFlat: %w", err)
+	}
+
+	idx := &IndexBinaryFlat{
+		ptr: ptr,
+		d:   d,
+	}
+
+	runtime.SetFinalizer(idx, func(i *IndexBinaryFlat) {
+		if i.ptr != 0 {
+			_ = i.Close()
+		}
+	})
+
+	return idx, nil
+}
+
+// D returns the dimension of the vectors in bits
+func (idx *IndexBinaryFlat) D() int {
+	return idx.d
+}
+
+// CodeSize returns the number of bytes per vector (d/8)
+func (idx *IndexBinaryFlat) CodeSize() int {
+	return idx.d / 8
+}
+
+// Ntotal returns the number of vectors in the index
+func (idx *IndexBinaryFlat) Ntotal() int64 {
+	return idx.ntotal
+}
+
+// checkCodes validates a buffer of packed codes and returns the vector count
+func (idx *IndexBinaryFlat) checkCodes(codes []uint8) (int, error) {
+	if len(codes) == 0 {
+		return 0, ErrInvalidVectors
+	}
+	codeSize := idx.CodeSize()
+	if len(codes)%codeSize != 0 {
+		return 0, fmt.Errorf("faiss: codes length %d must be a multiple of the code size %d: %w",
+			len(codes), codeSize, ErrDimensionMismatch)
+	}
+	return len(codes) / codeSize, nil
+}
+
+// Add adds packed codes to the index; vectors get sequential IDs starting
+// at Ntotal
+func (idx *IndexBinaryFlat) Add(codes []uint8) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	n, err := idx.checkCodes(codes)
+	if err != nil {
+		return err
+	}
+
+	if err := faissIndexBinaryAdd(idx.ptr, codes, n); err != nil {
+		return fmt.Errorf("faiss: add failed: %w", err)
+	}
+	idx.ntotal += int64(n)
+	return nil
+}
+
+// Search returns the k nearest vectors of each query by Hamming distance
+//
+// Results are laid out like Index.Search: k entries per query, with label -1
+// and distance 0 padding queries that have fewer than k neighbors.
+func (idx *IndexBinaryFlat) Search(queries []uint8, k int) (distances []int32, labels []int64, err error) {
+	if idx.ptr == 0 {
+		return nil, nil, ErrNullPointer
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+	nq, err := idx.checkCodes(queries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	distances = make([]int32, nq*k)
+	labels = make([]int64, nq*k)
+	if err := faissIndexBinarySearch(idx.ptr, queries, nq, k, distances, labels); err != nil {
+		return nil, nil, fmt.Errorf("faiss: search failed: %w", err)
+	}
+	return distances, labels, nil
+}
+
+// BinaryResult holds the k nearest neighbors of one binary query
+//
+// Distances are Hamming distances in ascending order; entries past the
+// last neighbor have label -1, as in IndexBinaryFlat.Search.
+type BinaryResult struct {
+	Distances []int32
+	Labels    []int64
+}
+
+// SearchBinaryHybrid returns the k nearest vectors of one query together
+// with the number of stored vectors at Hamming distance at most radius
+//
+// Both come from a single scan over the stored codes, so showing "best
+// matches" alongside "N similar items" costs one pass instead of a search
+// plus a range search. Unlike FAISS range search, which keeps distances
+// strictly below the radius, withinRadius counts distances equal to it.
+//
+// Example:
+//   top, similar, err := index.SearchBinaryHybrid(fingerprint, 10, 4)
+//   // top.Labels: the 10 nearest; similar: how many are within distance 4
+func (idx *IndexBinaryFlat) SearchBinaryHybrid(query []uint8, k int, radius int) (topK BinaryResult, withinRadius int, err error) {
+	if idx.ptr == 0 {
+		return BinaryResult{}, 0, ErrNullPointer
+	}
+	if k <= 0 {
+		return BinaryResult{}, 0, ErrInvalidK
+	}
+	if radius < 0 {
+		return BinaryResult{}, 0, fmt.Errorf("faiss: radius must be non-negative, got %d", radius)
+	}
+	if len(query) != idx.CodeSize() {
+		return BinaryResult{}, 0, fmt.Errorf("faiss: query length %d does not match the code size %d: %w",
+			len(query), idx.CodeSize(), ErrDimensionMismatch)
+	}
+
+	topK = BinaryResult{
+		Distances: make([]int32, k),
+		Labels:    make([]int64, k),
+	}
+	within, err := faissIndexBinaryFlatSearchHybrid(idx.ptr, query, k, radius, topK.Distances, topK.Labels)
+	if err != nil {
+		return BinaryResult{}, 0, fmt.Errorf("faiss: search failed: %w", err)
+	}
+	return topK, int(within), nil
+}
+
+// Reset removes all vectors from the index
+func (idx *IndexBinaryFlat) Reset() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if err := faissIndexBinaryReset(idx.ptr); err != nil {
+		return fmt.Errorf("faiss: reset failed: %w", err)
+	}
+	idx.ntotal = 0
+	return nil
+}
+
+// Close frees the index
+func (idx *IndexBinaryFlat) Close() error {
+	if idx.ptr == 0 {
+		return nil
+	}
+	faissIndexBinaryFree(idx.ptr)
+	idx.ptr = 0
+	idx.ntotal = 0
+	return nil
+}
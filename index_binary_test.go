@@ -0,0 +1,158 @@
+package faiss
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIndexBinaryFlat_AddSearch(t *testing.T) {
+	d := 64
+	nb := 200
+	codes, err := BinarizeVectors(generateVectors(nb, d), d, 0.5)
+	if err != nil {
+		t.Fatalf("BinarizeVectors() failed: %v", err)
+	}
+
+	index, err := NewIndexBinaryFlat(d)
+	if err != nil {
+		t.Fatalf("NewIndexBinaryFlat() failed: %v", err)
+	}
+	defer index.Close()
+	if index.D() != d || index.CodeSize() != d/8 {
+		t.Errorf("D(), CodeSize() = %d, %d, want %d, %d", index.D(), index.CodeSize(), d, d/8)
+	}
+
+	if err := index.Add(codes); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if index.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), nb)
+	}
+
+	k := 5
+	nq := 10
+	codeSize := index.CodeSize()
+	distances, labels, err := index.Search(codes[:nq*codeSize], k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for q := 0; q < nq; q++ {
+		// The query itself comes first, unless an exact duplicate ties
+		if distances[q*k] != 0 {
+			t.Errorf("query %d: nearest distance = %d, want 0", q, distances[q*k])
+		}
+		for j := 0; j < k; j++ {
+			label := labels[q*k+j]
+			code := codes[label*int64(codeSize) : (label+1)*int64(codeSize)]
+			want := BitstringHammingDistance(codes[q*codeSize:(q+1)*codeSize], code)
+			if int(distances[q*k+j]) != want {
+				t.Errorf("query %d, rank %d: distance = %d, want %d", q, j, distances[q*k+j], want)
+			}
+			if j > 0 && distances[q*k+j] < distances[q*k+j-1] {
+				t.Errorf("query %d: distances not sorted: %v", q, distances[q*k:(q+1)*k])
+			}
+		}
+	}
+
+	if err := index.Reset(); err != nil {
+		t.Fatalf("Reset() failed: %v", err)
+	}
+	if index.Ntotal() != 0 {
+		t.Errorf("Ntotal() after Reset() = %d, want 0", index.Ntotal())
+	}
+}
+
+func TestIndexBinaryFlat_Invalid(t *testing.T) {
+	if _, err := NewIndexBinaryFlat(12); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("NewIndexBinaryFlat(12) error = %v, want %v", err, ErrInvalidDimension)
+	}
+
+	index, err := NewIndexBinaryFlat(16)
+	if err != nil {
+		t.Fatalf("NewIndexBinaryFlat() failed: %v", err)
+	}
+	if err := index.Add([]uint8{1, 2, 3}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Add() with partial code error = %v, want %v", err, ErrDimensionMismatch)
+	}
+	if _, _, err := index.Search([]uint8{1, 2}, 0); !errors.Is(err, ErrInvalidK) {
+		t.Errorf("Search(k=0) error = %v, want %v", err, ErrInvalidK)
+	}
+
+	index.Close()
+	if err := index.Add([]uint8{1, 2}); !errors.Is(err, ErrNullPointer) {
+		t.Errorf("Add() after Close() error = %v, want %v", err, ErrNullPointer)
+	}
+}
+
+func TestIndexBinaryFlat_SearchBinaryHybrid(t *testing.T) {
+	index, err := NewIndexBinaryFlat(16)
+	if err != nil {
+		t.Fatalf("NewIndexBinaryFlat() failed: %v", err)
+	}
+	defer index.Close()
+
+	// Fingerprints at Hamming distance 0, 1, 2, 3, 4, 5, 8 and 16 from zero
+	fingerprints := []uint8{
+		0x00, 0x00,
+		0x01, 0x00,
+		0x03, 0x00,
+		0x07, 0x00,
+		0x0f, 0x00,
+		0x1f, 0x00,
+		0xff, 0x00,
+		0xff, 0xff,
+	}
+	if err := index.Add(fingerprints); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	query := []uint8{0x00, 0x00}
+
+	top, within, err := index.SearchBinaryHybrid(query, 3, 4)
+	if err != nil {
+		t.Fatalf("SearchBinaryHybrid() failed: %v", err)
+	}
+	for i, want := range []int32{0, 1, 2} {
+		if top.Distances[i] != want || top.Labels[i] != int64(i) {
+			t.Errorf("rank %d = (%d, label %d), want (%d, label %d)", i, top.Distances[i], top.Labels[i], want, i)
+		}
+	}
+	if within != 5 {
+		t.Errorf("withinRadius = %d, want 5", within)
+	}
+
+	tests := []struct {
+		radius int
+		want   int
+	}{{0, 1}, {3, 4}, {7, 6}, {8, 7}, {16, 8}}
+	for _, tt := range tests {
+		if _, within, _ := index.SearchBinaryHybrid(query, 1, tt.radius); within != tt.want {
+			t.Errorf("SearchBinaryHybrid(radius=%d) withinRadius = %d, want %d", tt.radius, within, tt.want)
+		}
+	}
+
+	// Top-k matches Search, padding included
+	k := 10
+	top, _, err = index.SearchBinaryHybrid(query, k, 4)
+	if err != nil {
+		t.Fatalf("SearchBinaryHybrid(k=%d) failed: %v", k, err)
+	}
+	distances, labels, _ := index.Search(query, k)
+	for i := 0; i < k; i++ {
+		if top.Distances[i] != distances[i] || top.Labels[i] != labels[i] {
+			t.Errorf("rank %d = (%d, %d), Search gives (%d, %d)", i, top.Distances[i], top.Labels[i], distances[i], labels[i])
+		}
+	}
+	if top.Labels[k-1] != -1 {
+		t.Errorf("Labels[%d] = %d, want -1 past the last neighbor", k-1, top.Labels[k-1])
+	}
+
+	if _, _, err := index.SearchBinaryHybrid([]uint8{0}, 3, 4); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("SearchBinaryHybrid(short query) error = %v, want %v", err, ErrDimensionMismatch)
+	}
+	if _, _, err := index.SearchBinaryHybrid(query, 0, 4); !errors.Is(err, ErrInvalidK) {
+		t.Errorf("SearchBinaryHybrid(k=0) error = %v, want %v", err, ErrInvalidK)
+	}
+	if _, _, err := index.SearchBinaryHybrid(query, 3, -1); err == nil {
+		t.Error("SearchBinaryHybrid(radius=-1) should return error")
+	}
+}
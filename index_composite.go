@@ -2,7 +2,10 @@ package faiss
 
 import (
 	"fmt"
+	"math"
 	"runtime"
+	"sort"
+	"sync"
 )
 
 // ========================================
@@ -43,7 +46,7 @@ func NewIndexRefine(baseIndex, refineIndex Index) (*IndexRefine, error) {
 		return nil, fmt.Errorf("both base and refine indexes must be non-nil")
 	}
 	if baseIndex.D() != refineIndex.D() {
-		return nil, fmt.Errorf("base and refine indexes must have same dimension")
+		return nil, fmt.Errorf("base and refine indexes must have same dimension: %w", ErrDimensionMismatch)
 	}
 	if baseIndex.MetricType() != refineIndex.MetricType() {
 		return nil, fmt.Errorf("base and refine indexes must use same metric")
@@ -115,6 +118,9 @@ func (idx *IndexRefine) MetricType() MetricType {
 
 // SetK_factor sets the factor for base search candidates
 func (idx *IndexRefine) SetK_factor(kFactor float32) error {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
+		return ErrNullPointer
+	}
 	if kFactor < 1.0 {
 		return fmt.Errorf("k_factor must be >= 1.0")
 	}
@@ -128,11 +134,18 @@ func (idx *IndexRefine) SetK_factor(kFactor float32) error {
 
 // Train trains the IndexRefine (which internally trains both base and refine indexes)
 func (idx *IndexRefine) Train(vectors []float32) error {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
 		return fmt.Errorf("empty training vectors")
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	// Call faiss_Index_train on the IndexRefine pointer
@@ -149,11 +162,18 @@ func (idx *IndexRefine) Train(vectors []float32) error {
 
 // Add adds vectors through IndexRefine (which delegates to both child indexes)
 func (idx *IndexRefine) Add(vectors []float32) error {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
+		return ErrNullPointer
+	}
 	if !idx.IsTrained() {
 		return fmt.Errorf("index must be trained before adding vectors")
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	// Call faiss_Index_add on the IndexRefine pointer
@@ -170,11 +190,18 @@ func (idx *IndexRefine) Add(vectors []float32) error {
 
 // Search performs two-stage search
 func (idx *IndexRefine) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
+		return nil, nil, ErrNullPointer
+	}
 	if len(queries) == 0 {
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -239,6 +266,17 @@ func (idx *IndexRefine) Close() error {
 //
 //   // Vectors are automatically reduced before indexing
 //   index.Add(vectors)
+//
+// Ownership: the IndexPreTransform borrows the transform and the base index,
+// it never frees them. Close frees only the composite, so the caller closes
+// all three, in any order:
+//
+//   defer pca.Close()
+//   defer baseIndex.Close()
+//   defer index.Close()
+//
+// Closing any of them more than once is a no-op. Once the transform or the
+// base index is closed, operations on the composite return ErrNullPointer.
 type IndexPreTransform struct {
 	ptr       uintptr        // C pointer
 	transform VectorTransform // preprocessing transform
@@ -254,13 +292,16 @@ type IndexPreTransform struct {
 var _ Index = (*IndexPreTransform)(nil)
 
 // NewIndexPreTransform creates a new index with preprocessing
+//
+// transform and index stay owned by the caller and must outlive every use of
+// the returned index (see IndexPreTransform).
 func NewIndexPreTransform(transform VectorTransform, index Index) (*IndexPreTransform, error) {
 	if transform == nil || index == nil {
 		return nil, fmt.Errorf("both transform and index must be non-nil")
 	}
 	if transform.DOut() != index.D() {
-		return nil, fmt.Errorf("transform output dimension (%d) must match index dimension (%d)",
-			transform.DOut(), index.D())
+		return nil, fmt.Errorf("transform output dimension (%d) must match index dimension (%d): %w",
+			transform.DOut(), index.D(), ErrDimensionMismatch)
 	}
 
 	// Get pointers based on type
@@ -295,7 +336,7 @@ func NewIndexPreTransform(transform VectorTransform, index Index) (*IndexPreTran
 	}
 
 	// CRITICAL: Set own_fields=0 to prevent FAISS from freeing the transform and index
-	// (Go manages their lifecycle via GC and finalizers)
+	// (they are borrowed; the caller closes them)
 	faiss_IndexPreTransform_set_own_fields(ptr, 0)
 
 	idx := &IndexPreTransform{
@@ -322,13 +363,19 @@ func (idx *IndexPreTransform) D() int {
 }
 
 // Ntotal returns the number of vectors in the index
+//
+// Vectors added through the composite bypass the base index wrapper, so the
+// count is read from FAISS while the composite is open.
 func (idx *IndexPreTransform) Ntotal() int64 {
-	return idx.index.Ntotal()
+	if idx.ptr == 0 || isClosed(idx.index) {
+		return idx.index.Ntotal()
+	}
+	return faissIndexNtotal(idx.ptr)
 }
 
 // IsTrained returns whether both transform and index are trained
 func (idx *IndexPreTransform) IsTrained() bool {
-	if idx.ptr == 0 {
+	if idx.ptr == 0 || isClosed(idx.index) || isTransformClosed(idx.transform) {
 		return false
 	}
 	return faiss_Index_is_trained(idx.ptr) != 0
@@ -341,11 +388,18 @@ func (idx *IndexPreTransform) MetricType() MetricType {
 
 // Train trains the IndexPreTransform (which internally trains transform and index)
 func (idx *IndexPreTransform) Train(vectors []float32) error {
+	if idx.ptr == 0 || isClosed(idx.index) || isTransformClosed(idx.transform) {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
 		return fmt.Errorf("empty training vectors")
 	}
 	if len(vectors)%idx.dIn != 0 {
-		return fmt.Errorf("vectors length must be multiple of input dimension %d", idx.dIn)
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", idx.dIn, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.dIn); err != nil {
+		return err
 	}
 
 	// Call faiss_Index_train on the IndexPreTransform pointer
@@ -362,6 +416,9 @@ func (idx *IndexPreTransform) Train(vectors []float32) error {
 
 // Add adds vectors after applying transformation (FAISS handles transformation internally)
 func (idx *IndexPreTransform) Add(vectors []float32) error {
+	if idx.ptr == 0 || isClosed(idx.index) || isTransformClosed(idx.transform) {
+		return ErrNullPointer
+	}
 	if !idx.IsTrained() {
 		return fmt.Errorf("index must be trained before adding vectors")
 	}
@@ -369,7 +426,11 @@ func (idx *IndexPreTransform) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.dIn != 0 {
-		return fmt.Errorf("vectors length must be multiple of input dimension %d", idx.dIn)
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", idx.dIn, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.dIn); err != nil {
+		return err
 	}
 
 	// Call faiss_Index_add on the IndexPreTransform pointer
@@ -386,11 +447,18 @@ func (idx *IndexPreTransform) Add(vectors []float32) error {
 
 // Search searches after applying transformation to queries (FAISS handles transformation internally)
 func (idx *IndexPreTransform) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 || isClosed(idx.index) || isTransformClosed(idx.transform) {
+		return nil, nil, ErrNullPointer
+	}
 	if len(queries) == 0 {
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.dIn != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of input dimension %d", idx.dIn)
+		return nil, nil, fmt.Errorf("queries length must be multiple of input dimension %d: %w", idx.dIn, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.dIn); err != nil {
+		return nil, nil, err
 	}
 
 	// Call faiss_Index_search on the IndexPreTransform pointer
@@ -419,6 +487,9 @@ func (idx *IndexPreTransform) SetEfSearch(efSearch int) error {
 
 // Reset removes all vectors from the underlying index
 func (idx *IndexPreTransform) Reset() error {
+	if idx.ptr == 0 || isClosed(idx.index) || isTransformClosed(idx.transform) {
+		return ErrNullPointer
+	}
 	if err := idx.index.Reset(); err != nil {
 		return err
 	}
@@ -426,7 +497,9 @@ func (idx *IndexPreTransform) Reset() error {
 	return nil
 }
 
-// Close frees the index
+// Close frees the composite index, leaving the transform and base index open
+//
+// Calling Close more than once is safe.
 func (idx *IndexPreTransform) Close() error {
 	if idx.ptr != 0 {
 		faiss_Index_free(idx.ptr)
@@ -506,7 +579,7 @@ func (idx *IndexShards) AddShard(shard Index) error {
 		return fmt.Errorf("shard cannot be nil")
 	}
 	if shard.D() != idx.d {
-		return fmt.Errorf("shard dimension %d != index dimension %d", shard.D(), idx.d)
+		return fmt.Errorf("shard dimension %d != index dimension %d: %w", shard.D(), idx.d, ErrDimensionMismatch)
 	}
 
 	var shardPtr uintptr
@@ -528,6 +601,16 @@ func (idx *IndexShards) AddShard(shard Index) error {
 	return nil
 }
 
+// hasClosedShard reports whether any sub-index was closed independently
+func (idx *IndexShards) hasClosedShard() bool {
+	for _, shard := range idx.shards {
+		if isClosed(shard) {
+			return true
+		}
+	}
+	return false
+}
+
 // D returns the dimension
 func (idx *IndexShards) D() int {
 	return idx.d
@@ -559,6 +642,9 @@ func (idx *IndexShards) MetricType() MetricType {
 
 // Train trains all shards via the IndexShards composite index
 func (idx *IndexShards) Train(vectors []float32) error {
+	if idx.ptr == 0 || idx.hasClosedShard() {
+		return ErrNullPointer
+	}
 	if len(idx.shards) == 0 {
 		return fmt.Errorf("no shards added")
 	}
@@ -566,7 +652,11 @@ func (idx *IndexShards) Train(vectors []float32) error {
 		return fmt.Errorf("empty training vectors")
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	// Call faiss_Index_train on the IndexShards pointer
@@ -583,6 +673,9 @@ func (idx *IndexShards) Train(vectors []float32) error {
 
 // Add distributes vectors across shards
 func (idx *IndexShards) Add(vectors []float32) error {
+	if idx.ptr == 0 || idx.hasClosedShard() {
+		return ErrNullPointer
+	}
 	if len(idx.shards) == 0 {
 		return fmt.Errorf("no shards added")
 	}
@@ -590,7 +683,11 @@ func (idx *IndexShards) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -604,6 +701,9 @@ func (idx *IndexShards) Add(vectors []float32) error {
 
 // Search searches across all shards
 func (idx *IndexShards) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 || idx.hasClosedShard() {
+		return nil, nil, ErrNullPointer
+	}
 	if len(idx.shards) == 0 {
 		return nil, nil, fmt.Errorf("no shards added")
 	}
@@ -611,7 +711,11 @@ func (idx *IndexShards) Search(queries []float32, k int) (distances []float32, i
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -678,3 +782,441 @@ func (idx *IndexShards) Close() error {
 	idx.shards = nil
 	return nil
 }
+
+// ========================================
+// FederatedSearch - Search Independent Indexes
+// ========================================
+
+// FederatedSearch searches several independent indexes concurrently and merges
+// the per-query results into a global top-k.
+//
+// Unlike IndexShards, the indexes do not need to share a configuration or be
+// owned by one composite object; they only need the same dimension and metric.
+// Labels from index i are offset by the total number of vectors in indexes
+// 0..i-1, which matches the IDs a single index would assign if the shards were
+// added to it in order. Use FederatedSearchWithOffsets to choose the offsets.
+//
+// Example:
+//   distances, labels, err := faiss.FederatedSearch(
+//       []faiss.Index{shard0, shard1}, queries, 10)
+func FederatedSearch(indexes []Index, query []float32, k int) (distances []float32, labels []int64, err error) {
+	offsets := make([]int64, len(indexes))
+	var total int64
+	for i, index := range indexes {
+		offsets[i] = total
+		if index != nil {
+			total += index.Ntotal()
+		}
+	}
+	return FederatedSearchWithOffsets(indexes, offsets, query, k)
+}
+
+// FederatedSearchWithOffsets is like FederatedSearch but adds offsets[i] to
+// every label returned by indexes[i]. Pass zero offsets if the shards already
+// use disjoint global IDs (e.g. via IndexIDMap).
+func FederatedSearchWithOffsets(indexes []Index, offsets []int64, query []float32, k int) (distances []float32, labels []int64, err error) {
+	if len(indexes) == 0 {
+		return nil, nil, fmt.Errorf("faiss: no indexes to search")
+	}
+	if len(offsets) != len(indexes) {
+		return nil, nil, fmt.Errorf("faiss: got %d offsets for %d indexes", len(offsets), len(indexes))
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+	for i, index := range indexes {
+		if index == nil {
+			return nil, nil, fmt.Errorf("faiss: index %d is nil", i)
+		}
+	}
+
+	d := indexes[0].D()
+	metric := indexes[0].MetricType()
+	for i, index := range indexes[1:] {
+		if index.D() != d {
+			return nil, nil, fmt.Errorf("faiss: index %d dimension %d != %d: %w", i+1, index.D(), d, ErrDimensionMismatch)
+		}
+		if index.MetricType() != metric {
+			return nil, nil, fmt.Errorf("faiss: index %d metric %v != %v", i+1, index.MetricType(), metric)
+		}
+	}
+	if len(query) == 0 || len(query)%d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+	nq := len(query) / d
+
+	// Search every index concurrently
+	type shardResult struct {
+		distances []float32
+		labels    []int64
+		err       error
+	}
+	results := make([]shardResult, len(indexes))
+	var wg sync.WaitGroup
+	for i, index := range indexes {
+		wg.Add(1)
+		go func(i int, index Index) {
+			defer wg.Done()
+			dist, lab, err := index.Search(query, k)
+			results[i] = shardResult{distances: dist, labels: lab, err: err}
+		}(i, index)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r.err != nil {
+			return nil, nil, fmt.Errorf("faiss: search on index %d failed: %w", i, r.err)
+		}
+	}
+
+	// Similarity metrics rank larger scores first
+	descending := metric == MetricInnerProduct
+	padding := float32(math.MaxFloat32)
+	if descending {
+		padding = -math.MaxFloat32
+	}
+
+	distances = make([]float32, nq*k)
+	labels = make([]int64, nq*k)
+	candidates := make([]Neighbor, 0, len(indexes)*k)
+	for q := 0; q < nq; q++ {
+		candidates = candidates[:0]
+		for i, r := range results {
+			for j := q * k; j < (q+1)*k; j++ {
+				if r.labels[j] < 0 {
+					continue
+				}
+				candidates = append(candidates, Neighbor{ID: r.labels[j] + offsets[i], Distance: r.distances[j]})
+			}
+		}
+
+		sort.SliceStable(candidates, func(a, b int) bool {
+			if descending {
+				return candidates[a].Distance > candidates[b].Distance
+			}
+			return candidates[a].Distance < candidates[b].Distance
+		})
+
+		for j := 0; j < k; j++ {
+			if j < len(candidates) {
+				distances[q*k+j] = candidates[j].Distance
+				labels[q*k+j] = candidates[j].ID
+			} else {
+				distances[q*k+j] = padding
+				labels[q*k+j] = -1
+			}
+		}
+	}
+
+	return distances, labels, nil
+}
+
+// ========================================
+// WeightedEnsemble - Weighted Score Fusion
+// ========================================
+
+// WeightedEnsemble searches several sub-indexes that describe the same items
+// in different embedding spaces and fuses their distances into one ranking
+//
+// Each vector (and each query) is the concatenation of one segment per
+// sub-index, in order, each segment matching that sub-index's dimension. The
+// sub-indexes must share an ID space: item i must be stored at ID i in every
+// sub-index, which Add guarantees by splitting rows across all of them.
+//
+// The fused score of an item is sum(weights[i] * distance_i). Each sub-index
+// is searched for k*kFactor candidates; when an item is a candidate in one
+// space but not in another, the worst distance returned by the other space is
+// used in its place.
+//
+// The ensemble does not own its sub-indexes: closing it leaves them open.
+//
+// Example:
+//   text, _ := faiss.NewIndexFlatIP(384)
+//   image, _ := faiss.NewIndexFlatIP(512)
+//   ensemble, _ := faiss.NewWeightedEnsemble([]faiss.Index{text, image}, []float32{0.7, 0.3})
+//
+//   ensemble.Add(vectors)  // each row: 384 text dims followed by 512 image dims
+//   distances, ids, _ := ensemble.Search(queries, 10)
+type WeightedEnsemble struct {
+	indexes []Index   // sub-indexes, one per embedding space
+	weights []float32 // fusion weight per sub-index
+	d       int       // total dimension (sum of sub-index dimensions)
+	metric  MetricType
+	kFactor int  // candidates per sub-index = k * kFactor
+	closed  bool // set by Close
+}
+
+// Ensure WeightedEnsemble implements Index
+var _ Index = (*WeightedEnsemble)(nil)
+
+// NewWeightedEnsemble creates an ensemble over indexes with one weight per index
+func NewWeightedEnsemble(indexes []Index, weights []float32) (*WeightedEnsemble, error) {
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("at least one index is required")
+	}
+	if len(weights) != len(indexes) {
+		return nil, fmt.Errorf("got %d weights for %d indexes", len(weights), len(indexes))
+	}
+
+	d := 0
+	for i, index := range indexes {
+		if index == nil {
+			return nil, fmt.Errorf("index %d is nil", i)
+		}
+		if index.MetricType() != indexes[0].MetricType() {
+			return nil, fmt.Errorf("all indexes must use same metric")
+		}
+		d += index.D()
+	}
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(float64(w)) || math.IsInf(float64(w), 0) {
+			return nil, fmt.Errorf("weight %d must be a non-negative finite number", i)
+		}
+	}
+
+	return &WeightedEnsemble{
+		indexes: append([]Index(nil), indexes...),
+		weights: append([]float32(nil), weights...),
+		d:       d,
+		metric:  indexes[0].MetricType(),
+		kFactor: 4,
+	}, nil
+}
+
+// D returns the total dimension (sum of the sub-index dimensions)
+func (idx *WeightedEnsemble) D() int {
+	return idx.d
+}
+
+// Ntotal returns the number of items (taken from the first sub-index)
+func (idx *WeightedEnsemble) Ntotal() int64 {
+	if idx.closed {
+		return 0
+	}
+	return idx.indexes[0].Ntotal()
+}
+
+// IsTrained returns whether all sub-indexes are trained
+func (idx *WeightedEnsemble) IsTrained() bool {
+	if idx.closed {
+		return false
+	}
+	for _, index := range idx.indexes {
+		if !index.IsTrained() {
+			return false
+		}
+	}
+	return true
+}
+
+// MetricType returns the distance metric shared by the sub-indexes
+func (idx *WeightedEnsemble) MetricType() MetricType {
+	return idx.metric
+}
+
+// SetKFactor sets how many candidates (k * kFactor) each sub-index returns
+// before fusion. Larger values make the fused ranking more accurate.
+func (idx *WeightedEnsemble) SetKFactor(kFactor int) error {
+	if kFactor < 1 {
+		return fmt.Errorf("k_factor must be >= 1")
+	}
+	idx.kFactor = kFactor
+	return nil
+}
+
+// split cuts concatenated rows into one contiguous buffer per sub-index
+func (idx *WeightedEnsemble) split(vectors []float32) [][]float32 {
+	n := len(vectors) / idx.d
+	parts := make([][]float32, len(idx.indexes))
+	offset := 0
+	for i, index := range idx.indexes {
+		di := index.D()
+		part := make([]float32, n*di)
+		for row := 0; row < n; row++ {
+			copy(part[row*di:(row+1)*di], vectors[row*idx.d+offset:row*idx.d+offset+di])
+		}
+		parts[i] = part
+		offset += di
+	}
+	return parts
+}
+
+// Train trains every sub-index on its segment of the vectors
+func (idx *WeightedEnsemble) Train(vectors []float32) error {
+	if idx.closed {
+		return ErrNullPointer
+	}
+	if len(vectors) == 0 {
+		return fmt.Errorf("empty training vectors")
+	}
+	if len(vectors)%idx.d != 0 {
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	for i, part := range idx.split(vectors) {
+		if err := idx.indexes[i].Train(part); err != nil {
+			return fmt.Errorf("training index %d failed: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Add adds every row to all sub-indexes so that IDs stay aligned
+func (idx *WeightedEnsemble) Add(vectors []float32) error {
+	if idx.closed {
+		return ErrNullPointer
+	}
+	if len(vectors) == 0 {
+		return nil
+	}
+	if len(vectors)%idx.d != 0 {
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	for i, part := range idx.split(vectors) {
+		if err := idx.indexes[i].Add(part); err != nil {
+			return fmt.Errorf("add to index %d failed: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Search searches every sub-index and returns the k items with the best
+// weighted sum of distances
+func (idx *WeightedEnsemble) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.closed {
+		return nil, nil, ErrNullPointer
+	}
+	if len(queries) == 0 {
+		return nil, nil, fmt.Errorf("empty query vectors")
+	}
+	if len(queries)%idx.d != 0 {
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+
+	nq := len(queries) / idx.d
+	kSub := k * idx.kFactor
+	if ntotal := idx.Ntotal(); ntotal > 0 && int64(kSub) > ntotal {
+		kSub = int(ntotal)
+	}
+	if kSub < k {
+		kSub = k
+	}
+
+	subDistances := make([][]float32, len(idx.indexes))
+	subLabels := make([][]int64, len(idx.indexes))
+	for i, part := range idx.split(queries) {
+		subDistances[i], subLabels[i], err = idx.indexes[i].Search(part, kSub)
+		if err != nil {
+			return nil, nil, fmt.Errorf("search on index %d failed: %w", i, err)
+		}
+	}
+
+	// Similarity metrics rank larger scores first
+	descending := idx.metric == MetricInnerProduct
+	padding := float32(math.MaxFloat32)
+	if descending {
+		padding = -math.MaxFloat32
+	}
+
+	distances = make([]float32, nq*k)
+	indices = make([]int64, nq*k)
+	for q := 0; q < nq; q++ {
+		// Per sub-index distance of every candidate, plus its worst distance
+		// to stand in for candidates it did not return
+		perIndex := make([]map[int64]float32, len(idx.indexes))
+		worst := make([]float32, len(idx.indexes))
+		var ids []int64
+		seen := make(map[int64]bool)
+		for i := range idx.indexes {
+			perIndex[i] = make(map[int64]float32, kSub)
+			for j := q * kSub; j < (q+1)*kSub; j++ {
+				id := subLabels[i][j]
+				if id < 0 {
+					continue
+				}
+				dist := subDistances[i][j]
+				perIndex[i][id] = dist
+				worst[i] = dist // results are sorted best first
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+
+		fused := make([]Neighbor, 0, len(ids))
+		for _, id := range ids {
+			var score float32
+			for i, w := range idx.weights {
+				dist, ok := perIndex[i][id]
+				if !ok {
+					dist = worst[i]
+				}
+				score += w * dist
+			}
+			fused = append(fused, Neighbor{ID: id, Distance: score})
+		}
+
+		sort.SliceStable(fused, func(a, b int) bool {
+			if descending {
+				return fused[a].Distance > fused[b].Distance
+			}
+			return fused[a].Distance < fused[b].Distance
+		})
+
+		for j := 0; j < k; j++ {
+			if j < len(fused) {
+				distances[q*k+j] = fused[j].Distance
+				indices[q*k+j] = fused[j].ID
+			} else {
+				distances[q*k+j] = padding
+				indices[q*k+j] = -1
+			}
+		}
+	}
+
+	return distances, indices, nil
+}
+
+// SetNprobe sets nprobe on all sub-indexes, return error if any fails
+func (idx *WeightedEnsemble) SetNprobe(nprobe int) error {
+	for _, index := range idx.indexes {
+		if err := index.SetNprobe(nprobe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetEfSearch sets efSearch on all sub-indexes, return error if any fails
+func (idx *WeightedEnsemble) SetEfSearch(efSearch int) error {
+	for _, index := range idx.indexes {
+		if err := index.SetEfSearch(efSearch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset removes all vectors from all sub-indexes
+func (idx *WeightedEnsemble) Reset() error {
+	if idx.closed {
+		return ErrNullPointer
+	}
+	for _, index := range idx.indexes {
+		if err := index.Reset(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the ensemble; the sub-indexes are left open
+func (idx *WeightedEnsemble) Close() error {
+	idx.closed = true
+	return nil
+}
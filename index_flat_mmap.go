@@ -0,0 +1,202 @@
+package faiss
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ioFlagMmapIFC is FAISS's IO_FLAG_MMAP_IFC: flat code storage is mapped
+// from the file instead of copied into memory
+const ioFlagMmapIFC = 1 << 9
+
+// flatMmapSuffix ends the name of the FAISS index file NewIndexFlatMmap
+// converts a vector file into (see flatMmapFile)
+const flatMmapSuffix = ".flat.faiss"
+
+// NewIndexFlatMmap opens an exact search index whose vectors stay on disk
+//
+// The file is memory-mapped instead of read, so the operating system pages
+// vectors in as searches touch them and the dataset may be larger than RAM.
+// Every search scans all vectors, so throughput is bounded by disk speed once
+// the file no longer fits in the page cache.
+//
+// path may be:
+//   - a flat index written by WriteIndexToFile, mapped as is
+//   - a .fvecs file (each vector prefixed by its int32 dimension)
+//   - any other file, read as raw little-endian float32 vectors
+//
+// Vector files are converted once, by FAISS and with constant memory use,
+// into a FAISS index file next to path named after d and metric (e.g.
+// "base.fvecs.d768.l2.flat.faiss"); later calls with the same d and metric
+// reuse it as long as it is newer than path. The conversion needs as much
+// free disk space as the vectors themselves, twice that for .fvecs files.
+//
+// The index is read-only: Add, Reset and Compact return ErrReadOnly because
+// the mapped storage cannot grow or shrink.
+//
+// Example:
+//
+//	index, err := faiss.NewIndexFlatMmap("base.fvecs", 768, faiss.MetricL2)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer index.Close()
+//	distances, labels, _ := index.Search(queries, 10)
+func NewIndexFlatMmap(path string, d int, metric MetricType) (*IndexFlat, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if metric != MetricL2 && metric != MetricInnerProduct {
+		return nil, fmt.Errorf("faiss: unsupported metric type %d for memory-mapped flat index", metric)
+	}
+
+	indexFile, err := flatIndexFileFor(path, d, metric)
+	if err != nil {
+		return nil, err
+	}
+
+	ptr, err := readIndexFile(indexFile, ioFlagMmapIFC)
+	if err != nil {
+		return nil, err
+	}
+	fileD, ntotal, fileMetric := indexProperties(ptr)
+	if fileD != d {
+		_ = faissIndexFree(ptr)
+		return nil, fmt.Errorf("faiss: %s holds a d=%d flat index, want d=%d: %w",
+			indexFile, fileD, d, ErrDimensionMismatch)
+	}
+	if fileMetric != metric {
+		_ = faissIndexFree(ptr)
+		return nil, fmt.Errorf("faiss: %s holds a %s flat index, want %s: %w",
+			indexFile, fileMetric, metric, ErrMetricMismatch)
+	}
+
+	idx := &IndexFlat{
+		ptr:       ptr,
+		d:         d,
+		metric:    metric,
+		ntotal:    ntotal,
+		isTrained: true,
+		mmapped:   true,
+	}
+
+	runtime.SetFinalizer(idx, func(i *IndexFlat) {
+		if i.ptr != 0 {
+			_ = i.Close()
+		}
+	})
+
+	return idx, nil
+}
+
+// flatMmapFile returns the FAISS index file NewIndexFlatMmap converts the
+// vector file at path into for dimension d and metric
+func flatMmapFile(path string, d int, metric MetricType) string {
+	return fmt.Sprintf("%s.d%d.%s%s", path, d, strings.ToLower(metric.String()), flatMmapSuffix)
+}
+
+// flatIndexFileFor returns the FAISS index file to map for path, converting
+// a vector file first if needed
+func flatIndexFileFor(path string, d int, metric MetricType) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("faiss: failed to open vector file: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err == nil && (string(magic) == "IxF2" || string(magic) == "IxFI") {
+		return path, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("faiss: failed to stat vector file: %w", err)
+	}
+	indexFile := flatMmapFile(path, d, metric)
+	if converted, err := os.Stat(indexFile); err == nil && !converted.ModTime().Before(info.ModTime()) {
+		return indexFile, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("faiss: failed to rewind vector file: %w", err)
+	}
+	fvecs := strings.HasSuffix(path, ".fvecs")
+	if err := writeFlatIndexFile(f, path, info.Size(), fvecs, indexFile, d, metric); err != nil {
+		return "", err
+	}
+	return indexFile, nil
+}
+
+// writeFlatIndexFile converts the vector file at path, open as r, into a
+// FAISS IndexFlat file written by FAISS itself
+//
+// FAISS maps the raw float32 vectors instead of reading them, so memory use
+// stays constant. An .fvecs file is first unpacked into a temporary raw
+// file next to filename.
+func writeFlatIndexFile(r io.Reader, path string, size int64, fvecs bool, filename string, d int, metric MetricType) error {
+	recordSize := int64(4 * d)
+	if fvecs {
+		recordSize += 4
+	}
+	if size%recordSize != 0 {
+		return fmt.Errorf("faiss: vector file size %d is not a multiple of the %d-byte record size for d=%d", size, recordSize, d)
+	}
+	n := size / recordSize
+
+	raw := path
+	if fvecs {
+		raw = filename + ".raw.tmp"
+		defer os.Remove(raw)
+		if err := unpackFvecs(r, n, d, raw); err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		raw = ""
+	}
+
+	tmp := filename + ".tmp"
+	defer os.Remove(tmp)
+	if err := faissWriteFlatIndexFromRaw(raw, d, metric, tmp); err != nil {
+		return fmt.Errorf("faiss: failed to convert %s: %w", path, err)
+	}
+	return os.Rename(tmp, filename)
+}
+
+// unpackFvecs streams the n vectors of the .fvecs data in r into a raw
+// little-endian float32 file
+func unpackFvecs(r io.Reader, n int64, d int, filename string) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("faiss: failed to create %s: %w", filename, err)
+	}
+	w := bufio.NewWriter(out)
+	br := bufio.NewReader(r)
+	batch := make([]float32, min(n, DefaultAddBatchSize)*int64(d))
+	for done := int64(0); done < n; {
+		chunk := batch[:min(n-done, DefaultAddBatchSize)*int64(d)]
+		if err := readFvecs(br, d, done, chunk); err != nil {
+			out.Close()
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, chunk); err != nil {
+			out.Close()
+			return fmt.Errorf("faiss: failed to write %s: %w", filename, err)
+		}
+		done += int64(len(chunk) / d)
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return fmt.Errorf("faiss: failed to write %s: %w", filename, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("faiss: failed to write %s: %w", filename, err)
+	}
+	return nil
+}
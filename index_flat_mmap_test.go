@@ -0,0 +1,145 @@
+package faiss
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ========================================
+// NewIndexFlatMmap Tests
+// ========================================
+
+func TestNewIndexFlatMmap(t *testing.T) {
+	d := 16
+	nb := 500
+	vectors := generateVectors(nb, d)
+	queries := vectors[:5*d]
+
+	ref := mustCreateIndexFlatL2(t, d)
+	defer ref.Close()
+	ref.Add(vectors)
+	wantDist, wantLabels, _ := ref.Search(queries, 5)
+
+	dir := t.TempDir()
+
+	// Raw float32 file
+	raw := filepath.Join(dir, "base.bin")
+	f, _ := os.Create(raw)
+	binary.Write(f, binary.LittleEndian, vectors)
+	f.Close()
+
+	// .fvecs file
+	fvecs := filepath.Join(dir, "base.fvecs")
+	f, _ = os.Create(fvecs)
+	for i := 0; i < nb; i++ {
+		binary.Write(f, binary.LittleEndian, int32(d))
+		binary.Write(f, binary.LittleEndian, vectors[i*d:(i+1)*d])
+	}
+	f.Close()
+
+	// Index written by WriteIndexToFile
+	indexFile := filepath.Join(dir, "base.faiss")
+	if err := WriteIndexToFile(ref, indexFile); err != nil {
+		t.Fatalf("WriteIndexToFile() failed: %v", err)
+	}
+
+	for _, path := range []string{raw, fvecs, indexFile} {
+		index, err := NewIndexFlatMmap(path, d, MetricL2)
+		if err != nil {
+			t.Fatalf("NewIndexFlatMmap(%s) failed: %v", filepath.Base(path), err)
+		}
+		if index.Ntotal() != int64(nb) {
+			t.Errorf("%s: Ntotal() = %d, want %d", filepath.Base(path), index.Ntotal(), nb)
+		}
+
+		distances, labels, err := index.Search(queries, 5)
+		if err != nil {
+			t.Fatalf("%s: Search() failed: %v", filepath.Base(path), err)
+		}
+		for i := range labels {
+			if labels[i] != wantLabels[i] || distances[i] != wantDist[i] {
+				t.Errorf("%s: result %d = (%d, %v), want (%d, %v)", filepath.Base(path), i, labels[i], distances[i], wantLabels[i], wantDist[i])
+				break
+			}
+		}
+
+		// The mapped storage cannot grow or shrink
+		if err := index.Add(vectors[:d]); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: Add() error = %v, want ErrReadOnly", filepath.Base(path), err)
+		}
+		if err := index.Reset(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: Reset() error = %v, want ErrReadOnly", filepath.Base(path), err)
+		}
+		index.Close()
+	}
+
+	// Vector files are converted once and the result reused
+	if _, err := os.Stat(flatMmapFile(fvecs, d, MetricL2)); err != nil {
+		t.Errorf("converted index file missing: %v", err)
+	}
+	if _, err := os.Stat(flatMmapFile(indexFile, d, MetricL2)); err == nil {
+		t.Error("FAISS index file should be mapped without conversion")
+	}
+}
+
+func TestNewIndexFlatMmap_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "base.bin")
+	os.WriteFile(raw, make([]byte, 4*8*3), 0o644)
+
+	if _, err := NewIndexFlatMmap(raw, 0, MetricL2); err == nil {
+		t.Error("Expected error for d = 0")
+	}
+	if _, err := NewIndexFlatMmap(filepath.Join(dir, "missing.bin"), 8, MetricL2); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if _, err := NewIndexFlatMmap(raw, 5, MetricL2); err == nil {
+		t.Error("Expected error for size not a multiple of the record size")
+	}
+
+	// A converted L2 file does not satisfy an inner product request
+	index, err := NewIndexFlatMmap(raw, 8, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexFlatMmap() failed: %v", err)
+	}
+	index.Close()
+	if _, err := NewIndexFlatMmap(flatMmapFile(raw, 8, MetricL2), 8, MetricInnerProduct); !errors.Is(err, ErrMetricMismatch) {
+		t.Errorf("metric mismatch error = %v, want ErrMetricMismatch", err)
+	}
+
+	// The same vector file read with another metric or dimension gets its
+	// own conversion
+	for _, tt := range []struct {
+		d      int
+		metric MetricType
+		ntotal int64
+	}{
+		{8, MetricInnerProduct, 3},
+		{4, MetricL2, 6},
+	} {
+		index, err := NewIndexFlatMmap(raw, tt.d, tt.metric)
+		if err != nil {
+			t.Fatalf("NewIndexFlatMmap(d=%d, %v) failed: %v", tt.d, tt.metric, err)
+		}
+		if index.D() != tt.d || index.MetricType() != tt.metric || index.Ntotal() != tt.ntotal {
+			t.Errorf("NewIndexFlatMmap(d=%d, %v) = d=%d %v with %d vectors, want %d vectors",
+				tt.d, tt.metric, index.D(), index.MetricType(), index.Ntotal(), tt.ntotal)
+		}
+		index.Close()
+	}
+
+	// An empty vector file maps to an empty index
+	empty := filepath.Join(dir, "empty.bin")
+	os.WriteFile(empty, nil, 0o644)
+	index, err = NewIndexFlatMmap(empty, 8, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexFlatMmap(empty) failed: %v", err)
+	}
+	if index.Ntotal() != 0 {
+		t.Errorf("Ntotal() = %d, want 0", index.Ntotal())
+	}
+	index.Close()
+}
@@ -3,6 +3,7 @@ package faiss
 import (
 	"fmt"
 	"runtime"
+	"time"
 )
 
 // GenericIndex wraps any index created by the factory function.
@@ -23,12 +24,15 @@ import (
 //	// Create IVF+PQ index
 //	index, _ := faiss.IndexFactory(128, "IVF100,PQ8", faiss.MetricL2)
 type GenericIndex struct {
-	ptr         uintptr    // C pointer to FaissIndex
-	d           int        // dimension
-	metric      MetricType // metric type
-	ntotal      int64      // number of vectors
-	isTrained   bool       // training status (cached)
-	description string     // factory description string
+	ptr            uintptr    // C pointer to FaissIndex
+	d              int        // dimension
+	metric         MetricType // metric type
+	ntotal         int64      // number of vectors
+	isTrained      bool       // training status (cached)
+	description    string     // factory description string
+	normGuard      bool       // Add/Search reject vectors that are not unit-norm
+
+	shared *SharedQuantizer // shared quantizer reference, released on Close
 }
 
 // Ensure GenericIndex implements Index interface
@@ -77,7 +81,14 @@ func (idx *GenericIndex) Train(vectors []float32) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
+	if err := checkTrainingSize(n, idx.trainingMinimum()); err != nil {
+		return err
+	}
 
 	timer := StartTimer()
 	if err := faissIndexTrain(idx.ptr, vectors, n); err != nil {
@@ -89,9 +100,55 @@ func (idx *GenericIndex) Train(vectors []float32) error {
 	return nil
 }
 
+// trainingMinimum returns the number of vectors Train requires (see
+// TrainingSizeError), read from the FAISS object
+//
+// IVF indexes built on a SharedQuantizer skip the IVF minimum, since their
+// coarse quantizer is already trained.
+func (idx *GenericIndex) trainingMinimum() int {
+	return indexTrainingMinimum(idx.ptr, idx.shared == nil)
+}
+
+// indexTrainingMinimum returns the number of training vectors Train requires
+// for the index at ptr: 30*nlist for its coarse quantizer (if trainQuantizer
+// is set) and 39*2^nbits for its product quantizer
+func indexTrainingMinimum(ptr uintptr, trainQuantizer bool) int {
+	minN := 0
+	if trainQuantizer {
+		if nlist, err := faissIndexIVFGetNlist(ptr); err == nil {
+			minN = ivfTrainingMinimum(nlist)
+		}
+	}
+	if _, nbits, err := faissIndexPQParams(ptr); err == nil && nbits < 31 {
+		minN = max(minN, 39<<nbits)
+	}
+	return minN
+}
+
+// TrainSample trains the index on a random subset of vectors
+//
+// sampleFraction must be in (0, 1]. The sample is never smaller than
+// RecommendedTrainingSize (or the whole input, if that is smaller), so
+// k-means gets enough points per centroid. See IndexIVFFlat.TrainSample.
+func (idx *GenericIndex) TrainSample(vectors []float32, sampleFraction float64) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	minN := RecommendedTrainingSize(idx)
+	sample, err := sampleTrainingVectors(vectors, idx.d, sampleFraction, minN)
+	if err != nil {
+		return err
+	}
+	return idx.Train(sample)
+}
+
 // Add adds vectors to the index
 //
 // For indexes that require training, Train() must be called first.
+//
+// Vectors get sequential IDs starting at Ntotal(). For IDMap indexes (e.g.
+// "IDMap,Flat") those IDs are assigned here, matching IndexIDMap.Add; use
+// AddWithIDs to choose them.
 func (idx *GenericIndex) Add(vectors []float32) error {
 	if idx.ptr == 0 {
 		return ErrNullPointer
@@ -103,8 +160,25 @@ func (idx *GenericIndex) Add(vectors []float32) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+	if err := validateNormalized(idx.normGuard, vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
 
+	// FAISS refuses plain add on IDMap indexes
+	if faissIndexIsIDMap(idx.ptr) {
+		ids := make([]int64, n)
+		start := faissIndexNtotal(idx.ptr)
+		for i := range ids {
+			ids[i] = start + int64(i)
+		}
+		return idx.AddWithIDs(vectors, ids)
+	}
+
 	timer := StartTimer()
 	if err := faissIndexAdd(idx.ptr, vectors, n); err != nil {
 		return fmt.Errorf("add failed: %w", err)
@@ -115,6 +189,60 @@ func (idx *GenericIndex) Add(vectors []float32) error {
 	return nil
 }
 
+// AddWithIDs adds vectors with custom IDs, which Search then returns
+//
+// Only indexes that store IDs support this: IDMap-wrapped indexes (e.g.
+// "IDMap,HNSW32") and IVF indexes. Other indexes, such as Flat or HNSW,
+// return an error; prefix their description with "IDMap," instead.
+//
+// Example:
+//
+//	index, _ := faiss.IndexFactory(128, "IDMap,HNSW32", faiss.MetricL2)
+//	index.(*faiss.GenericIndex).AddWithIDs(vectors, []int64{1001, 1002})
+func (idx *GenericIndex) AddWithIDs(vectors []float32, ids []int64) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if len(vectors) == 0 {
+		return nil
+	}
+	if len(vectors)%idx.d != 0 {
+		return ErrInvalidVectors
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+	if err := validateNormalized(idx.normGuard, vectors, idx.d); err != nil {
+		return err
+	}
+
+	n := len(vectors) / idx.d
+	if len(ids) != n {
+		return fmt.Errorf("faiss: number of IDs (%d) must match number of vectors (%d)", len(ids), n)
+	}
+
+	timer := StartTimer()
+	if err := faissIndexAddWithIDs(idx.ptr, vectors, ids, n); err != nil {
+		return fmt.Errorf("faiss: add with IDs failed (the index may not store IDs): %w", err)
+	}
+	timer.RecordAdd(n)
+
+	idx.ntotal += int64(n)
+	return nil
+}
+
+// AddSequential adds vectors with the IDs startID, startID+1, ... and returns
+// the next unused ID (indexes that store IDs only, see AddWithIDs)
+//
+// See IndexIDMap.AddSequential.
+func (idx *GenericIndex) AddSequential(vectors []float32, startID int64) (nextID int64, err error) {
+	if idx.ptr == 0 {
+		return startID, ErrNullPointer
+	}
+	return addSequential(idx.AddWithIDs, vectors, idx.d, startID)
+}
+
 // Search performs k-nearest neighbor search
 //
 // Parameters:
@@ -139,6 +267,13 @@ func (idx *GenericIndex) Search(queries []float32, k int) (distances []float32,
 		return nil, nil, ErrInvalidK
 	}
 
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
 	nq := len(queries) / idx.d
 	distances = make([]float32, nq*k)
 	labels = make([]int64, nq*k)
@@ -152,7 +287,83 @@ func (idx *GenericIndex) Search(queries []float32, k int) (distances []float32,
 	return distances, labels, nil
 }
 
+// SearchWithTimeout searches with increasing effort until timeout runs out
+// and returns the best results obtained in time
+//
+// IVF indexes are searched with nprobe 1, 2, 4, ... up to nlist and HNSW
+// indexes with efSearch k, 2k, 4k, ... up to Ntotal(); the results of the
+// last round that finished are returned. The first round always completes,
+// so results are returned for any timeout. Neither nprobe nor efSearch of the
+// index is changed. Other index types have no effort parameter and are
+// searched once, as with Search. See IndexIVFFlat.SearchWithTimeout.
+func (idx *GenericIndex) SearchWithTimeout(queries []float32, k int, timeout time.Duration) (distances []float32, labels []int64, err error) {
+	if idx.ptr == 0 {
+		return nil, nil, ErrNullPointer
+	}
+	if len(queries) == 0 {
+		return []float32{}, []int64{}, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
+	nq := len(queries) / idx.d
+	nlist, ivfErr := faissIndexIVFGetNlist(idx.ptr)
+	_, hnswErr := faissIndexHNSWGetEfSearch(idx.ptr)
+	switch {
+	case ivfErr == nil:
+		distances, labels, err = searchProgressive(timeout, 1, nlist, nq, k, func(nprobe int, distances []float32, labels []int64) error {
+			return faissIndexIVFSearchWithNprobe(idx.ptr, queries, nq, k, nprobe, distances, labels)
+		})
+	case hnswErr == nil:
+		bounded, queueErr := faissIndexHNSWSearchBoundedQueue(idx.ptr)
+		if queueErr != nil {
+			return nil, nil, fmt.Errorf("search failed: %w", queueErr)
+		}
+		limit := max(k, int(idx.Ntotal()))
+		distances, labels, err = searchProgressive(timeout, k, limit, nq, k, func(efSearch int, distances []float32, labels []int64) error {
+			return faissIndexHNSWSearchWithQueue(idx.ptr, queries, nq, k, efSearch, bounded, distances, labels)
+		})
+	default:
+		return idx.Search(queries, k)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("search failed: %w", err)
+	}
+	return distances, labels, nil
+}
+
+// Warmup performs a throwaway search so that one-time start-up costs are not
+// paid by the first real query.
+//
+// FAISS starts its OpenMP thread pool and allocates per-thread scratch
+// buffers lazily, which can make the first search (notably on IVFPQ indexes)
+// several times slower than the following ones. Call Warmup once during
+// service start-up, after the index has been trained and loaded.
+func (idx *GenericIndex) Warmup() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if !idx.IsTrained() {
+		return ErrNotTrained
+	}
+	return warmupIndex(idx.ptr, idx.d)
+}
+
 // Reset removes all vectors from the index
+//
+// Only the stored vectors are dropped; training state (IVF centroids, PQ/SQ
+// codebooks) is kept and IsTrained() stays true, so the index can be refilled
+// with Add without calling Train again.
 func (idx *GenericIndex) Reset() error {
 	if idx.ptr == 0 {
 		return ErrNullPointer
@@ -178,6 +389,12 @@ func (idx *GenericIndex) Close() error {
 	idx.ptr = 0
 	idx.ntotal = 0
 
+	// The quantizer may only be freed after the index that uses it
+	if idx.shared != nil {
+		idx.shared.release()
+		idx.shared = nil
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to free index: %w", err)
 	}
@@ -211,6 +428,60 @@ func (idx *GenericIndex) SetNprobe(nprobe int) error {
 	return nil
 }
 
+// SetClusteringParams sets the k-means parameters used by Train (IVF and PQ indexes only)
+//
+// niter is the number of k-means iterations per run and nredo the number of
+// runs; the run with the lowest objective is kept. On noisy data nredo > 1
+// gives better centroids and recall, at the cost of proportionally longer
+// training. They apply to the coarse quantizer of IVF indexes and to the
+// codebooks of PQ and IVFPQ indexes. Must be called before Train.
+//
+// Returns an error if called on other index types.
+func (idx *GenericIndex) SetClusteringParams(niter, nredo int) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if niter <= 0 || nredo <= 0 {
+		return fmt.Errorf("faiss: niter and nredo must be positive (got %d, %d)", niter, nredo)
+	}
+	if idx.IsTrained() {
+		return fmt.Errorf("faiss: clustering parameters must be set before training")
+	}
+	if err := faissIndexSetClusteringParams(idx.ptr, niter, nredo); err != nil {
+		return fmt.Errorf("failed to set clustering parameters: %w", err)
+	}
+	return nil
+}
+
+// SetMaxCodes caps the total number of codes scanned per query (IVF indexes only)
+//
+// Even with a fixed nprobe, a few dense inverted lists can make search latency
+// unpredictable. With max_codes set, FAISS stops visiting lists once that many
+// codes have been scanned, trading some recall on dense queries for a bounded
+// worst case. A value of 0 removes the limit (the default).
+//
+// Returns an error if called on non-IVF indexes.
+//
+// Example:
+//
+//	index, _ := faiss.IndexFactory(128, "IVF1024,PQ16", faiss.MetricL2)
+//	index.SetNprobe(32)
+//	index.SetMaxCodes(20000) // Never scan more than 20k codes per query
+func (idx *GenericIndex) SetMaxCodes(maxCodes int) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if maxCodes < 0 {
+		return fmt.Errorf("max_codes must be non-negative")
+	}
+
+	if err := faissSetIndexParameter(idx.ptr, "max_codes", float64(maxCodes)); err != nil {
+		return fmt.Errorf("failed to set max_codes (index may not be IVF-based): %w", err)
+	}
+
+	return nil
+}
+
 // GetNprobe gets the number of lists to probe during search (IVF indexes only)
 func (idx *GenericIndex) GetNprobe() (int, error) {
 	if idx.ptr == 0 {
@@ -225,6 +496,57 @@ func (idx *GenericIndex) GetNprobe() (int, error) {
 	return nprobe, nil
 }
 
+// SetParallelMode chooses how searches are parallelized (IVF indexes only)
+//
+// See IndexIVFFlat.SetParallelMode and the IVFParallel* constants. Returns an
+// error if called on non-IVF indexes.
+//
+// Example:
+//
+//	index, _ := faiss.IndexFactory(128, "IVF1024,PQ16", faiss.MetricL2)
+//	index.SetParallelMode(faiss.IVFParallelLists) // few, large query batches
+func (idx *GenericIndex) SetParallelMode(mode int) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if err := validateParallelMode(mode); err != nil {
+		return err
+	}
+
+	if err := faissIndexIVFSetParallelMode(idx.ptr, mode); err != nil {
+		return fmt.Errorf("failed to set parallel mode (index may not be IVF-based): %w", err)
+	}
+
+	return nil
+}
+
+// GetParallelMode gets the search parallel mode (IVF indexes only)
+func (idx *GenericIndex) GetParallelMode() (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+
+	mode, err := faissIndexIVFGetParallelMode(idx.ptr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get parallel mode (index may not be IVF-based): %w", err)
+	}
+
+	return mode, nil
+}
+
+// SearchExplain searches a single query and also returns the inverted lists it probed (IVF indexes only)
+//
+// See IndexIVFFlat.SearchExplain. Returns an error if called on non-IVF indexes.
+func (idx *GenericIndex) SearchExplain(query []float32, k int) (result SearchResult, probedLists []int64, err error) {
+	if idx.ptr == 0 {
+		return SearchResult{}, nil, ErrNullPointer
+	}
+	if !idx.IsTrained() {
+		return SearchResult{}, nil, ErrNotTrained
+	}
+	return searchExplainIVF(idx, idx.ptr, query, k, idx.normGuard)
+}
+
 // SetEfSearch sets the search-time effort parameter for HNSW indexes.
 //
 // The efSearch parameter controls how many nodes are visited during search.
@@ -249,9 +571,108 @@ func (idx *GenericIndex) SetEfSearch(efSearch int) error {
 	return faissIndexHNSWSetEfSearch(idx.ptr, efSearch)
 }
 
-// Description returns the factory description string used to create this index
+// SetHNSWSearchBoundedQueue selects the candidate queue used by HNSW search
+//
+// With a bounded queue (the FAISS default) the search keeps at most efSearch
+// candidates, which gives the lowest latency. An unbounded queue keeps every
+// candidate it visits, which can improve recall for high-recall settings at
+// the cost of more work per query.
+//
+// The setting is stored on the FAISS index, so every search path (Search,
+// RangeSearch, SearchExplain, ...) uses it. It is not saved with the index.
+//
+// Note: This only works for HNSW indexes. Returns error for other index types.
+//
+// Example:
+//
+//	index, _ := faiss.NewIndexHNSWFlat(128, 32, faiss.MetricL2)
+//	index.(*faiss.GenericIndex).SetHNSWSearchBoundedQueue(false)
+func (idx *GenericIndex) SetHNSWSearchBoundedQueue(bounded bool) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if err := faissIndexHNSWSetSearchBoundedQueue(idx.ptr, bounded); err != nil {
+		return fmt.Errorf("faiss: search_bounded_queue requires an HNSW index: %w", err)
+	}
+	return nil
+}
+
+// HNSWSearchBoundedQueue reports whether HNSW search uses a bounded queue
+//
+// It returns true, the FAISS default, for closed and non-HNSW indexes.
+func (idx *GenericIndex) HNSWSearchBoundedQueue() bool {
+	if idx.ptr == 0 {
+		return true
+	}
+	bounded, err := faissIndexHNSWSearchBoundedQueue(idx.ptr)
+	return err != nil || bounded
+}
+
+// SetRequireNormalized makes Add and Search reject vectors whose L2 norm is
+// not ~1.0 (inner product indexes only); see IndexFlat.SetRequireNormalized
+//
+// Indexes built with MetricCosine normalize their input themselves and
+// report MetricInnerProduct, so the guard is accepted but rarely useful there.
+func (idx *GenericIndex) SetRequireNormalized(require bool) error {
+	if err := checkRequireNormalized(require, idx.metric); err != nil {
+		return err
+	}
+	idx.normGuard = require
+	return nil
+}
+
+// RequireNormalized reports whether Add and Search reject un-normalized vectors
+func (idx *GenericIndex) RequireNormalized() bool {
+	return idx.normGuard
+}
+
+// Description returns the factory description string used to create this
+// index
+//
+// Indexes loaded with ReadIndexFromFile or DeserializeIndex have no factory
+// string, so their FAISS class name (see ClassName) is returned instead.
 func (idx *GenericIndex) Description() string {
-	return idx.description
+	if idx.description != "" {
+		return idx.description
+	}
+	return idx.ClassName()
+}
+
+// ClassName returns the FAISS class of the underlying object, such as
+// "IndexIVFPQ", "IndexHNSWFlat" or "IndexPreTransform"
+//
+// The name is read from the object's runtime type, so it is accurate for
+// composite and loaded indexes. It returns "" if the index is closed.
+func (idx *GenericIndex) ClassName() string {
+	if idx.ptr == 0 {
+		return ""
+	}
+	name, err := indexClassName(idx.ptr)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// indexClassAliases maps C++ template instantiations to the class names
+// FAISS exposes in Python
+var indexClassAliases = map[string]string{
+	"IndexIDMapTemplate<faiss::Index>":        "IndexIDMap",
+	"IndexIDMap2Template<faiss::Index>":       "IndexIDMap2",
+	"IndexIDMapTemplate<faiss::IndexBinary>":  "IndexBinaryIDMap",
+	"IndexIDMap2Template<faiss::IndexBinary>": "IndexBinaryIDMap2",
+}
+
+// indexClassName returns the FAISS class name of the index at ptr
+func indexClassName(ptr uintptr) (string, error) {
+	name, err := faissIndexClassName(ptr)
+	if err != nil {
+		return "", err
+	}
+	if alias, ok := indexClassAliases[name]; ok {
+		return alias, nil
+	}
+	return name, nil
 }
 
 // WriteToFile writes the index to a file
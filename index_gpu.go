@@ -23,12 +23,79 @@ import (
 //   index.Add(vectors)
 //   distances, indices, _ := index.Search(queries, 10)
 type GpuIndexFlat struct {
-	ptr       uintptr
-	resources *StandardGpuResources
-	deviceID  int
-	d         int
-	metric    MetricType
-	ntotal    int64
+	ptr        uintptr
+	resources  *StandardGpuResources
+	deviceID   int
+	d          int
+	metric     MetricType
+	ntotal     int64
+	useFloat16 bool
+
+	// addChunkSize is the number of vectors copied to the device per
+	// add call; 0 means gpuAddChunkBytes worth of vectors
+	addChunkSize int
+
+	mirror cpuMirror
+}
+
+// gpuAddChunkBytes bounds the host data handed to a single GPU add by default
+const gpuAddChunkBytes = 256 << 20
+
+// cpuMirror keeps a host-side copy of the vectors added to a GPU index, which
+// cannot reconstruct them itself
+type cpuMirror struct {
+	enabled bool
+	vectors []float32
+}
+
+// enable turns the mirror on; vectors already on the device can't be copied
+// back, so the index must be empty
+func (m *cpuMirror) enable(ntotal int64) error {
+	if ntotal > 0 {
+		return fmt.Errorf("faiss: the CPU mirror must be enabled before adding vectors (index holds %d)", ntotal)
+	}
+	m.enabled = true
+	return nil
+}
+
+func (m *cpuMirror) add(vectors []float32) {
+	if m.enabled {
+		m.vectors = append(m.vectors, vectors...)
+	}
+}
+
+func (m *cpuMirror) reset() {
+	m.vectors = nil
+}
+
+// reconstruct copies the mirrored vectors for keys into a new slice; every
+// key must be in range
+func (m *cpuMirror) reconstruct(keys []int64, d int) ([]float32, error) {
+	return m.copyVectors(keys, d, false)
+}
+
+// reconstructResults copies the mirrored vectors for search results into a
+// new slice, leaving zeros for missing results (label -1)
+func (m *cpuMirror) reconstructResults(labels []int64, d int) ([]float32, error) {
+	return m.copyVectors(labels, d, true)
+}
+
+func (m *cpuMirror) copyVectors(labels []int64, d int, skipMissing bool) ([]float32, error) {
+	if !m.enabled {
+		return nil, fmt.Errorf("faiss: reconstruction needs the CPU mirror (EnableCPUMirror): %w", ErrNotSupportedOnGPU)
+	}
+	n := int64(len(m.vectors) / d)
+	recons := make([]float32, len(labels)*d)
+	for i, label := range labels {
+		if label == -1 && skipMissing {
+			continue
+		}
+		if label < 0 || label >= n {
+			return nil, fmt.Errorf("faiss: key %d out of range [0, %d)", label, n)
+		}
+		copy(recons[i*d:(i+1)*d], m.vectors[label*int64(d):(label+1)*int64(d)])
+	}
+	return recons, nil
 }
 
 // Ensure GpuIndexFlat implements Index
@@ -44,6 +111,75 @@ func NewGpuIndexFlatIP(res *StandardGpuResources, d, device int) (*GpuIndexFlat,
 	return newGpuIndexFlat(res, d, device, MetricInnerProduct)
 }
 
+// GpuFlatConfig configures vector storage for GPU flat indexes
+//
+// Python equivalent: faiss.GpuIndexFlatConfig
+type GpuFlatConfig struct {
+	// UseFloat16 stores vectors as float16, halving VRAM usage at the cost of
+	// a small loss of precision in the computed distances
+	UseFloat16 bool
+}
+
+// NewGpuIndexFlatL2Config creates a GPU flat L2 index with the given config
+//
+// Example:
+//   res, _ := faiss.NewStandardGpuResources()
+//   index, _ := faiss.NewGpuIndexFlatL2Config(res, 128, 0, faiss.GpuFlatConfig{UseFloat16: true})
+func NewGpuIndexFlatL2Config(res *StandardGpuResources, d, device int, config GpuFlatConfig) (*GpuIndexFlat, error) {
+	return newGpuIndexFlatConfig(res, d, device, MetricL2, config)
+}
+
+// NewGpuIndexFlatIPConfig creates a GPU flat inner product index with the given config
+func NewGpuIndexFlatIPConfig(res *StandardGpuResources, d, device int, config GpuFlatConfig) (*GpuIndexFlat, error) {
+	return newGpuIndexFlatConfig(res, d, device, MetricInnerProduct, config)
+}
+
+func newGpuIndexFlatConfig(res *StandardGpuResources, d, device int, metric MetricType, config GpuFlatConfig) (*GpuIndexFlat, error) {
+	if !config.UseFloat16 {
+		return newGpuIndexFlat(res, d, device, metric)
+	}
+	if res == nil {
+		return nil, fmt.Errorf("GPU resources cannot be nil")
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("dimension must be positive")
+	}
+
+	// The C API only exposes float16 storage through the cloner options, so
+	// build an empty CPU flat index and clone it with useFloat16 set
+	cpuPtr, err := faissIndexFlatNew(d, int(metric))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPU flat index: %w", err)
+	}
+	defer faiss_Index_free(cpuPtr)
+
+	var ptr uintptr
+	if err := faiss_index_cpu_to_gpu_with_float16(res.ptr, device, cpuPtr, true, &ptr); err != nil {
+		return nil, fmt.Errorf("failed to create GPU flat index: %w", err)
+	}
+
+	idx := &GpuIndexFlat{
+		ptr:        ptr,
+		resources:  res,
+		deviceID:   device,
+		d:          d,
+		metric:     metric,
+		ntotal:     0,
+		useFloat16: true,
+	}
+
+	runtime.SetFinalizer(idx, func(idx *GpuIndexFlat) {
+		idx.Close()
+	})
+
+	return idx, nil
+}
+
+// UseFloat16 reports whether vectors are stored as float16
+func (idx *GpuIndexFlat) UseFloat16() bool {
+	return idx.useFloat16
+}
+
 func newGpuIndexFlat(res *StandardGpuResources, d, device int, metric MetricType) (*GpuIndexFlat, error) {
 	if res == nil {
 		return nil, fmt.Errorf("GPU resources cannot be nil")
@@ -107,26 +243,62 @@ func (idx *GpuIndexFlat) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
 	}
 
-	n := int64(len(vectors) / idx.d)
-	ret := faiss_Index_add(idx.ptr, n, &vectors[0])
-	if ret != 0 {
-		return fmt.Errorf("add failed")
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
-	idx.ntotal += n
+	// Stream the vectors in bounded chunks so the device never needs room
+	// for the whole batch plus temporary memory at once
+	chunk := idx.AddChunkSize() * idx.d
+	for start := 0; start < len(vectors); start += chunk {
+		end := min(start+chunk, len(vectors))
+		n := int64((end - start) / idx.d)
+		ret := faiss_Index_add(idx.ptr, n, &vectors[start])
+		if ret != 0 {
+			return fmt.Errorf("add failed after %d of %d vectors", start/idx.d, len(vectors)/idx.d)
+		}
+		idx.mirror.add(vectors[start:end])
+		idx.ntotal += n
+	}
 	return nil
 }
 
+// SetAddChunkSize sets how many vectors Add copies to the device at a time
+//
+// Large adds are split into chunks of this size so VRAM usage stays bounded
+// regardless of the batch passed to Add. The default is 256MB worth of
+// vectors; smaller chunks lower the peak memory at the cost of more
+// host-to-device transfers.
+func (idx *GpuIndexFlat) SetAddChunkSize(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("add chunk size must be positive")
+	}
+	idx.addChunkSize = n
+	return nil
+}
+
+// AddChunkSize returns how many vectors Add copies to the device at a time
+func (idx *GpuIndexFlat) AddChunkSize() int {
+	if idx.addChunkSize > 0 {
+		return idx.addChunkSize
+	}
+	return max(1, gpuAddChunkBytes/(4*idx.d))
+}
+
 // Search performs k-NN search on GPU
 func (idx *GpuIndexFlat) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
 	if len(queries) == 0 {
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -141,6 +313,38 @@ func (idx *GpuIndexFlat) Search(queries []float32, k int) (distances []float32,
 	return distances, indices, nil
 }
 
+// EnableCPUMirror keeps a host-side copy of every vector added from now on,
+// so SearchAndReconstruct can return neighbor vectors
+//
+// GPU indexes cannot reconstruct vectors, so the mirror is plain Go-side
+// bookkeeping: it doubles the memory used by the vectors (once in VRAM, once
+// in host RAM). It must be enabled while the index is empty.
+func (idx *GpuIndexFlat) EnableCPUMirror() error {
+	return idx.mirror.enable(idx.Ntotal())
+}
+
+// SearchAndReconstruct searches on the GPU and also returns the neighbor
+// vectors, taken from the CPU mirror (see EnableCPUMirror)
+//
+// recons holds nq*k*d values, the vector of each result in result order;
+// missing results (label -1) are left zero.
+//
+// Python equivalent: index.search_and_reconstruct(queries, k)
+func (idx *GpuIndexFlat) SearchAndReconstruct(queries []float32, k int) (distances []float32, labels []int64, recons []float32, err error) {
+	if !idx.mirror.enabled {
+		return nil, nil, nil, fmt.Errorf("faiss: SearchAndReconstruct needs the CPU mirror (EnableCPUMirror): %w", ErrNotSupportedOnGPU)
+	}
+	distances, labels, err = idx.Search(queries, k)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	recons, err = idx.mirror.reconstructResults(labels, idx.d)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return distances, labels, recons, nil
+}
+
 // SetNprobe is not supported for GPU flat indexes (not an IVF index)
 func (idx *GpuIndexFlat) SetNprobe(nprobe int) error {
 	return fmt.Errorf("faiss: SetNprobe not supported for GpuIndexFlat (not an IVF index)")
@@ -151,12 +355,19 @@ func (idx *GpuIndexFlat) SetEfSearch(efSearch int) error {
 	return fmt.Errorf("faiss: SetEfSearch not supported for GpuIndexFlat (not an HNSW index)")
 }
 
+// CanReconstruct returns false: reconstruction is not exposed for GPU flat
+// indexes (SearchAndReconstruct works with the CPU mirror enabled)
+func (idx *GpuIndexFlat) CanReconstruct() bool {
+	return false
+}
+
 // Reset removes all vectors
 func (idx *GpuIndexFlat) Reset() error {
 	ret := faiss_Index_reset(idx.ptr)
 	if ret != 0 {
 		return fmt.Errorf("reset failed")
 	}
+	idx.mirror.reset()
 	idx.ntotal = 0
 	return nil
 }
@@ -196,6 +407,7 @@ type GpuIndexIVFFlat struct {
 	isTrained bool
 	nlist     int
 	nprobe    int
+	mirror    cpuMirror
 }
 
 // Ensure GpuIndexIVFFlat implements Index
@@ -212,6 +424,10 @@ func NewGpuIndexIVFFlat(res *StandardGpuResources, quantizer Index, d, nlist, de
 	if d <= 0 || nlist <= 0 {
 		return nil, fmt.Errorf("d and nlist must be positive")
 	}
+	if quantizer.MetricType() != metric {
+		return nil, fmt.Errorf("faiss: quantizer metric %s does not match index metric %s",
+			quantizer.MetricType(), metric)
+	}
 
 	var quantizerPtr uintptr
 	switch q := quantizer.(type) {
@@ -304,7 +520,11 @@ func (idx *GpuIndexIVFFlat) Train(vectors []float32) error {
 		return fmt.Errorf("empty training vectors")
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -330,7 +550,11 @@ func (idx *GpuIndexIVFFlat) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -339,6 +563,7 @@ func (idx *GpuIndexIVFFlat) Add(vectors []float32) error {
 		return fmt.Errorf("add failed")
 	}
 
+	idx.mirror.add(vectors)
 	idx.ntotal += n
 	return nil
 }
@@ -349,7 +574,11 @@ func (idx *GpuIndexIVFFlat) Search(queries []float32, k int) (distances []float3
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -364,6 +593,68 @@ func (idx *GpuIndexIVFFlat) Search(queries []float32, k int) (distances []float3
 	return distances, indices, nil
 }
 
+// EnableCPUMirror keeps a host-side copy of every vector added from now on,
+// so Reconstruct and SearchAndReconstruct work
+//
+// GPU IVF indexes cannot reconstruct vectors, so the mirror is plain Go-side
+// bookkeeping: it doubles the memory used by the vectors (once in VRAM, once
+// in host RAM). It must be enabled while the index is empty.
+func (idx *GpuIndexIVFFlat) EnableCPUMirror() error {
+	return idx.mirror.enable(idx.Ntotal())
+}
+
+// SearchAndReconstruct searches on the GPU and also returns the neighbor
+// vectors, taken from the CPU mirror (see EnableCPUMirror)
+//
+// recons holds nq*k*d values, the vector of each result in result order;
+// missing results (label -1) are left zero.
+//
+// Python equivalent: index.search_and_reconstruct(queries, k)
+func (idx *GpuIndexIVFFlat) SearchAndReconstruct(queries []float32, k int) (distances []float32, labels []int64, recons []float32, err error) {
+	if !idx.mirror.enabled {
+		return nil, nil, nil, fmt.Errorf("faiss: SearchAndReconstruct needs the CPU mirror (EnableCPUMirror): %w", ErrNotSupportedOnGPU)
+	}
+	distances, labels, err = idx.Search(queries, k)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	recons, err = idx.mirror.reconstructResults(labels, idx.d)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return distances, labels, recons, nil
+}
+
+// CanReconstruct reports whether the CPU mirror is enabled: GPU IVF indexes
+// do not maintain the direct map needed to locate vectors in the inverted
+// lists, so without the mirror reconstruction returns ErrNotSupportedOnGPU.
+// Copy the index back with IndexGpuToCpu, or see EnableCPUMirror.
+func (idx *GpuIndexIVFFlat) CanReconstruct() bool {
+	return idx.mirror.enabled
+}
+
+// Reconstruct returns a vector from the CPU mirror
+func (idx *GpuIndexIVFFlat) Reconstruct(key int64) ([]float32, error) {
+	return idx.mirror.reconstruct([]int64{key}, idx.d)
+}
+
+// ReconstructN returns n consecutive vectors from the CPU mirror
+func (idx *GpuIndexIVFFlat) ReconstructN(i0, n int64) ([]float32, error) {
+	if i0 < 0 || n < 0 {
+		return nil, fmt.Errorf("faiss: invalid range [%d, %d)", i0, i0+n)
+	}
+	keys := make([]int64, n)
+	for i := range keys {
+		keys[i] = i0 + int64(i)
+	}
+	return idx.mirror.reconstruct(keys, idx.d)
+}
+
+// ReconstructBatch returns the vectors for keys from the CPU mirror
+func (idx *GpuIndexIVFFlat) ReconstructBatch(keys []int64) ([]float32, error) {
+	return idx.mirror.reconstruct(keys, idx.d)
+}
+
 // SetEfSearch is not supported for GPU IVF indexes (not an HNSW index)
 func (idx *GpuIndexIVFFlat) SetEfSearch(efSearch int) error {
 	return fmt.Errorf("faiss: SetEfSearch not supported for GpuIndexIVFFlat (not an HNSW index)")
@@ -375,6 +666,7 @@ func (idx *GpuIndexIVFFlat) Reset() error {
 	if ret != 0 {
 		return fmt.Errorf("reset failed")
 	}
+	idx.mirror.reset()
 	idx.ntotal = 0
 	return nil
 }
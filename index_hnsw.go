@@ -59,3 +59,132 @@ func NewIndexHNSWFlat(d, M int, metric MetricType) (Index, error) {
 func NewIndexHNSW(d, M int, metric MetricType) (Index, error) {
 	return NewIndexHNSWFlat(d, M, metric)
 }
+
+// ========================================
+// HNSW graph inspection
+// ========================================
+
+// HNSWGraph is a snapshot of the link structure of an HNSW index
+//
+// Node i lives on layers 0..Level(i); layer 0 holds every node with up to 2*M
+// neighbors each, and the upper layers hold exponentially fewer nodes with up
+// to M neighbors each.
+type HNSWGraph struct {
+	levels       []int32  // number of layers per node (top layer + 1)
+	offsets      []uint64 // start of each node's neighbor slots
+	cumNeighbors []int32  // cumulative neighbor slots per layer
+	neighbors    []int32  // neighbor slots, -1 marks unused slots
+	entryPoint   int32
+	maxLevel     int32
+}
+
+// Ntotal returns the number of nodes in the graph
+func (g *HNSWGraph) Ntotal() int64 {
+	return int64(len(g.levels))
+}
+
+// EntryPoint returns the node where every search starts
+func (g *HNSWGraph) EntryPoint() int64 {
+	return int64(g.entryPoint)
+}
+
+// MaxLevel returns the highest layer of the graph
+func (g *HNSWGraph) MaxLevel() int {
+	return int(g.maxLevel)
+}
+
+// Level returns the highest layer that node belongs to (0 = bottom layer only)
+func (g *HNSWGraph) Level(node int64) (int, error) {
+	if node < 0 || node >= int64(len(g.levels)) {
+		return 0, fmt.Errorf("faiss: node %d out of range [0, %d)", node, len(g.levels))
+	}
+	return int(g.levels[node]) - 1, nil
+}
+
+// Neighbors returns the neighbors of node on the given layer
+func (g *HNSWGraph) Neighbors(node int64, level int) ([]int64, error) {
+	top, err := g.Level(node)
+	if err != nil {
+		return nil, err
+	}
+	if level < 0 || level > top {
+		return nil, fmt.Errorf("faiss: node %d has no layer %d (top layer is %d)", node, level, top)
+	}
+
+	begin := g.offsets[node] + uint64(g.cumNeighbors[level])
+	end := g.offsets[node] + uint64(g.cumNeighbors[level+1])
+	if end > uint64(len(g.neighbors)) {
+		return nil, fmt.Errorf("faiss: corrupt HNSW graph at node %d", node)
+	}
+
+	result := make([]int64, 0, end-begin)
+	for _, nb := range g.neighbors[begin:end] {
+		if nb < 0 {
+			break
+		}
+		result = append(result, int64(nb))
+	}
+	return result, nil
+}
+
+// HNSWGraph returns a snapshot of the graph of an HNSW index
+//
+// The link structure is copied, so the snapshot stays valid while the index
+// keeps growing; the vectors are not. Cosine HNSW indexes keep the graph
+// behind a normalization pre-transform, which is looked through.
+func (idx *GenericIndex) HNSWGraph() (*HNSWGraph, error) {
+	if idx.ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	return faissIndexHNSWGraph(idx.ptr)
+}
+
+// GetHNSWNeighbors returns the neighbors of node on the given layer of an
+// HNSW index
+//
+// The neighbors are read directly from the live graph.
+func (idx *GenericIndex) GetHNSWNeighbors(node int64, level int) ([]int64, error) {
+	if idx.ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	top, err := idx.GetHNSWLevel(node)
+	if err != nil {
+		return nil, err
+	}
+	if level < 0 || level > top {
+		return nil, fmt.Errorf("faiss: node %d has no layer %d (top layer is %d)", node, level, top)
+	}
+	return faissIndexHNSWNeighbors(idx.ptr, node, level)
+}
+
+// GetHNSWLevel returns the highest layer that node belongs to in an HNSW
+// index (0 = bottom layer only)
+func (idx *GenericIndex) GetHNSWLevel(node int64) (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+	return faissIndexHNSWNodeLevel(idx.ptr, node)
+}
+
+// GetHNSWEntryPoint returns the node where every search of an HNSW index
+// starts, or -1 for an empty index
+//
+// FAISS serializes the entry point with the graph, so it survives
+// WriteIndexToFile/ReadIndexFromFile and a reloaded index can keep growing.
+func (idx *GenericIndex) GetHNSWEntryPoint() (int64, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+	entry, _, err := faissIndexHNSWEntryPoint(idx.ptr)
+	return entry, err
+}
+
+// GetHNSWMaxLevel returns the layer every search of an HNSW index starts
+// from, or -1 for an empty index
+func (idx *GenericIndex) GetHNSWMaxLevel() (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+	_, maxLevel, err := faissIndexHNSWEntryPoint(idx.ptr)
+	return maxLevel, err
+}
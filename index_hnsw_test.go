@@ -1,7 +1,10 @@
 package faiss
 
 import (
+	"math"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewIndexHNSWFlat(t *testing.T) {
@@ -227,3 +230,391 @@ func TestIndexHNSW_DifferentMValues(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexHNSW_SetSearchBoundedQueue(t *testing.T) {
+	d := 16
+	nb := 2000
+	nq := 50
+	k := 10
+
+	index, err := NewIndexHNSWFlat(d, 16, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexHNSWFlat() failed: %v", err)
+	}
+	defer index.Close()
+	hnsw := index.(*GenericIndex)
+
+	vectors := generateVectors(nb, d)
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetEfSearch(64)
+	queries := vectors[:nq*d]
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, _ := flat.Search(queries, k)
+
+	if !hnsw.HNSWSearchBoundedQueue() {
+		t.Error("HNSWSearchBoundedQueue() = false, want true by default")
+	}
+
+	for _, bounded := range []bool{true, false} {
+		if err := hnsw.SetHNSWSearchBoundedQueue(bounded); err != nil {
+			t.Fatalf("SetHNSWSearchBoundedQueue(%v) failed: %v", bounded, err)
+		}
+		if hnsw.HNSWSearchBoundedQueue() != bounded {
+			t.Errorf("HNSWSearchBoundedQueue() = %v, want %v", hnsw.HNSWSearchBoundedQueue(), bounded)
+		}
+
+		distances, labels, err := index.Search(queries, k)
+		if err != nil {
+			t.Fatalf("Search(bounded=%v) failed: %v", bounded, err)
+		}
+		for q := 0; q < nq; q++ {
+			if labels[q*k] != int64(q) {
+				t.Errorf("bounded=%v: query %d nearest = %d, want %d", bounded, q, labels[q*k], q)
+			}
+			for j := 1; j < k; j++ {
+				if distances[q*k+j] < distances[q*k+j-1] {
+					t.Errorf("bounded=%v: query %d distances not sorted", bounded, q)
+					break
+				}
+			}
+		}
+
+		// The mode is set on the FAISS index, so a plain search matches one
+		// that passes it explicitly
+		wantDistances := make([]float32, nq*k)
+		wantLabels := make([]int64, nq*k)
+		if err := faissIndexHNSWSearchWithQueue(hnsw.ptr, queries, nq, k, 64, bounded, wantDistances, wantLabels); err != nil {
+			t.Fatalf("search with explicit queue mode failed: %v", err)
+		}
+		for i := range labels {
+			if labels[i] != wantLabels[i] {
+				t.Fatalf("bounded=%v: Search() label %d = %d, want %d", bounded, i, labels[i], wantLabels[i])
+			}
+		}
+
+		recall := ComputeRecall(groundTruth, labels, nq, k, k)
+		t.Logf("bounded=%v: recall@%d = %.3f", bounded, k, recall)
+		if recall < 0.8 {
+			t.Errorf("bounded=%v: recall = %.3f, want >= 0.8", bounded, recall)
+		}
+	}
+
+	ivf, _ := IndexFactory(d, "IVF4,Flat", MetricL2)
+	defer ivf.Close()
+	if err := ivf.(*GenericIndex).SetHNSWSearchBoundedQueue(false); err == nil {
+		t.Error("SetHNSWSearchBoundedQueue() on IVF index should return error")
+	}
+}
+
+func TestIndexHNSW_SearchWithTimeout(t *testing.T) {
+	d := 16
+	nb := 2000
+	nq := 20
+	k := 10
+
+	index, err := NewIndexHNSWFlat(d, 16, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexHNSWFlat() failed: %v", err)
+	}
+	defer index.Close()
+	hnsw := index.(*GenericIndex)
+	hnsw.Add(generateVectors(nb, d))
+	hnsw.SetEfSearch(16)
+	queries := generateVectors(nq, d)
+
+	for _, timeout := range []time.Duration{time.Nanosecond, time.Minute} {
+		distances, labels, err := hnsw.SearchWithTimeout(queries, k, timeout)
+		if err != nil {
+			t.Fatalf("SearchWithTimeout(%v) failed: %v", timeout, err)
+		}
+		if len(distances) != nq*k || len(labels) != nq*k {
+			t.Fatalf("SearchWithTimeout(%v) returned %d distances, %d labels, want %d", timeout, len(distances), len(labels), nq*k)
+		}
+	}
+
+	if ef, _ := faissIndexHNSWGetEfSearch(hnsw.ptr); ef != 16 {
+		t.Errorf("efSearch = %d after SearchWithTimeout, want 16 (unchanged)", ef)
+	}
+}
+
+func TestIndexHNSW_Graph(t *testing.T) {
+	d := 8
+	M := 8
+	nb := 500
+
+	index, err := NewIndexHNSWFlat(d, M, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexHNSWFlat() failed: %v", err)
+	}
+	defer index.Close()
+	hnsw := index.(*GenericIndex)
+	index.Add(generateVectors(nb, d))
+
+	graph, err := hnsw.HNSWGraph()
+	if err != nil {
+		t.Fatalf("HNSWGraph() failed: %v", err)
+	}
+	if graph.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", graph.Ntotal(), nb)
+	}
+
+	// Layer 0 links every node to at most 2*M neighbors, upper layers to M
+	for node := int64(0); node < int64(nb); node++ {
+		level, err := graph.Level(node)
+		if err != nil {
+			t.Fatalf("Level(%d) failed: %v", node, err)
+		}
+		if level < 0 || level > graph.MaxLevel() {
+			t.Fatalf("Level(%d) = %d, want within [0, %d]", node, level, graph.MaxLevel())
+		}
+		for l := 0; l <= level; l++ {
+			neighbors, err := graph.Neighbors(node, l)
+			if err != nil {
+				t.Fatalf("Neighbors(%d, %d) failed: %v", node, l, err)
+			}
+			limit := M
+			if l == 0 {
+				limit = 2 * M
+			}
+			if len(neighbors) > limit {
+				t.Errorf("Neighbors(%d, %d) has %d entries, want <= %d", node, l, len(neighbors), limit)
+			}
+			if l == 0 && len(neighbors) == 0 {
+				t.Errorf("node %d is disconnected on layer 0", node)
+			}
+			for _, neighbor := range neighbors {
+				if neighbor < 0 || neighbor >= graph.Ntotal() {
+					t.Errorf("Neighbors(%d, %d) contains invalid node %d", node, l, neighbor)
+				}
+			}
+		}
+	}
+
+	entryLevel, _ := graph.Level(graph.EntryPoint())
+	if entryLevel != graph.MaxLevel() {
+		t.Errorf("entry point level = %d, want max level %d", entryLevel, graph.MaxLevel())
+	}
+
+	// The index methods agree with the snapshot
+	want, _ := graph.Neighbors(0, 0)
+	got, err := hnsw.GetHNSWNeighbors(0, 0)
+	if err != nil {
+		t.Fatalf("GetHNSWNeighbors() failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("GetHNSWNeighbors(0, 0) = %v, want %v", got, want)
+	}
+	if _, err := hnsw.GetHNSWLevel(0); err != nil {
+		t.Errorf("GetHNSWLevel(0) failed: %v", err)
+	}
+
+	if _, err := hnsw.GetHNSWNeighbors(int64(nb), 0); err == nil {
+		t.Error("GetHNSWNeighbors() out of range should return error")
+	}
+	if _, err := hnsw.GetHNSWNeighbors(0, graph.MaxLevel()+1); err == nil {
+		t.Error("GetHNSWNeighbors() above the node's top layer should return error")
+	}
+
+	flat, _ := IndexFactory(d, "Flat", MetricL2)
+	defer flat.Close()
+	if _, err := flat.(*GenericIndex).HNSWGraph(); err == nil {
+		t.Error("HNSWGraph() on flat index should return error")
+	}
+}
+
+func TestIndexHNSW_EntryPointSurvivesReload(t *testing.T) {
+	d := 16
+	nb := 2000
+	nq := 50
+	k := 10
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+
+	index, err := NewIndexHNSWFlat(d, 16, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexHNSWFlat() failed: %v", err)
+	}
+	defer index.Close()
+	hnsw := index.(*GenericIndex)
+
+	if entry, err := hnsw.GetHNSWEntryPoint(); err != nil || entry != -1 {
+		t.Errorf("GetHNSWEntryPoint() on empty index = %d, %v, want -1", entry, err)
+	}
+
+	// Build half, save, reload, and resume the build on the reloaded index
+	index.Add(vectors[:nb/2*d])
+	tmpFile := filepath.Join(t.TempDir(), "hnsw.faiss")
+	if err := WriteIndexToFile(index, tmpFile); err != nil {
+		t.Fatalf("WriteIndexToFile() failed: %v", err)
+	}
+	loaded, err := ReadIndexFromFile(tmpFile)
+	if err != nil {
+		t.Fatalf("ReadIndexFromFile() failed: %v", err)
+	}
+	defer loaded.Close()
+	resumed := loaded.(*GenericIndex)
+
+	for _, idx := range []*GenericIndex{hnsw, resumed} {
+		if err := idx.SetEfSearch(64); err != nil {
+			t.Fatalf("SetEfSearch() failed: %v", err)
+		}
+	}
+
+	wantEntry, _ := hnsw.GetHNSWEntryPoint()
+	wantLevel, _ := hnsw.GetHNSWMaxLevel()
+	gotEntry, err := resumed.GetHNSWEntryPoint()
+	if err != nil || gotEntry != wantEntry {
+		t.Errorf("reloaded GetHNSWEntryPoint() = %d, %v, want %d", gotEntry, err, wantEntry)
+	}
+	gotLevel, err := resumed.GetHNSWMaxLevel()
+	if err != nil || gotLevel != wantLevel {
+		t.Errorf("reloaded GetHNSWMaxLevel() = %d, %v, want %d", gotLevel, err, wantLevel)
+	}
+	if entryLevel, _ := resumed.GetHNSWLevel(gotEntry); entryLevel != gotLevel {
+		t.Errorf("entry point level = %d, want max level %d", entryLevel, gotLevel)
+	}
+
+	_, gt, _ := KNN(vectors[:nb/2*d], queries, d, k, MetricL2)
+	_, before, _ := index.Search(queries, k)
+	_, after, err := loaded.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() on reloaded index failed: %v", err)
+	}
+	recallBefore := ComputeRecall(gt, before, nq, k, k)
+	recallAfter := ComputeRecall(gt, after, nq, k, k)
+	if recallAfter != recallBefore {
+		t.Errorf("recall after reload = %.3f, want %.3f", recallAfter, recallBefore)
+	}
+
+	if err := loaded.Add(vectors[nb/2*d:]); err != nil {
+		t.Fatalf("Add() on reloaded index failed: %v", err)
+	}
+	_, gt, _ = KNN(vectors, queries, d, k, MetricL2)
+	_, labels, _ := loaded.Search(queries, k)
+	if recall := ComputeRecall(gt, labels, nq, k, k); recall < 0.9 {
+		t.Errorf("recall after resumed build = %.3f, want >= 0.9", recall)
+	}
+}
+
+func TestIndexHNSW_FactoryCosine(t *testing.T) {
+	d := 16
+	nb := 1000
+	nq := 20
+	k := 10
+
+	// Scale every vector by a different factor so magnitudes vary widely
+	vectors := generateVectors(nb, d)
+	for i := 0; i < nb; i++ {
+		scale := float32(1 + i%50)
+		for j := i * d; j < (i+1)*d; j++ {
+			vectors[j] *= scale
+		}
+	}
+	queries := vectors[:nq*d]
+
+	index, err := IndexFactory(d, "HNSW32", MetricCosine)
+	if err != nil {
+		t.Fatalf("IndexFactory(MetricCosine) failed: %v", err)
+	}
+	defer index.Close()
+	if index.MetricType() != MetricInnerProduct {
+		t.Errorf("MetricType() = %v, want %v", index.MetricType(), MetricInnerProduct)
+	}
+	if err := index.SetEfSearch(128); err != nil {
+		t.Fatalf("SetEfSearch() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	sims, labels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	_, wantLabels, _ := KNNCosine(vectors, queries, d, k)
+	if recall := ComputeRecall(wantLabels, labels, nq, k, k); recall < 0.95 {
+		t.Errorf("recall against exact cosine = %.3f, want >= 0.95", recall)
+	}
+	for q := 0; q < nq; q++ {
+		// Each query is in the index, so its best match has similarity 1
+		if labels[q*k] != int64(q) || math.Abs(float64(sims[q*k]-1)) > 1e-4 {
+			t.Errorf("query %d: top hit = (%d, %v), want (%d, 1)", q, labels[q*k], sims[q*k], q)
+		}
+	}
+
+	// The graph is still reachable behind the pre-transform
+	if _, err := index.(*GenericIndex).HNSWGraph(); err != nil {
+		t.Errorf("HNSWGraph() failed: %v", err)
+	}
+
+	// Plain inner product keeps favoring large vectors
+	ip, _ := IndexFactory(d, "HNSW32", MetricInnerProduct)
+	defer ip.Close()
+	ip.Add(vectors)
+	_, ipLabels, _ := ip.Search(queries, k)
+	if recall := ComputeRecall(wantLabels, ipLabels, nq, k, k); recall > 0.5 {
+		t.Errorf("inner product recall against cosine = %.3f, want it to differ", recall)
+	}
+}
+
+func TestIndexHNSW_RangeSearch(t *testing.T) {
+	d := 16
+	nb := 2000
+	nDup := 5
+	radius := float32(0.01)
+
+	index, err := NewIndexHNSWFlat(d, 16, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexHNSWFlat() failed: %v", err)
+	}
+	defer index.Close()
+	hnsw := index.(*GenericIndex)
+
+	// Near-duplicates of the query get IDs nb..nb+nDup-1
+	vectors := generateVectors(nb, d)
+	query := generateVectors(1, d)
+	for i := 0; i < nDup; i++ {
+		for j := 0; j < d; j++ {
+			vectors = append(vectors, query[j]+0.001*float32(i+1))
+		}
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetEfSearch(64)
+
+	result, err := hnsw.RangeSearch(query, radius)
+	if err != nil {
+		t.Fatalf("RangeSearch() failed: %v", err)
+	}
+	if result.Nq != 1 {
+		t.Fatalf("Nq = %d, want 1", result.Nq)
+	}
+
+	labels, distances := result.GetResults(0)
+	found := make(map[int64]bool)
+	for i, id := range labels {
+		if distances[i] > radius {
+			t.Errorf("result %d: distance %v exceeds radius %v", id, distances[i], radius)
+		}
+		found[id] = true
+	}
+	for i := 0; i < nDup; i++ {
+		if id := int64(nb + i); !found[id] {
+			t.Errorf("near-duplicate %d not returned (got %v)", id, labels)
+		}
+	}
+
+	empty, err := hnsw.RangeSearch([]float32{}, radius)
+	if err != nil || empty.Nq != 0 {
+		t.Errorf("RangeSearch(empty) = (%v, %v), want 0 queries", empty, err)
+	}
+	if _, err := hnsw.RangeSearch(query[:d-1], radius); err == nil {
+		t.Error("RangeSearch() with invalid query length should return error")
+	}
+}
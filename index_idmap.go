@@ -2,12 +2,16 @@ package faiss
 
 import (
 	"fmt"
+	"math"
 	"runtime"
 )
 
 // IndexIDMap wraps another index to support custom IDs
 // This allows you to use your own IDs instead of sequential indices
 //
+// If the base index requires normalized vectors (SetRequireNormalized),
+// Add, AddWithIDs and Search reject un-normalized ones too.
+//
 // Python equivalent: faiss.IndexIDMap, faiss.IndexIDMap2
 type IndexIDMap struct {
 	ptr       uintptr    // C pointer
@@ -38,6 +42,9 @@ func NewIndexIDMap(baseIndex Index) (*IndexIDMap, error) {
 	var basePtr uintptr
 	switch idx := baseIndex.(type) {
 	case *IndexFlat:
+		if idx.mmapped {
+			return nil, ErrReadOnly
+		}
 		basePtr = idx.ptr
 	case *IndexIVFFlat:
 		basePtr = idx.ptr
@@ -95,13 +102,16 @@ func (idx *IndexIDMap) MetricType() MetricType {
 
 // Train trains the base index
 func (idx *IndexIDMap) Train(vectors []float32) error {
+	if isClosed(idx.baseIndex) {
+		return ErrNullPointer
+	}
 	return idx.baseIndex.Train(vectors)
 }
 
 // Add adds vectors with auto-generated sequential IDs
 // For custom IDs, use AddWithIDs instead
 func (idx *IndexIDMap) Add(vectors []float32) error {
-	if idx.ptr == 0 {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
 		return ErrNullPointer
 	}
 	if len(vectors) == 0 {
@@ -111,6 +121,10 @@ func (idx *IndexIDMap) Add(vectors []float32) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
 
 	// Generate sequential IDs starting from current ntotal
@@ -133,7 +147,7 @@ func (idx *IndexIDMap) Add(vectors []float32) error {
 //   ids := []int64{1000, 2000, 3000}
 //   index.AddWithIDs(vectors, ids)
 func (idx *IndexIDMap) AddWithIDs(vectors []float32, ids []int64) error {
-	if idx.ptr == 0 {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
 		return ErrNullPointer
 	}
 	if len(vectors) == 0 {
@@ -143,6 +157,14 @@ func (idx *IndexIDMap) AddWithIDs(vectors []float32, ids []int64) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+
+	if err := validateNormalized(requiresNormalized(idx.baseIndex), vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
 	if len(ids) != n {
 		return fmt.Errorf("faiss: number of IDs (%d) must match number of vectors (%d)", len(ids), n)
@@ -156,10 +178,51 @@ func (idx *IndexIDMap) AddWithIDs(vectors []float32, ids []int64) error {
 	return nil
 }
 
+// AddSequential adds vectors with the IDs startID, startID+1, ... and returns
+// the next unused ID
+//
+// Feeding nextID back as the startID of the following batch keeps IDs
+// contiguous across incremental ingestion, without gaps or overlaps. On
+// error nothing is added and nextID is startID.
+//
+// Example:
+//   next := int64(0)
+//   for _, batch := range batches {
+//       if next, err = index.AddSequential(batch, next); err != nil { ... }
+//   }
+func (idx *IndexIDMap) AddSequential(vectors []float32, startID int64) (nextID int64, err error) {
+	return addSequential(idx.AddWithIDs, vectors, idx.d, startID)
+}
+
+// addSequential adds the vectors through addWithIDs with IDs counting up from
+// startID, returning the next unused ID
+func addSequential(addWithIDs func(vectors []float32, ids []int64) error, vectors []float32, d int, startID int64) (int64, error) {
+	if startID < 0 {
+		return startID, fmt.Errorf("faiss: start ID %d must be non-negative", startID)
+	}
+	if len(vectors)%d != 0 {
+		return startID, ErrInvalidVectors
+	}
+
+	n := int64(len(vectors) / d)
+	if startID > math.MaxInt64-n {
+		return startID, fmt.Errorf("faiss: %d IDs from %d overflow int64", n, startID)
+	}
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = startID + int64(i)
+	}
+
+	if err := addWithIDs(vectors, ids); err != nil {
+		return startID, err
+	}
+	return startID + n, nil
+}
+
 // Search searches for k nearest neighbors
 // Returns distances and the custom IDs
 func (idx *IndexIDMap) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
-	if idx.ptr == 0 {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
 		return nil, nil, ErrNullPointer
 	}
 	if len(queries) == 0 {
@@ -172,6 +235,13 @@ func (idx *IndexIDMap) Search(queries []float32, k int) (distances []float32, in
 		return nil, nil, ErrInvalidK
 	}
 
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(requiresNormalized(idx.baseIndex), queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
 	nq := len(queries) / idx.d
 	distances = make([]float32, nq*k)
 	indices = make([]int64, nq*k)
@@ -223,7 +293,7 @@ func (idx *IndexIDMap) SetEfSearch(efSearch int) error {
 
 // Reset removes all vectors
 func (idx *IndexIDMap) Reset() error {
-	if idx.ptr == 0 {
+	if idx.ptr == 0 || isClosed(idx.baseIndex) {
 		return ErrNullPointer
 	}
 
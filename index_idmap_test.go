@@ -1,6 +1,7 @@
 package faiss
 
 import (
+	"math"
 	"testing"
 )
 
@@ -412,3 +413,59 @@ func TestIndexIDMap_Train(t *testing.T) {
 		t.Errorf("Train() on Flat-based IDMap failed: %v", err)
 	}
 }
+
+// ========================================
+// AddSequential Tests
+// ========================================
+
+func TestIndexIDMap_AddSequential(t *testing.T) {
+	d := 4
+	vectors := generateVectors(10, d)
+
+	base, _ := NewIndexFlatL2(d)
+	defer base.Close()
+	idmap, _ := NewIndexIDMap(base)
+	defer idmap.Close()
+	generic, _ := IndexFactory(d, "IDMap,Flat", MetricL2)
+	defer generic.Close()
+
+	for _, index := range []interface {
+		Index
+		AddSequential(vectors []float32, startID int64) (int64, error)
+	}{idmap, generic.(*GenericIndex)} {
+		// Two batches continuing from the returned ID
+		next, err := index.AddSequential(vectors[:6*d], 500)
+		if err != nil || next != 506 {
+			t.Fatalf("%T: AddSequential(first) = %d, %v, want 506, nil", index, next, err)
+		}
+		next, err = index.AddSequential(vectors[6*d:], next)
+		if err != nil || next != 510 {
+			t.Fatalf("%T: AddSequential(second) = %d, %v, want 510, nil", index, next, err)
+		}
+
+		// Every vector finds itself under a contiguous ID
+		_, labels, _ := index.Search(vectors, 1)
+		for i, label := range labels {
+			if label != int64(500+i) {
+				t.Errorf("%T: vector %d has ID %d, want %d", index, i, label, 500+i)
+			}
+		}
+
+		// Empty batches and errors leave the next ID unchanged
+		if next, err := index.AddSequential(nil, 510); err != nil || next != 510 {
+			t.Errorf("%T: AddSequential(empty) = %d, %v, want 510, nil", index, next, err)
+		}
+		if next, err := index.AddSequential(vectors[:d+1], 510); err == nil || next != 510 {
+			t.Errorf("%T: AddSequential(bad length) = %d, %v, want 510 and an error", index, next, err)
+		}
+		if _, err := index.AddSequential(vectors[:d], -1); err == nil {
+			t.Errorf("%T: Expected error for negative start ID", index)
+		}
+		if _, err := index.AddSequential(vectors[:2*d], math.MaxInt64-1); err == nil {
+			t.Errorf("%T: Expected error for ID overflow", index)
+		}
+		if index.Ntotal() != 10 {
+			t.Errorf("%T: Ntotal() = %d, want 10", index, index.Ntotal())
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync"
+	"time"
 )
 
 // IndexIVFFlat is an inverted file index with flat (uncompressed) vectors
@@ -12,20 +14,29 @@ import (
 //
 // Python equivalent: faiss.IndexIVFFlat
 type IndexIVFFlat struct {
-	ptr       uintptr     // C pointer
-	quantizer Index       // quantizer index (must be kept alive)
-	d         int         // dimension
-	metric    MetricType  // metric type
-	ntotal    int64       // number of vectors
-	isTrained bool        // training status
-	nlist     int         // number of inverted lists
-	nprobe    int         // number of lists to probe during search
+	ptr       uintptr          // C pointer
+	quantizer Index            // quantizer index (must be kept alive)
+	shared    *SharedQuantizer // shared quantizer reference, released on Close
+	d         int              // dimension
+	metric    MetricType       // metric type
+	ntotal    int64            // number of vectors
+	isTrained bool             // training status
+	nlist     int              // number of inverted lists
+	nprobe    int              // number of lists to probe during search
+	directMap bool             // whether the direct map is maintained (needed for reconstruction)
+	normGuard bool             // Add/Search reject vectors that are not unit-norm
 }
 
 // Ensure IndexIVFFlat implements Index and related interfaces
 var _ Index = (*IndexIVFFlat)(nil)
 var _ IndexWithAssign = (*IndexIVFFlat)(nil)
 
+// ivfTrainingMinimum is the number of training vectors Train requires for a
+// coarse quantizer with nlist centroids
+func ivfTrainingMinimum(nlist int) int {
+	return 30 * nlist
+}
+
 // NewIndexIVFFlat creates a new IVF index with flat storage
 //
 // Parameters:
@@ -40,8 +51,34 @@ var _ IndexWithAssign = (*IndexIVFFlat)(nil)
 //
 // Implementation note: This function uses the factory pattern internally
 // to avoid C pointer management bugs. The quantizer parameter is accepted
-// for API compatibility with Python FAISS but is not used.
+// for API compatibility with Python FAISS but is not used; it behaves
+// exactly like NewIndexIVFFlatAuto. A quantizer whose metric differs from
+// metric is rejected, since FAISS requires coarse assignment to use the same
+// metric as the index.
 func NewIndexIVFFlat(quantizer Index, d, nlist int, metric MetricType) (*IndexIVFFlat, error) {
+	// The quantizer itself is not used (the factory creates its own), but a
+	// mismatched metric almost certainly indicates a caller mistake
+	if quantizer != nil && quantizer.MetricType() != metric {
+		return nil, fmt.Errorf("faiss: quantizer metric %s does not match index metric %s",
+			quantizer.MetricType(), metric)
+	}
+
+	return NewIndexIVFFlatAuto(d, nlist, metric)
+}
+
+// NewIndexIVFFlatAuto creates a new IVF index with flat storage and an
+// internal flat quantizer using the same metric.
+//
+// The quantizer is created and owned by the index: it is freed when the IVF
+// index is closed, so there is no separate quantizer whose lifetime must be
+// managed by the caller.
+//
+// Example:
+//   index, _ := faiss.NewIndexIVFFlatAuto(128, 100, faiss.MetricL2)
+//   defer index.Close()
+//   index.Train(trainingVectors)
+//   index.Add(vectors)
+func NewIndexIVFFlatAuto(d, nlist int, metric MetricType) (*IndexIVFFlat, error) {
 	if d <= 0 {
 		return nil, ErrInvalidDimension
 	}
@@ -49,12 +86,49 @@ func NewIndexIVFFlat(quantizer Index, d, nlist int, metric MetricType) (*IndexIV
 		return nil, fmt.Errorf("faiss: nlist must be positive")
 	}
 
-	// Ignore the quantizer parameter (accepted for API compatibility)
-	// The factory creates its own quantizer internally
-	_ = quantizer
-
 	// Use the factory pattern internally to avoid C pointer bugs
-	description := fmt.Sprintf("IVF%d,Flat", nlist)
+	return newIndexIVFFlatFromFactory(d, nlist, fmt.Sprintf("IVF%d,Flat", nlist), metric)
+}
+
+// NewIndexIVFFlatHNSWQuantizer creates an IVF flat index whose coarse
+// quantizer is an HNSW graph over the centroids instead of a flat index
+//
+// A flat quantizer compares every query with all nlist centroids, which
+// dominates search time once nlist reaches hundreds of thousands. The HNSW
+// quantizer finds the nprobe nearest centroids in roughly logarithmic time.
+// Coarse assignment becomes approximate, so a vector may occasionally land in
+// a list that is not its exact nearest centroid; with nprobe > 1 this costs
+// little recall. Training is also slower, since the centroids are inserted
+// into the graph.
+//
+// Like NewIndexIVFFlatAuto, the quantizer is created and owned by the index.
+//
+// Python equivalent: faiss.index_factory(d, "IVF{nlist}_HNSW{hnswM},Flat")
+//
+// Example:
+//   index, _ := faiss.NewIndexIVFFlatHNSWQuantizer(128, 65536, 32, faiss.MetricL2)
+//   defer index.Close()
+//   index.Train(trainingVectors)
+//   index.Add(vectors)
+//   index.SetNprobe(64)
+func NewIndexIVFFlatHNSWQuantizer(d, nlist, hnswM int, metric MetricType) (*IndexIVFFlat, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if nlist <= 0 {
+		return nil, fmt.Errorf("faiss: nlist must be positive")
+	}
+	if hnswM <= 0 {
+		return nil, fmt.Errorf("faiss: HNSW M must be positive")
+	}
+
+	description := fmt.Sprintf("IVF%d_HNSW%d,Flat", nlist, hnswM)
+	return newIndexIVFFlatFromFactory(d, nlist, description, metric)
+}
+
+// newIndexIVFFlatFromFactory builds an IVF flat index from a factory
+// description and takes ownership of it as an IndexIVFFlat
+func newIndexIVFFlatFromFactory(d, nlist int, description string, metric MetricType) (*IndexIVFFlat, error) {
 	genericIdx, err := IndexFactory(d, description, metric)
 	if err != nil {
 		return nil, fmt.Errorf("faiss: failed to create IndexIVFFlat: %w", err)
@@ -63,6 +137,19 @@ func NewIndexIVFFlat(quantizer Index, d, nlist int, metric MetricType) (*IndexIV
 	// Extract the pointer and properties from the generic index
 	gen := genericIdx.(*GenericIndex)
 
+	// Coarse assignment must use the index metric, otherwise IP indexes
+	// probe the lists closest in L2 and miss the best matches
+	quantizerMetric, err := faissIndexIVFQuantizerMetric(gen.ptr)
+	if err != nil {
+		gen.Close()
+		return nil, fmt.Errorf("faiss: failed to read quantizer metric: %w", err)
+	}
+	if quantizerMetric != metric {
+		gen.Close()
+		return nil, fmt.Errorf("faiss: quantizer metric %s does not match index metric %s",
+			quantizerMetric, metric)
+	}
+
 	idx := &IndexIVFFlat{
 		ptr:       gen.ptr,
 		quantizer: nil, // Factory manages quantizer internally
@@ -133,6 +220,106 @@ func (idx *IndexIVFFlat) SetNprobe(nprobe int) error {
 	return nil
 }
 
+// SetClusteringParams sets the k-means parameters used by Train for the coarse quantizer
+// niter is the number of iterations per run and nredo the number of runs; the
+// run with the lowest objective is kept. nredo > 1 helps on noisy data at the
+// cost of proportionally longer training. Must be called before Train.
+func (idx *IndexIVFFlat) SetClusteringParams(niter, nredo int) error {
+	if niter <= 0 || nredo <= 0 {
+		return fmt.Errorf("faiss: niter and nredo must be positive (got %d, %d)", niter, nredo)
+	}
+	if idx.isTrained {
+		return errors.New("faiss: clustering parameters must be set before training")
+	}
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	return faissIndexSetClusteringParams(idx.ptr, niter, nredo)
+}
+
+// SetMaxCodes caps the number of codes scanned per query across all probed lists
+// This bounds search latency on dense lists at the cost of some recall
+// 0 means no limit (the default)
+func (idx *IndexIVFFlat) SetMaxCodes(maxCodes int) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if maxCodes < 0 {
+		return fmt.Errorf("faiss: max_codes must be non-negative")
+	}
+
+	if err := faissSetIndexParameter(idx.ptr, "max_codes", float64(maxCodes)); err != nil {
+		return fmt.Errorf("faiss: failed to set max_codes: %w", err)
+	}
+	return nil
+}
+
+// IVF parallel modes for SetParallelMode (FAISS IndexIVF.parallel_mode)
+//
+// They only change how a search is split across OpenMP threads; results are
+// identical. MaxCodes is honored only by IVFParallelQueries and
+// IVFParallelQueriesFine.
+const (
+	IVFParallelQueries     = 0 // split the queries of a batch across threads (default)
+	IVFParallelLists       = 1 // split the probed lists of each query across threads
+	IVFParallelBoth        = 2 // split over queries and over lists
+	IVFParallelQueriesFine = 3 // split over queries with a finer granularity
+)
+
+// validateParallelMode checks that mode is one of the IVFParallel* constants
+func validateParallelMode(mode int) error {
+	if mode < IVFParallelQueries || mode > IVFParallelQueriesFine {
+		return fmt.Errorf("faiss: invalid IVF parallel mode %d (want 0-3)", mode)
+	}
+	return nil
+}
+
+// SetParallelMode chooses how searches are parallelized (see IVFParallelQueries)
+// The default splits a batch over queries, which leaves cores idle when a
+// batch holds fewer queries than threads; IVFParallelLists also spreads the
+// work of a single query across its probed lists
+func (idx *IndexIVFFlat) SetParallelMode(mode int) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if err := validateParallelMode(mode); err != nil {
+		return err
+	}
+
+	if err := faissIndexIVFSetParallelMode(idx.ptr, mode); err != nil {
+		return fmt.Errorf("faiss: failed to set parallel mode: %w", err)
+	}
+	return nil
+}
+
+// GetParallelMode returns the current search parallel mode
+func (idx *IndexIVFFlat) GetParallelMode() (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+
+	mode, err := faissIndexIVFGetParallelMode(idx.ptr)
+	if err != nil {
+		return 0, fmt.Errorf("faiss: failed to get parallel mode: %w", err)
+	}
+	return mode, nil
+}
+
+// SetRequireNormalized makes Add and Search reject vectors whose L2 norm is
+// not ~1.0 (inner product indexes only); see IndexFlat.SetRequireNormalized
+func (idx *IndexIVFFlat) SetRequireNormalized(require bool) error {
+	if err := checkRequireNormalized(require, idx.metric); err != nil {
+		return err
+	}
+	idx.normGuard = require
+	return nil
+}
+
+// RequireNormalized reports whether Add and Search reject un-normalized vectors
+func (idx *IndexIVFFlat) RequireNormalized() bool {
+	return idx.normGuard
+}
+
 // Train trains the index on a representative set of vectors
 // This is REQUIRED before adding vectors to IVF indexes
 func (idx *IndexIVFFlat) Train(vectors []float32) error {
@@ -149,12 +336,14 @@ func (idx *IndexIVFFlat) Train(vectors []float32) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
 
-	// Recommend at least 30*nlist training vectors
-	minTraining := 30 * idx.nlist
-	if n < minTraining {
-		return fmt.Errorf("faiss: insufficient training data (have %d, recommend at least %d)", n, minTraining)
+	if err := checkTrainingSize(n, ivfTrainingMinimum(idx.nlist)); err != nil {
+		return err
 	}
 
 	if err := faissIndexTrain(idx.ptr, vectors, n); err != nil {
@@ -165,6 +354,185 @@ func (idx *IndexIVFFlat) Train(vectors []float32) error {
 	return nil
 }
 
+// TrainSample trains the index on a random subset of vectors
+//
+// Training only needs a representative sample, so on large datasets it is
+// common to train on a fraction and then Add the full set. sampleFraction
+// must be in (0, 1]; the sample is never smaller than 39*nlist vectors, the
+// minimum FAISS k-means uses without warning (or the whole input, if that is
+// smaller).
+//
+// Example:
+//   index.TrainSample(vectors, 0.1) // k-means on 10% of the data
+//   index.Add(vectors)
+func (idx *IndexIVFFlat) TrainSample(vectors []float32, sampleFraction float64) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if idx.isTrained {
+		return nil // already trained
+	}
+	sample, err := sampleTrainingVectors(vectors, idx.d, sampleFraction, 39*idx.nlist)
+	if err != nil {
+		return err
+	}
+	return idx.Train(sample)
+}
+
+// Retrain re-trains the coarse quantizer of index on newTrainingData and
+// re-assigns the vectors already in the index to the new clusters
+//
+// Use it when the data distribution drifts away from the one the centroids
+// were trained on. index may be an IndexIVFFlat, an IVF index built by the
+// factory (including "IDMap,IVF..." descriptions), an on-disk IVF index, or
+// an IndexIDMap over any of these. The stored vectors are read back from
+// the inverted lists together with their IDs (mapped through the IDMap's
+// id_map, if any), the index and its quantizer are reset, the index is
+// trained on newTrainingData (with the parameters of SetClusteringParams,
+// if set) and the vectors are added back with AddWithIDs, so every vector
+// keeps its ID even after RemoveIDs or custom IDs. nprobe and other search
+// settings are kept. Compressed encodings such as PQ are re-encoded from
+// their decoded vectors, so they lose some additional accuracy.
+//
+// The training data is validated before anything is changed. If training
+// itself fails after that, the index is left empty and untrained. Indexes
+// built from a SharedQuantizer cannot be retrained, since that would move
+// the centroids of every index sharing it.
+//
+// Example:
+//   if err := faiss.Retrain(index, recentVectors); err != nil {
+//       return err
+//   }
+func Retrain(index Index, newTrainingData []float32) error {
+	ptr, ok := indexPointer(index)
+	if !ok {
+		return fmt.Errorf("faiss: cannot retrain %T", index)
+	}
+	if ptr == 0 || isClosed(ivfOwner(index)) {
+		return ErrNullPointer
+	}
+	ivf, err := faissIndexIVFTarget(ptr)
+	if err != nil {
+		return fmt.Errorf("faiss: retrain failed: %w", err)
+	}
+	if sharesQuantizer(index) {
+		return errors.New("faiss: cannot retrain an index with a shared quantizer")
+	}
+	d := index.D()
+	if len(newTrainingData) == 0 {
+		return errors.New("faiss: cannot train on empty vectors")
+	}
+	if len(newTrainingData)%d != 0 {
+		return ErrInvalidVectors
+	}
+	if err := validateInput(newTrainingData, d); err != nil {
+		return err
+	}
+	if err := checkTrainingSize(len(newTrainingData)/d, indexTrainingMinimum(ivf, true)); err != nil {
+		return err
+	}
+
+	quantizer, err := faissIndexIVFQuantizer(ivf)
+	if err != nil {
+		return fmt.Errorf("faiss: retrain failed: %w", err)
+	}
+	ids, vectors, err := faissIndexIVFStoredVectors(ptr, index.Ntotal(), d)
+	if err != nil {
+		return fmt.Errorf("faiss: retrain failed: %w", err)
+	}
+	// An array direct map only allows sequential adds. The IDs read back
+	// are then a permutation of 0..ntotal-1, so the map is dropped while
+	// they are added and rebuilt afterwards.
+	mapType, err := faissIndexIVFDirectMapType(ivf)
+	if err != nil {
+		return fmt.Errorf("faiss: retrain failed: %w", err)
+	}
+	arrayMap := mapType == directMapArray
+
+	if err := index.Reset(); err != nil {
+		return err
+	}
+	if err := faissIndexReset(quantizer); err != nil {
+		return fmt.Errorf("faiss: failed to reset quantizer: %w", err)
+	}
+	markUntrained(index)
+	if err := index.Train(newTrainingData); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if arrayMap {
+		if err := faissIndexIVFMakeDirectMap(ivf, false); err != nil {
+			return fmt.Errorf("faiss: failed to disable direct map: %w", err)
+		}
+	}
+	if err := addWithIDs(index, vectors, ids); err != nil {
+		return fmt.Errorf("faiss: re-adding vectors after retrain failed: %w", err)
+	}
+	if arrayMap {
+		if err := faissIndexIVFMakeDirectMap(ivf, true); err != nil {
+			return fmt.Errorf("faiss: failed to rebuild direct map: %w", err)
+		}
+	}
+	return nil
+}
+
+// directMapArray is the FAISS DirectMap type of an array direct map
+const directMapArray = 1
+
+// ivfOwner returns the index that owns the IVF structure of index, looking
+// through IndexIDMap wrappers
+func ivfOwner(index Index) Index {
+	if idmap, ok := index.(*IndexIDMap); ok {
+		return ivfOwner(idmap.baseIndex)
+	}
+	return index
+}
+
+// sharesQuantizer reports whether index was built from a SharedQuantizer
+func sharesQuantizer(index Index) bool {
+	switch idx := ivfOwner(index).(type) {
+	case *IndexIVFFlat:
+		return idx.shared != nil
+	case *GenericIndex:
+		return idx.shared != nil
+	}
+	return false
+}
+
+// markUntrained clears the cached training flag of index, so that Train
+// runs again after the quantizer has been reset
+func markUntrained(index Index) {
+	switch idx := ivfOwner(index).(type) {
+	case *IndexIVFFlat:
+		idx.isTrained = false
+	case *GenericIndex:
+		idx.isTrained = false
+	case *IndexIVFFlatOnDisk:
+		idx.isTrained = false
+	case *IndexIVFPQOnDisk:
+		idx.isTrained = false
+	}
+}
+
+// addWithIDs adds vectors under the given IDs to an index that stores IDs
+func addWithIDs(index Index, vectors []float32, ids []int64) error {
+	switch idx := index.(type) {
+	case interface {
+		AddWithIDs(vectors []float32, ids []int64) error
+	}:
+		return idx.AddWithIDs(vectors, ids)
+	case *IndexIVFFlat:
+		if err := faissIndexAddWithIDs(idx.ptr, vectors, ids, len(ids)); err != nil {
+			return err
+		}
+		idx.ntotal += int64(len(ids))
+		return nil
+	}
+	return fmt.Errorf("faiss: %T does not store IDs", index)
+}
+
 // Add adds vectors to the index
 // The index must be trained before calling this
 func (idx *IndexIVFFlat) Add(vectors []float32) error {
@@ -181,6 +549,13 @@ func (idx *IndexIVFFlat) Add(vectors []float32) error {
 		return ErrInvalidVectors
 	}
 
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+	if err := validateNormalized(idx.normGuard, vectors, idx.d); err != nil {
+		return err
+	}
+
 	n := len(vectors) / idx.d
 
 	if err := faissIndexAdd(idx.ptr, vectors, n); err != nil {
@@ -209,6 +584,13 @@ func (idx *IndexIVFFlat) Search(queries []float32, k int) (distances []float32,
 		return nil, nil, ErrInvalidK
 	}
 
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
 	nq := len(queries) / idx.d
 	distances = make([]float32, nq*k)
 	indices = make([]int64, nq*k)
@@ -220,6 +602,76 @@ func (idx *IndexIVFFlat) Search(queries []float32, k int) (distances []float32,
 	return distances, indices, nil
 }
 
+// CopySubset returns a new index containing only the vectors of src whose IDs
+// are in [minID, maxID)
+//
+// The new index shares src's trained coarse quantizer (it gets its own copy),
+// so it is ready to search and accepts further Add calls without training.
+// Vectors keep their original IDs. This allows re-sharding a monolithic index
+// by ID range without re-adding the vectors.
+//
+// Python equivalent: index.copy_subset_to(other, 0, minID, maxID)
+//
+// Example:
+//   lower, _ := faiss.CopySubset(index, 0, 500_000)
+//   upper, _ := faiss.CopySubset(index, 500_000, 1_000_000)
+func CopySubset(src *IndexIVFFlat, minID, maxID int64) (*IndexIVFFlat, error) {
+	if src == nil || src.ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	if !src.isTrained {
+		return nil, ErrNotTrained
+	}
+	if minID < 0 || maxID < minID {
+		return nil, fmt.Errorf("faiss: invalid ID range [%d, %d)", minID, maxID)
+	}
+
+	ptr, err := faissIndexIVFFlatNewEmptyLike(src.ptr, src.d, src.nlist, int(src.metric))
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to create subset index: %w", err)
+	}
+	if err := faissIndexIVFCopySubsetTo(src.ptr, ptr, 0, minID, maxID); err != nil {
+		faissIndexFree(ptr)
+		return nil, fmt.Errorf("faiss: failed to copy subset: %w", err)
+	}
+
+	idx := &IndexIVFFlat{
+		ptr:       ptr,
+		d:         src.d,
+		metric:    src.metric,
+		ntotal:    faiss_Index_ntotal(ptr),
+		isTrained: true,
+		nlist:     src.nlist,
+		nprobe:    1,
+	}
+	if src.nprobe != 1 {
+		if err := idx.SetNprobe(src.nprobe); err != nil {
+			idx.Close()
+			return nil, err
+		}
+	}
+
+	runtime.SetFinalizer(idx, func(i *IndexIVFFlat) {
+		if i.ptr != 0 {
+			_ = i.Close()
+		}
+	})
+
+	return idx, nil
+}
+
+// Warmup performs a throwaway search so that one-time start-up costs (such as
+// the OpenMP thread pool) are not paid by the first real query
+func (idx *IndexIVFFlat) Warmup() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if !idx.isTrained {
+		return ErrNotTrained
+	}
+	return warmupIndex(idx.ptr, idx.d)
+}
+
 // Assign assigns vectors to their nearest cluster (inverted list)
 //
 // This uses the quantizer to find the nearest centroid for each vector.
@@ -239,11 +691,17 @@ func (idx *IndexIVFFlat) Assign(vectors []float32) ([]int64, error) {
 		return nil, ErrInvalidVectors
 	}
 
-	// For factory-created indexes, use the C API directly
+	// For factory-created indexes, search the internal quantizer directly
+	// (Index::assign on the IVF index itself would return vector IDs)
 	if idx.quantizer == nil {
+		quantizer, err := faissIndexIVFQuantizer(idx.ptr)
+		if err != nil {
+			return nil, fmt.Errorf("faiss: assignment failed: %w", err)
+		}
 		n := len(vectors) / idx.d
+		distances := make([]float32, n)
 		labels := make([]int64, n)
-		if err := faissIndexAssign(idx.ptr, vectors, n, labels, 1); err != nil {
+		if err := faissIndexSearch(quantizer, vectors, n, 1, distances, labels); err != nil {
 			return nil, fmt.Errorf("faiss: assignment failed: %w", err)
 		}
 		return labels, nil
@@ -258,12 +716,113 @@ func (idx *IndexIVFFlat) Assign(vectors []float32) ([]int64, error) {
 	return labels, nil
 }
 
+// SearchExplain searches a single query and also returns the inverted lists it probed
+//
+// probedLists holds the nprobe list IDs the coarse quantizer selected for the
+// query, nearest centroid first. If an expected neighbor is missing from the
+// result, checking which list it was assigned to (see Assign) against
+// probedLists tells whether nprobe is too low.
+func (idx *IndexIVFFlat) SearchExplain(query []float32, k int) (result SearchResult, probedLists []int64, err error) {
+	if idx.ptr == 0 {
+		return SearchResult{}, nil, ErrNullPointer
+	}
+	if !idx.isTrained {
+		return SearchResult{}, nil, ErrNotTrained
+	}
+	return searchExplainIVF(idx, idx.ptr, query, k, idx.normGuard)
+}
+
+// SearchWithTimeout searches with increasing nprobe until timeout runs out
+// and returns the best results obtained in time
+//
+// FAISS has no wall-clock deadline, so the search is repeated with nprobe
+// 1, 2, 4, ... up to nlist, and the results of the last round that finished
+// are returned. Under load this degrades recall gracefully instead of
+// missing the latency budget. The first round (nprobe=1) always completes,
+// even if it alone exceeds timeout, so results are returned for any timeout.
+// The index's own nprobe is not changed.
+func (idx *IndexIVFFlat) SearchWithTimeout(queries []float32, k int, timeout time.Duration) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 {
+		return nil, nil, ErrNullPointer
+	}
+	if !idx.isTrained {
+		return nil, nil, ErrNotTrained
+	}
+	if len(queries) == 0 {
+		return []float32{}, []int64{}, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
+	nq := len(queries) / idx.d
+	distances, indices, err = searchProgressive(timeout, 1, idx.nlist, nq, k, func(nprobe int, distances []float32, indices []int64) error {
+		return faissIndexIVFSearchWithNprobe(idx.ptr, queries, nq, k, nprobe, distances, indices)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("faiss: search failed: %w", err)
+	}
+	return distances, indices, nil
+}
+
+// searchExplainIVF runs a single-query search on an IVF index and returns
+// the coarse quantizer's top nprobe lists for the same query. If
+// requireNormalized is set, the query must be unit-norm.
+func searchExplainIVF(index Index, ptr uintptr, query []float32, k int, requireNormalized bool) (SearchResult, []int64, error) {
+	if len(query) != index.D() {
+		return SearchResult{}, nil, fmt.Errorf("faiss: query length %d does not match index dimension %d: %w",
+			len(query), index.D(), ErrDimensionMismatch)
+	}
+	if err := validateNormalized(requireNormalized, query, index.D()); err != nil {
+		return SearchResult{}, nil, err
+	}
+	if k <= 0 {
+		return SearchResult{}, nil, ErrInvalidK
+	}
+
+	nprobe, err := faissIndexIVFGetNprobe(ptr)
+	if err != nil {
+		return SearchResult{}, nil, fmt.Errorf("faiss: %w", err)
+	}
+	quantizer, err := faissIndexIVFQuantizer(ptr)
+	if err != nil {
+		return SearchResult{}, nil, fmt.Errorf("faiss: %w", err)
+	}
+
+	// IVF search asks the quantizer for the same nprobe nearest centroids
+	probed := make([]int64, nprobe)
+	centroidDistances := make([]float32, nprobe)
+	if err := faissIndexSearch(quantizer, query, 1, nprobe, centroidDistances, probed); err != nil {
+		return SearchResult{}, nil, fmt.Errorf("faiss: quantizer search failed: %w", err)
+	}
+	n := 0
+	for n < len(probed) && probed[n] >= 0 {
+		n++
+	}
+
+	distances, labels, err := index.Search(query, k)
+	if err != nil {
+		return SearchResult{}, nil, err
+	}
+	return *NewSearchResult(distances, labels, 1, k), probed[:n], nil
+}
+
 // SetEfSearch is not supported for IVF indexes (not an HNSW index)
 func (idx *IndexIVFFlat) SetEfSearch(efSearch int) error {
 	return fmt.Errorf("faiss: SetEfSearch not supported for IndexIVFFlat (not an HNSW index)")
 }
 
 // Reset removes all vectors from the index
+// The trained quantizer is kept, so the index can be refilled without re-training
 func (idx *IndexIVFFlat) Reset() error {
 	if idx.ptr == 0 {
 		return ErrNullPointer
@@ -288,9 +847,245 @@ func (idx *IndexIVFFlat) Close() error {
 	idx.ptr = 0
 	idx.ntotal = 0
 
+	// The quantizer may only be freed after the index that uses it
+	if idx.shared != nil {
+		idx.shared.release()
+		idx.shared = nil
+		idx.quantizer = nil
+	}
+
 	if err != nil {
 		return fmt.Errorf("faiss: failed to free index: %w", err)
 	}
 
 	return nil
 }
+
+// ========================================
+// Shared Quantizer
+// ========================================
+
+// SharedQuantizer lets several IVF indexes use one trained coarse quantizer
+//
+// Indexes built from the same SharedQuantizer assign vectors to the same
+// inverted lists, so their list IDs line up, and the centroids are stored
+// only once. The quantizer is reference counted: each index created from it
+// holds a reference that is released when the index is closed, and the
+// quantizer is freed only once the SharedQuantizer itself and every index
+// using it have been closed.
+//
+// The quantizer itself is owned by the SharedQuantizer: its Add, Reset,
+// Reserve, Compact and Close return an error until the quantizer is freed. A
+// SharedQuantizer that is dropped without Close releases its reference when
+// it is garbage collected.
+//
+// Example:
+//   quantizer, _ := faiss.NewIndexFlatL2(128)
+//   quantizer.Add(centroids) // e.g. from faiss.Kmeans
+//   shared, _ := faiss.NewSharedQuantizer(quantizer)
+//   a, _ := shared.NewIndexIVFFlat()
+//   b, _ := shared.NewIndexIVFScalarQuantizer(faiss.QT_8bit)
+//   c, _ := shared.NewIndexIVFPQ(16, 8)
+//   shared.Close() // a, b and c keep the quantizer alive
+type SharedQuantizer struct {
+	mu        sync.Mutex
+	quantizer *IndexFlat // freed when refs drops to zero
+	refs      int        // one for the SharedQuantizer plus one per index
+	closed    bool       // whether the SharedQuantizer's own reference is released
+}
+
+// errSharedQuantizerInUse is returned by the methods that would modify or
+// free a quantizer owned by a SharedQuantizer
+var errSharedQuantizerInUse = errors.New("faiss: index is owned by a shared quantizer; close the SharedQuantizer and its indexes instead")
+
+// NewSharedQuantizer takes ownership of a flat index holding the trained
+// centroids, one per inverted list
+//
+// The quantizer must not be modified directly afterwards; its Add, Reset,
+// Reserve, Compact and Close return an error while the SharedQuantizer or any
+// index built from it is open.
+func NewSharedQuantizer(quantizer *IndexFlat) (*SharedQuantizer, error) {
+	if quantizer == nil {
+		return nil, fmt.Errorf("faiss: quantizer cannot be nil")
+	}
+	if quantizer.ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	if quantizer.shared {
+		return nil, errSharedQuantizerInUse
+	}
+	if quantizer.Ntotal() == 0 {
+		return nil, fmt.Errorf("faiss: quantizer must contain the trained centroids")
+	}
+
+	sq := &SharedQuantizer{
+		quantizer: quantizer,
+		refs:      1,
+	}
+	quantizer.shared = true
+
+	// Release the SharedQuantizer's own reference if it is dropped without
+	// Close; the indexes built from it hold it alive until they are freed.
+	runtime.SetFinalizer(sq, func(s *SharedQuantizer) {
+		_ = s.Close()
+	})
+
+	return sq, nil
+}
+
+// D returns the dimension of the quantizer
+func (sq *SharedQuantizer) D() int {
+	return sq.quantizer.D()
+}
+
+// Nlist returns the number of inverted lists (centroids)
+func (sq *SharedQuantizer) Nlist() int {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	if sq.refs == 0 {
+		return 0
+	}
+	return int(sq.quantizer.Ntotal())
+}
+
+// MetricType returns the metric of the quantizer
+func (sq *SharedQuantizer) MetricType() MetricType {
+	return sq.quantizer.MetricType()
+}
+
+// References returns the number of live references: one for the
+// SharedQuantizer until it is closed, plus one per open index
+func (sq *SharedQuantizer) References() int {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	return sq.refs
+}
+
+// acquire takes a reference for a new index
+func (sq *SharedQuantizer) acquire() error {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	if sq.closed {
+		return fmt.Errorf("faiss: shared quantizer is closed")
+	}
+	sq.refs++
+	return nil
+}
+
+// release drops a reference and frees the quantizer with the last one
+func (sq *SharedQuantizer) release() {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	if sq.refs == 0 {
+		return
+	}
+	sq.refs--
+	if sq.refs == 0 {
+		sq.quantizer.shared = false
+		sq.quantizer.Close()
+	}
+}
+
+// NewIndexIVFFlat creates an IVF flat index using the shared quantizer
+//
+// The index is trained from the start, since the quantizer is.
+func (sq *SharedQuantizer) NewIndexIVFFlat() (*IndexIVFFlat, error) {
+	if err := sq.acquire(); err != nil {
+		return nil, err
+	}
+
+	d := sq.quantizer.D()
+	nlist := int(sq.quantizer.Ntotal())
+	metric := sq.quantizer.MetricType()
+	ptr, err := faissIndexIVFFlatNewWithQuantizer(sq.quantizer.ptr, d, nlist, int(metric))
+	if err != nil {
+		sq.release()
+		return nil, fmt.Errorf("faiss: failed to create IndexIVFFlat: %w", err)
+	}
+
+	idx := &IndexIVFFlat{
+		ptr:       ptr,
+		quantizer: sq.quantizer,
+		shared:    sq,
+		d:         d,
+		metric:    metric,
+		isTrained: faissIndexIsTrained(ptr),
+		nlist:     nlist,
+		nprobe:    1,
+	}
+
+	runtime.SetFinalizer(idx, func(i *IndexIVFFlat) {
+		if i.ptr != 0 {
+			_ = i.Close()
+		}
+	})
+
+	return idx, nil
+}
+
+// NewIndexIVFScalarQuantizer creates an IVF scalar quantizer index using the
+// shared quantizer
+//
+// The index still has to be trained to learn the scalar quantizer ranges;
+// training leaves the shared centroids untouched.
+func (sq *SharedQuantizer) NewIndexIVFScalarQuantizer(qtype QuantizerType) (*IndexIVFScalarQuantizer, error) {
+	if err := sq.acquire(); err != nil {
+		return nil, err
+	}
+
+	idx, err := NewIndexIVFScalarQuantizer(sq.quantizer, sq.quantizer.D(), int(sq.quantizer.Ntotal()), qtype, sq.quantizer.MetricType())
+	if err != nil {
+		sq.release()
+		return nil, err
+	}
+	idx.shared = sq
+	return idx, nil
+}
+
+// NewIndexIVFPQ creates an IVF product quantizer index using the shared
+// quantizer, with M subquantizers of nbits bits each
+//
+// The index still has to be trained to learn the product quantizer; training
+// leaves the shared centroids untouched.
+func (sq *SharedQuantizer) NewIndexIVFPQ(M, nbits int) (*GenericIndex, error) {
+	d := sq.quantizer.D()
+	if M <= 0 || d%M != 0 {
+		return nil, fmt.Errorf("faiss: d (%d) must be divisible by M (%d)", d, M)
+	}
+	if nbits <= 0 || nbits > 16 {
+		return nil, fmt.Errorf("faiss: nbits must be between 1 and 16")
+	}
+	if err := sq.acquire(); err != nil {
+		return nil, err
+	}
+
+	nlist := int(sq.quantizer.Ntotal())
+	metric := sq.quantizer.MetricType()
+	ptr, err := faissIndexIVFPQNewWithQuantizer(sq.quantizer.ptr, d, nlist, M, nbits, int(metric))
+	if err != nil {
+		sq.release()
+		return nil, fmt.Errorf("faiss: failed to create IndexIVFPQ: %w", err)
+	}
+
+	idx := newGenericIndex(ptr, d, metric, fmt.Sprintf("IVF%d,PQ%dx%d", nlist, M, nbits))
+	idx.isTrained = faissIndexIsTrained(ptr)
+	idx.shared = sq
+	return idx, nil
+}
+
+// Close releases the SharedQuantizer's own reference
+//
+// Indexes created from it keep working; the quantizer is freed when the last
+// of them is closed. Calling Close more than once is a no-op.
+func (sq *SharedQuantizer) Close() error {
+	sq.mu.Lock()
+	if sq.closed {
+		sq.mu.Unlock()
+		return nil
+	}
+	sq.closed = true
+	sq.mu.Unlock()
+
+	sq.release()
+	return nil
+}
@@ -1,7 +1,11 @@
 package faiss
 
 import (
+	"errors"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 // ========================================
@@ -452,3 +456,1280 @@ func TestIVF_SetGetNprobe(t *testing.T) {
 		t.Errorf("GetNprobe() = %d, want 5", nprobe)
 	}
 }
+
+func TestNewIndexIVFFlatAuto(t *testing.T) {
+	d := 32
+	nlist := 8
+
+	index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+
+	if index.D() != d {
+		t.Errorf("D() = %d, want %d", index.D(), d)
+	}
+	if index.Nlist() != nlist {
+		t.Errorf("Nlist() = %d, want %d", index.Nlist(), nlist)
+	}
+
+	vectors := generateTestVectors(nlist*40, d)
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := index.SetNprobe(nlist); err != nil {
+		t.Fatalf("SetNprobe() failed: %v", err)
+	}
+
+	_, indices, err := index.Search(vectors[:d], 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if indices[0] != 0 {
+		t.Errorf("Search() nearest = %d, want 0", indices[0])
+	}
+
+	// The quantizer is owned by the index: closing only the index must free
+	// everything without crashing, and closing again must be a no-op
+	if err := index.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := index.Close(); err != nil {
+		t.Errorf("second Close() failed: %v", err)
+	}
+}
+
+func TestNewIndexIVFFlatAuto_InvalidParams(t *testing.T) {
+	if _, err := NewIndexIVFFlatAuto(0, 8, MetricL2); err == nil {
+		t.Error("NewIndexIVFFlatAuto(d=0) should return error")
+	}
+	if _, err := NewIndexIVFFlatAuto(32, 0, MetricL2); err == nil {
+		t.Error("NewIndexIVFFlatAuto(nlist=0) should return error")
+	}
+}
+
+func TestNewIndexIVFFlatHNSWQuantizer(t *testing.T) {
+	d := 32
+	nlist := 64
+	nb := 5000
+	nq := 100
+	k := 10
+	nprobe := 8
+
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, _ := flat.Search(queries, k)
+
+	recall := func(index *IndexIVFFlat) float64 {
+		t.Helper()
+		if err := index.Train(vectors); err != nil {
+			t.Fatalf("Train() failed: %v", err)
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+		if index.Ntotal() != int64(nb) {
+			t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), nb)
+		}
+		index.SetNprobe(nprobe)
+		_, labels, err := index.Search(queries, k)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		return ComputeRecall(groundTruth, labels, nq, k, k)
+	}
+
+	index, err := NewIndexIVFFlatHNSWQuantizer(d, nlist, 16, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatHNSWQuantizer() failed: %v", err)
+	}
+	defer index.Close()
+	if index.Nlist() != nlist {
+		t.Errorf("Nlist() = %d, want %d", index.Nlist(), nlist)
+	}
+	hnswRecall := recall(index)
+
+	assigned, err := index.Assign(queries)
+	if err != nil {
+		t.Fatalf("Assign() failed: %v", err)
+	}
+	if len(assigned) != nq {
+		t.Errorf("len(Assign()) = %d, want %d", len(assigned), nq)
+	}
+
+	reference, _ := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	defer reference.Close()
+	flatRecall := recall(reference)
+
+	// The coarse search is approximate, so allow a small recall loss
+	t.Logf("recall@%d (nprobe=%d): flat quantizer = %.3f, HNSW quantizer = %.3f", k, nprobe, flatRecall, hnswRecall)
+	if hnswRecall < flatRecall-0.1 {
+		t.Errorf("HNSW quantizer recall %.3f, want within 0.1 of flat quantizer recall %.3f", hnswRecall, flatRecall)
+	}
+
+	if _, err := NewIndexIVFFlatHNSWQuantizer(d, nlist, 0, MetricL2); err == nil {
+		t.Error("NewIndexIVFFlatHNSWQuantizer(hnswM=0) should return error")
+	}
+	if _, err := NewIndexIVFFlatHNSWQuantizer(d, 0, 16, MetricL2); err == nil {
+		t.Error("NewIndexIVFFlatHNSWQuantizer(nlist=0) should return error")
+	}
+}
+
+func TestIndexIVFFlat_SetClusteringParams(t *testing.T) {
+	d := 32
+	nlist := 32
+	nb := 4000
+	nq := 50
+	k := 10
+
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, _ := flat.Search(queries, k)
+
+	index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer index.Close()
+
+	if err := index.SetClusteringParams(0, 3); err == nil {
+		t.Error("SetClusteringParams(niter=0) should return error")
+	}
+	if err := index.SetClusteringParams(10, 0); err == nil {
+		t.Error("SetClusteringParams(nredo=0) should return error")
+	}
+	if err := index.SetClusteringParams(10, 3); err != nil {
+		t.Fatalf("SetClusteringParams() failed: %v", err)
+	}
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetNprobe(8)
+	_, labels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	recall := ComputeRecall(groundTruth, labels, nq, k, k)
+	t.Logf("recall@%d with nredo=3: %.3f", k, recall)
+	if recall < 0.3 {
+		t.Errorf("recall = %.3f, want >= 0.3", recall)
+	}
+
+	if err := index.SetClusteringParams(10, 3); err == nil {
+		t.Error("SetClusteringParams() after Train should return error")
+	}
+
+	// IVFPQ via the factory: the coarse k-means and the PQ codebooks
+	ivfpq := mustCreateGenericIndex(t, d, "IVF16,PQ8x4").(*GenericIndex)
+	defer ivfpq.Close()
+	if err := ivfpq.SetClusteringParams(10, 3); err != nil {
+		t.Fatalf("GenericIndex.SetClusteringParams() failed: %v", err)
+	}
+	if err := ivfpq.Train(vectors); err != nil {
+		t.Fatalf("GenericIndex.Train() failed: %v", err)
+	}
+	if err := ivfpq.Add(vectors); err != nil {
+		t.Fatalf("GenericIndex.Add() failed: %v", err)
+	}
+	_, labels, err = ivfpq.Search(queries[:d], 1)
+	if err != nil {
+		t.Fatalf("GenericIndex.Search() failed: %v", err)
+	}
+	if labels[0] < 0 || labels[0] >= int64(nb) {
+		t.Errorf("label = %d, want in [0, %d)", labels[0], nb)
+	}
+
+	pq := mustCreateGenericIndex(t, d, "PQ8x4").(*GenericIndex)
+	defer pq.Close()
+	if err := pq.SetClusteringParams(10, 3); err != nil {
+		t.Fatalf("SetClusteringParams() on PQ index failed: %v", err)
+	}
+	if err := pq.Train(vectors); err != nil {
+		t.Fatalf("PQ Train() failed: %v", err)
+	}
+
+	// Inner-product IVF over an HNSW quantizer keeps its spherical k-means
+	ipIndex, err := IndexFactory(d, "IVF16_HNSW8,Flat", MetricInnerProduct)
+	if err != nil {
+		t.Fatalf("IndexFactory(IVF16_HNSW8,Flat) failed: %v", err)
+	}
+	hnswIVF := ipIndex.(*GenericIndex)
+	defer hnswIVF.Close()
+	if err := hnswIVF.SetClusteringParams(10, 3); err != nil {
+		t.Fatalf("SetClusteringParams() on IVF-HNSW index failed: %v", err)
+	}
+	if err := hnswIVF.Train(vectors); err != nil {
+		t.Fatalf("IVF-HNSW Train() failed: %v", err)
+	}
+
+	hnsw := mustCreateGenericIndex(t, d, "HNSW16").(*GenericIndex)
+	defer hnsw.Close()
+	if err := hnsw.SetClusteringParams(10, 3); err == nil {
+		t.Error("SetClusteringParams() on HNSW index should return error")
+	}
+}
+
+func TestIndexIVFFlat_TrainSample(t *testing.T) {
+	d := 16
+	nlist := 16
+	nb := 10000
+
+	vectors := generateVectors(nb, d)
+
+	for _, fraction := range []float64{0, -0.5, 1.5} {
+		index, _ := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+		if err := index.TrainSample(vectors, fraction); err == nil {
+			t.Errorf("TrainSample(fraction=%v) should return error", fraction)
+		}
+		index.Close()
+	}
+
+	// 0.001 of the data is below 39*nlist, so the sample is raised to the minimum
+	for _, fraction := range []float64{0.1, 0.001, 1} {
+		index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+		if err != nil {
+			t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+		}
+		if err := index.TrainSample(vectors, fraction); err != nil {
+			t.Fatalf("TrainSample(fraction=%v) failed: %v", fraction, err)
+		}
+		if !index.IsTrained() {
+			t.Errorf("IsTrained() = false after TrainSample(fraction=%v)", fraction)
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+		if index.Ntotal() != int64(nb) {
+			t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), nb)
+		}
+		index.Close()
+	}
+
+	generic := mustCreateGenericIndex(t, d, "IVF16,Flat").(*GenericIndex)
+	defer generic.Close()
+	if err := generic.TrainSample(vectors, 0.05); err != nil {
+		t.Fatalf("GenericIndex.TrainSample() failed: %v", err)
+	}
+	if !generic.IsTrained() {
+		t.Error("GenericIndex.IsTrained() = false after TrainSample")
+	}
+
+	sample, err := sampleTrainingVectors(vectors, d, 0.1, 0)
+	if err != nil {
+		t.Fatalf("sampleTrainingVectors() failed: %v", err)
+	}
+	if len(sample) != nb/10*d {
+		t.Errorf("len(sample) = %d, want %d", len(sample), nb/10*d)
+	}
+}
+
+func TestRetrain(t *testing.T) {
+	d := 8
+	nlist := 16
+	nb := 2000
+
+	index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer index.Close()
+
+	vectors := generateVectors(nb, d)
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	index.Add(vectors)
+	index.SetNprobe(1)
+	before, _ := index.Assign(vectors[:d*100])
+
+	// Reconstruction enables the array direct map, which Retrain keeps
+	if _, err := index.Reconstruct(0); err != nil {
+		t.Fatalf("Reconstruct() failed: %v", err)
+	}
+
+	// Rejected training data leaves the index untouched
+	if err := Retrain(index, vectors[:d*10]); !errors.Is(err, ErrTrainingTooSmall) {
+		t.Errorf("Retrain(10 vectors) error = %v, want ErrTrainingTooSmall", err)
+	}
+	if index.Ntotal() != int64(nb) {
+		t.Fatalf("Ntotal() = %d after rejected Retrain, want %d", index.Ntotal(), nb)
+	}
+
+	// The distribution drifted: new training data is shifted and scaled
+	drifted := generateVectors(nb, d)
+	for i := range drifted {
+		drifted[i] = drifted[i]*3 + 2
+	}
+	if err := Retrain(index, drifted); err != nil {
+		t.Fatalf("Retrain() failed: %v", err)
+	}
+
+	if index.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d after Retrain, want %d", index.Ntotal(), nb)
+	}
+	if index.Nprobe() != 1 {
+		t.Errorf("Nprobe() = %d after Retrain, want 1", index.Nprobe())
+	}
+
+	// Vectors keep their IDs and are found in their new lists
+	recons, err := index.ReconstructN(0, int64(nb))
+	if err != nil {
+		t.Fatalf("ReconstructN() failed: %v", err)
+	}
+	for i := range vectors {
+		if recons[i] != vectors[i] {
+			t.Fatalf("reconstructed[%d] = %v, want %v", i, recons[i], vectors[i])
+		}
+	}
+	_, labels, err := index.Search(vectors[:d*100], 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for q, id := range labels {
+		if id != int64(q) {
+			t.Fatalf("query %d: nearest ID %d, want %d", q, id, q)
+		}
+	}
+
+	after, _ := index.Assign(vectors[:d*100])
+	changed := 0
+	for i := range before {
+		if before[i] != after[i] {
+			changed++
+		}
+	}
+	if changed == 0 {
+		t.Error("no vector changed list after Retrain, want new centroids")
+	}
+}
+
+func TestRetrain_CustomIDs(t *testing.T) {
+	d := 8
+	nlist := 16
+	nb := 1000
+
+	vectors := generateVectors(nb, d)
+	ids := make([]int64, nb)
+	for i := range ids {
+		ids[i] = int64(5000 + 3*i)
+	}
+	drifted := generateVectors(nb, d)
+	for i := range drifted {
+		drifted[i] = drifted[i]*3 + 2
+	}
+
+	factoryIndex, err := IndexFactory(d, "IDMap,IVF16,Flat", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer factoryIndex.Close()
+
+	base, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer base.Close()
+	idmap, err := NewIndexIDMap(base)
+	if err != nil {
+		t.Fatalf("NewIndexIDMap() failed: %v", err)
+	}
+	defer idmap.Close()
+
+	tests := []struct {
+		name  string
+		index Index
+	}{
+		{"factory IDMap", factoryIndex},
+		{"IndexIDMap over IVFFlat", idmap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.index.Train(vectors); err != nil {
+				t.Fatalf("Train() failed: %v", err)
+			}
+			if err := addWithIDs(tt.index, vectors, ids); err != nil {
+				t.Fatalf("AddWithIDs() failed: %v", err)
+			}
+			tt.index.SetNprobe(nlist)
+
+			if err := Retrain(tt.index, drifted); err != nil {
+				t.Fatalf("Retrain() failed: %v", err)
+			}
+			if tt.index.Ntotal() != int64(nb) {
+				t.Errorf("Ntotal() = %d after Retrain, want %d", tt.index.Ntotal(), nb)
+			}
+
+			_, labels, err := tt.index.Search(vectors[:d*50], 1)
+			if err != nil {
+				t.Fatalf("Search() failed: %v", err)
+			}
+			for q, id := range labels {
+				if id != ids[q] {
+					t.Fatalf("query %d: nearest ID %d, want %d", q, id, ids[q])
+				}
+			}
+		})
+	}
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	if err := Retrain(flat, drifted); err == nil {
+		t.Error("Retrain(IndexFlat) succeeded, want error")
+	}
+}
+
+func TestIndexIVFFlat_SearchExplain(t *testing.T) {
+	d := 16
+	nlist := 32
+	nprobe := 4
+	nb := 3000
+	k := 5
+
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(10, d)
+
+	index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer index.Close()
+
+	if _, _, err := index.SearchExplain(queries[:d], k); !errors.Is(err, ErrNotTrained) {
+		t.Errorf("SearchExplain() before Train error = %v, want ErrNotTrained", err)
+	}
+
+	index.Train(vectors)
+	index.Add(vectors)
+	index.SetNprobe(nprobe)
+
+	for q := 0; q < 10; q++ {
+		query := queries[q*d : (q+1)*d]
+		result, probed, err := index.SearchExplain(query, k)
+		if err != nil {
+			t.Fatalf("SearchExplain() failed: %v", err)
+		}
+		if len(probed) != nprobe {
+			t.Fatalf("len(probedLists) = %d, want %d", len(probed), nprobe)
+		}
+
+		nearest, err := index.Assign(query)
+		if err != nil {
+			t.Fatalf("Assign() failed: %v", err)
+		}
+		if probed[0] != nearest[0] {
+			t.Errorf("query %d: probedLists[0] = %d, want nearest centroid %d", q, probed[0], nearest[0])
+		}
+
+		_, labels, _ := index.Search(query, k)
+		if result.Nq != 1 || result.K != k {
+			t.Errorf("result Nq, K = %d, %d, want 1, %d", result.Nq, result.K, k)
+		}
+		for i := range labels {
+			if result.Labels[i] != labels[i] {
+				t.Errorf("query %d: result.Labels[%d] = %d, want %d", q, i, result.Labels[i], labels[i])
+			}
+		}
+	}
+
+	if _, _, err := index.SearchExplain(queries[:d-1], k); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("SearchExplain() with short query error = %v, want ErrDimensionMismatch", err)
+	}
+
+	generic := mustCreateGenericIndex(t, d, "IVF16,Flat").(*GenericIndex)
+	defer generic.Close()
+	generic.Train(vectors)
+	generic.Add(vectors)
+	generic.SetNprobe(3)
+	if _, probed, err := generic.SearchExplain(queries[:d], k); err != nil {
+		t.Errorf("GenericIndex.SearchExplain() failed: %v", err)
+	} else if len(probed) != 3 {
+		t.Errorf("GenericIndex len(probedLists) = %d, want 3", len(probed))
+	}
+
+	hnsw := mustCreateGenericIndex(t, d, "HNSW16").(*GenericIndex)
+	defer hnsw.Close()
+	hnsw.Add(vectors)
+	if _, _, err := hnsw.SearchExplain(queries[:d], k); err == nil {
+		t.Error("SearchExplain() on non-IVF index should return error")
+	}
+}
+
+func TestIndexIVFFlat_SearchWithTimeout(t *testing.T) {
+	d := 16
+	nlist := 32
+	nb := 3000
+	nq := 20
+	k := 5
+
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+
+	index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer index.Close()
+	index.Train(vectors)
+	index.Add(vectors)
+	index.SetNprobe(2)
+
+	flat := mustCreateIndexFlatL2(t, d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, _ := flat.Search(queries, k)
+
+	// A tiny budget still returns a full (nprobe=1) result set
+	distances, labels, err := index.SearchWithTimeout(queries, k, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("SearchWithTimeout(1ns) failed: %v", err)
+	}
+	if len(distances) != nq*k || len(labels) != nq*k {
+		t.Fatalf("got %d distances, %d labels, want %d", len(distances), len(labels), nq*k)
+	}
+	for i, id := range labels {
+		if id < 0 {
+			t.Fatalf("labels[%d] = %d, want a result for every slot", i, id)
+		}
+	}
+	t.Logf("recall@%d with 1ns budget: %.2f", k, ComputeRecall(groundTruth, labels, nq, k, k))
+
+	// A generous budget reaches nprobe = nlist, i.e. exact results
+	_, labels, err = index.SearchWithTimeout(queries, k, time.Minute)
+	if err != nil {
+		t.Fatalf("SearchWithTimeout(1m) failed: %v", err)
+	}
+	if recall := ComputeRecall(groundTruth, labels, nq, k, k); recall != 1 {
+		t.Errorf("recall@%d with 1m budget = %.2f, want 1", k, recall)
+	}
+
+	if index.Nprobe() != 2 {
+		t.Errorf("Nprobe() = %d after SearchWithTimeout, want 2 (unchanged)", index.Nprobe())
+	}
+	if _, _, err := index.SearchWithTimeout(queries, 0, time.Second); !errors.Is(err, ErrInvalidK) {
+		t.Errorf("SearchWithTimeout(k=0) error = %v, want ErrInvalidK", err)
+	}
+}
+
+func TestIVFFlat_InnerProductQuantizer(t *testing.T) {
+	d := 32
+	nlist := 32
+	nb := 5000
+	nq := 100
+	k := 10
+
+	vectors := generateVectors(nb, d)
+	if err := NormalizeL2(vectors, d); err != nil {
+		t.Fatalf("NormalizeL2() failed: %v", err)
+	}
+	queries := generateVectors(nq, d)
+	if err := NormalizeL2(queries, d); err != nil {
+		t.Fatalf("NormalizeL2() failed: %v", err)
+	}
+
+	quantizer, _ := NewIndexFlatIP(d)
+	defer quantizer.Close()
+	index, err := NewIndexIVFFlat(quantizer, d, nlist, MetricInnerProduct)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlat() failed: %v", err)
+	}
+	defer index.Close()
+
+	// Coarse assignment must use inner product as well
+	metric, err := faissIndexIVFQuantizerMetric(index.ptr)
+	if err != nil {
+		t.Fatalf("faissIndexIVFQuantizerMetric() failed: %v", err)
+	}
+	if metric != MetricInnerProduct {
+		t.Errorf("quantizer metric = %s, want %s", metric, MetricInnerProduct)
+	}
+
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := index.SetNprobe(nlist / 2); err != nil {
+		t.Fatalf("SetNprobe() failed: %v", err)
+	}
+	_, labels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	flat, _ := NewIndexFlatIP(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, err := flat.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search(flat) failed: %v", err)
+	}
+
+	recall := ComputeRecall(groundTruth, labels, nq, k, k)
+	t.Logf("IVF-IP recall@%d with nprobe=%d: %.3f", k, nlist/2, recall)
+	if recall < 0.8 {
+		t.Errorf("recall = %.3f, want >= 0.8", recall)
+	}
+}
+
+func TestNewIndexIVFFlat_QuantizerMetricMismatch(t *testing.T) {
+	quantizer, _ := NewIndexFlatL2(16)
+	defer quantizer.Close()
+
+	_, err := NewIndexIVFFlat(quantizer, 16, 4, MetricInnerProduct)
+	if err == nil {
+		t.Fatal("NewIndexIVFFlat() with L2 quantizer and IP metric should return error")
+	}
+	if !strings.Contains(err.Error(), "quantizer metric") {
+		t.Errorf("error = %q, want it to mention the quantizer metric", err)
+	}
+}
+
+func TestIVFPQ_SetByResidual(t *testing.T) {
+	d := 16
+	nlist := 4
+	vectors := generateVectors(1000, d)
+	queries := vectors[:5*d]
+
+	search := func(byResidual bool) []float32 {
+		index, err := NewIndexIVFPQ(nil, d, nlist, 4, 4)
+		if err != nil {
+			t.Fatalf("NewIndexIVFPQ() failed: %v", err)
+		}
+		defer index.Close()
+
+		gen := index.(*GenericIndex)
+		ptr := gen.ptr
+		if err := gen.SetByResidual(byResidual); err != nil {
+			t.Fatalf("SetByResidual(%v) failed: %v", byResidual, err)
+		}
+		if gen.ptr != ptr {
+			t.Error("SetByResidual() replaced the FAISS index instead of updating it in place")
+		}
+		got, err := gen.ByResidual()
+		if err != nil {
+			t.Fatalf("ByResidual() failed: %v", err)
+		}
+		if got != byResidual {
+			t.Errorf("ByResidual() = %v, want %v", got, byResidual)
+		}
+
+		if err := index.Train(vectors); err != nil {
+			t.Fatalf("Train() failed: %v", err)
+		}
+		if err := gen.SetByResidual(!byResidual); err == nil {
+			t.Error("SetByResidual() after training should return error")
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+		if err := index.SetNprobe(nlist); err != nil {
+			t.Fatalf("SetNprobe() failed: %v", err)
+		}
+
+		distances, labels, err := index.Search(queries, 5)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		for i, label := range labels {
+			if label < 0 || label >= 1000 {
+				t.Fatalf("labels[%d] = %d, out of range", i, label)
+			}
+		}
+		return distances
+	}
+
+	residual := search(true)
+	raw := search(false)
+
+	same := true
+	for i := range residual {
+		if residual[i] != raw[i] {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Error("by_residual=true and by_residual=false produced identical distances")
+	}
+}
+
+func TestSetByResidual_NotIVFPQ(t *testing.T) {
+	index, err := IndexFactory(16, "IVF4,Flat", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer index.Close()
+
+	if err := index.(*GenericIndex).SetByResidual(false); err == nil {
+		t.Error("SetByResidual() on IVFFlat should return error")
+	}
+}
+
+func TestIVF_Warmup(t *testing.T) {
+	d := 16
+	nb := 1000
+	vectors := generateVectors(nb, d)
+
+	index, err := IndexFactory(d, "IVF8,PQ4x4", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer index.Close()
+
+	gen := index.(*GenericIndex)
+	if err := gen.Warmup(); err != ErrNotTrained {
+		t.Errorf("Warmup() before training = %v, want %v", err, ErrNotTrained)
+	}
+
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := gen.Warmup(); err != nil {
+		t.Fatalf("Warmup() failed: %v", err)
+	}
+
+	_, labels, err := index.Search(vectors[:d], 5)
+	if err != nil {
+		t.Fatalf("Search() after Warmup() failed: %v", err)
+	}
+	for i, label := range labels {
+		if label < 0 || label >= int64(nb) {
+			t.Errorf("labels[%d] = %d, out of range", i, label)
+		}
+	}
+
+	flat, err := NewIndexIVFFlatAuto(d, 8, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer flat.Close()
+	if err := flat.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := flat.Warmup(); err != nil {
+		t.Fatalf("IndexIVFFlat.Warmup() failed: %v", err)
+	}
+	if _, _, err := flat.Search(vectors[:d], 1); err != nil {
+		t.Fatalf("Search() after Warmup() failed: %v", err)
+	}
+}
+
+func TestIVF_SetMaxCodes(t *testing.T) {
+	d := 16
+	nlist := 8
+	k := 10
+
+	// Skewed data: most vectors fall into one tight cluster so that a
+	// handful of inverted lists are much denser than the rest
+	nb := 2000
+	vectors := generateVectors(nb, d)
+	for i := 0; i < nb*9/10; i++ {
+		for j := 0; j < d; j++ {
+			vectors[i*d+j] *= 0.05
+		}
+	}
+	queries := vectors[:20*d]
+
+	index, err := IndexFactory(d, "IVF8,Flat", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer index.Close()
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := index.SetNprobe(nlist); err != nil {
+		t.Fatalf("SetNprobe() failed: %v", err)
+	}
+
+	gen := index.(*GenericIndex)
+	_, full, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	if err := gen.SetMaxCodes(20); err != nil {
+		t.Fatalf("SetMaxCodes() failed: %v", err)
+	}
+	_, capped, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() with max_codes failed: %v", err)
+	}
+
+	// With nprobe=nlist the uncapped search is exhaustive, so it is the
+	// ground truth for the capped one
+	fullRecall := ComputeRecall(full, full, 20, k, k)
+	cappedRecall := ComputeRecall(full, capped, 20, k, k)
+	if cappedRecall >= fullRecall {
+		t.Errorf("recall with max_codes=20 = %v, want < %v", cappedRecall, fullRecall)
+	}
+
+	// Removing the limit restores exhaustive results
+	if err := gen.SetMaxCodes(0); err != nil {
+		t.Fatalf("SetMaxCodes(0) failed: %v", err)
+	}
+	_, restored, _ := index.Search(queries, k)
+	if recall := ComputeRecall(full, restored, 20, k, k); recall != fullRecall {
+		t.Errorf("recall after SetMaxCodes(0) = %v, want %v", recall, fullRecall)
+	}
+
+	if err := gen.SetMaxCodes(-1); err == nil {
+		t.Error("SetMaxCodes(-1) should return error")
+	}
+
+	flat, _ := IndexFactory(d, "Flat", MetricL2)
+	defer flat.Close()
+	if err := flat.(*GenericIndex).SetMaxCodes(10); err == nil {
+		t.Error("SetMaxCodes() on flat index should return error")
+	}
+}
+
+func TestIVF_SetParallelMode(t *testing.T) {
+	d := 16
+	nlist := 16
+	k := 10
+	vectors := generateVectors(3000, d)
+	queries := vectors[:50*d]
+
+	ivf, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer ivf.Close()
+	gen, err := IndexFactory(d, "IVF16,SQ8", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer gen.Close()
+
+	setters := map[Index]func(int) error{
+		ivf: ivf.SetParallelMode,
+		gen: gen.(*GenericIndex).SetParallelMode,
+	}
+	for index, setMode := range setters {
+		index.Train(vectors)
+		index.Add(vectors)
+		index.SetNprobe(4)
+
+		wantDist, wantLabels, err := index.Search(queries, k)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+
+		// Only the threading changes, never the results
+		for _, mode := range []int{IVFParallelLists, IVFParallelBoth, IVFParallelQueriesFine, IVFParallelQueries} {
+			if err := setMode(mode); err != nil {
+				t.Fatalf("%T: SetParallelMode(%d) failed: %v", index, mode, err)
+			}
+			dist, labels, err := index.Search(queries, k)
+			if err != nil {
+				t.Fatalf("%T: Search() in mode %d failed: %v", index, mode, err)
+			}
+			for i := range labels {
+				if labels[i] != wantLabels[i] || !approxEqual(dist[i], wantDist[i]) {
+					t.Fatalf("%T: mode %d result %d = (%d, %v), want (%d, %v)",
+						index, mode, i, labels[i], dist[i], wantLabels[i], wantDist[i])
+				}
+			}
+		}
+
+		if err := setMode(4); err == nil {
+			t.Errorf("%T: SetParallelMode(4) should return error", index)
+		}
+	}
+
+	if err := ivf.SetParallelMode(IVFParallelLists); err != nil {
+		t.Fatalf("SetParallelMode() failed: %v", err)
+	}
+	if mode, err := ivf.GetParallelMode(); err != nil || mode != IVFParallelLists {
+		t.Errorf("GetParallelMode() = %d, %v, want %d", mode, err, IVFParallelLists)
+	}
+	if mode, err := gen.(*GenericIndex).GetParallelMode(); err != nil || mode != IVFParallelQueries {
+		t.Errorf("GetParallelMode() = %d, %v, want %d", mode, err, IVFParallelQueries)
+	}
+
+	flat, _ := IndexFactory(d, "Flat", MetricL2)
+	defer flat.Close()
+	if err := flat.(*GenericIndex).SetParallelMode(IVFParallelLists); err == nil {
+		t.Error("SetParallelMode() on flat index should return error")
+	}
+}
+
+func TestIVF_ResetPreservesTraining(t *testing.T) {
+	d := 16
+	nb := 1000
+	vectors := generateVectors(nb, d)
+
+	ivfpq, err := NewIndexIVFPQ(nil, d, 8, 4, 4)
+	if err != nil {
+		t.Fatalf("NewIndexIVFPQ() failed: %v", err)
+	}
+	defer ivfpq.Close()
+
+	ivfflat, err := NewIndexIVFFlatAuto(d, 8, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer ivfflat.Close()
+
+	quantizer, err := NewIndexFlatL2(d)
+	if err != nil {
+		t.Fatalf("NewIndexFlatL2() failed: %v", err)
+	}
+	defer quantizer.Close()
+
+	ivfsq, err := NewIndexIVFScalarQuantizer(quantizer, d, 8, QT_8bit, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFScalarQuantizer() failed: %v", err)
+	}
+	defer ivfsq.Close()
+
+	indexes := map[string]Index{"IVFPQ": ivfpq, "IVFFlat": ivfflat, "IVFSQ": ivfsq}
+	for name, index := range indexes {
+		if err := index.Train(vectors); err != nil {
+			t.Fatalf("%s: Train() failed: %v", name, err)
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("%s: Add() failed: %v", name, err)
+		}
+
+		if err := index.Reset(); err != nil {
+			t.Fatalf("%s: Reset() failed: %v", name, err)
+		}
+		if index.Ntotal() != 0 {
+			t.Errorf("%s: Ntotal() after Reset = %d, want 0", name, index.Ntotal())
+		}
+		if !index.IsTrained() {
+			t.Errorf("%s: IsTrained() after Reset = false, want true", name)
+		}
+
+		// Refill without re-training
+		if err := index.Add(vectors[:100*d]); err != nil {
+			t.Fatalf("%s: Add() after Reset failed: %v", name, err)
+		}
+		if index.Ntotal() != 100 {
+			t.Errorf("%s: Ntotal() = %d, want 100", name, index.Ntotal())
+		}
+		index.SetNprobe(8)
+		_, labels, err := index.Search(vectors[:d], 1)
+		if err != nil {
+			t.Fatalf("%s: Search() after Reset failed: %v", name, err)
+		}
+		if labels[0] < 0 || labels[0] >= 100 {
+			t.Errorf("%s: label = %d, want in [0, 100)", name, labels[0])
+		}
+	}
+}
+
+func TestCopySubset(t *testing.T) {
+	d := 16
+	nb := 1000
+	vectors := generateVectors(nb, d)
+
+	index, err := NewIndexIVFFlatAuto(d, 8, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer index.Close()
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetNprobe(8)
+
+	lower, err := CopySubset(index, 0, 500)
+	if err != nil {
+		t.Fatalf("CopySubset(0, 500) failed: %v", err)
+	}
+	defer lower.Close()
+	upper, err := CopySubset(index, 500, 1000)
+	if err != nil {
+		t.Fatalf("CopySubset(500, 1000) failed: %v", err)
+	}
+	defer upper.Close()
+
+	if lower.Ntotal() != 500 || upper.Ntotal() != 500 {
+		t.Fatalf("Ntotal() = %d/%d, want 500/500", lower.Ntotal(), upper.Ntotal())
+	}
+	if !lower.IsTrained() || lower.Nprobe() != 8 {
+		t.Errorf("subset IsTrained()/Nprobe() = %v/%d, want true/8", lower.IsTrained(), lower.Nprobe())
+	}
+	if index.Ntotal() != int64(nb) {
+		t.Errorf("source Ntotal() = %d, want %d", index.Ntotal(), nb)
+	}
+
+	// Each vector is found (under its original ID) only in the shard owning it
+	for _, id := range []int{0, 123, 499, 500, 777, 999} {
+		query := vectors[id*d : (id+1)*d]
+		owner, other := lower, upper
+		if id >= 500 {
+			owner, other = upper, lower
+		}
+
+		_, labels, err := owner.Search(query, 1)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		if labels[0] != int64(id) {
+			t.Errorf("owning shard returned %d for vector %d", labels[0], id)
+		}
+
+		_, labels, err = other.Search(query, 1)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		if labels[0] == int64(id) {
+			t.Errorf("vector %d found in the wrong shard", id)
+		}
+	}
+
+	if _, err := CopySubset(index, 10, 5); err == nil {
+		t.Error("CopySubset() with inverted range should return error")
+	}
+}
+
+// ========================================
+// Shared Quantizer Tests
+// ========================================
+
+func TestSharedQuantizer_CloseOneIndex(t *testing.T) {
+	d := 16
+	nlist := 8
+	vectors := generateVectors(1000, d)
+
+	quantizer, _ := NewIndexFlatL2(d)
+	quantizer.Add(vectors[:nlist*d])
+	shared, err := NewSharedQuantizer(quantizer)
+	if err != nil {
+		t.Fatalf("NewSharedQuantizer() failed: %v", err)
+	}
+	defer shared.Close()
+	if shared.Nlist() != nlist {
+		t.Errorf("Nlist() = %d, want %d", shared.Nlist(), nlist)
+	}
+
+	first, err := shared.NewIndexIVFFlat()
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlat() failed: %v", err)
+	}
+	second, err := shared.NewIndexIVFFlat()
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlat() failed: %v", err)
+	}
+	defer second.Close()
+	if got := shared.References(); got != 3 {
+		t.Errorf("References() = %d, want 3", got)
+	}
+
+	if !first.IsTrained() || !second.IsTrained() {
+		t.Error("indexes built on a trained quantizer should be trained")
+	}
+	first.Add(vectors)
+	second.Add(vectors)
+
+	// Both indexes assign vectors to the same lists
+	firstLists, _ := first.Assign(vectors[:100*d])
+	secondLists, _ := second.Assign(vectors[:100*d])
+	for i := range firstLists {
+		if firstLists[i] != secondLists[i] {
+			t.Fatalf("vector %d assigned to lists %d and %d", i, firstLists[i], secondLists[i])
+		}
+	}
+
+	// Closing one index and the SharedQuantizer handle must not free the
+	// quantizer still used by the other index
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	shared.Close()
+	if got := shared.References(); got != 1 {
+		t.Errorf("References() = %d, want 1", got)
+	}
+
+	second.SetNprobe(nlist)
+	_, labels, err := second.Search(vectors[:10*d], 1)
+	if err != nil {
+		t.Fatalf("Search() after closing the other index failed: %v", err)
+	}
+	for i, label := range labels {
+		if label != int64(i) {
+			t.Errorf("Search(vector %d) = %d, want %d", i, label, i)
+		}
+	}
+
+	if _, err := shared.NewIndexIVFFlat(); err == nil {
+		t.Error("NewIndexIVFFlat() on closed SharedQuantizer should return error")
+	}
+
+	// The quantizer cannot be modified, freed or reallocated under the open index
+	if err := quantizer.Add(vectors[:d]); err == nil {
+		t.Error("Add() on a shared quantizer in use should return error")
+	}
+	if err := quantizer.Reset(); err == nil {
+		t.Error("Reset() on a shared quantizer in use should return error")
+	}
+	if got := quantizer.Ntotal(); got != int64(nlist) {
+		t.Errorf("quantizer Ntotal() = %d, want %d", got, nlist)
+	}
+	if err := quantizer.Compact(); err == nil {
+		t.Error("Compact() on a shared quantizer in use should return error")
+	}
+	if err := quantizer.Reserve(10 * int64(nlist)); err == nil {
+		t.Error("Reserve() on a shared quantizer in use should return error")
+	}
+	if err := quantizer.Close(); err == nil {
+		t.Error("Close() on a shared quantizer in use should return error")
+	}
+	if quantizer.ptr == 0 {
+		t.Fatal("quantizer freed by Close() while still in use")
+	}
+
+	second.Close()
+	if got := shared.References(); got != 0 {
+		t.Errorf("References() = %d, want 0", got)
+	}
+	if quantizer.ptr != 0 {
+		t.Error("quantizer should be freed after the last index is closed")
+	}
+}
+
+func TestSharedQuantizer_Finalizer(t *testing.T) {
+	d := 16
+	nlist := 8
+
+	quantizer, _ := NewIndexFlatL2(d)
+	quantizer.Add(generateVectors(nlist, d))
+	shared, err := NewSharedQuantizer(quantizer)
+	if err != nil {
+		t.Fatalf("NewSharedQuantizer() failed: %v", err)
+	}
+	index, err := shared.NewIndexIVFFlat()
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlat() failed: %v", err)
+	}
+	index.Close()
+
+	// Dropping the SharedQuantizer without Close frees the quantizer once
+	// it is garbage collected
+	shared = nil
+	for i := 0; i < 10 && quantizer.ptr != 0; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if quantizer.ptr != 0 {
+		t.Error("quantizer should be freed after its SharedQuantizer is collected")
+	}
+}
+
+func TestSharedQuantizer_ScalarQuantizer(t *testing.T) {
+	d := 16
+	nlist := 4
+	vectors := generateVectors(500, d)
+
+	quantizer, _ := NewIndexFlatL2(d)
+	quantizer.Add(vectors[:nlist*d])
+	shared, _ := NewSharedQuantizer(quantizer)
+	defer shared.Close()
+
+	index, err := shared.NewIndexIVFScalarQuantizer(QT_8bit)
+	if err != nil {
+		t.Fatalf("NewIndexIVFScalarQuantizer() failed: %v", err)
+	}
+	defer index.Close()
+
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if quantizer.Ntotal() != int64(nlist) {
+		t.Errorf("quantizer Ntotal() = %d after training, want %d", quantizer.Ntotal(), nlist)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetNprobe(nlist)
+	if _, _, err := index.Search(vectors[:d], 1); err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+}
+
+func TestSharedQuantizer_IVFPQ(t *testing.T) {
+	d := 16
+	nlist := 4
+	vectors := generateVectors(2000, d)
+
+	quantizer, _ := NewIndexFlatIP(d)
+	quantizer.Add(vectors[:nlist*d])
+	shared, _ := NewSharedQuantizer(quantizer)
+	defer shared.Close()
+
+	index, err := shared.NewIndexIVFPQ(4, 4)
+	if err != nil {
+		t.Fatalf("NewIndexIVFPQ() failed: %v", err)
+	}
+	if index.MetricType() != MetricInnerProduct {
+		t.Errorf("MetricType() = %v, want %v", index.MetricType(), MetricInnerProduct)
+	}
+	if got := shared.References(); got != 2 {
+		t.Errorf("References() = %d, want 2", got)
+	}
+
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if quantizer.Ntotal() != int64(nlist) {
+		t.Errorf("quantizer Ntotal() = %d after training, want %d", quantizer.Ntotal(), nlist)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetNprobe(nlist)
+	if _, _, err := index.Search(vectors[:d], 1); err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	if err := index.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if got := shared.References(); got != 1 {
+		t.Errorf("References() after Close() = %d, want 1", got)
+	}
+
+	if _, err := shared.NewIndexIVFPQ(3, 8); err == nil {
+		t.Error("NewIndexIVFPQ() with M not dividing d should return error")
+	}
+}
+
+func TestNewSharedQuantizer_Invalid(t *testing.T) {
+	if _, err := NewSharedQuantizer(nil); err == nil {
+		t.Error("NewSharedQuantizer(nil) should return error")
+	}
+
+	empty, _ := NewIndexFlatL2(8)
+	defer empty.Close()
+	if _, err := NewSharedQuantizer(empty); err == nil {
+		t.Error("NewSharedQuantizer() with no centroids should return error")
+	}
+}
@@ -101,6 +101,9 @@ func (idx *IndexLSH) D() int {
 
 // Ntotal returns the number of vectors in the index
 func (idx *IndexLSH) Ntotal() int64 {
+	if idx.ptr == 0 {
+		return 0
+	}
 	ntotal := faiss_Index_ntotal(idx.ptr)
 	idx.ntotal = ntotal
 	return ntotal
@@ -125,11 +128,18 @@ func (idx *IndexLSH) Nbits() int {
 
 // Train is a no-op for LSH (unless using rotation with training)
 func (idx *IndexLSH) Train(vectors []float32) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	// If using rotation, we might want to train it
@@ -147,11 +157,18 @@ func (idx *IndexLSH) Train(vectors []float32) error {
 
 // Add adds vectors to the index
 func (idx *IndexLSH) Add(vectors []float32) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -166,11 +183,18 @@ func (idx *IndexLSH) Add(vectors []float32) error {
 
 // Search performs k-NN search using LSH
 func (idx *IndexLSH) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 {
+		return nil, nil, ErrNullPointer
+	}
 	if len(queries) == 0 {
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -197,6 +221,9 @@ func (idx *IndexLSH) SetEfSearch(efSearch int) error {
 
 // Reset removes all vectors from the index
 func (idx *IndexLSH) Reset() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	ret := faiss_Index_reset(idx.ptr)
 	if ret != 0 {
 		return fmt.Errorf("reset failed")
@@ -0,0 +1,347 @@
+package faiss
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// IndexRowwiseMinMax scales every vector to [0, 1] before handing it to a base
+// index, and keeps the per-row (min, scale) pair so that the original vector
+// can be reconstructed
+//
+// Quantizers such as SQ8 learn one value range per dimension over the whole
+// dataset, so vectors with a small magnitude end up using only a few of the
+// available levels. Normalizing each row first lets every vector use the full
+// code range, which noticeably improves SQ recall on embeddings whose norms
+// vary a lot.
+//
+// Search runs the normalized query against the base index to collect
+// k*kFactor candidates, then reranks them by their exact distance to the
+// query after undoing the scaling. The base index must therefore support
+// reconstruction (flat and scalar quantizer indexes do).
+//
+// The base index must be empty when the wrapper is created, and all vectors
+// must be added through the wrapper. The wrapper does not own the base index.
+//
+// Python equivalent: faiss.IndexRowwiseMinMax (which FAISS only provides as a
+// standalone codec, without search)
+//
+// Example:
+//   sq, _ := faiss.NewIndexScalarQuantizer(128, faiss.QT_8bit, faiss.MetricL2)
+//   index, _ := faiss.NewIndexRowwiseMinMax(sq)
+//   index.Train(trainingVectors)
+//   index.Add(vectors)
+//   distances, ids, _ := index.Search(queries, 10)
+type IndexRowwiseMinMax struct {
+	base    Index
+	basePtr uintptr // C pointer of base, used for reconstruction
+	d       int
+	metric  MetricType
+	mins    []float32 // per-row minimum
+	scales  []float32 // per-row (max - min)
+	kFactor int       // candidates fetched from base = k * kFactor
+	closed  bool
+}
+
+// Ensure IndexRowwiseMinMax implements Index
+var _ Index = (*IndexRowwiseMinMax)(nil)
+
+// NewIndexRowwiseMinMax wraps an empty base index with per-row min-max scaling
+func NewIndexRowwiseMinMax(base Index) (*IndexRowwiseMinMax, error) {
+	if base == nil {
+		return nil, fmt.Errorf("faiss: base index cannot be nil")
+	}
+	ptr, ok := indexPointer(base)
+	if !ok {
+		return nil, fmt.Errorf("faiss: unsupported base index type %T", base)
+	}
+	if ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	if base.Ntotal() != 0 {
+		return nil, fmt.Errorf("faiss: base index must be empty")
+	}
+
+	return &IndexRowwiseMinMax{
+		base:    base,
+		basePtr: ptr,
+		d:       base.D(),
+		metric:  base.MetricType(),
+		kFactor: 10,
+	}, nil
+}
+
+// D returns the dimension
+func (idx *IndexRowwiseMinMax) D() int {
+	return idx.d
+}
+
+// Ntotal returns the number of vectors in the index
+func (idx *IndexRowwiseMinMax) Ntotal() int64 {
+	if idx.closed {
+		return 0
+	}
+	return int64(len(idx.mins))
+}
+
+// IsTrained returns whether the base index is trained
+func (idx *IndexRowwiseMinMax) IsTrained() bool {
+	if idx.closed {
+		return false
+	}
+	return idx.base.IsTrained()
+}
+
+// MetricType returns the metric of the base index
+func (idx *IndexRowwiseMinMax) MetricType() MetricType {
+	return idx.metric
+}
+
+// Base returns the wrapped index
+func (idx *IndexRowwiseMinMax) Base() Index {
+	return idx.base
+}
+
+// SetKFactor sets how many candidates (k * kFactor) are fetched from the base
+// index before exact reranking
+func (idx *IndexRowwiseMinMax) SetKFactor(kFactor int) error {
+	if kFactor < 1 {
+		return fmt.Errorf("faiss: k_factor must be >= 1")
+	}
+	idx.kFactor = kFactor
+	return nil
+}
+
+// checkUsable refuses operations once the wrapper or its base is closed
+func (idx *IndexRowwiseMinMax) checkUsable() error {
+	if idx.closed || isClosed(idx.base) {
+		return ErrNullPointer
+	}
+	return nil
+}
+
+// normalizeRows returns the rows scaled to [0, 1] with their min and scale
+func normalizeRows(vectors []float32, d int) (normalized, mins, scales []float32) {
+	n := len(vectors) / d
+	normalized = make([]float32, len(vectors))
+	mins = make([]float32, n)
+	scales = make([]float32, n)
+
+	for i := 0; i < n; i++ {
+		row := vectors[i*d : (i+1)*d]
+		lo, hi := row[0], row[0]
+		for _, v := range row[1:] {
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+		}
+
+		mins[i] = lo
+		scales[i] = hi - lo
+		if scales[i] == 0 {
+			// Constant row: every component maps to 0
+			continue
+		}
+		out := normalized[i*d : (i+1)*d]
+		for j, v := range row {
+			out[j] = (v - lo) / scales[i]
+		}
+	}
+	return normalized, mins, scales
+}
+
+// Train trains the base index on the normalized vectors
+func (idx *IndexRowwiseMinMax) Train(vectors []float32) error {
+	if err := idx.checkUsable(); err != nil {
+		return err
+	}
+	if len(vectors) == 0 {
+		return nil
+	}
+	if len(vectors)%idx.d != 0 {
+		return ErrInvalidVectors
+	}
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+
+	normalized, _, _ := normalizeRows(vectors, idx.d)
+	return idx.base.Train(normalized)
+}
+
+// Add normalizes the vectors, adds them to the base index and records their
+// scale factors
+func (idx *IndexRowwiseMinMax) Add(vectors []float32) error {
+	if err := idx.checkUsable(); err != nil {
+		return err
+	}
+	if len(vectors) == 0 {
+		return nil
+	}
+	if len(vectors)%idx.d != 0 {
+		return ErrInvalidVectors
+	}
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+
+	normalized, mins, scales := normalizeRows(vectors, idx.d)
+	if err := idx.base.Add(normalized); err != nil {
+		return err
+	}
+
+	idx.mins = append(idx.mins, mins...)
+	idx.scales = append(idx.scales, scales...)
+	return nil
+}
+
+// Reconstruct returns the stored vector with its original scale restored
+func (idx *IndexRowwiseMinMax) Reconstruct(key int64) ([]float32, error) {
+	if err := idx.checkUsable(); err != nil {
+		return nil, err
+	}
+	if key < 0 || key >= int64(len(idx.mins)) {
+		return nil, fmt.Errorf("faiss: key %d out of range [0, %d)", key, len(idx.mins))
+	}
+
+	recons := make([]float32, idx.d)
+	if err := idx.reconstructInto(key, recons); err != nil {
+		return nil, err
+	}
+	return recons, nil
+}
+
+func (idx *IndexRowwiseMinMax) reconstructInto(key int64, recons []float32) error {
+	if err := faissIndexReconstruct(idx.basePtr, key, recons); err != nil {
+		return fmt.Errorf("faiss: base index reconstruction failed: %w", err)
+	}
+	lo, scale := idx.mins[key], idx.scales[key]
+	for j := range recons {
+		recons[j] = recons[j]*scale + lo
+	}
+	return nil
+}
+
+// Search finds candidates in the normalized space and reranks them by their
+// exact distance to the query
+func (idx *IndexRowwiseMinMax) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if err := idx.checkUsable(); err != nil {
+		return nil, nil, err
+	}
+	if len(queries) == 0 {
+		return []float32{}, []int64{}, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+
+	nq := len(queries) / idx.d
+	kSub := k * idx.kFactor
+	if ntotal := len(idx.mins); ntotal > 0 && kSub > ntotal {
+		kSub = ntotal
+	}
+	if kSub < k {
+		kSub = k
+	}
+
+	normalized, _, _ := normalizeRows(queries, idx.d)
+	_, candidates, err := idx.base.Search(normalized, kSub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Similarity metrics rank larger scores first
+	descending := idx.metric == MetricInnerProduct
+	padding := float32(math.MaxFloat32)
+	if descending {
+		padding = -math.MaxFloat32
+	}
+
+	distances = make([]float32, nq*k)
+	indices = make([]int64, nq*k)
+	recons := make([]float32, idx.d)
+	reranked := make([]Neighbor, 0, kSub)
+	for q := 0; q < nq; q++ {
+		query := queries[q*idx.d : (q+1)*idx.d]
+		reranked = reranked[:0]
+		for _, id := range candidates[q*kSub : (q+1)*kSub] {
+			if id < 0 {
+				continue
+			}
+			if err := idx.reconstructInto(id, recons); err != nil {
+				return nil, nil, err
+			}
+
+			var dist float32
+			if descending {
+				dist, _ = InnerProduct(query, recons)
+			} else {
+				for j, v := range query {
+					diff := v - recons[j]
+					dist += diff * diff
+				}
+			}
+			reranked = append(reranked, Neighbor{ID: id, Distance: dist})
+		}
+
+		sort.SliceStable(reranked, func(a, b int) bool {
+			if descending {
+				return reranked[a].Distance > reranked[b].Distance
+			}
+			return reranked[a].Distance < reranked[b].Distance
+		})
+
+		for j := 0; j < k; j++ {
+			if j < len(reranked) {
+				distances[q*k+j] = reranked[j].Distance
+				indices[q*k+j] = reranked[j].ID
+			} else {
+				distances[q*k+j] = padding
+				indices[q*k+j] = -1
+			}
+		}
+	}
+
+	return distances, indices, nil
+}
+
+// SetNprobe delegates to the base index
+func (idx *IndexRowwiseMinMax) SetNprobe(nprobe int) error {
+	return idx.base.SetNprobe(nprobe)
+}
+
+// SetEfSearch delegates to the base index
+func (idx *IndexRowwiseMinMax) SetEfSearch(efSearch int) error {
+	return idx.base.SetEfSearch(efSearch)
+}
+
+// Reset removes all vectors from the base index and drops the scale factors
+func (idx *IndexRowwiseMinMax) Reset() error {
+	if err := idx.checkUsable(); err != nil {
+		return err
+	}
+	if err := idx.base.Reset(); err != nil {
+		return err
+	}
+	idx.mins = nil
+	idx.scales = nil
+	return nil
+}
+
+// Close releases the wrapper; the base index is left open
+func (idx *IndexRowwiseMinMax) Close() error {
+	idx.closed = true
+	idx.mins = nil
+	idx.scales = nil
+	return nil
+}
@@ -0,0 +1,156 @@
+package faiss
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// generateVaryingScaleVectors returns random vectors whose norms span
+// several orders of magnitude
+func generateVaryingScaleVectors(n, d int, rng *rand.Rand) []float32 {
+	vectors := make([]float32, n*d)
+	for i := 0; i < n; i++ {
+		scale := float32(math.Pow(10, rng.Float64()*4-2))
+		for j := 0; j < d; j++ {
+			vectors[i*d+j] = float32(rng.NormFloat64()) * scale
+		}
+	}
+	return vectors
+}
+
+// ========================================
+// IndexRowwiseMinMax Tests
+// ========================================
+
+func TestIndexRowwiseMinMax_Recall(t *testing.T) {
+	d := 32
+	nb := 5000
+	nq := 100
+	k := 10
+	rng := rand.New(rand.NewSource(42))
+
+	vectors := generateVaryingScaleVectors(nb, d, rng)
+	queries := make([]float32, nq*d)
+	for i := 0; i < nq; i++ {
+		src := rng.Intn(nb)
+		for j := 0; j < d; j++ {
+			v := vectors[src*d+j]
+			queries[i*d+j] = v + v*0.05*float32(rng.NormFloat64())
+		}
+	}
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, err := flat.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search(flat) failed: %v", err)
+	}
+
+	plain, _ := NewIndexScalarQuantizer(d, QT_8bit, MetricL2)
+	defer plain.Close()
+	if err := plain.Train(vectors); err != nil {
+		t.Fatalf("Train(SQ8) failed: %v", err)
+	}
+	plain.Add(vectors)
+	_, plainLabels, err := plain.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search(SQ8) failed: %v", err)
+	}
+
+	base, _ := NewIndexScalarQuantizer(d, QT_8bit, MetricL2)
+	defer base.Close()
+	index, err := NewIndexRowwiseMinMax(base)
+	if err != nil {
+		t.Fatalf("NewIndexRowwiseMinMax() failed: %v", err)
+	}
+	defer index.Close()
+
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if index.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", index.Ntotal(), nb)
+	}
+	_, labels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	plainRecall := ComputeRecall(groundTruth, plainLabels, nq, k, k)
+	minmaxRecall := ComputeRecall(groundTruth, labels, nq, k, k)
+	t.Logf("recall@%d: SQ8 = %.3f, MinMax+SQ8 = %.3f", k, plainRecall, minmaxRecall)
+	if minmaxRecall <= plainRecall {
+		t.Errorf("MinMax+SQ8 recall %.3f, want > SQ8 recall %.3f", minmaxRecall, plainRecall)
+	}
+}
+
+func TestIndexRowwiseMinMax_Reconstruct(t *testing.T) {
+	d := 8
+	base, _ := NewIndexFlatL2(d)
+	defer base.Close()
+	index, err := NewIndexRowwiseMinMax(base)
+	if err != nil {
+		t.Fatalf("NewIndexRowwiseMinMax() failed: %v", err)
+	}
+	defer index.Close()
+
+	vectors := []float32{
+		1, 2, 3, 4, 5, 6, 7, 8,
+		-100, 0, 100, 50, -50, 25, -25, 10,
+		3, 3, 3, 3, 3, 3, 3, 3, // constant row
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		recons, err := index.Reconstruct(int64(i))
+		if err != nil {
+			t.Fatalf("Reconstruct(%d) failed: %v", i, err)
+		}
+		for j := 0; j < d; j++ {
+			if !almostEqual(recons[j], vectors[i*d+j], 1e-3) {
+				t.Errorf("Reconstruct(%d)[%d] = %v, want %v", i, j, recons[j], vectors[i*d+j])
+			}
+		}
+	}
+
+	// Distances are reported in the original space
+	distances, labels, err := index.Search(vectors[d:2*d], 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if labels[0] != 1 || !almostEqual(distances[0], 0, 1e-2) {
+		t.Errorf("Search() = (%d, %v), want (1, 0)", labels[0], distances[0])
+	}
+
+	if _, err := index.Reconstruct(3); err == nil {
+		t.Error("Reconstruct() out of range should return error")
+	}
+}
+
+func TestIndexRowwiseMinMax_Invalid(t *testing.T) {
+	if _, err := NewIndexRowwiseMinMax(nil); err == nil {
+		t.Error("Expected error for nil base")
+	}
+
+	base, _ := NewIndexFlatL2(4)
+	defer base.Close()
+	base.Add([]float32{1, 2, 3, 4})
+	if _, err := NewIndexRowwiseMinMax(base); err == nil {
+		t.Error("Expected error for non-empty base")
+	}
+
+	empty, _ := NewIndexFlatL2(4)
+	defer empty.Close()
+	index, _ := NewIndexRowwiseMinMax(empty)
+	defer index.Close()
+	if err := index.SetKFactor(0); err == nil {
+		t.Error("SetKFactor(0) should return error")
+	}
+}
@@ -0,0 +1,290 @@
+package faiss
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ioFlagOnDiskSameDir is FAISS's IO_FLAG_ONDISK_SAME_DIR: the inverted lists
+// file is looked up next to the index file rather than at its original path
+const ioFlagOnDiskSameDir = 4
+
+// ioFlagReadOnly is FAISS's IO_FLAG_READ_ONLY: the inverted lists file is
+// mapped read-only, and FAISS rejects adds and removals
+const ioFlagReadOnly = 2
+
+// onDiskDataSuffix is appended to the index file to get the file holding the
+// inverted lists
+const onDiskDataSuffix = ".ivfdata"
+
+// onDiskIndex is an IVF index whose inverted lists live in a memory-mapped
+// file, so only the coarse quantizer and the list metadata take RAM
+//
+// Two files make up the index: the index file at path, written by Flush and
+// Close, holding the quantizer and the list metadata, and the inverted lists
+// file at path+".ivfdata", written as vectors are added. Vectors added after
+// the last Flush or Close are not part of the index file and are lost if the
+// process exits without closing the index. Indexes opened read-only never
+// write either file.
+type onDiskIndex struct {
+	*GenericIndex
+	path     string // index file
+	readOnly bool   // opened with Open...OnDiskReadOnly
+}
+
+// newOnDiskIndex builds an empty index from a factory description and moves
+// its inverted lists to path+".ivfdata"
+func newOnDiskIndex(d int, description, path string, metric MetricType) (onDiskIndex, error) {
+	if path == "" {
+		return onDiskIndex{}, fmt.Errorf("faiss: index path cannot be empty")
+	}
+	index, err := IndexFactory(d, description, metric)
+	if err != nil {
+		return onDiskIndex{}, err
+	}
+	gen := index.(*GenericIndex)
+	if err := faissIndexIVFUseOnDiskLists(gen.ptr, path+onDiskDataSuffix); err != nil {
+		gen.Close()
+		return onDiskIndex{}, fmt.Errorf("faiss: failed to create on-disk index: %w", err)
+	}
+	return onDiskIndex{GenericIndex: gen, path: path}, nil
+}
+
+// openOnDiskIndex reads an index written by Flush or Close, mapping its
+// inverted lists file from the same directory; class is the expected FAISS
+// class. With readOnly set the lists file is mapped read-only and the index
+// file is never written back.
+func openOnDiskIndex(path, class string, readOnly bool) (onDiskIndex, error) {
+	if _, err := os.Stat(path); err != nil {
+		return onDiskIndex{}, fmt.Errorf("faiss: failed to open on-disk index: %w", err)
+	}
+	ioFlags := ioFlagOnDiskSameDir
+	if readOnly {
+		ioFlags |= ioFlagReadOnly
+	}
+	ptr, err := readIndexFile(path, ioFlags)
+	if err != nil {
+		return onDiskIndex{}, err
+	}
+
+	name, err := faissIndexClassName(ptr)
+	if err == nil && name != class {
+		err = fmt.Errorf("%s holds an %s, want an %s", path, name, class)
+	}
+	if err == nil {
+		_, err = faissIndexIVFOnDiskFilename(ptr)
+	}
+	if err != nil {
+		_ = faissIndexFree(ptr)
+		return onDiskIndex{}, fmt.Errorf("faiss: failed to open on-disk index: %w", err)
+	}
+	return onDiskIndex{GenericIndex: wrapLoadedIndex(ptr), path: path, readOnly: readOnly}, nil
+}
+
+// Path returns the index file
+func (idx *onDiskIndex) Path() string {
+	return idx.path
+}
+
+// DataPath returns the file holding the inverted lists
+func (idx *onDiskIndex) DataPath() (string, error) {
+	if idx.ptr == 0 {
+		return "", ErrNullPointer
+	}
+	return faissIndexIVFOnDiskFilename(idx.ptr)
+}
+
+// Flush makes every vector added so far durable
+//
+// The inverted lists file is synced to disk, then the index file is written
+// to a temporary file, synced and renamed over path, so a crash during Flush
+// leaves the previous index file in place. Once Flush returns without error,
+// Open...OnDisk finds all vectors added before it, even if the process then
+// dies without calling Close.
+//
+// Flush returns an error on indexes opened read-only.
+func (idx *onDiskIndex) Flush() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if idx.readOnly {
+		return fmt.Errorf("faiss: %s was opened read-only", idx.path)
+	}
+	dataPath, err := idx.DataPath()
+	if err != nil {
+		return fmt.Errorf("faiss: flush failed: %w", err)
+	}
+	if err := faissIndexIVFOnDiskSync(idx.ptr); err != nil {
+		return fmt.Errorf("faiss: flush failed: %w", err)
+	}
+	// The lists file is created on the first add
+	if err := syncFile(dataPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("faiss: failed to sync %s: %w", dataPath, err)
+	}
+
+	tmp := idx.path + ".tmp"
+	if err := faissWriteIndex(idx.ptr, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("faiss: failed to write index file %s: %w", idx.path, err)
+	}
+	if err := syncFile(tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("faiss: failed to sync index file: %w", err)
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("faiss: failed to write index file %s: %w", idx.path, err)
+	}
+	// Best effort, as for CheckpointingIndex
+	_ = syncFile(filepath.Dir(idx.path))
+	return nil
+}
+
+// Close flushes the index (see Flush) and frees it
+//
+// The index is freed even if the flush fails; the index file then still
+// holds the state of the last successful Flush. Indexes opened read-only
+// are freed without writing anything.
+func (idx *onDiskIndex) Close() error {
+	if idx.ptr == 0 {
+		return nil
+	}
+	if idx.readOnly {
+		return idx.GenericIndex.Close()
+	}
+	flushErr := idx.Flush()
+	if err := idx.GenericIndex.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// IndexIVFFlatOnDisk is an IVF flat index whose inverted lists are stored in
+// a memory-mapped file
+//
+// Python equivalent: faiss.IndexIVFFlat with faiss.OnDiskInvertedLists
+//
+// Example:
+//   index, _ := faiss.NewIndexIVFFlatOnDisk(128, 1024, "vectors.index", faiss.MetricL2)
+//   index.Train(training)
+//   index.Add(vectors)      // written to vectors.index.ivfdata
+//   index.Flush()           // syncs both files to disk
+//   index.Close()           // writes vectors.index
+//
+//   index, _ = faiss.OpenIndexIVFFlatOnDiskReadOnly("vectors.index")
+//   distances, labels, _ := index.Search(queries, 10)
+type IndexIVFFlatOnDisk struct {
+	onDiskIndex
+}
+
+// Ensure IndexIVFFlatOnDisk implements Index
+var _ Index = (*IndexIVFFlatOnDisk)(nil)
+
+// NewIndexIVFFlatOnDisk creates an empty IVF flat index whose inverted lists
+// are stored in path+".ivfdata"; Close writes the index file to path
+func NewIndexIVFFlatOnDisk(d, nlist int, path string, metric MetricType) (*IndexIVFFlatOnDisk, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if nlist <= 0 {
+		return nil, fmt.Errorf("faiss: nlist must be positive")
+	}
+	base, err := newOnDiskIndex(d, fmt.Sprintf("IVF%d,Flat", nlist), path, metric)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexIVFFlatOnDisk{base}, nil
+}
+
+// OpenIndexIVFFlatOnDisk reopens an index created by NewIndexIVFFlatOnDisk
+//
+// The inverted lists file is mapped from the directory of path, so both
+// files can be moved to another directory as long as they keep their
+// names. The reopened index can be searched and extended; Flush and Close
+// write the index file back. Use OpenIndexIVFFlatOnDiskReadOnly to serve an index
+// without modifying it.
+func OpenIndexIVFFlatOnDisk(path string) (*IndexIVFFlatOnDisk, error) {
+	base, err := openOnDiskIndex(path, "IndexIVFFlat", false)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexIVFFlatOnDisk{base}, nil
+}
+
+// OpenIndexIVFFlatOnDiskReadOnly reopens an index created by NewIndexIVFFlatOnDisk
+// for search only
+//
+// The inverted lists file is mapped read-only, so both files may live on
+// read-only storage. Add, AddWithIDs, RemoveIDs and Reset return an error,
+// as does Flush, and Close frees the index without writing anything.
+func OpenIndexIVFFlatOnDiskReadOnly(path string) (*IndexIVFFlatOnDisk, error) {
+	base, err := openOnDiskIndex(path, "IndexIVFFlat", true)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexIVFFlatOnDisk{base}, nil
+}
+
+// IndexIVFPQOnDisk is an IVFPQ index whose inverted lists are stored in a
+// memory-mapped file
+//
+// Python equivalent: faiss.IndexIVFPQ with faiss.OnDiskInvertedLists
+type IndexIVFPQOnDisk struct {
+	onDiskIndex
+}
+
+// Ensure IndexIVFPQOnDisk implements Index
+var _ Index = (*IndexIVFPQOnDisk)(nil)
+
+// NewIndexIVFPQOnDisk creates an empty IVFPQ index whose inverted lists are
+// stored in path+".ivfdata"; Close writes the index file to path
+func NewIndexIVFPQOnDisk(d, nlist, M, nbits int, path string, metric MetricType) (*IndexIVFPQOnDisk, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if nlist <= 0 {
+		return nil, fmt.Errorf("faiss: nlist must be positive")
+	}
+	if M <= 0 || d%M != 0 {
+		return nil, fmt.Errorf("faiss: d (%d) must be divisible by M (%d)", d, M)
+	}
+	if nbits <= 0 || nbits > 16 {
+		return nil, fmt.Errorf("faiss: nbits must be between 1 and 16")
+	}
+	base, err := newOnDiskIndex(d, fmt.Sprintf("IVF%d,PQ%dx%d", nlist, M, nbits), path, metric)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexIVFPQOnDisk{base}, nil
+}
+
+// OpenIndexIVFPQOnDisk reopens an index created by NewIndexIVFPQOnDisk
+//
+// The inverted lists file is mapped from the directory of path, so both
+// files can be moved to another directory as long as they keep their
+// names. The reopened index can be searched and extended; Flush and Close
+// write the index file back. Use OpenIndexIVFPQOnDiskReadOnly to serve an index
+// without modifying it.
+func OpenIndexIVFPQOnDisk(path string) (*IndexIVFPQOnDisk, error) {
+	base, err := openOnDiskIndex(path, "IndexIVFPQ", false)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexIVFPQOnDisk{base}, nil
+}
+
+// OpenIndexIVFPQOnDiskReadOnly reopens an index created by NewIndexIVFPQOnDisk
+// for search only
+//
+// The inverted lists file is mapped read-only, so both files may live on
+// read-only storage. Add, AddWithIDs, RemoveIDs and Reset return an error,
+// as does Flush, and Close frees the index without writing anything.
+func OpenIndexIVFPQOnDiskReadOnly(path string) (*IndexIVFPQOnDisk, error) {
+	base, err := openOnDiskIndex(path, "IndexIVFPQ", true)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexIVFPQOnDisk{base}, nil
+}
@@ -0,0 +1,245 @@
+package faiss
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexIVFPQOnDisk_Reopen(t *testing.T) {
+	d := 32
+	nb := 2000
+	nq := 20
+	k := 5
+	vectors := generateVectors(nb, d)
+	queries := vectors[:nq*d]
+	path := filepath.Join(t.TempDir(), "ivfpq.index")
+
+	index, err := NewIndexIVFPQOnDisk(d, 16, 8, 4, path, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFPQOnDisk() failed: %v", err)
+	}
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if err := index.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	index.SetNprobe(4)
+	wantDist, wantLabels, err := index.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	dataPath, _ := index.DataPath()
+	if dataPath != path+".ivfdata" {
+		t.Errorf("DataPath() = %q, want %q", dataPath, path+".ivfdata")
+	}
+	if err := index.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	// Move both files to another directory: the inverted lists are found
+	// next to the index file
+	moved := filepath.Join(t.TempDir(), "ivfpq.index")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".ivfdata", moved+".ivfdata"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenIndexIVFPQOnDisk(moved)
+	if err != nil {
+		t.Fatalf("OpenIndexIVFPQOnDisk() failed: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", reopened.Ntotal(), nb)
+	}
+	if dataPath, _ := reopened.DataPath(); dataPath != moved+".ivfdata" {
+		t.Errorf("DataPath() = %q, want %q", dataPath, moved+".ivfdata")
+	}
+
+	reopened.SetNprobe(4)
+	gotDist, gotLabels, err := reopened.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() on reopened index failed: %v", err)
+	}
+	for i := range wantLabels {
+		if gotLabels[i] != wantLabels[i] || gotDist[i] != wantDist[i] {
+			t.Fatalf("result %d = (%d, %v), want (%d, %v)", i, gotLabels[i], gotDist[i], wantLabels[i], wantDist[i])
+		}
+	}
+}
+
+func TestIndexIVFFlatOnDisk_ReopenAndExtend(t *testing.T) {
+	d := 16
+	nb := 1000
+	vectors := generateVectors(nb, d)
+	path := filepath.Join(t.TempDir(), "ivfflat.index")
+
+	index, err := NewIndexIVFFlatOnDisk(d, 8, path, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatOnDisk() failed: %v", err)
+	}
+	index.Train(vectors)
+	index.Add(vectors[:nb/2*d])
+	if err := index.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left after Close: %v", err)
+	}
+
+	reopened, err := OpenIndexIVFFlatOnDisk(path)
+	if err != nil {
+		t.Fatalf("OpenIndexIVFFlatOnDisk() failed: %v", err)
+	}
+	defer reopened.Close()
+	if err := reopened.Add(vectors[nb/2*d:]); err != nil {
+		t.Fatalf("Add() on reopened index failed: %v", err)
+	}
+	reopened.SetNprobe(8)
+	_, labels, err := reopened.Search(vectors, 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for i, label := range labels {
+		if label != int64(i) {
+			t.Fatalf("Search(vector %d) = %d, want %d", i, label, i)
+		}
+	}
+
+	if _, err := OpenIndexIVFPQOnDisk(path); err == nil {
+		t.Error("OpenIndexIVFPQOnDisk() on an IVF flat index should return error")
+	}
+}
+
+func TestIndexIVFPQOnDisk_ReadOnly(t *testing.T) {
+	d := 16
+	nb := 1000
+	vectors := generateVectors(nb, d)
+	path := filepath.Join(t.TempDir(), "readonly.index")
+
+	index, err := NewIndexIVFPQOnDisk(d, 8, 4, 4, path, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFPQOnDisk() failed: %v", err)
+	}
+	index.Train(vectors)
+	index.Add(vectors)
+	if err := index.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenIndexIVFPQOnDiskReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenIndexIVFPQOnDiskReadOnly() failed: %v", err)
+	}
+	if reopened.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d, want %d", reopened.Ntotal(), nb)
+	}
+	reopened.SetNprobe(8)
+	if _, _, err := reopened.Search(vectors[:d], 1); err != nil {
+		t.Errorf("Search() failed: %v", err)
+	}
+	if err := reopened.Add(vectors[:d]); err == nil {
+		t.Error("Add() on a read-only index should return error")
+	}
+	if err := reopened.Flush(); err == nil {
+		t.Error("Flush() on a read-only index should return error")
+	}
+	if reopened.Ntotal() != int64(nb) {
+		t.Errorf("Ntotal() = %d after rejected Add, want %d", reopened.Ntotal(), nb)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("Close() on a read-only index rewrote the index file")
+	}
+	if infoAfter, err := os.Stat(path); err != nil || !infoAfter.ModTime().Equal(info.ModTime()) {
+		t.Errorf("index file modified by a read-only index: %v", err)
+	}
+}
+
+func TestOpenIndexOnDisk_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenIndexIVFFlatOnDisk(filepath.Join(dir, "missing.index")); err == nil {
+		t.Error("OpenIndexIVFFlatOnDisk() on a missing file should return error")
+	}
+
+	// An in-memory IVF index has no inverted lists file
+	path := filepath.Join(dir, "memory.index")
+	index, _ := IndexFactory(8, "IVF4,Flat", MetricL2)
+	defer index.Close()
+	if err := WriteIndexToFile(index, path); err != nil {
+		t.Fatalf("WriteIndexToFile() failed: %v", err)
+	}
+	if _, err := OpenIndexIVFFlatOnDisk(path); err == nil {
+		t.Error("OpenIndexIVFFlatOnDisk() on an in-memory index should return error")
+	}
+}
+
+func TestIndexIVFFlatOnDisk_Flush(t *testing.T) {
+	d := 16
+	nlist := 8
+	nb := 600
+	vectors := generateVectors(nb, d)
+	path := filepath.Join(t.TempDir(), "flushed.index")
+
+	index, err := NewIndexIVFFlatOnDisk(d, nlist, path, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatOnDisk() failed: %v", err)
+	}
+	defer index.Close()
+	if err := index.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+
+	// Each Flush makes the vectors added so far visible to a reopen, while
+	// the index stays open as after a crash
+	for _, n := range []int{nb / 2, nb} {
+		if err := index.Add(vectors[int(index.Ntotal())*d : n*d]); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+		if err := index.Flush(); err != nil {
+			t.Fatalf("Flush() failed: %v", err)
+		}
+
+		reopened, err := OpenIndexIVFFlatOnDiskReadOnly(path)
+		if err != nil {
+			t.Fatalf("OpenIndexIVFFlatOnDiskReadOnly() failed: %v", err)
+		}
+		if reopened.Ntotal() != int64(n) {
+			t.Errorf("Ntotal() = %d after Flush, want %d", reopened.Ntotal(), n)
+		}
+		reopened.SetNprobe(nlist)
+		distances, labels, err := reopened.Search(vectors[:n*d], 1)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		for i := 0; i < n; i++ {
+			if labels[i] != int64(i) || distances[i] != 0 {
+				t.Fatalf("vector %d: nearest = (%d, %v), want (%d, 0)", i, labels[i], distances[i], i)
+			}
+		}
+		reopened.Close()
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary index file left behind: %v", err)
+	}
+}
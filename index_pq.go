@@ -17,7 +17,8 @@ import (
 //   - M: number of subquantizers (must divide d evenly)
 //   - nbits: number of bits per subquantizer (typically 8)
 //
-// The index requires training before adding vectors.
+// The index requires training before adding vectors. Train returns a
+// *TrainingSizeError for fewer than max(30*nlist, 39*2^nbits) vectors.
 //
 // Recommended parameters:
 //   - nlist: sqrt(n) where n is the number of vectors
@@ -89,7 +90,8 @@ func NewIndexIVFPQ(quantizer Index, d, nlist, M, nbits int) (Index, error) {
 //   - nbits: number of bits per subquantizer (typically 8)
 //   - metric: distance metric (MetricL2 or MetricInnerProduct)
 //
-// The index requires training before adding vectors.
+// The index requires training before adding vectors. Train returns a
+// *TrainingSizeError for fewer than 39*2^nbits vectors (9984 for nbits=8).
 //
 // Python equivalent: faiss.IndexPQ(d, M, nbits)
 func NewIndexPQ(d, M, nbits int, metric MetricType) (Index, error) {
@@ -110,3 +112,111 @@ func NewIndexPQ(d, M, nbits int, metric MetricType) (Index, error) {
 	description := fmt.Sprintf("PQ%dx%d", M, nbits)
 	return IndexFactory(d, description, metric)
 }
+
+// NewIndex2Layer creates a two-level IVFPQ index whose coarse quantizer is
+// itself an IVF index, for collections too large for a flat coarse quantizer.
+//
+// With nlist in the millions, assigning every vector to its list by scanning
+// all centroids dominates add and search time. Here the nlistLevel2
+// centroids are stored in an IVF index with nlistLevel1 lists, so the coarse
+// assignment only scans a few of those lists. The vectors themselves are
+// PQ-encoded with M subquantizers of nbits bits, as in NewIndexIVFPQ.
+//
+// Parameters:
+//   - d: dimension of vectors
+//   - nlistLevel1: number of lists of the coarse quantizer's own IVF
+//   - nlistLevel2: number of inverted lists holding the vectors
+//     (nlistLevel1 <= nlistLevel2)
+//   - M: number of subquantizers (must divide d evenly)
+//   - nbits: number of bits per subquantizer (typically 8)
+//
+// This is the factory description "IVF{nlistLevel2}(IVF{nlistLevel1},Flat),
+// PQ{M}x{nbits}". It is not FAISS's Index2Layer class, which is a flat
+// two-level codec without an inverted file. SetNprobe sets the probes of
+// the outer index; the coarse quantizer probes one of its lists, so coarse
+// assignment is approximate.
+//
+// Training needs at least max(30*nlistLevel2, 39*2^nbits) vectors (see
+// TrainingSizeError).
+//
+// Python equivalent: faiss.index_factory(d, "IVF65536(IVF256,Flat),PQ32")
+//
+// Example:
+//
+//	index, _ := faiss.NewIndex2Layer(128, 1024, 1<<20, 16, 8)
+//	index.Train(trainingVectors)
+//	index.Add(vectors)
+//	index.SetNprobe(64)
+func NewIndex2Layer(d, nlistLevel1, nlistLevel2, M, nbits int) (Index, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if nlistLevel1 <= 0 || nlistLevel2 <= 0 {
+		return nil, fmt.Errorf("faiss: nlist must be positive")
+	}
+	if nlistLevel1 > nlistLevel2 {
+		return nil, fmt.Errorf("faiss: nlistLevel1 (%d) must not exceed nlistLevel2 (%d)", nlistLevel1, nlistLevel2)
+	}
+	if M <= 0 {
+		return nil, fmt.Errorf("faiss: M must be positive")
+	}
+	if d%M != 0 {
+		return nil, fmt.Errorf("faiss: d (%d) must be divisible by M (%d)", d, M)
+	}
+	if nbits <= 0 || nbits > 16 {
+		return nil, fmt.Errorf("faiss: nbits must be between 1 and 16")
+	}
+
+	description := fmt.Sprintf("IVF%d(IVF%d,Flat),PQ%dx%d", nlistLevel2, nlistLevel1, M, nbits)
+	index, err := IndexFactory(d, description, MetricL2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Residual encoding reconstructs centroids from the coarse quantizer,
+	// which an IVF index can only do through its direct map
+	ptr, _ := indexPointer(index)
+	quantizer, err := faissIndexIVFQuantizer(ptr)
+	if err == nil {
+		err = faissIndexIVFMakeDirectMap(quantizer, true)
+	}
+	if err != nil {
+		index.Close()
+		return nil, fmt.Errorf("faiss: failed to set up the level-1 quantizer: %w", err)
+	}
+	return index, nil
+}
+
+// ========================================
+// IVFPQ residual encoding
+// ========================================
+
+// SetByResidual controls whether an IVFPQ index encodes the residual after
+// coarse quantization (the FAISS default) or the raw vector.
+//
+// The flag must be set before training, because the PQ codebooks are
+// trained on whichever representation is encoded. Returns an error if the
+// index is not an IVFPQ index or is already trained.
+//
+// Example:
+//
+//	index, _ := faiss.NewIndexIVFPQ(nil, 128, 100, 16, 8)
+//	index.(*faiss.GenericIndex).SetByResidual(false)
+//	index.Train(trainingVectors)
+func (idx *GenericIndex) SetByResidual(enable bool) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
+	if idx.IsTrained() {
+		return fmt.Errorf("faiss: by_residual must be set before training")
+	}
+	return faissIndexIVFPQSetByResidual(idx.ptr, enable)
+}
+
+// ByResidual reports whether an IVFPQ index encodes residuals
+func (idx *GenericIndex) ByResidual() (bool, error) {
+	if idx.ptr == 0 {
+		return false, ErrNullPointer
+	}
+	return faissIndexIVFPQByResidual(idx.ptr)
+}
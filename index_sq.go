@@ -42,6 +42,7 @@ type IndexScalarQuantizer struct {
 	ntotal    int64         // number of vectors
 	isTrained bool          // training status
 	qtype     QuantizerType // quantizer type
+	normGuard bool          // Add/Search reject vectors that are not unit-norm
 }
 
 // Ensure IndexScalarQuantizer implements Index
@@ -78,6 +79,9 @@ func (idx *IndexScalarQuantizer) D() int {
 
 // Ntotal returns the number of vectors in the index
 func (idx *IndexScalarQuantizer) Ntotal() int64 {
+	if idx.ptr == 0 {
+		return 0
+	}
 	ntotal := faiss_Index_ntotal(idx.ptr)
 	idx.ntotal = ntotal
 	return ntotal
@@ -85,6 +89,9 @@ func (idx *IndexScalarQuantizer) Ntotal() int64 {
 
 // IsTrained returns whether the index has been trained
 func (idx *IndexScalarQuantizer) IsTrained() bool {
+	if idx.ptr == 0 {
+		return false
+	}
 	isTrained := faiss_Index_is_trained(idx.ptr)
 	idx.isTrained = (isTrained != 0)
 	return idx.isTrained
@@ -100,13 +107,35 @@ func (idx *IndexScalarQuantizer) QuantizerType() QuantizerType {
 	return idx.qtype
 }
 
+// SetRequireNormalized makes Add and Search reject vectors whose L2 norm is
+// not ~1.0 (inner product indexes only); see IndexFlat.SetRequireNormalized
+func (idx *IndexScalarQuantizer) SetRequireNormalized(require bool) error {
+	if err := checkRequireNormalized(require, idx.metric); err != nil {
+		return err
+	}
+	idx.normGuard = require
+	return nil
+}
+
+// RequireNormalized reports whether Add and Search reject un-normalized vectors
+func (idx *IndexScalarQuantizer) RequireNormalized() bool {
+	return idx.normGuard
+}
+
 // Train trains the index on the given vectors
 func (idx *IndexScalarQuantizer) Train(vectors []float32) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
-		return fmt.Errorf("empty training vectors")
+		return checkTrainingSize(0, 1)
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -121,6 +150,9 @@ func (idx *IndexScalarQuantizer) Train(vectors []float32) error {
 
 // Add adds vectors to the index
 func (idx *IndexScalarQuantizer) Add(vectors []float32) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if !idx.IsTrained() {
 		return fmt.Errorf("index must be trained before adding vectors")
 	}
@@ -128,7 +160,14 @@ func (idx *IndexScalarQuantizer) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+	if err := validateNormalized(idx.normGuard, vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -143,11 +182,21 @@ func (idx *IndexScalarQuantizer) Add(vectors []float32) error {
 
 // Search performs k-NN search
 func (idx *IndexScalarQuantizer) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 {
+		return nil, nil, ErrNullPointer
+	}
 	if len(queries) == 0 {
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -173,7 +222,11 @@ func (idx *IndexScalarQuantizer) SetEfSearch(efSearch int) error {
 }
 
 // Reset removes all vectors from the index
+// The trained quantizer ranges are kept, so the index can be refilled without re-training
 func (idx *IndexScalarQuantizer) Reset() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	ret := faiss_Index_reset(idx.ptr)
 	if ret != 0 {
 		return fmt.Errorf("reset failed")
@@ -224,15 +277,17 @@ func (idx *IndexScalarQuantizer) CompressionRatio() float64 {
 //   index.SetNprobe(10)
 //   index.Add(vectors)
 type IndexIVFScalarQuantizer struct {
-	ptr       uintptr       // C pointer
-	quantizer Index         // coarse quantizer
-	d         int           // dimension
-	metric    MetricType    // metric type
-	ntotal    int64         // number of vectors
-	isTrained bool          // training status
-	nlist     int           // number of clusters
-	nprobe    int           // number of clusters to probe
-	qtype     QuantizerType // quantizer type
+	ptr       uintptr          // C pointer
+	quantizer Index            // coarse quantizer
+	shared    *SharedQuantizer // shared quantizer reference, released on Close
+	d         int              // dimension
+	metric    MetricType       // metric type
+	ntotal    int64            // number of vectors
+	isTrained bool             // training status
+	nlist     int              // number of clusters
+	nprobe    int              // number of clusters to probe
+	qtype     QuantizerType    // quantizer type
+	normGuard bool             // Add/Search reject vectors that are not unit-norm
 }
 
 // Ensure IndexIVFScalarQuantizer implements Index
@@ -243,6 +298,12 @@ func NewIndexIVFScalarQuantizer(quantizer Index, d, nlist int, qtype QuantizerTy
 	if quantizer == nil {
 		return nil, fmt.Errorf("quantizer cannot be nil")
 	}
+	// The quantizer assigns vectors to lists, so an L2 quantizer under an IP
+	// index would probe the wrong lists and silently lose recall
+	if quantizer.MetricType() != metric {
+		return nil, fmt.Errorf("faiss: quantizer metric %s does not match index metric %s",
+			quantizer.MetricType(), metric)
+	}
 
 	// Get the quantizer pointer based on type
 	var quantizerPtr uintptr
@@ -285,6 +346,9 @@ func (idx *IndexIVFScalarQuantizer) D() int {
 
 // Ntotal returns the number of vectors in the index
 func (idx *IndexIVFScalarQuantizer) Ntotal() int64 {
+	if idx.ptr == 0 {
+		return 0
+	}
 	ntotal := faiss_Index_ntotal(idx.ptr)
 	idx.ntotal = ntotal
 	return ntotal
@@ -292,6 +356,9 @@ func (idx *IndexIVFScalarQuantizer) Ntotal() int64 {
 
 // IsTrained returns whether the index has been trained
 func (idx *IndexIVFScalarQuantizer) IsTrained() bool {
+	if idx.ptr == 0 {
+		return false
+	}
 	isTrained := faiss_Index_is_trained(idx.ptr)
 	idx.isTrained = (isTrained != 0)
 	return idx.isTrained
@@ -314,6 +381,9 @@ func (idx *IndexIVFScalarQuantizer) Nprobe() int {
 
 // SetNprobe sets the number of clusters to probe during search
 func (idx *IndexIVFScalarQuantizer) SetNprobe(nprobe int) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if nprobe < 1 || nprobe > idx.nlist {
 		return fmt.Errorf("nprobe must be between 1 and %d", idx.nlist)
 	}
@@ -332,18 +402,40 @@ func (idx *IndexIVFScalarQuantizer) QuantizerType() QuantizerType {
 	return idx.qtype
 }
 
+// SetRequireNormalized makes Add and Search reject vectors whose L2 norm is
+// not ~1.0 (inner product indexes only); see IndexFlat.SetRequireNormalized
+func (idx *IndexIVFScalarQuantizer) SetRequireNormalized(require bool) error {
+	if err := checkRequireNormalized(require, idx.metric); err != nil {
+		return err
+	}
+	idx.normGuard = require
+	return nil
+}
+
+// RequireNormalized reports whether Add and Search reject un-normalized vectors
+func (idx *IndexIVFScalarQuantizer) RequireNormalized() bool {
+	return idx.normGuard
+}
+
 // Train trains the index on the given vectors
 func (idx *IndexIVFScalarQuantizer) Train(vectors []float32) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
-		return fmt.Errorf("empty training vectors")
+		return checkTrainingSize(0, 1)
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
-	if n < int64(idx.nlist) {
-		return fmt.Errorf("need at least %d training vectors for %d clusters", idx.nlist, idx.nlist)
+	if err := checkTrainingSize(int(n), ivfTrainingMinimum(idx.nlist)); err != nil {
+		return err
 	}
 
 	ret := faiss_Index_train(idx.ptr, n, &vectors[0])
@@ -357,6 +449,9 @@ func (idx *IndexIVFScalarQuantizer) Train(vectors []float32) error {
 
 // Add adds vectors to the index
 func (idx *IndexIVFScalarQuantizer) Add(vectors []float32) error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	if !idx.IsTrained() {
 		return fmt.Errorf("index must be trained before adding vectors")
 	}
@@ -364,7 +459,14 @@ func (idx *IndexIVFScalarQuantizer) Add(vectors []float32) error {
 		return nil
 	}
 	if len(vectors)%idx.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", idx.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(vectors, idx.d); err != nil {
+		return err
+	}
+	if err := validateNormalized(idx.normGuard, vectors, idx.d); err != nil {
+		return err
 	}
 
 	n := int64(len(vectors) / idx.d)
@@ -379,11 +481,21 @@ func (idx *IndexIVFScalarQuantizer) Add(vectors []float32) error {
 
 // Search performs k-NN search
 func (idx *IndexIVFScalarQuantizer) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	if idx.ptr == 0 {
+		return nil, nil, ErrNullPointer
+	}
 	if len(queries) == 0 {
 		return nil, nil, fmt.Errorf("empty query vectors")
 	}
 	if len(queries)%idx.d != 0 {
-		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d", idx.d)
+		return nil, nil, fmt.Errorf("queries length must be multiple of dimension %d: %w", idx.d, ErrDimensionMismatch)
+	}
+
+	if err := validateInput(queries, idx.d); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, nil, err
 	}
 
 	nq := int64(len(queries) / idx.d)
@@ -398,13 +510,30 @@ func (idx *IndexIVFScalarQuantizer) Search(queries []float32, k int) (distances
 	return distances, indices, nil
 }
 
+// SearchExplain searches a single query and also returns the inverted lists it probed
+// See IndexIVFFlat.SearchExplain.
+func (idx *IndexIVFScalarQuantizer) SearchExplain(query []float32, k int) (result SearchResult, probedLists []int64, err error) {
+	if idx.ptr == 0 {
+		return SearchResult{}, nil, ErrNullPointer
+	}
+	if !idx.IsTrained() {
+		return SearchResult{}, nil, ErrNotTrained
+	}
+	return searchExplainIVF(idx, idx.ptr, query, k, idx.normGuard)
+}
+
 // SetEfSearch is not supported for IVF scalar quantizer indexes (not an HNSW index)
 func (idx *IndexIVFScalarQuantizer) SetEfSearch(efSearch int) error {
 	return fmt.Errorf("faiss: SetEfSearch not supported for IndexIVFScalarQuantizer (not an HNSW index)")
 }
 
 // Reset removes all vectors from the index
+// Training (coarse centroids and quantizer ranges) is kept, so the index can be
+// refilled without re-training
 func (idx *IndexIVFScalarQuantizer) Reset() error {
+	if idx.ptr == 0 {
+		return ErrNullPointer
+	}
 	ret := faiss_Index_reset(idx.ptr)
 	if ret != 0 {
 		return fmt.Errorf("reset failed")
@@ -418,6 +547,13 @@ func (idx *IndexIVFScalarQuantizer) Close() error {
 	if idx.ptr != 0 {
 		faiss_Index_free(idx.ptr)
 		idx.ptr = 0
+
+		// The quantizer may only be freed after the index that uses it
+		if idx.shared != nil {
+			idx.shared.release()
+			idx.shared = nil
+			idx.quantizer = nil
+		}
 	}
 	return nil
 }
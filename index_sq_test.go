@@ -1,6 +1,7 @@
 package faiss
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -386,6 +387,27 @@ func TestNewIndexIVFScalarQuantizer_NilQuantizer(t *testing.T) {
 	}
 }
 
+func TestNewIndexIVFScalarQuantizer_QuantizerMetricMismatch(t *testing.T) {
+	quantizer, _ := NewIndexFlatL2(16)
+	defer quantizer.Close()
+
+	_, err := NewIndexIVFScalarQuantizer(quantizer, 16, 4, QT_8bit, MetricInnerProduct)
+	if err == nil {
+		t.Fatal("NewIndexIVFScalarQuantizer() with L2 quantizer and IP metric should return error")
+	}
+	if !strings.Contains(err.Error(), "quantizer metric") {
+		t.Errorf("error = %q, want it to mention the quantizer metric", err)
+	}
+
+	ipQuantizer, _ := NewIndexFlatIP(16)
+	defer ipQuantizer.Close()
+	idx, err := NewIndexIVFScalarQuantizer(ipQuantizer, 16, 4, QT_8bit, MetricInnerProduct)
+	if err != nil {
+		t.Fatalf("NewIndexIVFScalarQuantizer() with matching IP quantizer failed: %v", err)
+	}
+	idx.Close()
+}
+
 func TestIndexIVFScalarQuantizer_TrainAndAdd(t *testing.T) {
 	quantizer, _ := NewIndexFlatL2(4)
 	defer quantizer.Close()
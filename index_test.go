@@ -1,6 +1,9 @@
 package faiss
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -8,6 +11,24 @@ import (
 // Index Interface Tests
 // ========================================
 
+// Compile-time checks that every concrete CPU index type implements Index.
+// HNSW indexes are created through the factory and are *GenericIndex values.
+var (
+	_ Index = (*IndexFlat)(nil)
+	_ Index = (*IndexIVFFlat)(nil)
+	_ Index = (*IndexIDMap)(nil)
+	_ Index = (*IndexLSH)(nil)
+	_ Index = (*IndexScalarQuantizer)(nil)
+	_ Index = (*IndexIVFScalarQuantizer)(nil)
+	_ Index = (*IndexRowwiseMinMax)(nil)
+	_ Index = (*IndexRefine)(nil)
+	_ Index = (*IndexPreTransform)(nil)
+	_ Index = (*IndexShards)(nil)
+	_ Index = (*WeightedEnsemble)(nil)
+	_ Index = (*GenericIndex)(nil)
+	_ Index = (*CheckpointingIndex)(nil)
+)
+
 // TestIndex_InterfaceCompliance verifies that all index types implement the Index interface
 func TestIndex_InterfaceCompliance(t *testing.T) {
 	tests := []struct {
@@ -267,6 +288,99 @@ func TestIndex_CloseMultipleTimes(t *testing.T) {
 	}
 }
 
+// TestIndex_UseAfterClose verifies operations on a closed index return an
+// error instead of crashing inside FAISS
+func TestIndex_UseAfterClose(t *testing.T) {
+	d := 16
+	vectors := generateTestVectors(10, d)
+
+	ivf, _ := NewIndexIVFFlatAuto(d, 2, MetricL2)
+
+	tests := []struct {
+		name string
+		idx  Index
+	}{
+		{"IndexFlat", mustCreateIndexFlatL2(t, d)},
+		{"IndexIVFFlat", ivf},
+		{"IndexLSH", mustCreateIndexLSH(t, d, 32)},
+		{"IndexScalarQuantizer", mustCreateIndexSQ(t, d)},
+		{"GenericIndex", mustCreateGenericIndex(t, d, "Flat")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.idx.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+
+			if tt.idx.Ntotal() != 0 {
+				t.Errorf("Ntotal() = %d after Close, want 0", tt.idx.Ntotal())
+			}
+			if err := tt.idx.Add(vectors); err == nil {
+				t.Error("Add() after Close should return error")
+			}
+			if _, _, err := tt.idx.Search(vectors[:d], 1); err == nil {
+				t.Error("Search() after Close should return error")
+			}
+			if err := tt.idx.Close(); err != nil {
+				t.Errorf("second Close() failed: %v", err)
+			}
+		})
+	}
+}
+
+// TestIndex_CloseOutOfOrder closes sub-indexes before and after the
+// composite indexes that reference them, repeatedly, to make sure neither
+// order double-frees or dereferences freed memory
+func TestIndex_CloseOutOfOrder(t *testing.T) {
+	d := 16
+	vectors := generateTestVectors(50, d)
+
+	for i := 0; i < 50; i++ {
+		// Child closed first: the parent must refuse to use it
+		base, _ := NewIndexFlatL2(d)
+		idmap, err := NewIndexIDMap(base)
+		if err != nil {
+			t.Fatalf("NewIndexIDMap() failed: %v", err)
+		}
+		if err := idmap.Add(vectors); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+
+		base.Close()
+		if _, _, err := idmap.Search(vectors[:d], 1); !errors.Is(err, ErrNullPointer) {
+			t.Fatalf("Search() with closed base index error = %v, want ErrNullPointer", err)
+		}
+		idmap.Close()
+		idmap.Close()
+		base.Close()
+
+		// Parent closed first: the child stays usable
+		base, _ = NewIndexFlatL2(d)
+		refine, err := NewIndexRefine(base, base)
+		if err != nil {
+			t.Fatalf("NewIndexRefine() failed: %v", err)
+		}
+		refine.Close()
+		refine.Close()
+		if err := base.Add(vectors); err != nil {
+			t.Errorf("Add() on base after parent Close failed: %v", err)
+		}
+		base.Close()
+
+		// Shards close their children; closing a shard again is a no-op
+		shards, _ := NewIndexShards(d, MetricL2)
+		shard, _ := NewIndexFlatL2(d)
+		shards.AddShard(shard)
+		shard.Close()
+		if _, _, err := shards.Search(vectors[:d], 1); !errors.Is(err, ErrNullPointer) {
+			t.Fatalf("Search() with closed shard error = %v, want ErrNullPointer", err)
+		}
+		shards.Close()
+		shard.Close()
+	}
+}
+
 // ========================================
 // SearchResult Tests
 // ========================================
@@ -397,6 +511,671 @@ func TestSearchResult_Integration(t *testing.T) {
 	}
 }
 
+func TestSearchResult_Neighbors(t *testing.T) {
+	sr := NewSearchResult(
+		[]float32{0.1, 0.2, 0.3, 0.4},
+		[]int64{7, 3, 5, -1},
+		2, 2,
+	)
+
+	neighbors := sr.Neighbors()
+	if len(neighbors) != 2 {
+		t.Fatalf("len(Neighbors()) = %d, want 2", len(neighbors))
+	}
+
+	want := [][]Neighbor{
+		{{ID: 7, Distance: 0.1}, {ID: 3, Distance: 0.2}},
+		{{ID: 5, Distance: 0.3}, {ID: -1, Distance: 0.4}},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if neighbors[i][j] != want[i][j] {
+				t.Errorf("Neighbors()[%d][%d] = %+v, want %+v", i, j, neighbors[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestSearchResults(t *testing.T) {
+	d := 16
+	k := 4
+	idx := mustCreateIndexFlatL2(t, d)
+	defer idx.Close()
+
+	vectors := generateVectors(100, d)
+	if err := idx.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	queries := vectors[:3*d]
+	distances, labels, err := idx.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	results, err := SearchResults(idx, queries, k)
+	if err != nil {
+		t.Fatalf("SearchResults() failed: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+	for i, neighbors := range results {
+		if len(neighbors) != k {
+			t.Fatalf("len(results[%d]) = %d, want %d", i, len(neighbors), k)
+		}
+		for j, n := range neighbors {
+			if n.ID != labels[i*k+j] || n.Distance != distances[i*k+j] {
+				t.Errorf("results[%d][%d] = %+v, want {ID:%d Distance:%v}",
+					i, j, n, labels[i*k+j], distances[i*k+j])
+			}
+		}
+	}
+
+	if _, err := SearchResults(idx, queries, 0); err == nil {
+		t.Error("SearchResults() with k=0 should return error")
+	}
+}
+
+func TestNearest(t *testing.T) {
+	d := 16
+	k := 5
+	idx := mustCreateIndexFlatL2(t, d)
+	defer idx.Close()
+
+	vectors := generateVectors(100, d)
+	if err := idx.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	query := vectors[7*d : 8*d]
+	distances, labels, err := idx.Search(query, k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	neighbors, err := Nearest(idx, query, k)
+	if err != nil {
+		t.Fatalf("Nearest() failed: %v", err)
+	}
+	if len(neighbors) != k {
+		t.Fatalf("len(Nearest()) = %d, want %d", len(neighbors), k)
+	}
+	for j, n := range neighbors {
+		if n.ID != labels[j] || n.Distance != distances[j] {
+			t.Errorf("neighbors[%d] = %+v, want {ID:%d Distance:%v}", j, n, labels[j], distances[j])
+		}
+	}
+	if neighbors[0].ID != 7 {
+		t.Errorf("neighbors[0].ID = %d, want 7", neighbors[0].ID)
+	}
+
+	if _, err := Nearest(idx, vectors[:2*d], k); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Nearest() with two queries error = %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := Nearest(idx, query[:d-1], k); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Nearest() with a short query error = %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := Nearest(idx, query, 0); err == nil {
+		t.Error("Nearest() with k=0 should return error")
+	}
+}
+
+func TestSearchCounted(t *testing.T) {
+	d := 8
+	k := 10
+	idx := mustCreateIndexFlatL2(t, d)
+	defer idx.Close()
+
+	vectors := generateVectors(3, d)
+	if err := idx.Add(vectors); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	queries := generateVectors(4, d)
+	result, counts, err := SearchCounted(idx, queries, k)
+	if err != nil {
+		t.Fatalf("SearchCounted() failed: %v", err)
+	}
+	if result.Nq != 4 || result.K != k {
+		t.Errorf("result Nq, K = %d, %d, want 4, %d", result.Nq, result.K, k)
+	}
+	if len(counts) != 4 {
+		t.Fatalf("len(counts) = %d, want 4", len(counts))
+	}
+	for i, c := range counts {
+		if c != 3 {
+			t.Errorf("counts[%d] = %d, want 3", i, c)
+		}
+		_, labels := result.GetNeighbors(i)
+		for j, label := range labels {
+			if (j < c) != (label != -1) {
+				t.Errorf("query %d: labels = %v, want %d real results then -1", i, labels, c)
+				break
+			}
+		}
+	}
+
+	if _, _, err := SearchCounted(idx, queries, 0); err == nil {
+		t.Error("SearchCounted() with k=0 should return error")
+	}
+}
+
+func TestSearchRanked(t *testing.T) {
+	d := 8
+	vectors := generateVectors(200, d)
+	NormalizeL2(vectors, d)
+	queries := vectors[:3*d]
+	k := 10
+
+	l2, _ := NewIndexFlatL2(d)
+	defer l2.Close()
+	l2.Add(vectors)
+	ip, _ := NewIndexFlatIP(d)
+	defer ip.Close()
+	ip.Add(vectors)
+
+	for _, tc := range []struct {
+		index  Index
+		higher bool
+	}{{l2, false}, {ip, true}} {
+		ranked, err := SearchRanked(tc.index, queries, k)
+		if err != nil {
+			t.Fatalf("%s: SearchRanked() failed: %v", tc.index.MetricType(), err)
+		}
+		if ranked.Higher != tc.higher {
+			t.Errorf("%s: Higher = %v, want %v", tc.index.MetricType(), ranked.Higher, tc.higher)
+		}
+		if ranked.Nq != 3 || ranked.K != k {
+			t.Errorf("%s: Nq, K = %d, %d, want 3, %d", tc.index.MetricType(), ranked.Nq, ranked.K, k)
+		}
+		for q := 0; q < ranked.Nq; q++ {
+			distances, labels := ranked.GetNeighbors(q)
+			// Each query is a stored vector, so it is its own best match
+			if labels[0] != int64(q) {
+				t.Errorf("%s: query %d best = %d, want %d", tc.index.MetricType(), q, labels[0], q)
+			}
+			for j := 1; j < k; j++ {
+				if tc.higher && distances[j] > distances[j-1] || !tc.higher && distances[j] < distances[j-1] {
+					t.Errorf("%s: query %d distances %v are not best-first", tc.index.MetricType(), q, distances)
+					break
+				}
+			}
+		}
+	}
+
+	// Merged results are reordered, with padding last
+	distances := []float32{0.5, -1, 0.9, 0.7}
+	labels := []int64{4, -1, 2, 7}
+	sortBestFirst(distances, labels, true)
+	if want := []int64{2, 7, 4, -1}; labels[0] != want[0] || labels[1] != want[1] || labels[2] != want[2] || labels[3] != want[3] {
+		t.Errorf("sortBestFirst() labels = %v, want %v", labels, want)
+	}
+	if distances[0] != 0.9 || distances[3] != -1 {
+		t.Errorf("sortBestFirst() distances = %v, want them to follow the labels", distances)
+	}
+
+	if _, err := SearchRanked(l2, queries, 0); err == nil {
+		t.Error("SearchRanked() with k=0 should return error")
+	}
+	if _, err := SearchRanked(nil, queries, 1); err == nil {
+		t.Error("SearchRanked() with nil index should return error")
+	}
+}
+
+func TestFlattenQueries(t *testing.T) {
+	flat, err := FlattenQueries([][]float32{{1, 2, 3}, {4, 5, 6}}, 3)
+	if err != nil {
+		t.Fatalf("FlattenQueries() failed: %v", err)
+	}
+	want := []float32{1, 2, 3, 4, 5, 6}
+	if len(flat) != len(want) {
+		t.Fatalf("len(FlattenQueries()) = %d, want %d", len(flat), len(want))
+	}
+	for i := range want {
+		if flat[i] != want[i] {
+			t.Errorf("flat[%d] = %v, want %v", i, flat[i], want[i])
+		}
+	}
+
+	// Ragged input: the second query is one component short
+	_, err = FlattenQueries([][]float32{{1, 2, 3}, {4, 5}, {6, 7, 8}}, 3)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("FlattenQueries(ragged) error = %v, want ErrDimensionMismatch", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "query 1") {
+		t.Errorf("FlattenQueries(ragged) error = %q, want it to name query 1", err)
+	}
+
+	if _, err := FlattenQueries([][]float32{{1, 2, 3}}, 0); err == nil {
+		t.Error("FlattenQueries(expectedDim=0) should return error")
+	}
+	if flat, err := FlattenQueries(nil, 3); err != nil || len(flat) != 0 {
+		t.Errorf("FlattenQueries(nil) = %v, %v, want empty, nil", flat, err)
+	}
+}
+
+func TestUnflattenResults(t *testing.T) {
+	d := 8
+	k := 3
+	idx := mustCreateIndexFlatL2(t, d)
+	defer idx.Close()
+	vectors := generateVectors(50, d)
+	idx.Add(vectors)
+
+	queries := [][]float32{vectors[0:d], vectors[5*d : 6*d]}
+	flat, err := FlattenQueries(queries, idx.D())
+	if err != nil {
+		t.Fatalf("FlattenQueries() failed: %v", err)
+	}
+	distances, labels, _ := idx.Search(flat, k)
+
+	results, err := UnflattenResults(distances, labels, len(queries), k)
+	if err != nil {
+		t.Fatalf("UnflattenResults() failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+	if results[0][0].ID != 0 || results[1][0].ID != 5 {
+		t.Errorf("nearest IDs = %d, %d, want 0, 5", results[0][0].ID, results[1][0].ID)
+	}
+	for i := range results {
+		for j, n := range results[i] {
+			if n.ID != labels[i*k+j] || n.Distance != distances[i*k+j] {
+				t.Errorf("results[%d][%d] = %+v, want {ID:%d Distance:%v}",
+					i, j, n, labels[i*k+j], distances[i*k+j])
+			}
+		}
+	}
+
+	invalid := []struct {
+		name  string
+		nq, k int
+		n     int
+	}{
+		{"negative nq", -1, k, len(labels)},
+		{"negative k", 2, -1, len(labels)},
+		{"short slices", 3, k, len(labels)},
+		{"short labels", 2, k, len(labels) - 1},
+	}
+	for _, tt := range invalid {
+		if _, err := UnflattenResults(distances, labels[:tt.n], tt.nq, tt.k); err == nil {
+			t.Errorf("UnflattenResults(%s) should return error", tt.name)
+		}
+	}
+	if results, err := UnflattenResults(nil, nil, 0, k); err != nil || len(results) != 0 {
+		t.Errorf("UnflattenResults(nq=0) = %v, %v, want empty, nil", results, err)
+	}
+}
+
+func TestMergeTopK(t *testing.T) {
+	// Two searches of the same two queries; ID 7 appears in both for query 0
+	first := NewSearchResult(
+		[]float32{0.5, 1.0, 3.0, 0.1, 0.2, 0.3},
+		[]int64{7, 2, 9, 10, 11, -1},
+		2, 3)
+	second := NewSearchResult(
+		[]float32{0.2, 0.8, 0.15, 0.4},
+		[]int64{7, 4, 12, 13},
+		2, 2)
+
+	merged, err := MergeTopK([]SearchResult{*first, *second}, 3)
+	if err != nil {
+		t.Fatalf("MergeTopK() failed: %v", err)
+	}
+	if merged.Nq != 2 || merged.K != 3 {
+		t.Fatalf("MergeTopK() shape = (%d, %d), want (2, 3)", merged.Nq, merged.K)
+	}
+
+	want := [][]Neighbor{
+		{{ID: 7, Distance: 0.2}, {ID: 4, Distance: 0.8}, {ID: 2, Distance: 1.0}},
+		{{ID: 10, Distance: 0.1}, {ID: 12, Distance: 0.15}, {ID: 11, Distance: 0.2}},
+	}
+	got := merged.Neighbors()
+	for q := range want {
+		for j := range want[q] {
+			if got[q][j] != want[q][j] {
+				t.Errorf("query %d rank %d = %+v, want %+v", q, j, got[q][j], want[q][j])
+			}
+		}
+	}
+
+	// Fewer distinct hits than k are padded
+	padded, err := MergeTopK([]SearchResult{*second}, 3)
+	if err != nil {
+		t.Fatalf("MergeTopK() failed: %v", err)
+	}
+	if d, id := padded.Get(0, 2); id != -1 || d != math.MaxFloat32 {
+		t.Errorf("padding = (%v, %d), want (MaxFloat32, -1)", d, id)
+	}
+
+	if _, err := MergeTopK([]SearchResult{*first, *NewSearchResult(nil, nil, 1, 0)}, 3); err == nil {
+		t.Error("MergeTopK() with mismatched query counts should return error")
+	}
+}
+
+func TestEncodeResults(t *testing.T) {
+	d := 8
+	k := 4
+	idx := mustCreateIndexFlatL2(t, d)
+	defer idx.Close()
+	idx.Add(generateVectors(3, d)) // fewer than k, so some slots are -1
+
+	distances, labels, err := idx.Search(generateVectors(2, d), k)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	data := EncodeResults(distances, labels)
+	if want := 8 + 12*len(labels); len(data) != want {
+		t.Errorf("encoded length = %d, want %d", len(data), want)
+	}
+
+	gotDistances, gotLabels, err := DecodeResults(data)
+	if err != nil {
+		t.Fatalf("DecodeResults() failed: %v", err)
+	}
+	if len(gotDistances) != len(distances) || len(gotLabels) != len(labels) {
+		t.Fatalf("decoded %d distances, %d labels, want %d", len(gotDistances), len(gotLabels), len(labels))
+	}
+	for i := range labels {
+		// Compare bits so that the +Inf/-max distances of empty slots round-trip too
+		if gotLabels[i] != labels[i] || math.Float32bits(gotDistances[i]) != math.Float32bits(distances[i]) {
+			t.Errorf("entry %d = (%d, %v), want (%d, %v)", i, gotLabels[i], gotDistances[i], labels[i], distances[i])
+		}
+	}
+
+	// Empty results round-trip to empty slices
+	if d, l, err := DecodeResults(EncodeResults(nil, nil)); err != nil || len(d) != 0 || len(l) != 0 {
+		t.Errorf("DecodeResults(EncodeResults(nil, nil)) = %v, %v, %v, want empty", d, l, err)
+	}
+
+	for _, bad := range [][]byte{nil, data[:7], data[:len(data)-1], append(data, 0)} {
+		if _, _, err := DecodeResults(bad); err == nil {
+			t.Errorf("DecodeResults(%d bytes) succeeded, want error", len(bad))
+		}
+	}
+}
+
+// ========================================
+// Error Sentinel Tests
+// ========================================
+
+func TestErrors_Is(t *testing.T) {
+	d := 8
+	vectors := generateVectors(100, d)
+
+	flat := mustCreateIndexFlatL2(t, d)
+	defer flat.Close()
+	flat.Add(vectors)
+
+	ivf, err := NewIndexIVFFlatAuto(d, 16, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer ivf.Close()
+
+	closed := mustCreateIndexFlatL2(t, d)
+	closed.Close()
+
+	sq := mustCreateIndexSQ(t, d)
+	defer sq.Close()
+
+	tests := []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"search untrained IVF", func() error {
+			_, _, err := ivf.Search(vectors[:d], 1)
+			return err
+		}, ErrNotTrained},
+		{"add untrained IVF", func() error { return ivf.Add(vectors) }, ErrNotTrained},
+		{"train IVF on too few vectors", func() error { return ivf.Train(vectors) }, ErrTrainingTooSmall},
+		{"search with k=0", func() error {
+			_, _, err := flat.Search(vectors[:d], 0)
+			return err
+		}, ErrInvalidK},
+		{"SearchBatch with k=0", func() error {
+			_, _, err := SearchBatch(flat, vectors[:d], 0)
+			return err
+		}, ErrInvalidK},
+		{"add with wrong length", func() error { return flat.Add(vectors[:d+1]) }, ErrDimensionMismatch},
+		{"search with wrong length", func() error {
+			_, _, err := flat.Search(vectors[:d-1], 1)
+			return err
+		}, ErrDimensionMismatch},
+		{"SQ train with wrong length", func() error { return sq.Train(vectors[:d+1]) }, ErrDimensionMismatch},
+		{"search closed index", func() error {
+			_, _, err := closed.Search(vectors[:d], 1)
+			return err
+		}, ErrIndexClosed},
+		{"add to closed index", func() error { return closed.Add(vectors) }, ErrIndexClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err()
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want errors.Is(err, %v)", err, tt.want)
+			}
+		})
+	}
+
+	// Existing sentinels keep working alongside the new ones
+	if !errors.Is(ErrIndexNotTrained, ErrNotTrained) {
+		t.Error("errors.Is(ErrIndexNotTrained, ErrNotTrained) = false, want true")
+	}
+	if !errors.Is(ErrNullPointer, ErrIndexClosed) {
+		t.Error("errors.Is(ErrNullPointer, ErrIndexClosed) = false, want true")
+	}
+	if errors.Is(ErrNullPointer, ErrDimensionMismatch) {
+		t.Error("errors.Is(ErrNullPointer, ErrDimensionMismatch) = true, want false")
+	}
+}
+
+func TestTrainingSizeError(t *testing.T) {
+	d := 8
+	vectors := generateVectors(100, d)
+
+	ivf, _ := NewIndexIVFFlat(nil, d, 10, MetricL2)
+	defer ivf.Close()
+	sq, _ := NewIndexScalarQuantizer(d, QT_8bit, MetricL2)
+	defer sq.Close()
+	quantizer := mustCreateIndexFlatL2(t, d)
+	defer quantizer.Close()
+	ivfsq, _ := NewIndexIVFScalarQuantizer(quantizer, d, 10, QT_8bit, MetricL2)
+	defer ivfsq.Close()
+	pq, _ := NewIndexPQ(d, 4, 8, MetricL2)
+	defer pq.Close()
+	pq4, _ := NewIndexPQ(d, 4, 4, MetricL2)
+	defer pq4.Close()
+	ivfpq, _ := NewIndexIVFPQ(nil, d, 10, 4, 8)
+	defer ivfpq.Close()
+	ivfpq4, _ := NewIndexIVFPQ(nil, d, 50, 4, 4)
+	defer ivfpq4.Close()
+
+	tests := []struct {
+		name     string
+		index    Index
+		vectors  []float32
+		required int
+	}{
+		{"IVFFlat", ivf, vectors, 300},
+		{"SQ empty", sq, nil, 1},
+		{"IVFSQ", ivfsq, vectors, 300},
+		{"PQ nbits=8", pq, vectors, 39 * 256},
+		{"PQ nbits=4", pq4, vectors, 39 * 16},
+		{"IVFPQ nbits=8", ivfpq, vectors, 39 * 256},
+		{"IVFPQ nlist dominates", ivfpq4, vectors, 30 * 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.index.Train(tt.vectors)
+			if !errors.Is(err, ErrTrainingTooSmall) {
+				t.Fatalf("Train() error = %v, want ErrTrainingTooSmall", err)
+			}
+			var sizeErr *TrainingSizeError
+			if !errors.As(err, &sizeErr) {
+				t.Fatalf("Train() error = %T, want *TrainingSizeError", err)
+			}
+			if want := len(tt.vectors) / d; sizeErr.Provided != want {
+				t.Errorf("Provided = %d, want %d", sizeErr.Provided, want)
+			}
+			if sizeErr.Required != tt.required {
+				t.Errorf("Required = %d, want %d", sizeErr.Required, tt.required)
+			}
+			if tt.index.IsTrained() {
+				t.Error("IsTrained() = true after rejected Train()")
+			}
+		})
+	}
+
+	// Exactly the required number of vectors trains
+	if err := pq4.Train(generateVectors(39*16, d)); err != nil {
+		t.Errorf("PQ4x4 Train(624 vectors) failed: %v", err)
+	}
+}
+
+func TestRecommendedTrainingSize(t *testing.T) {
+	d := 8
+	ivf, _ := NewIndexIVFFlat(nil, d, 10, MetricL2)
+	defer ivf.Close()
+	ivfpq, _ := NewIndexIVFPQ(nil, d, 10, 4, 8)
+	defer ivfpq.Close()
+	ivfpq4, _ := NewIndexIVFPQ(nil, d, 50, 4, 4)
+	defer ivfpq4.Close()
+	flat := mustCreateIndexFlatL2(t, d)
+	defer flat.Close()
+
+	tests := []struct {
+		name  string
+		index Index
+		want  int
+	}{
+		{"IVFFlat", ivf, 39 * 10},
+		{"IVFPQ nbits=8", ivfpq, 39 * 256},
+		{"IVFPQ nlist dominates", ivfpq4, 39 * 50},
+		{"Flat", flat, 0},
+	}
+	for _, tt := range tests {
+		if got := RecommendedTrainingSize(tt.index); got != tt.want {
+			t.Errorf("RecommendedTrainingSize(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// ========================================
+// ID Semantics Tests
+// ========================================
+
+// TestIndex_SearchReturnsAddIDs checks that Search reports the IDs the vectors
+// were added with, for both positional and custom-ID indexes
+func TestIndex_SearchReturnsAddIDs(t *testing.T) {
+	d := 8
+	n := 20
+	vectors := generateVectors(n, d)
+	customIDs := make([]int64, n)
+	for i := range customIDs {
+		customIDs[i] = int64(5000 + 7*i)
+	}
+
+	tests := []struct {
+		name   string
+		create func(t *testing.T) Index
+		custom bool
+	}{
+		{"IndexFlat", func(t *testing.T) Index { return mustCreateIndexFlatL2(t, d) }, false},
+		{"GenericFlat", func(t *testing.T) Index { return mustCreateGenericIndex(t, d, "Flat") }, false},
+		{"GenericHNSW", func(t *testing.T) Index { return mustCreateGenericIndex(t, d, "HNSW16") }, false},
+		{"GenericIDMapFlat", func(t *testing.T) Index { return mustCreateGenericIndex(t, d, "IDMap,Flat") }, true},
+		{"GenericIDMapHNSW", func(t *testing.T) Index { return mustCreateGenericIndex(t, d, "IDMap,HNSW16") }, true},
+		{"IndexIDMap", func(t *testing.T) Index {
+			idx, err := NewIndexIDMap(mustCreateIndexFlatL2(t, d))
+			if err != nil {
+				t.Fatalf("NewIndexIDMap() failed: %v", err)
+			}
+			return idx
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := tt.create(t)
+			defer idx.Close()
+
+			want := make([]int64, n)
+			if tt.custom {
+				withIDs, ok := idx.(interface {
+					AddWithIDs([]float32, []int64) error
+				})
+				if !ok {
+					t.Fatalf("%T does not support AddWithIDs", idx)
+				}
+				if err := withIDs.AddWithIDs(vectors, customIDs); err != nil {
+					t.Fatalf("AddWithIDs() failed: %v", err)
+				}
+				copy(want, customIDs)
+			} else {
+				if err := idx.Add(vectors); err != nil {
+					t.Fatalf("Add() failed: %v", err)
+				}
+				for i := range want {
+					want[i] = int64(i)
+				}
+			}
+
+			_, labels, err := idx.Search(vectors, 1)
+			if err != nil {
+				t.Fatalf("Search() failed: %v", err)
+			}
+			for i := 0; i < n; i++ {
+				if labels[i] != want[i] {
+					t.Errorf("query %d: Search() ID = %d, want %d", i, labels[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGenericIndex_IDMapAdd(t *testing.T) {
+	d := 4
+	idx := mustCreateGenericIndex(t, d, "IDMap,Flat").(*GenericIndex)
+	defer idx.Close()
+
+	// Plain Add on an IDMap assigns sequential IDs like IndexIDMap.Add
+	first := []float32{0, 0, 0, 0, 1, 1, 1, 1}
+	if err := idx.Add(first); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := idx.AddWithIDs([]float32{9, 9, 9, 9}, []int64{42}); err != nil {
+		t.Fatalf("AddWithIDs() failed: %v", err)
+	}
+	if idx.Ntotal() != 3 {
+		t.Errorf("Ntotal() = %d, want 3", idx.Ntotal())
+	}
+
+	_, labels, err := idx.Search([]float32{1, 1, 1, 1, 9, 9, 9, 9}, 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if labels[0] != 1 || labels[1] != 42 {
+		t.Errorf("Search() IDs = %v, want [1 42]", labels)
+	}
+
+	if err := idx.AddWithIDs([]float32{1, 2, 3, 4}, []int64{1, 2}); err == nil {
+		t.Error("AddWithIDs() with mismatched ID count should return error")
+	}
+
+	flat := mustCreateGenericIndex(t, d, "Flat").(*GenericIndex)
+	defer flat.Close()
+	if err := flat.AddWithIDs([]float32{1, 2, 3, 4}, []int64{7}); err == nil {
+		t.Error("AddWithIDs() on Flat should return error")
+	}
+}
+
 // ========================================
 // Helper Functions
 // ========================================
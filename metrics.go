@@ -245,3 +245,46 @@ func (s OperationStats) VectorsPerSecond() float64 {
 	}
 	return float64(s.VectorCount) / s.TotalTime.Seconds()
 }
+
+// ========================================
+// FAISS search statistics
+// ========================================
+
+// SearchStats is a snapshot of the counters FAISS accumulates while searching
+// IVF indexes (faiss::indexIVF_stats).
+//
+// The counters are process-wide: they include every IVF search run since the
+// last ResetSearchStats, across all indexes and goroutines.
+type SearchStats struct {
+	NQ               int64         // Number of queries run
+	NList            int64         // Number of inverted lists scanned
+	NDis             int64         // Number of distances computed
+	NHeapUpdates     int64         // Number of times a result heap was updated
+	QuantizationTime time.Duration // Time spent in the coarse quantizer
+	SearchTime       time.Duration // Time spent scanning inverted lists
+}
+
+// GetSearchStats returns a snapshot of the FAISS IVF search statistics.
+//
+// Example:
+//
+//	faiss.ResetSearchStats()
+//	index.Search(queries, 10)
+//	stats := faiss.GetSearchStats()
+//	fmt.Printf("%.0f distances per query\n", stats.DistancesPerQuery())
+func GetSearchStats() SearchStats {
+	return faissGetIndexIVFStats()
+}
+
+// ResetSearchStats zeroes the FAISS IVF search statistics.
+func ResetSearchStats() {
+	faissResetIndexIVFStats()
+}
+
+// DistancesPerQuery returns the average number of distances computed per query.
+func (s SearchStats) DistancesPerQuery() float64 {
+	if s.NQ == 0 {
+		return 0
+	}
+	return float64(s.NDis) / float64(s.NQ)
+}
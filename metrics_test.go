@@ -194,6 +194,48 @@ func TestMetrics_WithRealIndex(t *testing.T) {
 		snapshot.Search.Count, snapshot.Search.VectorCount, snapshot.Search.AvgTime, snapshot.Search.QPS())
 }
 
+func TestSearchStats(t *testing.T) {
+	d := 16
+	nlist := 8
+	nq := 10
+	vectors := generateVectors(1000, d)
+
+	index, err := NewIndexIVFFlatAuto(d, nlist, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer index.Close()
+	index.Train(vectors)
+	index.Add(vectors)
+	index.SetNprobe(2)
+
+	ResetSearchStats()
+	if stats := GetSearchStats(); stats.NQ != 0 || stats.NDis != 0 {
+		t.Errorf("after ResetSearchStats(): NQ = %d, NDis = %d, want 0", stats.NQ, stats.NDis)
+	}
+
+	if _, _, err := index.Search(vectors[:nq*d], 5); err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	stats := GetSearchStats()
+	if stats.NQ != int64(nq) {
+		t.Errorf("NQ = %d, want %d", stats.NQ, nq)
+	}
+	if stats.NDis <= 0 {
+		t.Errorf("NDis = %d, want > 0", stats.NDis)
+	}
+	if stats.NList <= 0 || stats.NList > int64(nq*2) {
+		t.Errorf("NList = %d, want within (0, %d]", stats.NList, nq*2)
+	}
+	t.Logf("search stats: %+v (%.1f distances/query)", stats, stats.DistancesPerQuery())
+
+	ResetSearchStats()
+	if stats := GetSearchStats(); stats.NDis != 0 {
+		t.Errorf("NDis after ResetSearchStats() = %d, want 0", stats.NDis)
+	}
+}
+
 func BenchmarkMetrics_Overhead(b *testing.B) {
 	ResetMetrics()
 	EnableMetrics()
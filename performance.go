@@ -1,5 +1,11 @@
 package faiss
 
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
 // PerformanceHints provides guidance on optimizing FAISS operations
 //
 // Zero-Copy Operations:
@@ -17,9 +23,18 @@ package faiss
 // locks the goroutine to an OS thread during C++ computation to prevent
 // scheduler overhead and optimize cache locality.
 
+// searchBatchSize is the number of queries SearchBatch and
+// SearchBatchCallback pass to a single Search call; FAISS is optimized for
+// batches of 100-10000 queries
+const searchBatchSize = 10000
+
 // SearchBatch is a helper to demonstrate optimal batch searching
 // Use this pattern when searching multiple queries
 func SearchBatch(index Index, queries []float32, k int) ([]float32, []int64, error) {
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+
 	// Validate batch size
 	nq := len(queries) / index.D()
 	if nq == 0 {
@@ -27,10 +42,7 @@ func SearchBatch(index Index, queries []float32, k int) ([]float32, []int64, err
 	}
 
 	// For very large batches, consider splitting to manage memory
-	// FAISS is optimized for batches of 100-10000 queries
-	const optimalBatchSize = 10000
-
-	if nq <= optimalBatchSize {
+	if nq <= searchBatchSize {
 		// Single batch - optimal path
 		return index.Search(queries, k)
 	}
@@ -40,8 +52,8 @@ func SearchBatch(index Index, queries []float32, k int) ([]float32, []int64, err
 	allDistances := make([]float32, 0, nq*k)
 	allIndices := make([]int64, 0, nq*k)
 
-	for i := 0; i < nq; i += optimalBatchSize {
-		end := i + optimalBatchSize
+	for i := 0; i < nq; i += searchBatchSize {
+		end := i + searchBatchSize
 		if end > nq {
 			end = nq
 		}
@@ -59,6 +71,174 @@ func SearchBatch(index Index, queries []float32, k int) ([]float32, []int64, err
 	return allDistances, allIndices, nil
 }
 
+// SearchBatchCallback searches queries in chunks and hands each query's
+// results to fn as they become available
+//
+// Unlike SearchBatch, which returns nq*k distances and labels at once, only
+// one chunk of results (10000 queries) is held in memory at a time, so
+// result sets larger than RAM can be streamed to disk or another consumer.
+// fn is called once per query, in order, with queryIdx counting from 0
+// across all chunks. The slices are only valid during the call; copy them
+// to keep them.
+//
+// If fn returns an error, the search stops and that error is returned.
+//
+// Example:
+//   err := faiss.SearchBatchCallback(index, queries, 100, func(q int, distances []float32, labels []int64) error {
+//       return writeResults(w, q, labels)
+//   })
+func SearchBatchCallback(index Index, queries []float32, k int, fn func(queryIdx int, distances []float32, labels []int64) error) error {
+	if k <= 0 {
+		return ErrInvalidK
+	}
+	d := index.D()
+	if len(queries)%d != 0 {
+		return fmt.Errorf("faiss: queries length %d is not a multiple of dimension %d: %w", len(queries), d, ErrDimensionMismatch)
+	}
+
+	nq := len(queries) / d
+	for i := 0; i < nq; i += searchBatchSize {
+		end := min(i+searchBatchSize, nq)
+		distances, labels, err := index.Search(queries[i*d:end*d], k)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < end-i; j++ {
+			if err := fn(i+j, distances[j*k:(j+1)*k:(j+1)*k], labels[j*k:(j+1)*k:(j+1)*k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StreamResult is the result of one query searched by SearchChannel
+type StreamResult struct {
+	SearchResult
+	Err error // Set instead of results when the query could not be searched
+}
+
+// searchChannelMaxBatch caps how many queued queries SearchChannel searches
+// in one call
+const searchChannelMaxBatch = 256
+
+// SearchChannel searches a stream of queries and emits one result per query
+//
+// Each value received from in is a single query of index.D() floats. The
+// results are sent on the returned channel in input order, each with Nq = 1,
+// and the channel is closed once in is closed and drained. Queries already
+// waiting in in are searched together (up to 256 per call), so a buffered
+// input channel gets batch throughput while a trickle of queries still sees
+// single-query latency.
+//
+// The output channel is unbuffered: a slow consumer stops the search, which
+// stops reading in and so pushes back on the producer. The consumer must
+// drain the output channel, otherwise the search goroutine never exits.
+//
+// A query that cannot be searched (wrong length, invalid k, search error)
+// yields a result with Err set and no distances or labels; the stream goes
+// on with the next query.
+//
+// Example:
+//   results := faiss.SearchChannel(index, queries, 10)
+//   for r := range results {
+//       if r.Err != nil { ... }
+//       respond(r.Labels)
+//   }
+func SearchChannel(index Index, in <-chan []float32, k int) <-chan StreamResult {
+	out := make(chan StreamResult)
+	go func() {
+		defer close(out)
+		for query := range in {
+			queries := [][]float32{query}
+		drain:
+			for len(queries) < searchChannelMaxBatch {
+				select {
+				case q, ok := <-in:
+					if !ok {
+						break drain
+					}
+					queries = append(queries, q)
+				default:
+					break drain
+				}
+			}
+
+			for _, result := range searchMicroBatch(index, queries, k) {
+				out <- result
+			}
+		}
+	}()
+	return out
+}
+
+// searchMicroBatch searches the valid queries in one call and returns one
+// result per query, in order
+func searchMicroBatch(index Index, queries [][]float32, k int) []StreamResult {
+	results := make([]StreamResult, len(queries))
+	if k <= 0 {
+		for i := range results {
+			results[i].Err = ErrInvalidK
+		}
+		return results
+	}
+
+	d := index.D()
+	packed := make([]float32, 0, len(queries)*d)
+	valid := make([]int, 0, len(queries))
+	for i, q := range queries {
+		if len(q) != d {
+			results[i].Err = fmt.Errorf("faiss: query length %d does not match index dimension %d: %w", len(q), d, ErrDimensionMismatch)
+			continue
+		}
+		packed = append(packed, q...)
+		valid = append(valid, i)
+	}
+	if len(valid) == 0 {
+		return results
+	}
+
+	distances, labels, err := index.Search(packed, k)
+	for j, i := range valid {
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].SearchResult = SearchResult{
+			Distances: distances[j*k : (j+1)*k : (j+1)*k],
+			Labels:    labels[j*k : (j+1)*k : (j+1)*k],
+			Nq:        1,
+			K:         k,
+		}
+	}
+	return results
+}
+
+// searchProgressive repeats a search with its effort parameter (nprobe or
+// efSearch) doubling from start up to limit, and returns the results of the
+// last round that finished
+//
+// The first round always runs, whatever the timeout. Each further round is
+// started only if it is expected to end before the deadline, assuming it
+// takes twice as long as the previous round.
+func searchProgressive(timeout time.Duration, start, limit, nq, k int, search func(param int, distances []float32, labels []int64) error) ([]float32, []int64, error) {
+	deadline := time.Now().Add(timeout)
+	distances, labels := make([]float32, nq*k), make([]int64, nq*k)
+
+	// A round is never abandoned once started, so it can overwrite the
+	// previous round's results in place
+	for param := start; ; param = min(2*param, limit) {
+		roundStart := time.Now()
+		if err := search(param, distances, labels); err != nil {
+			return nil, nil, err
+		}
+		elapsed := time.Since(roundStart)
+		if param >= limit || time.Now().Add(2*elapsed).After(deadline) {
+			return distances, labels, nil
+		}
+	}
+}
+
 // AddBatch is a helper to demonstrate optimal batch addition
 // Use this pattern when adding multiple vectors
 func AddBatch(index Index, vectors []float32) error {
@@ -94,6 +274,110 @@ func AddBatch(index Index, vectors []float32) error {
 	return nil
 }
 
+// ConcurrentAdd adds vectors like AddBatch, but can be called from several
+// goroutines on the same index
+//
+// Adds to one index are serialized through a mutex keyed by the index's C
+// pointer (or by the Index value for types without one), so parallel
+// ingestion goroutines take turns instead of corrupting the index. Adds to
+// different indexes still run in parallel.
+//
+// Only ConcurrentAdd calls are serialized: a direct Add, or a Search running
+// while ConcurrentAdd modifies the index, is still unsafe. Guard searches
+// with your own sync.RWMutex (read lock for Search, write lock around the
+// adds) if both happen at once.
+//
+// Example:
+//
+//	var wg sync.WaitGroup
+//	for _, shard := range shards {
+//	    wg.Add(1)
+//	    go func(vectors []float32) {
+//	        defer wg.Done()
+//	        faiss.ConcurrentAdd(index, vectors)
+//	    }(shard)
+//	}
+//	wg.Wait()
+func ConcurrentAdd(index Index, vectors []float32) error {
+	unlock := lockIndexForAdd(index)
+	defer unlock()
+	return AddBatch(index, vectors)
+}
+
+// addLocks holds one mutex per index with a ConcurrentAdd in progress;
+// entries are dropped when their last user unlocks
+var addLocks = struct {
+	sync.Mutex
+	m map[any]*addLock
+}{m: map[any]*addLock{}}
+
+type addLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockIndexForAdd locks the add mutex for index and returns its unlock func
+func lockIndexForAdd(index Index) func() {
+	var key any = index
+	if ptr, ok := indexPointer(index); ok && ptr != 0 {
+		key = ptr
+	}
+
+	addLocks.Lock()
+	l := addLocks.m[key]
+	if l == nil {
+		l = &addLock{}
+		addLocks.m[key] = l
+	}
+	l.refs++
+	addLocks.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		addLocks.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(addLocks.m, key)
+		}
+		addLocks.Unlock()
+	}
+}
+
+// AddBatchWithIDs adds vectors with custom IDs in chunks of chunkSize vectors
+// Both the vector and ID slices are cut at the same boundaries, so every
+// chunk carries exactly the IDs of its vectors
+// chunkSize <= 0 uses DefaultAddBatchSize
+func AddBatchWithIDs(index IndexWithIDs, vectors []float32, ids []int64, chunkSize int) error {
+	d := index.D()
+	if len(vectors)%d != 0 {
+		return ErrInvalidVectors
+	}
+	n := len(vectors) / d
+	if len(ids) != n {
+		return fmt.Errorf("faiss: got %d IDs for %d vectors", len(ids), n)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultAddBatchSize
+	}
+
+	for i := 0; i < n; i += chunkSize {
+		end := i + chunkSize
+		if end > n {
+			end = n
+		}
+
+		if err := index.AddWithIDs(vectors[i*d:end*d], ids[i:end]); err != nil {
+			return fmt.Errorf("faiss: adding vectors %d-%d failed: %w", i, end-1, err)
+		}
+	}
+
+	return nil
+}
+
 // BatchConfig provides configuration for batch operations
 type BatchConfig struct {
 	// BatchSize is the number of vectors per batch
@@ -110,3 +394,23 @@ const DefaultSearchBatchSize = 10000
 
 // DefaultAddBatchSize is the recommended batch size for add operations
 const DefaultAddBatchSize = 100000
+
+// warmupQueries is the number of throwaway queries issued by Warmup. A small
+// batch (rather than a single query) makes FAISS spin up its OpenMP pool.
+const warmupQueries = 16
+
+// warmupIndex runs a throwaway search against a trained index so that
+// one-time costs (OpenMP thread pool start-up, lazily allocated scratch
+// buffers, cold caches) are paid up front instead of on the first real query.
+// It calls the C API directly so the warm-up does not show up in the search
+// metrics.
+func warmupIndex(ptr uintptr, d int) error {
+	queries := make([]float32, warmupQueries*d)
+	distances := make([]float32, warmupQueries)
+	labels := make([]int64, warmupQueries)
+
+	if err := faissIndexSearch(ptr, queries, warmupQueries, 1, distances, labels); err != nil {
+		return fmt.Errorf("faiss: warmup search failed: %w", err)
+	}
+	return nil
+}
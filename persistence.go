@@ -20,9 +20,12 @@ int faiss_Index_metric_type(FaissIndex index);
 */
 import "C"
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"time"
 	"unsafe"
 )
 
@@ -40,31 +43,9 @@ func WriteIndexToFile(index Index, filename string) error {
 		return fmt.Errorf("faiss: index cannot be nil")
 	}
 
-	var ptr uintptr
-
 	// Extract pointer from any Index type
-	switch idx := index.(type) {
-	case *IndexFlat:
-		ptr = idx.ptr
-	case *IndexIVFFlat:
-		ptr = idx.ptr
-	case *IndexLSH:
-		ptr = idx.ptr
-	case *IndexScalarQuantizer:
-		ptr = idx.ptr
-	case *IndexIVFScalarQuantizer:
-		ptr = idx.ptr
-	case *IndexIDMap:
-		ptr = idx.ptr
-	case *IndexRefine:
-		ptr = idx.ptr
-	case *IndexPreTransform:
-		ptr = idx.ptr
-	case *IndexShards:
-		ptr = idx.ptr
-	case *GenericIndex:
-		ptr = idx.ptr
-	default:
+	ptr, ok := indexPointer(index)
+	if !ok {
 		return fmt.Errorf("faiss: unsupported index type for serialization: %T", index)
 	}
 
@@ -102,30 +83,105 @@ func ReadIndexFromFile(filename string) (Index, error) {
 		return nil, fmt.Errorf("faiss: index file not found: %s", filename)
 	}
 
+	// io_flags = 0 means no special flags (no mmap, not read-only)
+	ptr, err := readIndexFile(filename, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapLoadedIndex(ptr), nil
+}
+
+// readIndexFile reads an index with faiss_read_index_fname and the given
+// FAISS IO flags, returning the raw index pointer
+func readIndexFile(filename string, ioFlags int) (uintptr, error) {
 	cFilename := C.CString(filename)
 	defer C.free(unsafe.Pointer(cFilename))
 
 	var idx *C.FaissIndex
-	// io_flags = 0 means no special flags (no mmap, not read-only)
-	ret := C.faiss_read_index_fname(cFilename, 0, &idx)
+	ret := C.faiss_read_index_fname(cFilename, C.int(ioFlags), &idx)
 	if ret != 0 {
-		return nil, fmt.Errorf("faiss: failed to read index from %s (error code: %d)", filename, ret)
+		return 0, fmt.Errorf("faiss: failed to read index from %s (error code: %d)", filename, ret)
 	}
 
 	ptr := uintptr(unsafe.Pointer(idx))
 	if ptr == 0 {
-		return nil, fmt.Errorf("faiss: loaded index pointer is null")
+		return 0, fmt.Errorf("faiss: loaded index pointer is null")
+	}
+	return ptr, nil
+}
+
+// indexProperties reads the basic properties of a loaded index
+func indexProperties(ptr uintptr) (d int, ntotal int64, metric MetricType) {
+	idxVal := C.FaissIndex(unsafe.Pointer(ptr))
+	return int(C.faiss_Index_d(idxVal)), int64(C.faiss_Index_ntotal(idxVal)), MetricType(C.faiss_Index_metric_type(idxVal))
+}
+
+// SerializeIndex writes the index into a byte slice
+//
+// The bytes use the same format as WriteIndexToFile, so they can be cached,
+// sent over the network, or written to disk and read back with either
+// DeserializeIndex or ReadIndexFromFile.
+//
+// Python equivalent: faiss.serialize_index(index)
+//
+// Example:
+//
+//	data, _ := faiss.SerializeIndex(index)
+//	restored, _ := faiss.DeserializeIndex(data)
+//	restored.Add(moreVectors)
+//	data, _ = faiss.SerializeIndex(restored)
+func SerializeIndex(index Index) ([]byte, error) {
+	if index == nil {
+		return nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+
+	ptr, ok := indexPointer(index)
+	if !ok {
+		return nil, fmt.Errorf("faiss: unsupported index type for serialization: %T", index)
+	}
+
+	if ptr == 0 {
+		return nil, fmt.Errorf("faiss: index pointer is null")
 	}
 
+	data, err := faissSerializeIndex(ptr)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to serialize index: %w", err)
+	}
+
+	return data, nil
+}
+
+// DeserializeIndex loads an index from bytes produced by SerializeIndex
+//
+// The returned index is independent of data and can be modified and
+// serialized again.
+//
+// Python equivalent: faiss.deserialize_index(data)
+func DeserializeIndex(data []byte) (Index, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("faiss: index data cannot be empty")
+	}
+
+	ptr, err := faissDeserializeIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to deserialize index: %w", err)
+	}
+
+	return wrapLoadedIndex(ptr), nil
+}
+
+// wrapLoadedIndex wraps a freshly loaded index in a GenericIndex, since the
+// concrete type isn't known
+func wrapLoadedIndex(ptr uintptr) *GenericIndex {
 	// Get index properties using C functions
-	// Convert *FaissIndex to FaissIndex (void*) for value-type functions
-	idxVal := C.FaissIndex(idx)
+	idxVal := C.FaissIndex(unsafe.Pointer(ptr))
 	d := int(C.faiss_Index_d(idxVal))
 	ntotal := int64(C.faiss_Index_ntotal(idxVal))
 	isTrained := int(C.faiss_Index_is_trained(idxVal)) != 0
 	metricType := MetricType(C.faiss_Index_metric_type(idxVal))
 
-	// Wrap in GenericIndex since we don't know the specific type
 	genericIdx := &GenericIndex{
 		ptr:       ptr,
 		d:         d,
@@ -140,5 +196,310 @@ func ReadIndexFromFile(filename string) (Index, error) {
 		}
 	})
 
-	return genericIdx, nil
+	return genericIdx
+}
+
+// ========================================
+// Index Header
+// ========================================
+
+// indexHeaderSuffix is appended to an index filename to get its sidecar
+const indexHeaderSuffix = ".meta"
+
+// IndexHeader is the provenance WriteIndexWithHeader records next to an index
+//
+// FAISS file formats occasionally change between versions, so comparing
+// FAISSVersion with the running FAISSVersion tells whether an index may
+// need rebuilding before it is loaded.
+type IndexHeader struct {
+	Factory        string    `json:"factory,omitempty"` // factory description, if known
+	Class          string    `json:"class,omitempty"`   // FAISS class name, e.g. "IndexIVFFlat"
+	D              int       `json:"d"`
+	Ntotal         int64     `json:"ntotal"`
+	Metric         string    `json:"metric"`
+	Created        time.Time `json:"created"` // when the file was written
+	FAISSVersion   string    `json:"faiss_version"`
+	BindingVersion string    `json:"binding_version"` // faiss-go Version
+}
+
+// WriteIndexWithHeader saves the index to a file, like WriteIndexToFile, and
+// records where it came from in a small JSON sidecar filename+".meta" (see
+// IndexHeader); the FAISS file format has no room for it. Read it back with
+// ReadIndexHeader.
+//
+// The index file is written first. If only the sidecar cannot be written,
+// the index file is kept and the returned error matches ErrHeaderNotWritten.
+//
+// Example:
+//
+//	if err := faiss.WriteIndexWithHeader(index, "my_index.faiss"); err != nil {
+//	    if !errors.Is(err, faiss.ErrHeaderNotWritten) {
+//	        return err
+//	    }
+//	    log.Printf("index saved without provenance: %v", err)
+//	}
+func WriteIndexWithHeader(index Index, filename string) error {
+	if err := WriteIndexToFile(index, filename); err != nil {
+		return err
+	}
+	if err := writeIndexHeader(index, filename+indexHeaderSuffix); err != nil {
+		return fmt.Errorf("%w: %v", ErrHeaderNotWritten, err)
+	}
+	return nil
+}
+
+// ReadIndexHeader reads the sidecar header WriteIndexWithHeader wrote next
+// to filename, without loading the index itself
+//
+// Files written by WriteIndexToFile or other tools have no sidecar; the
+// returned error then matches os.ErrNotExist.
+//
+// Example:
+//
+//	header, err := faiss.ReadIndexHeader("my_index.faiss")
+//	if err == nil && header.FAISSVersion != faiss.FAISSVersion {
+//	    log.Printf("index built with FAISS %s, rebuilding", header.FAISSVersion)
+//	}
+func ReadIndexHeader(filename string) (*IndexHeader, error) {
+	data, err := os.ReadFile(filename + indexHeaderSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to read index header: %w", err)
+	}
+	var header IndexHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("faiss: invalid index header %s: %w", filename+indexHeaderSuffix, err)
+	}
+	return &header, nil
+}
+
+// writeIndexHeader writes the sidecar header of index to path
+func writeIndexHeader(index Index, path string) error {
+	header := IndexHeader{
+		Factory:        factoryDescription(index),
+		D:              index.D(),
+		Ntotal:         index.Ntotal(),
+		Metric:         index.MetricType().String(),
+		Created:        time.Now().UTC(),
+		FAISSVersion:   FAISSVersion,
+		BindingVersion: Version,
+	}
+	if ptr, ok := indexPointer(index); ok && ptr != 0 {
+		header.Class, _ = indexClassName(ptr)
+	}
+
+	data, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return fmt.Errorf("faiss: failed to encode index header: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("faiss: failed to write index header: %w", err)
+	}
+	return nil
+}
+
+// factoryDescription returns the factory description that builds an index
+// like index, or "" if it is not known
+func factoryDescription(index Index) string {
+	switch idx := index.(type) {
+	case *GenericIndex:
+		return idx.description
+	case *IndexFlat:
+		return "Flat"
+	case *IndexIVFFlat:
+		return fmt.Sprintf("IVF%d,Flat", idx.nlist)
+	case *CheckpointingIndex:
+		return factoryDescription(idx.base)
+	}
+	return ""
+}
+
+// CheckpointingIndex wraps an index and writes it to disk every everyN added
+// vectors, so a long ingestion can resume from the last snapshot after a crash
+//
+// Snapshots are written to a temporary file next to path, synced to stable
+// storage and renamed into place, so path always holds a complete index and
+// a snapshot survives a crash once Checkpoint (or the Add that triggered it)
+// has returned. To resume, load the snapshot with ReadIndexFromFile and
+// continue adding from its Ntotal().
+//
+// Example:
+//
+//	ckpt, _ := faiss.NewCheckpointingIndex(index, "ingest.faiss", 1_000_000)
+//	for batch := range batches {
+//	    ckpt.Add(batch) // snapshots after every 1M vectors
+//	}
+//	ckpt.Checkpoint() // final snapshot
+type CheckpointingIndex struct {
+	base          Index
+	path          string
+	everyN        int64
+	sinceSnapshot int64 // vectors added since the last snapshot
+	checkpoints   int   // number of snapshots written
+}
+
+// Ensure CheckpointingIndex implements Index
+var _ Index = (*CheckpointingIndex)(nil)
+
+// NewCheckpointingIndex wraps base so that Add snapshots it to path every
+// everyN vectors. The wrapper takes ownership of base: Close closes it.
+func NewCheckpointingIndex(base Index, path string, everyN int64) (*CheckpointingIndex, error) {
+	if base == nil {
+		return nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("faiss: checkpoint path cannot be empty")
+	}
+	if everyN <= 0 {
+		return nil, fmt.Errorf("faiss: everyN must be positive, got %d", everyN)
+	}
+	if _, ok := indexPointer(base); !ok {
+		return nil, fmt.Errorf("faiss: unsupported index type for serialization: %T", base)
+	}
+
+	return &CheckpointingIndex{
+		base:   base,
+		path:   path,
+		everyN: everyN,
+	}, nil
+}
+
+// Base returns the wrapped index
+func (idx *CheckpointingIndex) Base() Index {
+	return idx.base
+}
+
+// Checkpoints returns the number of snapshots written so far
+func (idx *CheckpointingIndex) Checkpoints() int {
+	return idx.checkpoints
+}
+
+// Checkpoint writes a snapshot of the index now
+func (idx *CheckpointingIndex) Checkpoint() error {
+	tmp := idx.path + ".tmp"
+	if err := WriteIndexToFile(idx.base, tmp); err != nil {
+		return err
+	}
+	if err := syncFile(tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("faiss: failed to sync checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("faiss: failed to write checkpoint: %w", err)
+	}
+	// Persist the rename itself; directories cannot be synced on every
+	// platform, so this is best effort
+	_ = syncFile(filepath.Dir(idx.path))
+	// The header is written after the index is in place, so it never
+	// describes a snapshot that failed to land
+	if err := writeIndexHeader(idx.base, idx.path+indexHeaderSuffix); err != nil {
+		return err
+	}
+
+	idx.sinceSnapshot = 0
+	idx.checkpoints++
+	return nil
+}
+
+// syncFile flushes a file (or directory) to stable storage
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// D returns the dimension of the wrapped index
+func (idx *CheckpointingIndex) D() int {
+	return idx.base.D()
+}
+
+// Ntotal returns the number of vectors in the wrapped index
+func (idx *CheckpointingIndex) Ntotal() int64 {
+	return idx.base.Ntotal()
+}
+
+// IsTrained returns whether the wrapped index is trained
+func (idx *CheckpointingIndex) IsTrained() bool {
+	return idx.base.IsTrained()
+}
+
+// MetricType returns the metric of the wrapped index
+func (idx *CheckpointingIndex) MetricType() MetricType {
+	return idx.base.MetricType()
+}
+
+// Train trains the wrapped index
+func (idx *CheckpointingIndex) Train(vectors []float32) error {
+	return idx.base.Train(vectors)
+}
+
+// Add adds vectors and writes a snapshot once everyN vectors have been added
+// since the last one. If the snapshot fails the vectors stay added and the
+// error is returned; the next Add retries the snapshot.
+func (idx *CheckpointingIndex) Add(vectors []float32) error {
+	before := idx.base.Ntotal()
+	if err := idx.base.Add(vectors); err != nil {
+		return err
+	}
+	return idx.added(idx.base.Ntotal() - before)
+}
+
+// AddWithIDs adds vectors with custom IDs (if the wrapped index supports
+// them) and snapshots like Add
+func (idx *CheckpointingIndex) AddWithIDs(vectors []float32, ids []int64) error {
+	withIDs, ok := idx.base.(interface {
+		AddWithIDs(vectors []float32, ids []int64) error
+	})
+	if !ok {
+		return fmt.Errorf("faiss: %T does not support AddWithIDs", idx.base)
+	}
+
+	before := idx.base.Ntotal()
+	if err := withIDs.AddWithIDs(vectors, ids); err != nil {
+		return err
+	}
+	return idx.added(idx.base.Ntotal() - before)
+}
+
+// added records n new vectors and snapshots when the interval is reached
+func (idx *CheckpointingIndex) added(n int64) error {
+	idx.sinceSnapshot += n
+	if idx.sinceSnapshot < idx.everyN {
+		return nil
+	}
+	return idx.Checkpoint()
+}
+
+// Search searches the wrapped index
+func (idx *CheckpointingIndex) Search(queries []float32, k int) (distances []float32, indices []int64, err error) {
+	return idx.base.Search(queries, k)
+}
+
+// SetNprobe sets nprobe on the wrapped index
+func (idx *CheckpointingIndex) SetNprobe(nprobe int) error {
+	return idx.base.SetNprobe(nprobe)
+}
+
+// SetEfSearch sets efSearch on the wrapped index
+func (idx *CheckpointingIndex) SetEfSearch(efSearch int) error {
+	return idx.base.SetEfSearch(efSearch)
+}
+
+// Reset removes all vectors from the wrapped index
+// The snapshot on disk is left untouched until the next checkpoint.
+func (idx *CheckpointingIndex) Reset() error {
+	if err := idx.base.Reset(); err != nil {
+		return err
+	}
+	idx.sinceSnapshot = 0
+	return nil
+}
+
+// Close closes the wrapped index without writing a final snapshot
+// Call Checkpoint first to persist vectors added since the last one.
+func (idx *CheckpointingIndex) Close() error {
+	return idx.base.Close()
 }
@@ -1,9 +1,12 @@
 package faiss
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // ========================================
@@ -229,6 +232,55 @@ func TestReadIndexFromFile_InnerProduct(t *testing.T) {
 	}
 }
 
+func TestReadIndexFromFile_ClassName(t *testing.T) {
+	d := 16
+	vectors := generateVectors(1000, d)
+
+	tests := []struct {
+		description string
+		className   string
+	}{
+		{"IVF8,PQ4x4", "IndexIVFPQ"},
+		{"HNSW16", "IndexHNSWFlat"},
+		{"PCA8,Flat", "IndexPreTransform"},
+		{"IDMap,Flat", "IndexIDMap"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			index, err := IndexFactory(d, tt.description, MetricL2)
+			if err != nil {
+				t.Fatalf("IndexFactory(%q) failed: %v", tt.description, err)
+			}
+			if err := index.Train(vectors); err != nil {
+				t.Fatalf("Train() failed: %v", err)
+			}
+			tmpFile := filepath.Join(t.TempDir(), "index.faiss")
+			if err := WriteIndexToFile(index, tmpFile); err != nil {
+				t.Fatalf("WriteIndexToFile() failed: %v", err)
+			}
+			index.Close()
+
+			loaded, err := ReadIndexFromFile(tmpFile)
+			if err != nil {
+				t.Fatalf("ReadIndexFromFile() failed: %v", err)
+			}
+			defer loaded.Close()
+
+			gen := loaded.(*GenericIndex)
+			if got := gen.ClassName(); got != tt.className {
+				t.Errorf("ClassName() = %q, want %q", got, tt.className)
+			}
+			if got := gen.Description(); got != tt.className {
+				t.Errorf("Description() = %q, want %q", got, tt.className)
+			}
+			if got := GetIndexDescription(loaded); !strings.HasPrefix(got, tt.className+"(") {
+				t.Errorf("GetIndexDescription() = %q, want prefix %q", got, tt.className+"(")
+			}
+		})
+	}
+}
+
 // ========================================
 // Roundtrip Tests
 // ========================================
@@ -436,6 +488,228 @@ func TestPersistence_OverwriteFile(t *testing.T) {
 	}
 }
 
+// ========================================
+// In-Memory Serialization Tests
+// ========================================
+
+func TestSerializeIndex_AppendRoundtrip(t *testing.T) {
+	d := 16
+	first := generateVectors(100, d)
+	second := generateVectors(50, d)
+
+	idx, _ := NewIndexFlatL2(d)
+	defer idx.Close()
+	idx.Add(first)
+
+	data, err := SerializeIndex(idx)
+	if err != nil {
+		t.Fatalf("SerializeIndex() failed: %v", err)
+	}
+
+	restored, err := DeserializeIndex(data)
+	if err != nil {
+		t.Fatalf("DeserializeIndex() failed: %v", err)
+	}
+	defer restored.Close()
+	if restored.Ntotal() != 100 {
+		t.Errorf("Ntotal() = %d, want 100", restored.Ntotal())
+	}
+
+	if err := restored.Add(second); err != nil {
+		t.Fatalf("Add() on deserialized index failed: %v", err)
+	}
+
+	data, err = SerializeIndex(restored)
+	if err != nil {
+		t.Fatalf("SerializeIndex() after add failed: %v", err)
+	}
+	final, err := DeserializeIndex(data)
+	if err != nil {
+		t.Fatalf("DeserializeIndex() after add failed: %v", err)
+	}
+	defer final.Close()
+
+	if final.Ntotal() != 150 {
+		t.Fatalf("Ntotal() = %d, want 150", final.Ntotal())
+	}
+	if final.D() != d {
+		t.Errorf("D() = %d, want %d", final.D(), d)
+	}
+
+	// Every vector from both batches is found as its own nearest neighbor
+	all := append(append([]float32{}, first...), second...)
+	_, labels, err := final.Search(all, 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for i, label := range labels {
+		if label != int64(i) {
+			t.Errorf("vector %d: nearest neighbor = %d, want %d", i, label, i)
+		}
+	}
+}
+
+func TestSerializeIndex_Invalid(t *testing.T) {
+	if _, err := SerializeIndex(nil); err == nil {
+		t.Error("SerializeIndex(nil) should return error")
+	}
+	if _, err := DeserializeIndex(nil); err == nil {
+		t.Error("DeserializeIndex(nil) should return error")
+	}
+	if _, err := DeserializeIndex([]byte("not an index")); err == nil {
+		t.Error("DeserializeIndex() with garbage should return error")
+	}
+}
+
+// ========================================
+// Checkpointing Tests
+// ========================================
+
+func TestCheckpointingIndex(t *testing.T) {
+	d := 16
+	batch := 100
+	vectors := generateVectors(6*batch, d)
+	path := filepath.Join(t.TempDir(), "ingest.faiss")
+
+	base, _ := NewIndexFlatL2(d)
+	ckpt, err := NewCheckpointingIndex(base, path, 250)
+	if err != nil {
+		t.Fatalf("NewCheckpointingIndex() failed: %v", err)
+	}
+	defer ckpt.Close()
+
+	// Snapshots happen on the add that reaches 250 vectors since the last one
+	wantNtotal := []int64{0, 0, 300, 300, 300, 600}
+	for i, want := range wantNtotal {
+		if err := ckpt.Add(vectors[i*batch*d : (i+1)*batch*d]); err != nil {
+			t.Fatalf("Add() batch %d failed: %v", i, err)
+		}
+
+		if want == 0 {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("after batch %d: snapshot exists, want none yet", i)
+			}
+			continue
+		}
+		loaded, err := ReadIndexFromFile(path)
+		if err != nil {
+			t.Fatalf("after batch %d: ReadIndexFromFile() failed: %v", i, err)
+		}
+		if loaded.Ntotal() != want {
+			t.Errorf("after batch %d: snapshot Ntotal() = %d, want %d", i, loaded.Ntotal(), want)
+		}
+		loaded.Close()
+	}
+	if ckpt.Checkpoints() != 2 {
+		t.Errorf("Checkpoints() = %d, want 2", ckpt.Checkpoints())
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary snapshot file left behind")
+	}
+
+	// The snapshot resumes with the same search results
+	loaded, _ := ReadIndexFromFile(path)
+	defer loaded.Close()
+	_, want, _ := ckpt.Search(vectors[:d], 5)
+	_, got, _ := loaded.Search(vectors[:d], 5)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resumed labels[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+
+	if _, err := NewCheckpointingIndex(base, path, 0); err == nil {
+		t.Error("NewCheckpointingIndex(everyN=0) should return error")
+	}
+	if _, err := NewCheckpointingIndex(base, "", 10); err == nil {
+		t.Error("NewCheckpointingIndex(path=\"\") should return error")
+	}
+	if err := ckpt.AddWithIDs(vectors[:d], []int64{1}); err == nil {
+		t.Error("AddWithIDs() on IndexFlat should return error")
+	}
+}
+
+// ========================================
+// Index Header Tests
+// ========================================
+
+func TestReadIndexHeader(t *testing.T) {
+	d := 16
+	index := mustCreateGenericIndex(t, d, "HNSW16")
+	defer index.Close()
+	index.Add(generateVectors(100, d))
+
+	tmpFile := filepath.Join(t.TempDir(), "index.faiss")
+	before := time.Now()
+	if err := WriteIndexWithHeader(index, tmpFile); err != nil {
+		t.Fatalf("WriteIndexWithHeader() failed: %v", err)
+	}
+
+	header, err := ReadIndexHeader(tmpFile)
+	if err != nil {
+		t.Fatalf("ReadIndexHeader() failed: %v", err)
+	}
+	want := IndexHeader{
+		Factory:        "HNSW16",
+		Class:          "IndexHNSWFlat",
+		D:              d,
+		Ntotal:         100,
+		Metric:         MetricL2.String(),
+		FAISSVersion:   FAISSVersion,
+		BindingVersion: Version,
+	}
+	got := *header
+	got.Created = time.Time{}
+	if got != want {
+		t.Errorf("ReadIndexHeader() = %+v, want %+v", got, want)
+	}
+	if header.Created.Before(before.Add(-time.Second)) || header.Created.After(time.Now().Add(time.Second)) {
+		t.Errorf("Created = %v, want around %v", header.Created, before)
+	}
+
+	// The index itself still loads as before
+	loaded, err := ReadIndexFromFile(tmpFile)
+	if err != nil {
+		t.Fatalf("ReadIndexFromFile() failed: %v", err)
+	}
+	loaded.Close()
+
+	// Typed indexes get a factory string too
+	flat := mustCreateIndexFlatL2(t, d)
+	defer flat.Close()
+	flatFile := filepath.Join(t.TempDir(), "flat.faiss")
+	WriteIndexWithHeader(flat, flatFile)
+	if header, err := ReadIndexHeader(flatFile); err != nil || header.Factory != "Flat" {
+		t.Errorf("ReadIndexHeader(flat) = %+v, %v, want Factory \"Flat\"", header, err)
+	}
+
+	// WriteIndexToFile writes no sidecar
+	bare := filepath.Join(t.TempDir(), "bare.faiss")
+	if err := WriteIndexToFile(flat, bare); err != nil {
+		t.Fatalf("WriteIndexToFile() failed: %v", err)
+	}
+	if _, err := os.Stat(bare + ".meta"); !os.IsNotExist(err) {
+		t.Errorf("WriteIndexToFile() wrote a sidecar: %v", err)
+	}
+	if _, err := ReadIndexHeader(bare); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadIndexHeader() without sidecar error = %v, want os.ErrNotExist", err)
+	}
+
+	// A sidecar that cannot be written leaves the index file in place
+	blocked := filepath.Join(t.TempDir(), "blocked.faiss")
+	if err := os.Mkdir(blocked+".meta", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteIndexWithHeader(flat, blocked); !errors.Is(err, ErrHeaderNotWritten) {
+		t.Errorf("WriteIndexWithHeader() error = %v, want ErrHeaderNotWritten", err)
+	}
+	loaded, err = ReadIndexFromFile(blocked)
+	if err != nil {
+		t.Fatalf("ReadIndexFromFile() after header failure failed: %v", err)
+	}
+	loaded.Close()
+}
+
 // ========================================
 // Benchmark Tests
 // ========================================
@@ -3,6 +3,7 @@ package faiss
 import (
 	"fmt"
 	"math"
+	"sync/atomic"
 )
 
 // NormalizeL2 normalizes vectors to unit L2 norm (in place)
@@ -66,6 +67,71 @@ func NormalizeL2Copy(vectors []float32, d int) ([]float32, error) {
 	return normalized, nil
 }
 
+// NormalizeWithNorms normalizes vectors to unit L2 norm and returns their
+// original norms alongside (creates a copy)
+//
+// Use it to index normalized vectors for cosine search while keeping the
+// magnitudes, e.g. for ranking; Denormalize restores the raw vectors. Vectors
+// with a norm below 1e-10 are left unchanged and get a norm of 0, the same
+// threshold NormalizeL2 uses.
+//
+// Example:
+//   normalized, norms, _ := faiss.NormalizeWithNorms(vectors, d)
+//   index.Add(normalized)
+//   raw := faiss.Denormalize(normalized, norms, d)
+func NormalizeWithNorms(vectors []float32, d int) (normalized []float32, norms []float32, err error) {
+	if d <= 0 {
+		return nil, nil, ErrInvalidDimension
+	}
+	if len(vectors)%d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+
+	n := len(vectors) / d
+	normalized = make([]float32, len(vectors))
+	norms = make([]float32, n)
+	for i := 0; i < n; i++ {
+		src := vectors[i*d : (i+1)*d]
+		dst := normalized[i*d : (i+1)*d]
+
+		var sum float64
+		for _, v := range src {
+			sum += float64(v) * float64(v)
+		}
+		norm := float32(math.Sqrt(sum))
+
+		if norm < 1e-10 {
+			copy(dst, src)
+			continue
+		}
+		norms[i] = norm
+		for j, v := range src {
+			dst[j] = v / norm
+		}
+	}
+	return normalized, norms, nil
+}
+
+// Denormalize scales normalized vectors back by their norms (creates a copy)
+//
+// It is the inverse of NormalizeWithNorms: vector i is multiplied by
+// norms[i]. A norm of 0 yields a zero vector.
+//
+// Denormalize panics if len(normalized) is not len(norms)*d.
+func Denormalize(normalized, norms []float32, d int) []float32 {
+	if d <= 0 || len(normalized) != len(norms)*d {
+		panic(fmt.Sprintf("faiss: Denormalize got %d values for %d norms of dimension %d", len(normalized), len(norms), d))
+	}
+
+	raw := make([]float32, len(normalized))
+	for i, norm := range norms {
+		for j := i * d; j < (i+1)*d; j++ {
+			raw[j] = normalized[j] * norm
+		}
+	}
+	return raw
+}
+
 // PairwiseDistances computes pairwise distances between two sets of vectors
 //
 // Python equivalent: faiss.pairwise_distances(x, y, metric)
@@ -199,6 +265,88 @@ func RangeKNN(vectors, queries []float32, d, k int, maxDistance float32, metric
 	return filteredDist, filteredIdx, nil
 }
 
+// KNNCosine finds the k most cosine-similar data vectors for each query
+//
+// Like KNN, this doesn't require creating an index: data and queries are
+// L2-normalized (on copies) and searched by inner product in one call.
+// Similarities are clamped to [-1, 1] and sorted highest first.
+//
+// Parameters:
+//   - data: candidate vectors (n vectors of dimension d)
+//   - query: query vectors (nq vectors of dimension d)
+//   - d: dimension
+//   - k: number of neighbors
+//
+// Returns: similarities and indices for each query
+//
+// Example:
+//   sims, labels, _ := faiss.KNNCosine(candidates, embedding, 384, 10)
+func KNNCosine(data, query []float32, d, k int) (sims []float32, labels []int64, err error) {
+	if d <= 0 {
+		return nil, nil, ErrInvalidDimension
+	}
+	if len(data)%d != 0 || len(query)%d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+
+	normData, err := NormalizeL2Copy(data, d)
+	if err != nil {
+		return nil, nil, err
+	}
+	normQuery, err := NormalizeL2Copy(query, d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sims, labels, err = KNN(normData, normQuery, d, k, MetricInnerProduct)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, sim := range sims {
+		if labels[i] >= 0 {
+			sims[i] = clampCosine(sim)
+		}
+	}
+	return sims, labels, nil
+}
+
+// ComputeGroundTruth returns the exact top-k neighbors of each query, the
+// reference that approximate results are scored against
+//
+// A temporary flat index is built over data and searched exhaustively.
+// metric may be MetricL2 (distances ascending), MetricInnerProduct or
+// MetricCosine (similarities descending). If data holds fewer than k vectors
+// the remaining slots have label -1.
+//
+// Example:
+//   gt, _, _ := faiss.ComputeGroundTruth(vectors, queries, d, 10, faiss.MetricL2)
+//   _, labels, _ := index.Search(queries, 10)
+//   recall := faiss.ComputeRecall(gt, labels, nq, 10, 10)
+func ComputeGroundTruth(data, queries []float32, d, k int, metric MetricType) (gtLabels []int64, gtDists []float32, err error) {
+	if d <= 0 {
+		return nil, nil, ErrInvalidDimension
+	}
+	if len(data) == 0 || len(queries) == 0 || len(data)%d != 0 || len(queries)%d != 0 {
+		return nil, nil, ErrInvalidVectors
+	}
+	if k <= 0 {
+		return nil, nil, ErrInvalidK
+	}
+
+	switch metric {
+	case MetricL2, MetricInnerProduct:
+		gtDists, gtLabels, err = KNN(data, queries, d, k, metric)
+	case MetricCosine:
+		gtDists, gtLabels, err = KNNCosine(data, queries, d, k)
+	default:
+		return nil, nil, fmt.Errorf("faiss: unsupported ground truth metric %v", metric)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return gtLabels, gtDists, nil
+}
+
 // ComputeRecall computes recall between ground truth and search results
 //
 // Recall = fraction of true neighbors found in the results
@@ -312,3 +460,118 @@ func ComputeVectorStats(vectors []float32, d int) (*VectorStats, error) {
 
 	return stats, nil
 }
+
+// ========================================
+// Input Validation
+// ========================================
+
+// validateInputEnabled controls whether Add/Train/Search check for NaN/Inf
+var validateInputEnabled atomic.Bool
+
+// SetValidateInput enables or disables NaN/Inf checking of input vectors
+//
+// When enabled, Add, AddWithIDs, Train and Search on every index type scan
+// their input with CheckVectors and reject it before it reaches FAISS. A
+// single NaN embedding can otherwise produce garbage distances or silently
+// poison an IVF centroid during training. The check is a linear scan over the
+// input, so it is disabled by default.
+//
+// Example:
+//   faiss.SetValidateInput(true)
+//   if err := index.Add(vectors); err != nil {
+//       log.Println(err) // faiss: vector 42 contains NaN at component 7
+//   }
+func SetValidateInput(enabled bool) {
+	validateInputEnabled.Store(enabled)
+}
+
+// ValidateInput reports whether NaN/Inf checking of input vectors is enabled
+func ValidateInput() bool {
+	return validateInputEnabled.Load()
+}
+
+// CheckVectors returns an error naming the first vector that contains a NaN or
+// infinite component
+//
+// It can be used directly (for example to filter a batch of embeddings) and is
+// what SetValidateInput(true) runs on every Add/Train/Search.
+func CheckVectors(vectors []float32, d int) error {
+	if d <= 0 {
+		return ErrInvalidDimension
+	}
+	if len(vectors)%d != 0 {
+		return ErrInvalidVectors
+	}
+
+	for i, v := range vectors {
+		f := float64(v)
+		if math.IsNaN(f) {
+			return fmt.Errorf("faiss: vector %d contains NaN at component %d", i/d, i%d)
+		}
+		if math.IsInf(f, 0) {
+			return fmt.Errorf("faiss: vector %d contains %v at component %d", i/d, v, i%d)
+		}
+	}
+	return nil
+}
+
+// validateInput runs CheckVectors when input validation is enabled
+func validateInput(vectors []float32, d int) error {
+	if !validateInputEnabled.Load() {
+		return nil
+	}
+	return CheckVectors(vectors, d)
+}
+
+// normalizedTolerance is how far from 1 the L2 norm of a vector may be for
+// CheckNormalized, well above the rounding left by NormalizeL2
+const normalizedTolerance = 1e-3
+
+// CheckNormalized returns an error naming the first vector whose L2 norm is
+// not ~1.0
+//
+// It is what an index with SetRequireNormalized(true) runs on every Add and
+// Search. The error wraps ErrNotNormalized.
+func CheckNormalized(vectors []float32, d int) error {
+	if d <= 0 {
+		return ErrInvalidDimension
+	}
+	if len(vectors)%d != 0 {
+		return ErrInvalidVectors
+	}
+
+	for i := 0; i < len(vectors)/d; i++ {
+		sum := 0.0
+		for _, v := range vectors[i*d : (i+1)*d] {
+			sum += float64(v) * float64(v)
+		}
+		if norm := math.Sqrt(sum); !(math.Abs(norm-1) <= normalizedTolerance) {
+			return fmt.Errorf("faiss: vector %d has L2 norm %g (normalize it with NormalizeL2): %w", i, norm, ErrNotNormalized)
+		}
+	}
+	return nil
+}
+
+// validateNormalized runs CheckNormalized for indexes that require it
+func validateNormalized(require bool, vectors []float32, d int) error {
+	if !require {
+		return nil
+	}
+	return CheckNormalized(vectors, d)
+}
+
+// requiresNormalized reports whether index rejects vectors that are not
+// unit-norm (see IndexFlat.SetRequireNormalized)
+func requiresNormalized(index Index) bool {
+	guarded, ok := index.(interface{ RequireNormalized() bool })
+	return ok && guarded.RequireNormalized()
+}
+
+// checkRequireNormalized rejects the normalization guard on indexes whose
+// metric is not inner product, where the norm is part of the distance
+func checkRequireNormalized(require bool, metric MetricType) error {
+	if require && metric != MetricInnerProduct {
+		return fmt.Errorf("faiss: normalized input can only be required for inner product indexes, not %s", metric)
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package faiss
 
 import (
+	"errors"
 	"math"
+	"math/rand"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 // ========================================
@@ -103,6 +108,51 @@ func TestNormalizeL2Copy(t *testing.T) {
 	}
 }
 
+func TestNormalizeWithNorms(t *testing.T) {
+	d := 2
+	original := []float32{3, 4, 0, 0, -5, 12}
+
+	normalized, norms, err := NormalizeWithNorms(original, d)
+	if err != nil {
+		t.Fatalf("NormalizeWithNorms() failed: %v", err)
+	}
+
+	wantNorms := []float32{5, 0, 13}
+	for i, want := range wantNorms {
+		if !approxEqual(norms[i], want) {
+			t.Errorf("norms[%d] = %v, want %v", i, norms[i], want)
+		}
+	}
+	if !approxEqual(normalized[0], 0.6) || !approxEqual(normalized[1], 0.8) {
+		t.Errorf("normalized[0] = %v, want [0.6 0.8]", normalized[:2])
+	}
+	if original[0] != 3 {
+		t.Error("NormalizeWithNorms() modified original vectors")
+	}
+
+	// Round trip restores the raw vectors
+	raw := Denormalize(normalized, norms, d)
+	for i := range original {
+		if !approxEqual(raw[i], original[i]) {
+			t.Errorf("Denormalize()[%d] = %v, want %v", i, raw[i], original[i])
+		}
+	}
+
+	if _, _, err := NormalizeWithNorms([]float32{1, 2, 3}, d); err == nil {
+		t.Error("Expected error for invalid vectors length")
+	}
+	if _, _, err := NormalizeWithNorms(original, 0); err == nil {
+		t.Error("Expected error for d = 0")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Denormalize() with mismatched norms did not panic")
+		}
+	}()
+	Denormalize(normalized, norms[:2], d)
+}
+
 // ========================================
 // PairwiseDistances Tests
 // ========================================
@@ -316,6 +366,136 @@ func TestRangeKNN(t *testing.T) {
 	}
 }
 
+func TestKNNCosine(t *testing.T) {
+	d := 16
+	n := 500
+	nq := 3
+	k := 10
+	rng := rand.New(rand.NewSource(11))
+
+	data := make([]float32, n*d)
+	for i := range data {
+		data[i] = float32(rng.NormFloat64())
+	}
+	queries := make([]float32, nq*d)
+	for i := range queries {
+		queries[i] = float32(rng.NormFloat64())
+	}
+
+	sims, labels, err := KNNCosine(data, queries, d, k)
+	if err != nil {
+		t.Fatalf("KNNCosine() failed: %v", err)
+	}
+	if len(sims) != nq*k || len(labels) != nq*k {
+		t.Fatalf("got %d sims and %d labels, want %d", len(sims), len(labels), nq*k)
+	}
+
+	for q := 0; q < nq; q++ {
+		query := queries[q*d : (q+1)*d]
+		want := make([]Neighbor, n)
+		for i := 0; i < n; i++ {
+			var dot, nq2, nv2 float64
+			for j := 0; j < d; j++ {
+				a, b := float64(query[j]), float64(data[i*d+j])
+				dot += a * b
+				nq2 += a * a
+				nv2 += b * b
+			}
+			want[i] = Neighbor{ID: int64(i), Distance: float32(dot / math.Sqrt(nq2*nv2))}
+		}
+		sort.Slice(want, func(a, b int) bool { return want[a].Distance > want[b].Distance })
+
+		for j := 0; j < k; j++ {
+			got := Neighbor{ID: labels[q*k+j], Distance: sims[q*k+j]}
+			if got.ID != want[j].ID || !almostEqual(got.Distance, want[j].Distance, 1e-4) {
+				t.Errorf("query %d rank %d = %+v, want %+v", q, j, got, want[j])
+			}
+		}
+	}
+
+	if _, _, err := KNNCosine(data, queries[:d-1], d, k); err == nil {
+		t.Error("Expected error for invalid query length")
+	}
+	if _, _, err := KNNCosine(data, queries, d, 0); err != ErrInvalidK {
+		t.Errorf("KNNCosine(k=0) error = %v, want ErrInvalidK", err)
+	}
+}
+
+// ========================================
+// ComputeGroundTruth Tests
+// ========================================
+
+func TestComputeGroundTruth(t *testing.T) {
+	d := 2
+	data := []float32{
+		0, 0, // 0
+		1, 0, // 1
+		5, 0, // 2
+		0, 3, // 3
+	}
+
+	tests := []struct {
+		name       string
+		queries    []float32
+		k          int
+		metric     MetricType
+		wantLabels []int64
+		wantDists  []float32
+	}{
+		{"L2", []float32{0.9, 0}, 3, MetricL2, []int64{1, 0, 3}, []float32{0.01, 0.81, 9.81}},
+		{"InnerProduct", []float32{1, 0}, 2, MetricInnerProduct, []int64{2, 1}, []float32{5, 1}},
+		{"Cosine", []float32{0, 2}, 1, MetricCosine, []int64{3}, []float32{1}},
+		{"MultipleQueries", []float32{4, 0, 0, 4}, 1, MetricL2, []int64{2, 3}, []float32{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, dists, err := ComputeGroundTruth(data, tt.queries, d, tt.k, tt.metric)
+			if err != nil {
+				t.Fatalf("ComputeGroundTruth() failed: %v", err)
+			}
+			if len(labels) != len(tt.wantLabels) || len(dists) != len(tt.wantDists) {
+				t.Fatalf("got %d labels, %d distances, want %d", len(labels), len(dists), len(tt.wantLabels))
+			}
+			for i := range labels {
+				if labels[i] != tt.wantLabels[i] || !almostEqual(dists[i], tt.wantDists[i], 1e-5) {
+					t.Errorf("result %d = (%d, %v), want (%d, %v)", i, labels[i], dists[i], tt.wantLabels[i], tt.wantDists[i])
+				}
+			}
+		})
+	}
+
+	// Fewer vectors than k: the tail is padded with -1
+	labels, _, err := ComputeGroundTruth(data, []float32{0, 0}, d, 6, MetricL2)
+	if err != nil {
+		t.Fatalf("ComputeGroundTruth() failed: %v", err)
+	}
+	if labels[3] == -1 || labels[4] != -1 || labels[5] != -1 {
+		t.Errorf("labels = %v, want 4 real results then -1", labels)
+	}
+}
+
+func TestComputeGroundTruth_Invalid(t *testing.T) {
+	data := []float32{0, 0, 1, 0}
+	query := []float32{1, 1}
+
+	if _, _, err := ComputeGroundTruth(data, query, 0, 1, MetricL2); err == nil {
+		t.Error("Expected error for d = 0")
+	}
+	if _, _, err := ComputeGroundTruth(nil, query, 2, 1, MetricL2); err == nil {
+		t.Error("Expected error for empty data")
+	}
+	if _, _, err := ComputeGroundTruth(data, []float32{1}, 2, 1, MetricL2); err == nil {
+		t.Error("Expected error for wrong query length")
+	}
+	if _, _, err := ComputeGroundTruth(data, query, 2, 0, MetricL2); err != ErrInvalidK {
+		t.Errorf("ComputeGroundTruth(k=0) error = %v, want ErrInvalidK", err)
+	}
+	if _, _, err := ComputeGroundTruth(data, query, 2, 1, MetricType(99)); err == nil {
+		t.Error("Expected error for unsupported metric")
+	}
+}
+
 // ========================================
 // ComputeRecall Tests
 // ========================================
@@ -467,3 +647,241 @@ func TestComputeVectorStats_EmptyVectors(t *testing.T) {
 func approxEqual(a, b float32) bool {
 	return math.Abs(float64(a-b)) < 1e-5
 }
+
+// ========================================
+// Input Validation Tests
+// ========================================
+
+func TestCheckVectors(t *testing.T) {
+	d := 4
+	vectors := make([]float32, 5*d)
+	if err := CheckVectors(vectors, d); err != nil {
+		t.Errorf("CheckVectors() on finite vectors = %v, want nil", err)
+	}
+
+	vectors[3*d+2] = float32(math.NaN())
+	err := CheckVectors(vectors, d)
+	if err == nil {
+		t.Fatal("CheckVectors() should reject NaN")
+	}
+	if want := "vector 3 contains NaN at component 2"; !strings.Contains(err.Error(), want) {
+		t.Errorf("CheckVectors() error = %q, want it to contain %q", err, want)
+	}
+
+	vectors[3*d+2] = 0
+	vectors[1*d] = float32(math.Inf(-1))
+	err = CheckVectors(vectors, d)
+	if err == nil || !strings.Contains(err.Error(), "vector 1") {
+		t.Errorf("CheckVectors() error = %v, want it to name vector 1", err)
+	}
+
+	if err := CheckVectors(vectors[:d+1], d); err != ErrInvalidVectors {
+		t.Errorf("CheckVectors() with bad length = %v, want %v", err, ErrInvalidVectors)
+	}
+}
+
+func TestSetValidateInput(t *testing.T) {
+	d := 8
+	index, err := NewIndexFlatL2(d)
+	if err != nil {
+		t.Fatalf("NewIndexFlatL2() failed: %v", err)
+	}
+	defer index.Close()
+
+	vectors := generateVectors(10, d)
+	vectors[7*d+1] = float32(math.NaN())
+
+	SetValidateInput(true)
+	defer SetValidateInput(false)
+	if !ValidateInput() {
+		t.Fatal("ValidateInput() = false after SetValidateInput(true)")
+	}
+
+	err = index.Add(vectors)
+	if err == nil {
+		t.Fatal("Add() with NaN should fail when validation is on")
+	}
+	if !strings.Contains(err.Error(), "vector 7") {
+		t.Errorf("Add() error = %q, want it to name vector 7", err)
+	}
+	if index.Ntotal() != 0 {
+		t.Errorf("Ntotal() = %d after rejected Add, want 0", index.Ntotal())
+	}
+
+	if _, _, err := index.Search(vectors[7*d:8*d], 1); err == nil {
+		t.Error("Search() with NaN query should fail when validation is on")
+	}
+
+	ivf, err := NewIndexIVFFlatAuto(d, 2, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer ivf.Close()
+	if err := ivf.Train(vectors); err == nil {
+		t.Error("Train() with NaN should fail when validation is on")
+	}
+
+	SetValidateInput(false)
+	if err := index.Add(vectors); err != nil {
+		t.Errorf("Add() with validation off = %v, want nil", err)
+	}
+}
+
+func TestSetRequireNormalized(t *testing.T) {
+	d := 8
+	vectors := generateVectors(10, d)
+	NormalizeL2(vectors, d)
+	raw := append([]float32(nil), vectors...)
+	for i := 6 * d; i < 7*d; i++ {
+		raw[i] *= 3
+	}
+
+	flat, _ := NewIndexFlatIP(d)
+	defer flat.Close()
+	ivf, _ := IndexFactory(d, "IVF2,Flat", MetricInnerProduct)
+	defer ivf.Close()
+	ivf.Train(generateVectors(30*2, d))
+
+	for _, index := range []interface {
+		Index
+		SetRequireNormalized(require bool) error
+		RequireNormalized() bool
+	}{flat, ivf.(*GenericIndex)} {
+		// Off by default: un-normalized vectors are accepted silently
+		if index.RequireNormalized() {
+			t.Errorf("%T: RequireNormalized() = true by default", index)
+		}
+		if err := index.Add(raw); err != nil {
+			t.Fatalf("%T: Add() without guard failed: %v", index, err)
+		}
+		index.Reset()
+
+		if err := index.SetRequireNormalized(true); err != nil {
+			t.Fatalf("%T: SetRequireNormalized() failed: %v", index, err)
+		}
+		err := index.Add(raw)
+		if !errors.Is(err, ErrNotNormalized) {
+			t.Fatalf("%T: Add() error = %v, want ErrNotNormalized", index, err)
+		}
+		if !strings.Contains(err.Error(), "vector 6") {
+			t.Errorf("%T: Add() error = %q, want it to name vector 6", index, err)
+		}
+		if index.Ntotal() != 0 {
+			t.Errorf("%T: Ntotal() = %d after rejected Add, want 0", index, index.Ntotal())
+		}
+
+		if err := index.Add(vectors); err != nil {
+			t.Errorf("%T: Add(normalized) failed: %v", index, err)
+		}
+		if _, _, err := index.Search(raw[6*d:7*d], 1); !errors.Is(err, ErrNotNormalized) {
+			t.Errorf("%T: Search() error = %v, want ErrNotNormalized", index, err)
+		}
+		if _, _, err := index.Search(vectors[:d], 1); err != nil {
+			t.Errorf("%T: Search(normalized) failed: %v", index, err)
+		}
+	}
+
+	// L2 distances depend on the norm, so the guard is refused there
+	l2, _ := NewIndexFlatL2(d)
+	defer l2.Close()
+	if err := l2.SetRequireNormalized(true); err == nil {
+		t.Error("Expected error for L2 index")
+	}
+	if err := l2.SetRequireNormalized(false); err != nil {
+		t.Errorf("SetRequireNormalized(false) = %v, want nil", err)
+	}
+}
+
+func TestSetRequireNormalized_AllPaths(t *testing.T) {
+	d := 8
+	vectors := generateVectors(39*16, d)
+	NormalizeL2(vectors, d)
+	raw := append([]float32(nil), vectors[:d]...)
+	for i := range raw {
+		raw[i] *= 3
+	}
+
+	flat, _ := NewIndexFlatIP(d)
+	defer flat.Close()
+	ivf, _ := NewIndexIVFFlatAuto(d, 2, MetricInnerProduct)
+	defer ivf.Close()
+	hnsw, _ := IndexFactory(d, "HNSW8", MetricInnerProduct)
+	defer hnsw.Close()
+	pq, _ := IndexFactory(d, "PQ4x4", MetricInnerProduct)
+	defer pq.Close()
+	sq, _ := NewIndexScalarQuantizer(d, QT_8bit, MetricInnerProduct)
+	defer sq.Close()
+	quantizer, _ := NewIndexFlatIP(d)
+	defer quantizer.Close()
+	ivfsq, _ := NewIndexIVFScalarQuantizer(quantizer, d, 2, QT_8bit, MetricInnerProduct)
+	defer ivfsq.Close()
+
+	for _, index := range []interface {
+		Index
+		SetRequireNormalized(require bool) error
+	}{flat, ivf, hnsw.(*GenericIndex), pq.(*GenericIndex), sq, ivfsq} {
+		if err := index.Train(vectors); err != nil {
+			t.Fatalf("%T: Train() failed: %v", index, err)
+		}
+		if err := index.SetRequireNormalized(true); err != nil {
+			t.Fatalf("%T: SetRequireNormalized() failed: %v", index, err)
+		}
+		if err := index.Add(raw); !errors.Is(err, ErrNotNormalized) {
+			t.Errorf("%T: Add() error = %v, want ErrNotNormalized", index, err)
+		}
+		if err := index.Add(vectors); err != nil {
+			t.Fatalf("%T: Add(normalized) failed: %v", index, err)
+		}
+		if _, _, err := index.Search(raw, 1); !errors.Is(err, ErrNotNormalized) {
+			t.Errorf("%T: Search() error = %v, want ErrNotNormalized", index, err)
+		}
+
+		if s, ok := index.(interface {
+			SearchWithTimeout(queries []float32, k int, timeout time.Duration) ([]float32, []int64, error)
+		}); ok {
+			if _, _, err := s.SearchWithTimeout(raw, 1, time.Second); !errors.Is(err, ErrNotNormalized) {
+				t.Errorf("%T: SearchWithTimeout() error = %v, want ErrNotNormalized", index, err)
+			}
+		}
+		if s, ok := index.(interface {
+			SearchExplain(query []float32, k int) (SearchResult, []int64, error)
+		}); ok {
+			if _, _, err := s.SearchExplain(raw, 1); !errors.Is(err, ErrNotNormalized) {
+				t.Errorf("%T: SearchExplain() error = %v, want ErrNotNormalized", index, err)
+			}
+		}
+		if s, ok := index.(interface {
+			RangeSearch(queries []float32, radius float32) (*RangeSearchResult, error)
+			RangeSearchCount(queries []float32, radius float32) (int, error)
+		}); ok {
+			if _, err := s.RangeSearch(raw, 0.5); !errors.Is(err, ErrNotNormalized) {
+				t.Errorf("%T: RangeSearch() error = %v, want ErrNotNormalized", index, err)
+			}
+			if _, err := s.RangeSearchCount(raw, 0.5); !errors.Is(err, ErrNotNormalized) {
+				t.Errorf("%T: RangeSearchCount() error = %v, want ErrNotNormalized", index, err)
+			}
+		}
+	}
+
+	// An IndexIDMap enforces the guard of its base index
+	base, _ := NewIndexFlatIP(d)
+	defer base.Close()
+	base.SetRequireNormalized(true)
+	idmap, err := NewIndexIDMap(base)
+	if err != nil {
+		t.Fatalf("NewIndexIDMap() failed: %v", err)
+	}
+	defer idmap.Close()
+	if err := idmap.AddWithIDs(raw, []int64{100}); !errors.Is(err, ErrNotNormalized) {
+		t.Errorf("IndexIDMap: AddWithIDs() error = %v, want ErrNotNormalized", err)
+	}
+	if err := idmap.Add(raw); !errors.Is(err, ErrNotNormalized) {
+		t.Errorf("IndexIDMap: Add() error = %v, want ErrNotNormalized", err)
+	}
+	if err := idmap.AddWithIDs(vectors[:d], []int64{100}); err != nil {
+		t.Fatalf("IndexIDMap: AddWithIDs(normalized) failed: %v", err)
+	}
+	if _, _, err := idmap.Search(raw, 1); !errors.Is(err, ErrNotNormalized) {
+		t.Errorf("IndexIDMap: Search() error = %v, want ErrNotNormalized", err)
+	}
+}
@@ -6,14 +6,44 @@ import (
 
 // RangeSearchResult contains the results of a range search
 // For each query, it returns all vectors within the specified radius
+//
+// Layout: the results of all queries are stored back to back in Labels and
+// Distances (the same layout as FAISS). Query i owns the entries in
+// [Lims[i], Lims[i+1]), so Lims has Nq+1 elements, Lims[0] is 0 and
+// Lims[Nq] is the total number of results. Within a query, results are in
+// the order FAISS returned them (not sorted by distance).
+//
+// Most callers should use GetResults for per-query access; AllLabels,
+// AllDistances and Lims are for code that processes all queries at once.
+//
+// The result holds only Go memory: the FAISS-side result is copied and freed
+// inside RangeSearch, so there is nothing to close and dropping the result
+// releases everything.
 type RangeSearchResult struct {
 	Nq        int       // Number of queries
 	Lims      []int64   // Offsets: lims[i] to lims[i+1] are results for query i
-	Labels    []int64   // Labels of results
-	Distances []float32 // Distances of results
+	Labels    []int64   // Labels of results (flattened over all queries)
+	Distances []float32 // Distances of results (flattened over all queries)
+}
+
+// AllLabels returns a copy of the labels of all queries, flattened in query
+// order; query i's labels are AllLabels()[Lims[i]:Lims[i+1]]
+func (rsr *RangeSearchResult) AllLabels() []int64 {
+	labels := make([]int64, rsr.TotalResults())
+	copy(labels, rsr.Labels)
+	return labels
+}
+
+// AllDistances returns a copy of the distances of all queries, flattened in
+// query order; query i's distances are AllDistances()[Lims[i]:Lims[i+1]]
+func (rsr *RangeSearchResult) AllDistances() []float32 {
+	distances := make([]float32, rsr.TotalResults())
+	copy(distances, rsr.Distances)
+	return distances
 }
 
 // GetResults returns the results for a specific query
+// The returned slices alias the result's flattened arrays
 func (rsr *RangeSearchResult) GetResults(queryIdx int) (labels []int64, distances []float32) {
 	if queryIdx < 0 || queryIdx >= rsr.Nq {
 		return nil, nil
@@ -66,28 +96,18 @@ func (idx *IndexFlat) RangeSearch(queries []float32, radius float32) (*RangeSear
 	if len(queries)%idx.d != 0 {
 		return nil, ErrInvalidVectors
 	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, err
+	}
 
 	nq := len(queries) / idx.d
 
-	resultPtr, lims, labels, distances, err := faissIndexRangeSearch(idx.ptr, queries, nq, radius)
+	lims, labels, distances, err := faissIndexRangeSearch(idx.ptr, queries, nq, radius)
 	if err != nil {
 		return nil, fmt.Errorf("faiss: range search failed: %w", err)
 	}
-	defer faissRangeSearchResultFree(resultPtr)
-
-	// Copy results (FAISS owns the memory, we need to copy)
-	result := &RangeSearchResult{
-		Nq:        nq,
-		Lims:      make([]int64, nq+1),
-		Labels:    make([]int64, len(labels)),
-		Distances: make([]float32, len(distances)),
-	}
-
-	copy(result.Lims, lims)
-	copy(result.Labels, labels)
-	copy(result.Distances, distances)
 
-	return result, nil
+	return &RangeSearchResult{Nq: nq, Lims: lims, Labels: labels, Distances: distances}, nil
 }
 
 // RangeSearch for IVF indexes
@@ -104,28 +124,151 @@ func (idx *IndexIVFFlat) RangeSearch(queries []float32, radius float32) (*RangeS
 	if len(queries)%idx.d != 0 {
 		return nil, ErrInvalidVectors
 	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, err
+	}
 
 	nq := len(queries) / idx.d
 
-	resultPtr, lims, labels, distances, err := faissIndexRangeSearch(idx.ptr, queries, nq, radius)
+	lims, labels, distances, err := faissIndexRangeSearch(idx.ptr, queries, nq, radius)
 	if err != nil {
 		return nil, fmt.Errorf("faiss: range search failed: %w", err)
 	}
-	defer faissRangeSearchResultFree(resultPtr)
 
-	result := &RangeSearchResult{
-		Nq:        nq,
-		Lims:      make([]int64, nq+1),
-		Labels:    make([]int64, len(labels)),
-		Distances: make([]float32, len(distances)),
+	return &RangeSearchResult{Nq: nq, Lims: lims, Labels: labels, Distances: distances}, nil
+}
+
+// RangeSearch for factory-created indexes
+//
+// Works for every index type whose FAISS implementation supports range
+// search, including Flat, IVF and HNSW (e.g. "HNSW32" or "IDMap,HNSW32");
+// other types return an error.
+//
+// On HNSW the search is approximate: the graph is explored like a k-NN
+// search with efSearch candidates, and only the vectors reached that way are
+// checked against the radius. Vectors within the radius can therefore be
+// missed, mostly when a query has many of them. Raise efSearch with
+// SetEfSearch to improve coverage.
+//
+// Example:
+//   index, _ := faiss.IndexFactory(128, "HNSW32", faiss.MetricL2)
+//   index.Add(vectors)
+//   index.SetEfSearch(128)
+//   result, _ := index.(*faiss.GenericIndex).RangeSearch(query, 0.1)
+//   duplicates, _ := result.GetResults(0)
+func (idx *GenericIndex) RangeSearch(queries []float32, radius float32) (*RangeSearchResult, error) {
+	if idx.ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	if len(queries) == 0 {
+		return &RangeSearchResult{Nq: 0, Lims: []int64{0}, Labels: []int64{}, Distances: []float32{}}, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return nil, ErrInvalidVectors
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return nil, err
+	}
+	if !idx.IsTrained() {
+		return nil, ErrNotTrained
+	}
+
+	nq := len(queries) / idx.d
+
+	lims, labels, distances, err := faissIndexRangeSearch(idx.ptr, queries, nq, radius)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: range search failed: %w", err)
+	}
+
+	return &RangeSearchResult{Nq: nq, Lims: lims, Labels: labels, Distances: distances}, nil
+}
+
+// RangeSearchCount returns the total number of vectors within radius of the
+// queries, summed over all queries, without copying labels or distances
+//
+// FAISS still collects the matches internally, but they are freed without
+// being copied into Go memory, which makes this cheaper than
+// RangeSearch(...).TotalResults() when only the count is needed, e.g. to
+// estimate the density around a point before choosing a radius.
+//
+// Example:
+//   n, err := index.RangeSearchCount(query, 0.5)
+func (idx *IndexFlat) RangeSearchCount(queries []float32, radius float32) (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+	if len(queries) == 0 {
+		return 0, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return 0, ErrInvalidVectors
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return 0, err
+	}
+
+	nq := len(queries) / idx.d
+
+	count, err := faissIndexRangeSearchCount(idx.ptr, queries, nq, radius)
+	if err != nil {
+		return 0, fmt.Errorf("faiss: range search failed: %w", err)
 	}
+	return int(count), nil
+}
 
-	copy(result.Lims, lims)
-	copy(result.Labels, labels)
-	copy(result.Distances, distances)
+// RangeSearchCount for IVF indexes
+func (idx *IndexIVFFlat) RangeSearchCount(queries []float32, radius float32) (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+	if !idx.isTrained {
+		return 0, ErrNotTrained
+	}
+	if len(queries) == 0 {
+		return 0, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return 0, ErrInvalidVectors
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return 0, err
+	}
+
+	nq := len(queries) / idx.d
 
-	return result, nil
+	count, err := faissIndexRangeSearchCount(idx.ptr, queries, nq, radius)
+	if err != nil {
+		return 0, fmt.Errorf("faiss: range search failed: %w", err)
+	}
+	return int(count), nil
 }
 
-// RangeSearch for HNSW indexes - disabled (HNSW not available in static library)
-// func (idx *IndexHNSW) RangeSearch(queries []float32, radius float32) (*RangeSearchResult, error) { ... }
+// RangeSearchCount for factory-created indexes
+//
+// Supported for the same index types as RangeSearch; on HNSW the count is
+// approximate in the same way.
+func (idx *GenericIndex) RangeSearchCount(queries []float32, radius float32) (int, error) {
+	if idx.ptr == 0 {
+		return 0, ErrNullPointer
+	}
+	if len(queries) == 0 {
+		return 0, nil
+	}
+	if len(queries)%idx.d != 0 {
+		return 0, ErrInvalidVectors
+	}
+	if err := validateNormalized(idx.normGuard, queries, idx.d); err != nil {
+		return 0, err
+	}
+	if !idx.IsTrained() {
+		return 0, ErrNotTrained
+	}
+
+	nq := len(queries) / idx.d
+
+	count, err := faissIndexRangeSearchCount(idx.ptr, queries, nq, radius)
+	if err != nil {
+		return 0, fmt.Errorf("faiss: range search failed: %w", err)
+	}
+	return int(count), nil
+}
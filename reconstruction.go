@@ -1,6 +1,10 @@
 package faiss
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"sort"
+)
 
 // Reconstruct reconstructs a single vector by its index
 //
@@ -38,23 +42,94 @@ func (idx *IndexFlat) Reconstruct(key int64) ([]float32, error) {
 //
 // Python equivalent: vectors = index.reconstruct_n(i0, n)
 func (idx *IndexFlat) ReconstructN(i0, n int64) ([]float32, error) {
+	n = max(n, 0)
+	recons := make([]float32, n*int64(idx.d))
+	if err := idx.ReconstructNInto(i0, n, recons); err != nil {
+		return nil, err
+	}
+
+	return recons, nil
+}
+
+// ReconstructNInto reconstructs ni consecutive vectors starting at i0 into buf
+//
+// buf must hold at least ni * d floats; the vectors are written to
+// buf[:ni*d]. Unlike ReconstructN it does not allocate, so an export loop
+// can reuse one buffer across chunks.
+//
+// Example:
+//   buf := make([]float32, chunk*int64(index.D()))
+//   for i0 := int64(0); i0 < index.Ntotal(); i0 += chunk {
+//       ni := min(chunk, index.Ntotal()-i0)
+//       if err := index.ReconstructNInto(i0, ni, buf); err != nil { ... }
+//       write(buf[:ni*int64(index.D())])
+//   }
+func (idx *IndexFlat) ReconstructNInto(i0, ni int64, buf []float32) error {
 	if idx.ptr == 0 {
-		return nil, ErrNullPointer
+		return ErrNullPointer
 	}
-	if i0 < 0 || i0+n > idx.ntotal {
-		return nil, fmt.Errorf("faiss: range [%d, %d) out of bounds [0, %d)",
-			i0, i0+n, idx.ntotal)
+	if ni < 0 {
+		return fmt.Errorf("faiss: invalid vector count %d", ni)
 	}
-	if n <= 0 {
-		return []float32{}, nil
+	if err := checkReconstructRange(i0, ni, idx.ntotal); err != nil {
+		return err
+	}
+	if err := checkReconstructBuffer(ni, idx.d, buf); err != nil {
+		return err
+	}
+	if ni == 0 {
+		return nil
 	}
 
-	recons := make([]float32, n*int64(idx.d))
-	if err := faissIndexReconstructN(idx.ptr, i0, n, recons); err != nil {
-		return nil, fmt.Errorf("faiss: reconstruction failed: %w", err)
+	if err := faissIndexReconstructN(idx.ptr, i0, ni, buf); err != nil {
+		return fmt.Errorf("faiss: reconstruction failed: %w", err)
 	}
+	return nil
+}
 
-	return recons, nil
+// RawVectors returns the stored vectors without copying them
+//
+// The result is a read-only view of length ntotal * d that aliases the
+// FAISS-owned buffer, so large indexes can be scanned by custom code at no
+// cost. It stays valid only until the next Add, Reset, Reserve, Compact or
+// Close, which may move or free the buffer; keeping it longer reads freed
+// memory. Writing through it corrupts the index, and faults for an index
+// opened with NewIndexFlatMmap. Copy the slice (or use ReconstructN) when
+// the data must outlive the index or be modified.
+//
+// Example:
+//   xb, _ := index.RawVectors()
+//   for i := 0; i < len(xb)/d; i++ {
+//       score(xb[i*d : (i+1)*d])
+//   }
+func (idx *IndexFlat) RawVectors() ([]float32, error) {
+	if idx.ptr == 0 {
+		return nil, ErrNullPointer
+	}
+
+	n := idx.ntotal * int64(idx.d)
+	xb := faissIndexFlatXb(idx.ptr)
+	if int64(len(xb)) < n {
+		return nil, fmt.Errorf("faiss: flat storage holds %d floats, want %d", len(xb), n)
+	}
+	return xb[:n:n], nil
+}
+
+// checkReconstructRange validates a [i0, i0+n) reconstruction range
+func checkReconstructRange(i0, n, ntotal int64) error {
+	if i0 < 0 || i0+n > ntotal {
+		return fmt.Errorf("faiss: range [%d, %d) out of bounds [0, %d)",
+			i0, i0+n, ntotal)
+	}
+	return nil
+}
+
+// checkReconstructBuffer validates that buf can hold n vectors of dimension d
+func checkReconstructBuffer(n int64, d int, buf []float32) error {
+	if int64(len(buf)) < n*int64(d) {
+		return fmt.Errorf("faiss: buffer too small (have %d floats, need %d)", len(buf), n*int64(d))
+	}
+	return nil
 }
 
 // ReconstructBatch reconstructs multiple vectors by their indices
@@ -89,6 +164,10 @@ func (idx *IndexFlat) ReconstructBatch(keys []int64) ([]float32, error) {
 }
 
 // Reconstruction for IVF indexes
+//
+// IVF indexes need a direct map (ID -> inverted list entry) to reconstruct
+// vectors. It is enabled lazily on the first reconstruction call and then
+// maintained by FAISS on subsequent adds.
 func (idx *IndexIVFFlat) Reconstruct(key int64) ([]float32, error) {
 	if idx.ptr == 0 {
 		return nil, ErrNullPointer
@@ -96,6 +175,9 @@ func (idx *IndexIVFFlat) Reconstruct(key int64) ([]float32, error) {
 	if key < 0 || key >= idx.ntotal {
 		return nil, fmt.Errorf("faiss: key %d out of range [0, %d)", key, idx.ntotal)
 	}
+	if err := idx.ensureDirectMap(); err != nil {
+		return nil, err
+	}
 
 	recons := make([]float32, idx.d)
 	if err := faissIndexReconstruct(idx.ptr, key, recons); err != nil {
@@ -106,23 +188,41 @@ func (idx *IndexIVFFlat) Reconstruct(key int64) ([]float32, error) {
 }
 
 func (idx *IndexIVFFlat) ReconstructN(i0, n int64) ([]float32, error) {
+	n = max(n, 0)
+	recons := make([]float32, n*int64(idx.d))
+	if err := idx.ReconstructNInto(i0, n, recons); err != nil {
+		return nil, err
+	}
+
+	return recons, nil
+}
+
+// ReconstructNInto reconstructs ni consecutive vectors starting at i0 into buf
+// See IndexFlat.ReconstructNInto.
+func (idx *IndexIVFFlat) ReconstructNInto(i0, ni int64, buf []float32) error {
 	if idx.ptr == 0 {
-		return nil, ErrNullPointer
+		return ErrNullPointer
 	}
-	if i0 < 0 || i0+n > idx.ntotal {
-		return nil, fmt.Errorf("faiss: range [%d, %d) out of bounds [0, %d)",
-			i0, i0+n, idx.ntotal)
+	if ni < 0 {
+		return fmt.Errorf("faiss: invalid vector count %d", ni)
 	}
-	if n <= 0 {
-		return []float32{}, nil
+	if err := checkReconstructRange(i0, ni, idx.ntotal); err != nil {
+		return err
 	}
-
-	recons := make([]float32, n*int64(idx.d))
-	if err := faissIndexReconstructN(idx.ptr, i0, n, recons); err != nil {
-		return nil, fmt.Errorf("faiss: reconstruction failed: %w", err)
+	if err := checkReconstructBuffer(ni, idx.d, buf); err != nil {
+		return err
+	}
+	if ni == 0 {
+		return nil
+	}
+	if err := idx.ensureDirectMap(); err != nil {
+		return err
 	}
 
-	return recons, nil
+	if err := faissIndexReconstructN(idx.ptr, i0, ni, buf); err != nil {
+		return fmt.Errorf("faiss: reconstruction failed: %w", err)
+	}
+	return nil
 }
 
 func (idx *IndexIVFFlat) ReconstructBatch(keys []int64) ([]float32, error) {
@@ -149,7 +249,604 @@ func (idx *IndexIVFFlat) ReconstructBatch(keys []int64) ([]float32, error) {
 	return recons, nil
 }
 
+// Reconstruction for pre-transform indexes
+//
+// The base index reconstructs the transformed vector, which the transform's
+// ReverseTransform maps back to the original space (FAISS runs the reverse
+// chain itself, so it works even if the transform was trained through the
+// composite). For a dimension-reducing PCA the result is the projection of
+// the added vector onto the kept components: it differs from the original
+// by the PCA reconstruction error. FAISS maps PCA back with the transpose of
+// its matrix, which restores the training mean only along the kept
+// components, so the error is smallest for centered data.
+func (idx *IndexPreTransform) Reconstruct(key int64) ([]float32, error) {
+	if err := idx.prepareReconstruct(); err != nil {
+		return nil, err
+	}
+	ntotal := idx.Ntotal()
+	if key < 0 || key >= ntotal {
+		return nil, fmt.Errorf("faiss: key %d out of range [0, %d)", key, ntotal)
+	}
+
+	recons := make([]float32, idx.dIn)
+	if err := faissIndexReconstruct(idx.ptr, key, recons); err != nil {
+		return nil, fmt.Errorf("faiss: reconstruction failed: %w", err)
+	}
+
+	return recons, nil
+}
+
+// ReconstructN reconstructs n consecutive vectors in the original space
+// See IndexPreTransform.Reconstruct.
+func (idx *IndexPreTransform) ReconstructN(i0, n int64) ([]float32, error) {
+	if err := idx.prepareReconstruct(); err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("faiss: invalid vector count %d", n)
+	}
+	if err := checkReconstructRange(i0, n, idx.Ntotal()); err != nil {
+		return nil, err
+	}
+
+	recons := make([]float32, n*int64(idx.dIn))
+	if n == 0 {
+		return recons, nil
+	}
+	if err := faissIndexReconstructN(idx.ptr, i0, n, recons); err != nil {
+		return nil, fmt.Errorf("faiss: reconstruction failed: %w", err)
+	}
+
+	return recons, nil
+}
+
+// prepareReconstruct checks that the composite is open and enables the
+// direct map of an IVF base index
+func (idx *IndexPreTransform) prepareReconstruct() error {
+	if idx.ptr == 0 || isClosed(idx.index) || isTransformClosed(idx.transform) {
+		return ErrNullPointer
+	}
+	if ivf, ok := idx.index.(*IndexIVFFlat); ok {
+		return ivf.ensureDirectMap()
+	}
+	return nil
+}
+
+// ensureDirectMap enables the IVF direct map if it is not already maintained
+func (idx *IndexIVFFlat) ensureDirectMap() error {
+	if idx.directMap {
+		return nil
+	}
+	if err := faissIndexIVFMakeDirectMap(idx.ptr, true); err != nil {
+		return fmt.Errorf("faiss: failed to enable direct map: %w", err)
+	}
+	idx.directMap = true
+	return nil
+}
+
+// ========================================
+// Reconstruction capability
+// ========================================
+//
+// CanReconstruct reports whether the vectors stored in an index can be
+// decoded back, which is what the reconstruction-based helpers
+// (ExactRerank, ReconstructionError) need. Indexes that also implement
+// IndexWithReconstruction expose the same capability as Reconstruct,
+// ReconstructN and ReconstructBatch. Callers that handle indexes
+// generically can branch on it instead of relying on the error:
+//
+//   if r, ok := index.(interface{ CanReconstruct() bool }); ok && r.CanReconstruct() {
+//       ranked, err := faiss.ExactRerank(index, query, candidates, faiss.MetricL2)
+//       ...
+//   }
+
+// CanReconstruct returns true: flat indexes store raw vectors
+func (idx *IndexFlat) CanReconstruct() bool { return true }
+
+// CanReconstruct returns true: IVF flat indexes store raw vectors in their inverted lists
+func (idx *IndexIVFFlat) CanReconstruct() bool { return true }
+
+// CanReconstruct returns false: IDMap does not expose reconstruction
+func (idx *IndexIDMap) CanReconstruct() bool { return false }
+
+// CanReconstruct returns false: LSH stores binary codes only
+func (idx *IndexLSH) CanReconstruct() bool { return false }
+
+// CanReconstruct returns true: scalar quantizer codes decode back to approximate vectors
+func (idx *IndexScalarQuantizer) CanReconstruct() bool { return true }
+
+// CanReconstruct returns false: reconstruction is not exposed for IVF scalar quantizer indexes
+func (idx *IndexIVFScalarQuantizer) CanReconstruct() bool { return false }
+
+// CanReconstruct returns false: reconstruction is not exposed for refine indexes
+func (idx *IndexRefine) CanReconstruct() bool { return false }
+
+// CanReconstruct reports whether the base index can reconstruct: its vectors
+// are mapped back through the transform
+func (idx *IndexPreTransform) CanReconstruct() bool {
+	r, ok := idx.index.(interface{ CanReconstruct() bool })
+	return ok && r.CanReconstruct()
+}
+
+// CanReconstruct returns false: reconstruction is not exposed for sharded indexes
+func (idx *IndexShards) CanReconstruct() bool { return false }
+
+// CanReconstruct probes FAISS, since a factory string can describe any
+// index: it enables the direct map of IVF indexes, as reconstruction does,
+// and decodes the first stored vector. An empty index reports false as
+// there is nothing to decode yet.
+func (idx *GenericIndex) CanReconstruct() bool {
+	if idx.ptr == 0 || idx.ntotal == 0 {
+		return false
+	}
+	if err := ensureGenericDirectMap(idx); err != nil {
+		return false
+	}
+	recons := make([]float32, idx.d)
+	return faissIndexReconstruct(idx.ptr, 0, recons) == nil
+}
+
 // HNSW doesn't support reconstruction - methods removed (HNSW not available in static library)
 // func (idx *IndexHNSW) Reconstruct(key int64) ([]float32, error) { ... }
 // func (idx *IndexHNSW) ReconstructN(i0, n int64) ([]float32, error) { ... }
 // func (idx *IndexHNSW) ReconstructBatch(keys []int64) ([]float32, error) { ... }
+
+// ========================================
+// Exact re-ranking
+// ========================================
+
+// ExactRerank reconstructs the candidate vectors from index, computes their
+// exact distance to query under metric, and returns them best first
+//
+// This is the usual "search a compressed index, then re-rank" pattern. Pass
+// an index that stores full vectors under the same IDs (for example an
+// IndexFlat filled in the same order as an IVFPQ index) to get exact
+// distances; reconstructing from a lossy index only yields distances to the
+// decoded vectors. Candidates with ID -1 (missing results) are skipped.
+//
+// metric must be MetricL2 (squared L2, ascending), MetricInnerProduct
+// (descending) or MetricCosine (cosine similarity, descending). Cosine
+// re-ranking works on any index, so L2 candidates can be ordered by angle
+// without normalizing the stored vectors.
+//
+// Example:
+//   _, candidates, _ := ivfpq.Search(query, 100)
+//   top, _ := faiss.ExactRerank(flat, query, candidates, faiss.MetricL2)
+//   best := top[:10]
+func ExactRerank(index Index, query []float32, candidateIDs []int64, metric MetricType) (sorted []Neighbor, err error) {
+	if index == nil {
+		return nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+	if metric != MetricL2 && metric != MetricInnerProduct && metric != MetricCosine {
+		return nil, fmt.Errorf("faiss: unsupported re-ranking metric %v", metric)
+	}
+	d := index.D()
+	if len(query) != d {
+		return nil, fmt.Errorf("faiss: query length %d does not match index dimension %d: %w", len(query), d, ErrDimensionMismatch)
+	}
+
+	reconstruct, err := reconstructFunc(index)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted = make([]Neighbor, 0, len(candidateIDs))
+	for _, id := range candidateIDs {
+		if id < 0 {
+			continue
+		}
+		vec, err := reconstruct(id)
+		if err != nil {
+			return nil, fmt.Errorf("faiss: failed to reconstruct candidate %d: %w", id, err)
+		}
+
+		var dist float32
+		switch metric {
+		case MetricInnerProduct:
+			dist, _ = InnerProduct(query, vec)
+		case MetricCosine:
+			dist, _ = CosineSimilarity(query, vec) // 0 for zero vectors
+		default:
+			for j, v := range query {
+				diff := v - vec[j]
+				dist += diff * diff
+			}
+		}
+		sorted = append(sorted, Neighbor{ID: id, Distance: dist})
+	}
+
+	sort.SliceStable(sorted, func(a, b int) bool {
+		if metric == MetricL2 {
+			return sorted[a].Distance < sorted[b].Distance
+		}
+		return sorted[a].Distance > sorted[b].Distance
+	})
+	return sorted, nil
+}
+
+// searchRerankFactor is how many candidates SearchRerank fetches per result
+// before re-ranking
+const searchRerankFactor = 4
+
+// SearchRerank searches index for k*4 candidates and returns the best k of
+// them under rerankMetric
+//
+// searchMetric must be the index's own metric, or MetricCosine for an inner
+// product index holding normalized vectors; it only makes the two-stage
+// intent explicit at the call site. A mismatch wraps ErrMetricMismatch. Candidates are reconstructed from index
+// and re-sorted with ExactRerank, so rerankMetric may be MetricL2,
+// MetricInnerProduct or MetricCosine regardless of how the index was built.
+// Fewer than k neighbors are returned when the index holds fewer vectors.
+//
+// Example:
+//   // fast L2 candidate generation, cosine final ranking
+//   top, _ := faiss.SearchRerank(index, query, 10, faiss.MetricL2, faiss.MetricCosine)
+func SearchRerank(index Index, query []float32, k int, searchMetric, rerankMetric MetricType) ([]Neighbor, error) {
+	if index == nil {
+		return nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("faiss: k = %d: %w", k, ErrInvalidK)
+	}
+	if len(query) != index.D() {
+		return nil, fmt.Errorf("faiss: query length %d does not match index dimension %d: %w", len(query), index.D(), ErrDimensionMismatch)
+	}
+	indexMetric := index.MetricType()
+	cosineOverIP := searchMetric == MetricCosine && indexMetric == MetricInnerProduct
+	if searchMetric != indexMetric && !cosineOverIP {
+		return nil, fmt.Errorf("faiss: search metric %v does not match index metric %v: %w", searchMetric, indexMetric, ErrMetricMismatch)
+	}
+
+	nCandidates := k * searchRerankFactor
+	if ntotal := index.Ntotal(); int64(nCandidates) > ntotal {
+		nCandidates = int(ntotal)
+	}
+	if nCandidates == 0 {
+		return []Neighbor{}, nil
+	}
+
+	_, candidates, err := index.Search(query, nCandidates)
+	if err != nil {
+		return nil, err
+	}
+	sorted, err := ExactRerank(index, query, candidates, rerankMetric)
+	if err != nil {
+		return nil, err
+	}
+	if len(sorted) > k {
+		sorted = sorted[:k]
+	}
+	return sorted, nil
+}
+
+// reconstructFunc returns a single-vector reconstruction function for index,
+// preferring its own Reconstruct method (which sets up e.g. IVF direct maps)
+func reconstructFunc(index Index) (func(key int64) ([]float32, error), error) {
+	if r, ok := index.(interface {
+		Reconstruct(key int64) ([]float32, error)
+	}); ok {
+		return r.Reconstruct, nil
+	}
+
+	ptr, ok := indexPointer(index)
+	if !ok {
+		return nil, fmt.Errorf("faiss: unsupported index type for reconstruction: %T", index)
+	}
+	if ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	if err := ensureGenericDirectMap(index); err != nil {
+		return nil, err
+	}
+	d := index.D()
+	return func(key int64) ([]float32, error) {
+		recons := make([]float32, d)
+		if err := faissIndexReconstruct(ptr, key, recons); err != nil {
+			return nil, err
+		}
+		return recons, nil
+	}, nil
+}
+
+// ========================================
+// Reconstruction error
+// ========================================
+
+// ReconstructionError measures how lossy an index's compression is on the
+// vectors it stores
+//
+// Encoded vectors can't be compared to anything once the originals are gone,
+// so the caller passes them in: originals must be the first vectors added to
+// the index, in order (IDs 0..n-1). Each is reconstructed and the result is
+// the mean over all components of the squared error, the same measure as
+// EstimateReconstructionError. Pass a sample prefix of the dataset to keep it
+// cheap on large indexes.
+//
+// Flat indexes return 0; PQ and SQ indexes return their quantization error.
+//
+// Example:
+//   pq, _ := faiss.IndexFactory(128, "PQ16", faiss.MetricL2)
+//   pq.Train(vectors)
+//   pq.Add(vectors)
+//   mse, _ := faiss.ReconstructionError(pq, vectors[:1000*128])
+func ReconstructionError(index Index, originals []float32) (meanMSE float64, err error) {
+	if index == nil {
+		return 0, fmt.Errorf("faiss: index cannot be nil")
+	}
+	d := index.D()
+	if len(originals) == 0 {
+		return 0, fmt.Errorf("faiss: originals cannot be empty")
+	}
+	if len(originals)%d != 0 {
+		return 0, ErrInvalidVectors
+	}
+	n := int64(len(originals) / d)
+	if n > index.Ntotal() {
+		return 0, fmt.Errorf("faiss: %d originals given but index holds %d vectors", n, index.Ntotal())
+	}
+
+	reconstruct, err := reconstructFunc(index)
+	if err != nil {
+		return 0, err
+	}
+
+	var sum float64
+	for i := int64(0); i < n; i++ {
+		recons, err := reconstruct(i)
+		if err != nil {
+			return 0, fmt.Errorf("faiss: failed to reconstruct vector %d: %w", i, err)
+		}
+		for j, v := range originals[i*int64(d) : (i+1)*int64(d)] {
+			diff := float64(v - recons[j])
+			sum += diff * diff
+		}
+	}
+	return sum / float64(len(originals)), nil
+}
+
+// ========================================
+// Recall verification
+// ========================================
+
+// VerifyRecall checks an approximate index against exact search over its own
+// vectors and reports which true neighbors it missed
+//
+// All vectors are reconstructed from approx into a temporary flat index with
+// the same metric, so the ground truth is computed on exactly what approx
+// stores. query may hold several queries; recall is the fraction of exact
+// top-k neighbors that approx also returned, and missing lists the ones it
+// did not, query by query. IDs are positions in approx (0..ntotal-1), so this
+// is meant for indexes filled with Add rather than custom IDs.
+//
+// The flat copy is rebuilt on every call and needs ntotal*d floats of
+// memory; use it in tests and tuning runs, not on the serving path.
+//
+// Example:
+//   recall, missing, _ := faiss.VerifyRecall(hnsw, queries, 10)
+//   fmt.Printf("recall %.3f, missed %v\n", recall, missing)
+func VerifyRecall(approx Index, query []float32, k int) (recall float64, missing []int64, err error) {
+	if approx == nil {
+		return 0, nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+	if k <= 0 {
+		return 0, nil, ErrInvalidK
+	}
+	d := approx.D()
+	if len(query) == 0 || len(query)%d != 0 {
+		return 0, nil, fmt.Errorf("faiss: query length %d is not a multiple of dimension %d: %w", len(query), d, ErrDimensionMismatch)
+	}
+	nq := len(query) / d
+
+	vectors, err := reconstructAll(approx)
+	if err != nil {
+		return 0, nil, err
+	}
+	exact, err := NewIndexFlat(d, approx.MetricType())
+	if err != nil {
+		return 0, nil, err
+	}
+	defer exact.Close()
+	if len(vectors) > 0 {
+		if err := exact.Add(vectors); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	_, truth, err := exact.Search(query, k)
+	if err != nil {
+		return 0, nil, err
+	}
+	_, found, err := approx.Search(query, k)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	total := 0
+	missing = []int64{}
+	for q := 0; q < nq; q++ {
+		returned := make(map[int64]bool, k)
+		for _, id := range found[q*k : (q+1)*k] {
+			returned[id] = true
+		}
+		for _, id := range truth[q*k : (q+1)*k] {
+			if id < 0 {
+				continue
+			}
+			total++
+			if !returned[id] {
+				missing = append(missing, id)
+			}
+		}
+	}
+	if total == 0 {
+		return 1, missing, nil
+	}
+	return float64(total-len(missing)) / float64(total), missing, nil
+}
+
+// reconstructAll returns every vector stored in index, in ID order,
+// preferring its own ReconstructN method (which sets up e.g. IVF direct maps)
+func reconstructAll(index Index) ([]float32, error) {
+	ntotal := index.Ntotal()
+	if r, ok := index.(interface {
+		ReconstructN(i0, n int64) ([]float32, error)
+	}); ok {
+		return r.ReconstructN(0, ntotal)
+	}
+
+	ptr, ok := indexPointer(index)
+	if !ok {
+		return nil, fmt.Errorf("faiss: unsupported index type for reconstruction: %T", index)
+	}
+	if ptr == 0 {
+		return nil, ErrNullPointer
+	}
+	vectors := make([]float32, ntotal*int64(index.D()))
+	if ntotal == 0 {
+		return vectors, nil
+	}
+	if err := faissIndexReconstructN(ptr, 0, ntotal, vectors); err != nil {
+		return nil, fmt.Errorf("faiss: reconstruction failed: %w", err)
+	}
+	return vectors, nil
+}
+
+// ensureGenericDirectMap enables the direct map of an IVF factory index so
+// its vectors can be reconstructed, as IndexIVFFlat.Reconstruct does lazily
+func ensureGenericDirectMap(index Index) error {
+	g, ok := index.(*GenericIndex)
+	if !ok || g.ptr == 0 {
+		return nil
+	}
+	if _, err := faissIndexIVFGetNlist(g.ptr); err != nil {
+		return nil
+	}
+	if err := faissIndexIVFMakeDirectMap(g.ptr, true); err != nil {
+		return fmt.Errorf("faiss: failed to enable direct map: %w", err)
+	}
+	return nil
+}
+
+// ========================================
+// Integrity self-test
+// ========================================
+
+// exhaustiveSearch searches index with nprobe = nlist for IVF indexes and
+// efSearch >= ntotal for HNSW indexes, so that every stored vector can be
+// found. Other indexes are searched as they are.
+func exhaustiveSearch(index Index, queries []float32, k int) ([]float32, []int64, error) {
+	ptr, ok := indexPointer(index)
+	if !ok || ptr == 0 {
+		return index.Search(queries, k)
+	}
+	nq := len(queries) / index.D()
+	distances := make([]float32, nq*k)
+	labels := make([]int64, nq*k)
+	if nlist, err := faissIndexIVFGetNlist(ptr); err == nil {
+		err = faissIndexIVFSearchWithNprobe(ptr, queries, nq, k, nlist, distances, labels)
+		return distances, labels, err
+	}
+	if _, err := faissIndexHNSWGetM(ptr); err == nil {
+		efSearch := int(min(max(index.Ntotal(), int64(k)), math.MaxInt32))
+		err = faissIndexHNSWSearchWithQueue(ptr, queries, nq, k, efSearch, true, distances, labels)
+		return distances, labels, err
+	}
+	return index.Search(queries, k)
+}
+
+// selfTestSamples is the number of stored vectors SelfTest searches for
+const selfTestSamples = 8
+
+// SelfTest checks that index finds its own stored vectors
+//
+// Up to 8 vectors spread over the index are reconstructed and searched for.
+// Each must decode to finite values and be matched by a result at distance
+// ~0 (L2), or by a score at least its own squared norm (inner product). The
+// tolerance is relative to the vector norm, so lossy codes such as PQ or SQ
+// pass: their search compares the query with the same decoded vector.
+//
+// The search probes every inverted list of IVF indexes and runs HNSW with
+// efSearch of at least ntotal, through per-call parameters that leave the
+// index's own nprobe and efSearch unchanged, so a vector missed by the
+// approximate search is not reported as corruption.
+//
+// A header check only proves a file starts like an index; SelfTest catches
+// payloads that load but hold garbage, such as corrupted codes or inverted
+// lists. Failures wrap ErrCorruptIndex. Indexes that cannot reconstruct
+// return that error instead; an IndexIDMap is tested through its base
+// index, and IVF factory indexes get their direct map enabled (as by
+// IndexIVFFlat.Reconstruct). Empty indexes pass.
+//
+// Example:
+//   index, err := faiss.ReadIndexFromFile(path)
+//   if err == nil {
+//       err = faiss.SelfTest(index)
+//   }
+func SelfTest(index Index) error {
+	if index == nil {
+		return fmt.Errorf("faiss: index cannot be nil")
+	}
+	if idmap, ok := index.(*IndexIDMap); ok {
+		index = idmap.baseIndex
+	}
+	ntotal := index.Ntotal()
+	if ntotal == 0 {
+		return nil
+	}
+
+	reconstruct, err := reconstructFunc(index)
+	if err != nil {
+		return err
+	}
+
+	d := index.D()
+	ids := make([]int64, 0, selfTestSamples)
+	for i := int64(0); i < selfTestSamples; i++ {
+		id := i * ntotal / selfTestSamples
+		if len(ids) == 0 || id != ids[len(ids)-1] {
+			ids = append(ids, id)
+		}
+	}
+	queries := make([]float32, 0, len(ids)*d)
+	for _, id := range ids {
+		vec, err := reconstruct(id)
+		if err != nil {
+			return fmt.Errorf("%w: vector %d cannot be reconstructed: %v", ErrCorruptIndex, id, err)
+		}
+		if err := CheckVectors(vec, d); err != nil {
+			return fmt.Errorf("%w: vector %d: %v", ErrCorruptIndex, id, err)
+		}
+		queries = append(queries, vec...)
+	}
+
+	k := int(min(ntotal, 10))
+	distances, labels, err := exhaustiveSearch(index, queries, k)
+	if err != nil {
+		return fmt.Errorf("%w: search failed: %v", ErrCorruptIndex, err)
+	}
+
+	for q, id := range ids {
+		var norm2 float32
+		for _, v := range queries[q*d : (q+1)*d] {
+			norm2 += v * v
+		}
+		tol := 1e-3 * (norm2 + 1)
+		best, label := distances[q*k], labels[q*k]
+		if label < 0 {
+			return fmt.Errorf("%w: search for vector %d returned no results", ErrCorruptIndex, id)
+		}
+
+		// Written as !(ok) so that NaN distances fail
+		switch index.MetricType() {
+		case MetricL2:
+			if !(best <= tol) {
+				return fmt.Errorf("%w: vector %d: nearest result at distance %g, want ~0", ErrCorruptIndex, id, best)
+			}
+		case MetricInnerProduct:
+			if !(best >= norm2-tol) {
+				return fmt.Errorf("%w: vector %d: best score %g, want at least its own %g", ErrCorruptIndex, id, best, norm2)
+			}
+		}
+	}
+	return nil
+}
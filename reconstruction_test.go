@@ -1,6 +1,7 @@
 package faiss
 
 import (
+	"errors"
 	"math"
 	"testing"
 )
@@ -215,6 +216,90 @@ func TestIndexFlat_ReconstructN_InvalidRange(t *testing.T) {
 	}
 }
 
+func TestIndexFlat_ReconstructNInto(t *testing.T) {
+	d := 4
+	idx, _ := NewIndexFlatL2(d)
+	defer idx.Close()
+
+	vectors := []float32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	idx.Add(vectors)
+
+	// Buffer larger than needed: only the first ni*d floats are written
+	buf := make([]float32, 3*d)
+	for i := range buf {
+		buf[i] = -1
+	}
+	if err := idx.ReconstructNInto(1, 2, buf); err != nil {
+		t.Fatalf("ReconstructNInto(1, 2) failed: %v", err)
+	}
+	for i := 0; i < 2*d; i++ {
+		if buf[i] != vectors[d+i] {
+			t.Errorf("buf[%d] = %v, want %v", i, buf[i], vectors[d+i])
+		}
+	}
+	if buf[2*d] != -1 {
+		t.Errorf("buf[%d] = %v, want untouched -1", 2*d, buf[2*d])
+	}
+
+	if err := idx.ReconstructNInto(0, 3, buf[:2*d]); err == nil {
+		t.Error("ReconstructNInto() with short buffer should return error")
+	}
+	if err := idx.ReconstructNInto(2, 2, buf); err == nil {
+		t.Error("ReconstructNInto(2, 2) should return error (only 3 vectors)")
+	}
+	if err := idx.ReconstructNInto(0, -1, buf); err == nil {
+		t.Error("ReconstructNInto(0, -1) should return error")
+	}
+	if err := idx.ReconstructNInto(0, 0, nil); err != nil {
+		t.Errorf("ReconstructNInto(0, 0) failed: %v", err)
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		idx.ReconstructNInto(0, 3, buf)
+	})
+	if allocs != 0 {
+		t.Errorf("ReconstructNInto() allocated %.0f times per call, want 0", allocs)
+	}
+}
+
+func TestIndexFlat_RawVectors(t *testing.T) {
+	d := 16
+	nb := 200
+	idx, _ := NewIndexFlatL2(d)
+	defer idx.Close()
+
+	raw, err := idx.RawVectors()
+	if err != nil {
+		t.Fatalf("RawVectors() on empty index failed: %v", err)
+	}
+	if len(raw) != 0 {
+		t.Errorf("len(RawVectors()) on empty index = %d, want 0", len(raw))
+	}
+
+	idx.Add(generateVectors(nb, d))
+	raw, err = idx.RawVectors()
+	if err != nil {
+		t.Fatalf("RawVectors() failed: %v", err)
+	}
+	want, err := idx.ReconstructN(0, idx.Ntotal())
+	if err != nil {
+		t.Fatalf("ReconstructN() failed: %v", err)
+	}
+	if len(raw) != len(want) {
+		t.Fatalf("len(RawVectors()) = %d, want %d", len(raw), len(want))
+	}
+	for i := range want {
+		if raw[i] != want[i] {
+			t.Fatalf("RawVectors()[%d] = %v, want %v", i, raw[i], want[i])
+		}
+	}
+
+	idx.Close()
+	if _, err := idx.RawVectors(); err == nil {
+		t.Error("RawVectors() on closed index should return error")
+	}
+}
+
 // ========================================
 // IndexFlat ReconstructBatch Tests
 // ========================================
@@ -350,6 +435,245 @@ func TestIndexIVFFlat_Reconstruct(t *testing.T) {
 	t.Log("IVFFlat reconstruction test - using factory-created index")
 }
 
+func TestIndexIVFFlat_Reconstruct_DirectMap(t *testing.T) {
+	d := 8
+	quantizer, _ := NewIndexFlatL2(d)
+	defer quantizer.Close()
+
+	idx, err := NewIndexIVFFlat(quantizer, d, 4, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlat failed: %v", err)
+	}
+	defer idx.Close()
+
+	vectors := generateVectors(200, d)
+	if err := idx.Train(vectors); err != nil {
+		t.Fatalf("Train failed: %v", err)
+	}
+	if err := idx.Add(vectors); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	recons, err := idx.Reconstruct(7)
+	if err != nil {
+		t.Fatalf("Reconstruct failed: %v", err)
+	}
+	for j := 0; j < d; j++ {
+		if recons[j] != vectors[7*d+j] {
+			t.Errorf("recons[%d] = %v, want %v", j, recons[j], vectors[7*d+j])
+		}
+	}
+
+	// Vectors added after the direct map is enabled are reconstructible too
+	if err := idx.Add(vectors[:d]); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	recons, err = idx.ReconstructN(200, 1)
+	if err != nil {
+		t.Fatalf("ReconstructN failed: %v", err)
+	}
+	for j := 0; j < d; j++ {
+		if recons[j] != vectors[j] {
+			t.Errorf("recons[%d] = %v, want %v", j, recons[j], vectors[j])
+		}
+	}
+}
+
+// ========================================
+// IndexPreTransform Reconstruct Tests
+// ========================================
+
+func TestIndexPreTransform_Reconstruct(t *testing.T) {
+	dIn, dOut := 32, 8
+	nb := 300
+
+	// Vectors in an 8-dimensional subspace, which PCA to 8 keeps exactly
+	basis := generateVectors(dOut, dIn)
+	coeffs := generateVectors(nb, dOut)
+	vectors := make([]float32, nb*dIn)
+	for i := 0; i < nb; i++ {
+		for j := 0; j < dOut; j++ {
+			for k := 0; k < dIn; k++ {
+				vectors[i*dIn+k] += coeffs[i*dOut+j] * basis[j*dIn+k]
+			}
+		}
+	}
+
+	pca, _ := NewPCAMatrix(dIn, dOut)
+	defer pca.Close()
+	base, _ := NewIndexFlatL2(dOut)
+	defer base.Close()
+	index, err := NewIndexPreTransform(pca, base)
+	if err != nil {
+		t.Fatalf("NewIndexPreTransform() failed: %v", err)
+	}
+	defer index.Close()
+	index.Train(vectors)
+	index.Add(vectors)
+
+	if !index.CanReconstruct() {
+		t.Error("CanReconstruct() = false, want true")
+	}
+
+	all, err := index.ReconstructN(0, int64(nb))
+	if err != nil {
+		t.Fatalf("ReconstructN() failed: %v", err)
+	}
+	if len(all) != nb*dIn {
+		t.Fatalf("ReconstructN() returned %d floats, want %d (original space)", len(all), nb*dIn)
+	}
+	dists, _ := PairwiseL2(all, vectors, dIn)
+	for i, dist := range dists {
+		norm, _ := L2Distance(vectors[i*dIn:(i+1)*dIn], make([]float32, dIn))
+		if dist > 1e-3*(norm+1) {
+			t.Errorf("vector %d reconstructed %v away from the original, want ~0", i, dist)
+			break
+		}
+	}
+
+	vec, err := index.Reconstruct(42)
+	if err != nil {
+		t.Fatalf("Reconstruct() failed: %v", err)
+	}
+	for k := range vec {
+		if vec[k] != all[42*dIn+k] {
+			t.Fatalf("Reconstruct(42) differs from ReconstructN at component %d", k)
+		}
+	}
+
+	if _, err := index.Reconstruct(int64(nb)); err == nil {
+		t.Error("Expected error for out-of-range key")
+	}
+	if _, err := index.ReconstructN(int64(nb-1), 2); err == nil {
+		t.Error("Expected error for out-of-range range")
+	}
+}
+
+func TestIndexPreTransform_Reconstruct_Lossy(t *testing.T) {
+	dIn, dOut := 32, 8
+	nb := 300
+
+	// Centered data: FAISS maps back with the transpose of the PCA matrix,
+	// which restores the mean only along the kept components
+	vectors := generateVectors(nb, dIn)
+	mean := make([]float32, dIn)
+	for i := 0; i < nb; i++ {
+		for k := 0; k < dIn; k++ {
+			mean[k] += vectors[i*dIn+k] / float32(nb)
+		}
+	}
+	for i := range vectors {
+		vectors[i] -= mean[i%dIn]
+	}
+
+	pca, _ := NewPCAMatrix(dIn, dOut)
+	defer pca.Close()
+	base, _ := NewIndexFlatL2(dOut)
+	defer base.Close()
+	index, _ := NewIndexPreTransform(pca, base)
+	defer index.Close()
+	index.Train(vectors)
+	index.Add(vectors)
+
+	// Full-rank data loses the discarded components, but the reconstruction
+	// is the projection onto the 8 strongest of 32: it keeps more than a
+	// quarter of the variance
+	recons, err := index.ReconstructN(0, int64(nb))
+	if err != nil {
+		t.Fatalf("ReconstructN() failed: %v", err)
+	}
+	var reconErr, variance float64
+	for i := 0; i < nb; i++ {
+		v := vectors[i*dIn : (i+1)*dIn]
+		r, _ := L2Distance(v, recons[i*dIn:(i+1)*dIn])
+		m, _ := L2Distance(v, make([]float32, dIn))
+		reconErr += float64(r * r)
+		variance += float64(m * m)
+	}
+	if !(reconErr < 0.75*variance) {
+		t.Errorf("reconstruction error %v, want below 3/4 of the variance %v", reconErr, variance)
+	}
+}
+
+// ========================================
+// CanReconstruct Tests
+// ========================================
+
+func TestCanReconstruct(t *testing.T) {
+	flat, _ := NewIndexFlatL2(8)
+	defer flat.Close()
+
+	if !flat.CanReconstruct() {
+		t.Error("IndexFlat.CanReconstruct() = false, want true")
+	}
+
+	lsh, _ := NewIndexLSH(8, 16)
+	defer lsh.Close()
+
+	if lsh.CanReconstruct() {
+		t.Error("IndexLSH.CanReconstruct() = true, want false")
+	}
+
+	sq, _ := NewIndexScalarQuantizer(8, QT_8bit, MetricL2)
+	defer sq.Close()
+
+	if !sq.CanReconstruct() {
+		t.Error("IndexScalarQuantizer.CanReconstruct() = false, want true")
+	}
+}
+
+func TestGenericIndex_CanReconstruct(t *testing.T) {
+	d, n := 8, 39*16
+	vectors := generateVectors(n, d)
+
+	tests := []struct {
+		desc string
+		want bool
+	}{
+		{"Flat", true},
+		{"PQ4x4", true},
+		{"IVF2,Flat", true},
+		{"IDMap,Flat", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			idx := mustCreateGenericIndex(t, d, tt.desc)
+			defer idx.Close()
+
+			r, ok := idx.(interface{ CanReconstruct() bool })
+			if !ok {
+				t.Fatalf("%T does not implement CanReconstruct", idx)
+			}
+			if _, generic := idx.(*GenericIndex); generic && r.CanReconstruct() {
+				t.Error("CanReconstruct() on empty index = true, want false")
+			}
+			if !idx.IsTrained() {
+				if err := idx.Train(vectors); err != nil {
+					t.Fatalf("Train() error = %v", err)
+				}
+			}
+			if tt.desc == "IDMap,Flat" {
+				ids := make([]int64, n)
+				for i := range ids {
+					ids[i] = int64(i)
+				}
+				idmap := idx.(interface {
+					AddWithIDs(vectors []float32, ids []int64) error
+				})
+				if err := idmap.AddWithIDs(vectors, ids); err != nil {
+					t.Fatalf("AddWithIDs() error = %v", err)
+				}
+			} else if err := idx.Add(vectors); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			if got := r.CanReconstruct(); got != tt.want {
+				t.Errorf("CanReconstruct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // ========================================
 // Reconstruction Accuracy Tests
 // ========================================
@@ -387,6 +711,403 @@ func TestReconstruct_Accuracy(t *testing.T) {
 	}
 }
 
+// ========================================
+// ExactRerank Tests
+// ========================================
+
+func TestExactRerank_ImprovesPQOrdering(t *testing.T) {
+	d := 32
+	nb := 5000
+	nq := 50
+	k := 10
+	kCand := 100
+
+	vectors := generateVectors(nb, d)
+	queries := generateVectors(nq, d)
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	_, groundTruth, err := flat.Search(queries, k)
+	if err != nil {
+		t.Fatalf("Search(flat) failed: %v", err)
+	}
+
+	pq, err := IndexFactory(d, "PQ8x4", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory(PQ8x4) failed: %v", err)
+	}
+	defer pq.Close()
+	if err := pq.Train(vectors); err != nil {
+		t.Fatalf("Train(PQ) failed: %v", err)
+	}
+	pq.Add(vectors)
+	_, candidates, err := pq.Search(queries, kCand)
+	if err != nil {
+		t.Fatalf("Search(PQ) failed: %v", err)
+	}
+
+	pqTop := make([]int64, nq*k)
+	reranked := make([]int64, nq*k)
+	for q := 0; q < nq; q++ {
+		query := queries[q*d : (q+1)*d]
+		cand := candidates[q*kCand : (q+1)*kCand]
+		copy(pqTop[q*k:], cand[:k])
+
+		sorted, err := ExactRerank(flat, query, cand, MetricL2)
+		if err != nil {
+			t.Fatalf("ExactRerank() failed: %v", err)
+		}
+		for i, n := range sorted {
+			if i > 0 && n.Distance < sorted[i-1].Distance {
+				t.Fatalf("query %d: results not sorted at %d", q, i)
+			}
+			if i < k {
+				reranked[q*k+i] = n.ID
+			}
+		}
+	}
+
+	pqRecall := ComputeRecall(groundTruth, pqTop, nq, k, k)
+	rerankRecall := ComputeRecall(groundTruth, reranked, nq, k, k)
+	t.Logf("recall@%d: PQ = %.3f, PQ+rerank = %.3f", k, pqRecall, rerankRecall)
+	if rerankRecall <= pqRecall {
+		t.Errorf("re-ranked recall %.3f, want > PQ recall %.3f", rerankRecall, pqRecall)
+	}
+}
+
+func TestExactRerank_InnerProduct(t *testing.T) {
+	index, _ := NewIndexFlatIP(2)
+	defer index.Close()
+	index.Add([]float32{1, 0, 0, 1, 2, 2})
+
+	sorted, err := ExactRerank(index, []float32{1, 0.5}, []int64{0, -1, 1, 2}, MetricInnerProduct)
+	if err != nil {
+		t.Fatalf("ExactRerank() failed: %v", err)
+	}
+	wantIDs := []int64{2, 0, 1}
+	wantDist := []float32{3, 1, 0.5}
+	if len(sorted) != len(wantIDs) {
+		t.Fatalf("len(sorted) = %d, want %d", len(sorted), len(wantIDs))
+	}
+	for i, n := range sorted {
+		if n.ID != wantIDs[i] || !almostEqual(n.Distance, wantDist[i], 1e-6) {
+			t.Errorf("sorted[%d] = %+v, want {ID:%d Distance:%v}", i, n, wantIDs[i], wantDist[i])
+		}
+	}
+}
+
+func TestExactRerank_Invalid(t *testing.T) {
+	index, _ := NewIndexFlatL2(2)
+	defer index.Close()
+	index.Add([]float32{1, 2})
+
+	if _, err := ExactRerank(nil, []float32{1, 2}, []int64{0}, MetricL2); err == nil {
+		t.Error("Expected error for nil index")
+	}
+	if _, err := ExactRerank(index, []float32{1}, []int64{0}, MetricL2); err == nil {
+		t.Error("Expected error for wrong query length")
+	}
+	if _, err := ExactRerank(index, []float32{1, 2}, []int64{0}, MetricType(99)); err == nil {
+		t.Error("Expected error for unsupported metric")
+	}
+	if _, err := ExactRerank(index, []float32{1, 2}, []int64{5}, MetricL2); err == nil {
+		t.Error("Expected error for out-of-range candidate")
+	}
+}
+
+func TestSearchRerank_CosineOverL2(t *testing.T) {
+	index, _ := NewIndexFlatL2(2)
+	defer index.Close()
+	// Short vector near the query, long vectors pointing the same way
+	index.Add([]float32{0.5, 0.5, 10, 1, 2, 0.1})
+	query := []float32{1, 0}
+
+	_, labels, err := index.Search(query, 3)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	wantL2 := []int64{0, 2, 1}
+	for i := range labels {
+		if labels[i] != wantL2[i] {
+			t.Fatalf("L2 labels = %v, want %v", labels, wantL2)
+		}
+	}
+
+	top, err := SearchRerank(index, query, 3, MetricL2, MetricCosine)
+	if err != nil {
+		t.Fatalf("SearchRerank() failed: %v", err)
+	}
+	wantIDs := []int64{2, 1, 0}
+	if len(top) != len(wantIDs) {
+		t.Fatalf("len(top) = %d, want %d", len(top), len(wantIDs))
+	}
+	for i, n := range top {
+		if n.ID != wantIDs[i] {
+			t.Errorf("top[%d].ID = %d, want %d", i, n.ID, wantIDs[i])
+		}
+		vec, _ := index.Reconstruct(n.ID)
+		want, _ := CosineSimilarity(query, vec)
+		if !almostEqual(n.Distance, want, 1e-6) {
+			t.Errorf("top[%d].Distance = %v, want %v", i, n.Distance, want)
+		}
+	}
+
+	top, err = SearchRerank(index, query, 1, MetricL2, MetricCosine)
+	if err != nil {
+		t.Fatalf("SearchRerank() failed: %v", err)
+	}
+	if len(top) != 1 || top[0].ID != 2 {
+		t.Errorf("SearchRerank(k=1) = %+v, want ID 2", top)
+	}
+}
+
+func TestSearchRerank_Invalid(t *testing.T) {
+	index, _ := NewIndexFlatL2(2)
+	defer index.Close()
+	index.Add([]float32{1, 2})
+
+	if _, err := SearchRerank(nil, []float32{1, 2}, 1, MetricL2, MetricCosine); err == nil {
+		t.Error("Expected error for nil index")
+	}
+	if _, err := SearchRerank(index, []float32{1, 2}, 0, MetricL2, MetricCosine); !errors.Is(err, ErrInvalidK) {
+		t.Errorf("SearchRerank(k=0) error = %v, want ErrInvalidK", err)
+	}
+	if _, err := SearchRerank(index, []float32{1}, 1, MetricL2, MetricCosine); err == nil {
+		t.Error("Expected error for wrong query length")
+	}
+	if _, err := SearchRerank(index, []float32{1, 2}, 1, MetricInnerProduct, MetricCosine); !errors.Is(err, ErrMetricMismatch) {
+		t.Errorf("SearchRerank(search metric mismatch) error = %v, want ErrMetricMismatch", err)
+	}
+	if _, err := SearchRerank(index, []float32{1, 2}, 1, MetricCosine, MetricL2); !errors.Is(err, ErrMetricMismatch) {
+		t.Errorf("SearchRerank(cosine over L2 index) error = %v, want ErrMetricMismatch", err)
+	}
+
+	// Cosine search is an inner product search over normalized vectors
+	ip, _ := NewIndexFlatIP(2)
+	defer ip.Close()
+	ip.Add([]float32{1, 0, 0, 1, 0.6, 0.8})
+	top, err := SearchRerank(ip, []float32{0.6, 0.8}, 1, MetricCosine, MetricCosine)
+	if err != nil {
+		t.Fatalf("SearchRerank(cosine over inner product index) failed: %v", err)
+	}
+	if len(top) != 1 || top[0].ID != 2 {
+		t.Errorf("SearchRerank(cosine over inner product index) = %v, want ID 2 first", top)
+	}
+	if _, err := SearchRerank(index, []float32{1, 2}, 1, MetricL2, MetricType(99)); err == nil {
+		t.Error("Expected error for unsupported re-rank metric")
+	}
+}
+
+// ========================================
+// ReconstructionError Tests
+// ========================================
+
+func TestReconstructionError_PQvsSQ8(t *testing.T) {
+	d := 32
+	nb := 2000
+	vectors := generateVectors(nb, d)
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(vectors)
+	flatMSE, err := ReconstructionError(flat, vectors)
+	if err != nil {
+		t.Fatalf("ReconstructionError(flat) failed: %v", err)
+	}
+	if flatMSE != 0 {
+		t.Errorf("flat MSE = %v, want 0", flatMSE)
+	}
+
+	sq, _ := NewIndexScalarQuantizer(d, QT_8bit, MetricL2)
+	defer sq.Close()
+	if err := sq.Train(vectors); err != nil {
+		t.Fatalf("Train(SQ8) failed: %v", err)
+	}
+	sq.Add(vectors)
+	sqMSE, err := ReconstructionError(sq, vectors)
+	if err != nil {
+		t.Fatalf("ReconstructionError(SQ8) failed: %v", err)
+	}
+
+	pq, _ := IndexFactory(d, "PQ8x4", MetricL2)
+	defer pq.Close()
+	if err := pq.Train(vectors); err != nil {
+		t.Fatalf("Train(PQ) failed: %v", err)
+	}
+	pq.Add(vectors)
+	pqMSE, err := ReconstructionError(pq, vectors[:500*d])
+	if err != nil {
+		t.Fatalf("ReconstructionError(PQ) failed: %v", err)
+	}
+
+	t.Logf("MSE: SQ8 = %.6f, PQ8x4 = %.6f", sqMSE, pqMSE)
+	if sqMSE <= 0 {
+		t.Errorf("SQ8 MSE = %v, want > 0", sqMSE)
+	}
+	if pqMSE <= sqMSE {
+		t.Errorf("PQ MSE %v, want > SQ8 MSE %v", pqMSE, sqMSE)
+	}
+}
+
+func TestReconstructionError_Invalid(t *testing.T) {
+	index, _ := NewIndexFlatL2(4)
+	defer index.Close()
+	index.Add([]float32{1, 2, 3, 4})
+
+	if _, err := ReconstructionError(nil, []float32{1, 2, 3, 4}); err == nil {
+		t.Error("Expected error for nil index")
+	}
+	if _, err := ReconstructionError(index, nil); err == nil {
+		t.Error("Expected error for empty originals")
+	}
+	if _, err := ReconstructionError(index, []float32{1, 2, 3}); err == nil {
+		t.Error("Expected error for invalid originals length")
+	}
+	if _, err := ReconstructionError(index, make([]float32, 8)); err == nil {
+		t.Error("Expected error for more originals than stored vectors")
+	}
+}
+
+// ========================================
+// VerifyRecall Tests
+// ========================================
+
+func TestVerifyRecall_HNSW(t *testing.T) {
+	d := 32
+	nb := 2000
+	nq := 20
+	k := 10
+	vectors := generateVectors(nb, d)
+
+	index := mustCreateGenericIndex(t, d, "HNSW32")
+	defer index.Close()
+	index.Add(vectors)
+	if err := index.SetEfSearch(128); err != nil {
+		t.Fatalf("SetEfSearch() failed: %v", err)
+	}
+
+	recall, missing, err := VerifyRecall(index, generateVectors(nq, d), k)
+	if err != nil {
+		t.Fatalf("VerifyRecall() failed: %v", err)
+	}
+	t.Logf("recall = %.3f, missing = %v", recall, missing)
+	if recall < 0.9 {
+		t.Errorf("recall = %.3f, want >= 0.9", recall)
+	}
+	if want := int(float64(nq*k)*(1-recall) + 0.5); len(missing) != want {
+		t.Errorf("len(missing) = %d, want %d", len(missing), want)
+	}
+	for _, id := range missing {
+		if id < 0 || id >= int64(nb) {
+			t.Errorf("missing ID %d out of range [0, %d)", id, nb)
+		}
+	}
+
+	// An exact index never misses anything
+	flat := mustCreateIndexFlatL2(t, d)
+	defer flat.Close()
+	flat.Add(vectors)
+	recall, missing, err = VerifyRecall(flat, vectors[:d], k)
+	if err != nil || recall != 1 || len(missing) != 0 {
+		t.Errorf("VerifyRecall(flat) = %v, %v, %v, want 1, [], nil", recall, missing, err)
+	}
+}
+
+func TestVerifyRecall_Invalid(t *testing.T) {
+	index := mustCreateIndexFlatL2(t, 4)
+	defer index.Close()
+
+	if _, _, err := VerifyRecall(nil, []float32{1, 2, 3, 4}, 1); err == nil {
+		t.Error("Expected error for nil index")
+	}
+	if _, _, err := VerifyRecall(index, []float32{1, 2, 3, 4}, 0); err == nil {
+		t.Error("Expected error for k = 0")
+	}
+	if _, _, err := VerifyRecall(index, []float32{1, 2, 3}, 1); err == nil {
+		t.Error("Expected error for invalid query length")
+	}
+}
+
+// ========================================
+// SelfTest Tests
+// ========================================
+
+func TestSelfTest_Valid(t *testing.T) {
+	d := 16
+	vectors := generateVectors(1000, d)
+
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	ip, _ := NewIndexFlatIP(d)
+	defer ip.Close()
+	hnsw := mustCreateGenericIndex(t, d, "HNSW16")
+	defer hnsw.Close()
+	sq := mustCreateGenericIndex(t, d, "SQ8")
+	defer sq.Close()
+	ivf := mustCreateGenericIndex(t, d, "IVF8,Flat")
+	defer ivf.Close()
+	ivfpq := mustCreateGenericIndex(t, d, "IVF8,PQ4x4")
+	defer ivfpq.Close()
+
+	for _, index := range []Index{flat, ip, hnsw, sq, ivf, ivfpq} {
+		index.Train(vectors)
+		index.Add(vectors)
+		// Searches with the index's own settings would miss vectors
+		// stored outside the single probed list
+		index.SetNprobe(1)
+		index.SetEfSearch(1)
+		if err := SelfTest(index); err != nil {
+			t.Errorf("SelfTest(%T %v) = %v, want nil", index, index.MetricType(), err)
+		}
+	}
+	if nprobe, _ := ivf.(*GenericIndex).GetNprobe(); nprobe != 1 {
+		t.Errorf("nprobe = %d after SelfTest, want 1", nprobe)
+	}
+
+	empty, _ := NewIndexFlatL2(d)
+	defer empty.Close()
+	if err := SelfTest(empty); err != nil {
+		t.Errorf("SelfTest(empty) = %v, want nil", err)
+	}
+	if err := SelfTest(nil); err == nil {
+		t.Error("SelfTest(nil) should return error")
+	}
+}
+
+func TestSelfTest_Corrupt(t *testing.T) {
+	d := 16
+	flat, _ := NewIndexFlatL2(d)
+	defer flat.Close()
+	flat.Add(generateVectors(100, d))
+	data, err := SerializeIndex(flat)
+	if err != nil {
+		t.Fatalf("SerializeIndex() failed: %v", err)
+	}
+
+	// Truncated: either the load or the self-test must fail
+	if index, err := DeserializeIndex(data[:len(data)-d*4]); err == nil {
+		if err := SelfTest(index); err == nil {
+			t.Error("truncated index passed SelfTest")
+		}
+		index.Close()
+	}
+
+	// Corrupted payload that still loads: the last vectors become NaN
+	corrupt := append([]byte(nil), data...)
+	for i := len(corrupt) - 20*d*4; i < len(corrupt); i++ {
+		corrupt[i] = 0xff
+	}
+	index, err := DeserializeIndex(corrupt)
+	if err != nil {
+		t.Fatalf("DeserializeIndex() of corrupted payload failed: %v", err)
+	}
+	defer index.Close()
+	if err := SelfTest(index); !errors.Is(err, ErrCorruptIndex) {
+		t.Errorf("SelfTest(corrupted) = %v, want ErrCorruptIndex", err)
+	}
+}
+
 // ========================================
 // Benchmark Tests
 // ========================================
@@ -417,12 +1138,34 @@ func BenchmarkIndexFlat_ReconstructN(b *testing.B) {
 	}
 	idx.Add(vectors)
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		idx.ReconstructN(0, 100)
 	}
 }
 
+// BenchmarkIndexFlat_ReconstructNInto reuses one buffer; compare allocs/op
+// with BenchmarkIndexFlat_ReconstructN
+func BenchmarkIndexFlat_ReconstructNInto(b *testing.B) {
+	idx, _ := NewIndexFlatL2(128)
+	defer idx.Close()
+
+	vectors := make([]float32, 128*1000)
+	for i := range vectors {
+		vectors[i] = float32(i % 100)
+	}
+	idx.Add(vectors)
+
+	buf := make([]float32, 100*128)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.ReconstructNInto(0, 100, buf)
+	}
+}
+
 func BenchmarkIndexFlat_ReconstructBatch(b *testing.B) {
 	idx, _ := NewIndexFlatL2(128)
 	defer idx.Close()
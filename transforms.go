@@ -1,7 +1,11 @@
 package faiss
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"runtime"
 )
 
@@ -16,6 +20,19 @@ type VectorTransform interface {
 	Close() error
 }
 
+// isTransformClosed reports whether a known transform type has already been closed
+func isTransformClosed(t VectorTransform) bool {
+	switch tr := t.(type) {
+	case *PCAMatrix:
+		return tr.ptr == 0
+	case *OPQMatrix:
+		return tr.ptr == 0
+	case *RandomRotationMatrix:
+		return tr.ptr == 0
+	}
+	return false
+}
+
 // ========================================
 // PCAMatrix - Principal Component Analysis
 // ========================================
@@ -112,11 +129,14 @@ func (pca *PCAMatrix) IsTrained() bool {
 
 // Train trains the PCA on the given vectors
 func (pca *PCAMatrix) Train(vectors []float32) error {
+	if pca.ptr == 0 {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
 		return fmt.Errorf("empty training vectors")
 	}
 	if len(vectors)%pca.dIn != 0 {
-		return fmt.Errorf("vectors length must be multiple of input dimension %d", pca.dIn)
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", pca.dIn, ErrDimensionMismatch)
 	}
 
 	n := int64(len(vectors) / pca.dIn)
@@ -131,6 +151,9 @@ func (pca *PCAMatrix) Train(vectors []float32) error {
 
 // Apply applies the PCA transformation to vectors
 func (pca *PCAMatrix) Apply(vectors []float32) ([]float32, error) {
+	if pca.ptr == 0 {
+		return nil, ErrNullPointer
+	}
 	if !pca.isTrained {
 		return nil, fmt.Errorf("PCA must be trained before applying")
 	}
@@ -138,7 +161,7 @@ func (pca *PCAMatrix) Apply(vectors []float32) ([]float32, error) {
 		return []float32{}, nil
 	}
 	if len(vectors)%pca.dIn != 0 {
-		return nil, fmt.Errorf("vectors length must be multiple of input dimension %d", pca.dIn)
+		return nil, fmt.Errorf("vectors length must be multiple of input dimension %d: %w", pca.dIn, ErrDimensionMismatch)
 	}
 
 	n := len(vectors) / pca.dIn
@@ -149,8 +172,98 @@ func (pca *PCAMatrix) Apply(vectors []float32) ([]float32, error) {
 	return output, nil
 }
 
+// ApplyInto applies the PCA transformation writing into a caller-provided buffer
+//
+// out must hold exactly n*DOut() values for the n input vectors. Reusing the
+// same buffer across calls avoids allocating an output slice per batch.
+func (pca *PCAMatrix) ApplyInto(vectors, out []float32) error {
+	if pca.ptr == 0 {
+		return ErrNullPointer
+	}
+	if !pca.isTrained {
+		return fmt.Errorf("PCA must be trained before applying")
+	}
+	if len(vectors)%pca.dIn != 0 {
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", pca.dIn, ErrDimensionMismatch)
+	}
+
+	n := len(vectors) / pca.dIn
+	if len(out) != n*pca.dOut {
+		return fmt.Errorf("output length %d does not match %d vectors of dimension %d: %w", len(out), n, pca.dOut, ErrDimensionMismatch)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	faiss_VectorTransform_apply(pca.ptr, int64(n), &vectors[0], &out[0])
+
+	return nil
+}
+
+// ApplyStream applies the PCA transformation in chunks and writes the result
+// to w in .fvecs format (each vector is prefixed by its int32 dimension,
+// little-endian).
+//
+// Only one chunk of output is held in memory at a time, which keeps memory
+// bounded when reducing large datasets before indexing.
+//
+// Example:
+//   f, _ := os.Create("reduced.fvecs")
+//   defer f.Close()
+//   err := pca.ApplyStream(vectors, 10000, f)
+func (pca *PCAMatrix) ApplyStream(vectors []float32, chunkSize int, w io.Writer) error {
+	if !pca.isTrained {
+		return fmt.Errorf("PCA must be trained before applying")
+	}
+	if chunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive")
+	}
+	if len(vectors)%pca.dIn != 0 {
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", pca.dIn, ErrDimensionMismatch)
+	}
+
+	n := len(vectors) / pca.dIn
+	if chunkSize > n {
+		chunkSize = n
+	}
+
+	out := make([]float32, chunkSize*pca.dOut)
+	buf := make([]byte, chunkSize*(4+pca.dOut*4))
+
+	for start := 0; start < n; start += chunkSize {
+		end := start + chunkSize
+		if end > n {
+			end = n
+		}
+		m := end - start
+
+		if err := pca.ApplyInto(vectors[start*pca.dIn:end*pca.dIn], out[:m*pca.dOut]); err != nil {
+			return err
+		}
+
+		off := 0
+		for i := 0; i < m; i++ {
+			binary.LittleEndian.PutUint32(buf[off:], uint32(pca.dOut))
+			off += 4
+			for _, v := range out[i*pca.dOut : (i+1)*pca.dOut] {
+				binary.LittleEndian.PutUint32(buf[off:], math.Float32bits(v))
+				off += 4
+			}
+		}
+
+		if _, err := w.Write(buf[:off]); err != nil {
+			return fmt.Errorf("failed to write chunk at vector %d: %w", start, err)
+		}
+	}
+
+	return nil
+}
+
 // ReverseTransform attempts to reverse the PCA transformation (approximate reconstruction)
 func (pca *PCAMatrix) ReverseTransform(vectors []float32) ([]float32, error) {
+	if pca.ptr == 0 {
+		return nil, ErrNullPointer
+	}
 	if !pca.isTrained {
 		return nil, fmt.Errorf("PCA must be trained before reverse transform")
 	}
@@ -158,7 +271,7 @@ func (pca *PCAMatrix) ReverseTransform(vectors []float32) ([]float32, error) {
 		return []float32{}, nil
 	}
 	if len(vectors)%pca.dOut != 0 {
-		return nil, fmt.Errorf("vectors length must be multiple of output dimension %d", pca.dOut)
+		return nil, fmt.Errorf("vectors length must be multiple of output dimension %d: %w", pca.dOut, ErrDimensionMismatch)
 	}
 
 	n := len(vectors) / pca.dOut
@@ -178,6 +291,209 @@ func (pca *PCAMatrix) Close() error {
 	return nil
 }
 
+// ========================================
+// IncrementalPCA - streaming PCA training
+// ========================================
+
+// IncrementalPCA trains a PCA transform from a stream of batches
+//
+// FAISS PCA training needs all vectors at once. IncrementalPCA instead
+// accumulates the mean and covariance of the data batch by batch in Go
+// (memory is O(dIn^2), independent of the number of vectors). Finalize then
+// trains a FAISS PCAMatrix on a small synthetic set of 2*dIn vectors that has
+// exactly the accumulated mean and covariance, which yields the same
+// principal components as training on the full data.
+//
+// Example:
+//   ipca, _ := faiss.NewIncrementalPCA(256, 64)
+//   for batch := range batches {
+//       ipca.PartialFit(batch)
+//   }
+//   pca, _ := ipca.Finalize()
+//   defer pca.Close()
+//   reduced, _ := pca.Apply(vectors)
+type IncrementalPCA struct {
+	dIn      int       // input dimension
+	dOut     int       // output dimension
+	n        int64     // number of vectors seen
+	mean     []float64 // running mean (dIn)
+	comoment []float64 // running sum of centered outer products (dIn*dIn)
+}
+
+// NewIncrementalPCA creates a streaming PCA trainer
+func NewIncrementalPCA(dIn, dOut int) (*IncrementalPCA, error) {
+	if dIn <= 0 || dOut <= 0 || dOut > dIn {
+		return nil, fmt.Errorf("invalid dimensions: dIn=%d, dOut=%d (need 0 < dOut <= dIn)", dIn, dOut)
+	}
+
+	return &IncrementalPCA{
+		dIn:      dIn,
+		dOut:     dOut,
+		mean:     make([]float64, dIn),
+		comoment: make([]float64, dIn*dIn),
+	}, nil
+}
+
+// DIn returns the input dimension
+func (ip *IncrementalPCA) DIn() int {
+	return ip.dIn
+}
+
+// DOut returns the output dimension
+func (ip *IncrementalPCA) DOut() int {
+	return ip.dOut
+}
+
+// NumSamples returns the number of vectors accumulated so far
+func (ip *IncrementalPCA) NumSamples() int64 {
+	return ip.n
+}
+
+// PartialFit accumulates the statistics of a batch of vectors
+func (ip *IncrementalPCA) PartialFit(batch []float32) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if len(batch)%ip.dIn != 0 {
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", ip.dIn, ErrDimensionMismatch)
+	}
+
+	d := ip.dIn
+	nb := len(batch) / d
+
+	// Batch mean and centered comoment
+	bmean := make([]float64, d)
+	for i := 0; i < nb; i++ {
+		for j := 0; j < d; j++ {
+			bmean[j] += float64(batch[i*d+j])
+		}
+	}
+	for j := range bmean {
+		bmean[j] /= float64(nb)
+	}
+
+	bcomoment := make([]float64, d*d)
+	centered := make([]float64, d)
+	for i := 0; i < nb; i++ {
+		for j := 0; j < d; j++ {
+			centered[j] = float64(batch[i*d+j]) - bmean[j]
+		}
+		for r := 0; r < d; r++ {
+			row := bcomoment[r*d : (r+1)*d]
+			for c := r; c < d; c++ {
+				row[c] += centered[r] * centered[c]
+			}
+		}
+	}
+
+	// Merge with the running statistics (Chan et al. parallel update)
+	na := float64(ip.n)
+	nbf := float64(nb)
+	total := na + nbf
+	delta := make([]float64, d)
+	for j := range delta {
+		delta[j] = bmean[j] - ip.mean[j]
+	}
+
+	for r := 0; r < d; r++ {
+		for c := r; c < d; c++ {
+			v := ip.comoment[r*d+c] + bcomoment[r*d+c] + delta[r]*delta[c]*na*nbf/total
+			ip.comoment[r*d+c] = v
+			ip.comoment[c*d+r] = v
+		}
+	}
+	for j := range ip.mean {
+		ip.mean[j] += delta[j] * nbf / total
+	}
+
+	ip.n += int64(nb)
+	return nil
+}
+
+// Finalize computes the principal components from the accumulated
+// statistics and returns a trained PCAMatrix. The caller owns the returned
+// matrix and must Close it. Finalize can be called again after further
+// PartialFit calls to get an updated matrix.
+func (ip *IncrementalPCA) Finalize() (*PCAMatrix, error) {
+	if ip.n < 2 {
+		return nil, fmt.Errorf("need at least 2 vectors to compute PCA, have %d", ip.n)
+	}
+
+	d := ip.dIn
+
+	// Covariance matrix
+	cov := make([]float64, d*d)
+	for i, v := range ip.comoment {
+		cov[i] = v / float64(ip.n)
+	}
+
+	// Square root L with L*L^T = cov (Cholesky, tolerating a
+	// positive semi-definite covariance by zeroing degenerate columns)
+	l := choleskyPSD(cov, d)
+
+	// 2*d points mean +/- sqrt(d)*L[:,i] have exactly the target mean and
+	// covariance: (1/2d) * sum_i 2*d*L[:,i]*L[:,i]^T = L*L^T
+	scale := math.Sqrt(float64(d))
+	points := make([]float32, 2*d*d)
+	for i := 0; i < d; i++ {
+		plus := points[(2*i)*d : (2*i+1)*d]
+		minus := points[(2*i+1)*d : (2*i+2)*d]
+		for j := 0; j < d; j++ {
+			off := scale * l[j*d+i]
+			plus[j] = float32(ip.mean[j] + off)
+			minus[j] = float32(ip.mean[j] - off)
+		}
+	}
+
+	pca, err := NewPCAMatrix(ip.dIn, ip.dOut)
+	if err != nil {
+		return nil, err
+	}
+	if err := pca.Train(points); err != nil {
+		pca.Close()
+		return nil, err
+	}
+
+	return pca, nil
+}
+
+// choleskyPSD returns the lower-triangular factor of a symmetric positive
+// semi-definite d x d matrix (row-major). Columns with a non-positive pivot
+// are left at zero.
+func choleskyPSD(a []float64, d int) []float64 {
+	l := make([]float64, d*d)
+
+	maxDiag := 0.0
+	for i := 0; i < d; i++ {
+		if a[i*d+i] > maxDiag {
+			maxDiag = a[i*d+i]
+		}
+	}
+	eps := maxDiag * 1e-12
+
+	for j := 0; j < d; j++ {
+		sum := a[j*d+j]
+		for k := 0; k < j; k++ {
+			sum -= l[j*d+k] * l[j*d+k]
+		}
+		if sum <= eps {
+			continue
+		}
+		ljj := math.Sqrt(sum)
+		l[j*d+j] = ljj
+
+		for i := j + 1; i < d; i++ {
+			s := a[i*d+j]
+			for k := 0; k < j; k++ {
+				s -= l[i*d+k] * l[j*d+k]
+			}
+			l[i*d+j] = s / ljj
+		}
+	}
+
+	return l
+}
+
 // ========================================
 // OPQMatrix - Optimized Product Quantization
 // ========================================
@@ -247,11 +563,14 @@ func (opq *OPQMatrix) GetM() int {
 
 // Train trains the OPQ on the given vectors
 func (opq *OPQMatrix) Train(vectors []float32) error {
+	if opq.ptr == 0 {
+		return ErrNullPointer
+	}
 	if len(vectors) == 0 {
 		return fmt.Errorf("empty training vectors")
 	}
 	if len(vectors)%opq.d != 0 {
-		return fmt.Errorf("vectors length must be multiple of dimension %d", opq.d)
+		return fmt.Errorf("vectors length must be multiple of dimension %d: %w", opq.d, ErrDimensionMismatch)
 	}
 
 	n := int64(len(vectors) / opq.d)
@@ -266,6 +585,9 @@ func (opq *OPQMatrix) Train(vectors []float32) error {
 
 // Apply applies the OPQ rotation to vectors
 func (opq *OPQMatrix) Apply(vectors []float32) ([]float32, error) {
+	if opq.ptr == 0 {
+		return nil, ErrNullPointer
+	}
 	if !opq.isTrained {
 		return nil, fmt.Errorf("OPQ must be trained before applying")
 	}
@@ -273,7 +595,7 @@ func (opq *OPQMatrix) Apply(vectors []float32) ([]float32, error) {
 		return []float32{}, nil
 	}
 	if len(vectors)%opq.d != 0 {
-		return nil, fmt.Errorf("vectors length must be multiple of dimension %d", opq.d)
+		return nil, fmt.Errorf("vectors length must be multiple of dimension %d: %w", opq.d, ErrDimensionMismatch)
 	}
 
 	n := len(vectors) / opq.d
@@ -286,6 +608,9 @@ func (opq *OPQMatrix) Apply(vectors []float32) ([]float32, error) {
 
 // ReverseTransform reverses the OPQ rotation
 func (opq *OPQMatrix) ReverseTransform(vectors []float32) ([]float32, error) {
+	if opq.ptr == 0 {
+		return nil, ErrNullPointer
+	}
 	if !opq.isTrained {
 		return nil, fmt.Errorf("OPQ must be trained before reverse transform")
 	}
@@ -293,7 +618,7 @@ func (opq *OPQMatrix) ReverseTransform(vectors []float32) ([]float32, error) {
 		return []float32{}, nil
 	}
 	if len(vectors)%opq.d != 0 {
-		return nil, fmt.Errorf("vectors length must be multiple of dimension %d", opq.d)
+		return nil, fmt.Errorf("vectors length must be multiple of dimension %d: %w", opq.d, ErrDimensionMismatch)
 	}
 
 	n := len(vectors) / opq.d
@@ -376,6 +701,9 @@ func (rr *RandomRotationMatrix) IsTrained() bool {
 // Unlike PCA, RandomRotation doesn't learn from data, but the C library
 // requires train() to be called to initialize the rotation matrix.
 func (rr *RandomRotationMatrix) Train(vectors []float32) error {
+	if rr.ptr == 0 {
+		return ErrNullPointer
+	}
 	if rr.isTrained {
 		return nil // Already trained
 	}
@@ -387,7 +715,7 @@ func (rr *RandomRotationMatrix) Train(vectors []float32) error {
 	}
 
 	if len(vectors)%rr.dIn != 0 {
-		return fmt.Errorf("vectors length must be multiple of input dimension %d", rr.dIn)
+		return fmt.Errorf("vectors length must be multiple of input dimension %d: %w", rr.dIn, ErrDimensionMismatch)
 	}
 
 	n := int64(len(vectors) / rr.dIn)
@@ -402,6 +730,9 @@ func (rr *RandomRotationMatrix) Train(vectors []float32) error {
 
 // Apply applies the random rotation to vectors
 func (rr *RandomRotationMatrix) Apply(vectors []float32) ([]float32, error) {
+	if rr.ptr == 0 {
+		return nil, ErrNullPointer
+	}
 	if !rr.isTrained {
 		return nil, fmt.Errorf("random rotation must be trained before applying")
 	}
@@ -409,7 +740,7 @@ func (rr *RandomRotationMatrix) Apply(vectors []float32) ([]float32, error) {
 		return []float32{}, nil
 	}
 	if len(vectors)%rr.dIn != 0 {
-		return nil, fmt.Errorf("vectors length must be multiple of input dimension %d", rr.dIn)
+		return nil, fmt.Errorf("vectors length must be multiple of input dimension %d: %w", rr.dIn, ErrDimensionMismatch)
 	}
 
 	n := len(vectors) / rr.dIn
@@ -422,6 +753,9 @@ func (rr *RandomRotationMatrix) Apply(vectors []float32) ([]float32, error) {
 
 // ReverseTransform reverses the random rotation (if dIn == dOut)
 func (rr *RandomRotationMatrix) ReverseTransform(vectors []float32) ([]float32, error) {
+	if rr.ptr == 0 {
+		return nil, ErrNullPointer
+	}
 	if rr.dIn != rr.dOut {
 		return nil, fmt.Errorf("reverse transform only available when dIn == dOut")
 	}
@@ -429,7 +763,7 @@ func (rr *RandomRotationMatrix) ReverseTransform(vectors []float32) ([]float32,
 		return []float32{}, nil
 	}
 	if len(vectors)%rr.dOut != 0 {
-		return nil, fmt.Errorf("vectors length must be multiple of output dimension %d", rr.dOut)
+		return nil, fmt.Errorf("vectors length must be multiple of output dimension %d: %w", rr.dOut, ErrDimensionMismatch)
 	}
 
 	n := len(vectors) / rr.dOut
@@ -448,3 +782,169 @@ func (rr *RandomRotationMatrix) Close() error {
 	}
 	return nil
 }
+
+// ========================================
+// Transform Evaluation
+// ========================================
+
+// EstimateReconstructionError measures how much information a transform
+// loses on the given vectors
+//
+// The transform is trained on vectors if it isn't trained yet. Each vector is
+// then applied and reverse-transformed, and the result is the mean over all
+// vectors of the per-component squared error between the original and the
+// reconstruction. Comparing the error for several output dimensions helps
+// choose dOut for PCA or OPQ before building full indexes.
+//
+// Example:
+//   pca, _ := faiss.NewPCAMatrix(256, 64)
+//   defer pca.Close()
+//   mse, _ := faiss.EstimateReconstructionError(pca, sample)
+func EstimateReconstructionError(transform VectorTransform, vectors []float32) (meanMSE float64, err error) {
+	if transform == nil {
+		return 0, fmt.Errorf("transform cannot be nil")
+	}
+	dIn := transform.DIn()
+	if len(vectors) == 0 {
+		return 0, fmt.Errorf("empty vectors")
+	}
+	if len(vectors)%dIn != 0 {
+		return 0, fmt.Errorf("vectors length must be multiple of input dimension %d: %w", dIn, ErrDimensionMismatch)
+	}
+
+	if !transform.IsTrained() {
+		if err := transform.Train(vectors); err != nil {
+			return 0, err
+		}
+	}
+
+	transformed, err := transform.Apply(vectors)
+	if err != nil {
+		return 0, err
+	}
+	reconstructed, err := transform.ReverseTransform(transformed)
+	if err != nil {
+		return 0, err
+	}
+	if len(reconstructed) != len(vectors) {
+		return 0, fmt.Errorf("reverse transform returned %d values, want %d", len(reconstructed), len(vectors))
+	}
+
+	// Averaging over all components equals averaging the per-vector MSEs,
+	// since every vector has the same dimension
+	var sum float64
+	for i, v := range vectors {
+		diff := float64(v - reconstructed[i])
+		sum += diff * diff
+	}
+	return sum / float64(len(vectors)), nil
+}
+
+// ========================================
+// Transform Persistence
+// ========================================
+
+// WriteVectorTransform saves a transform to a file
+//
+// This lets a trained PCA, OPQ or random rotation be reused across processes
+// without retraining. The file uses the FAISS format and can be read back with
+// ReadVectorTransform or faiss.read_VectorTransform in Python.
+//
+// Python equivalent: faiss.write_VectorTransform(transform, filename)
+//
+// Example:
+//   pca, _ := faiss.NewPCAMatrix(256, 64)
+//   pca.Train(trainingVectors)
+//   faiss.WriteVectorTransform(pca, "pca.bin")
+func WriteVectorTransform(t VectorTransform, path string) error {
+	if t == nil {
+		return fmt.Errorf("faiss: transform cannot be nil")
+	}
+
+	var ptr uintptr
+	switch tr := t.(type) {
+	case *PCAMatrix:
+		ptr = tr.ptr
+	case *OPQMatrix:
+		ptr = tr.ptr
+	case *RandomRotationMatrix:
+		ptr = tr.ptr
+	default:
+		return fmt.Errorf("faiss: unsupported transform type for serialization: %T", t)
+	}
+	if ptr == 0 {
+		return ErrNullPointer
+	}
+
+	if err := faissWriteVectorTransform(ptr, path); err != nil {
+		return fmt.Errorf("faiss: failed to write transform to %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadVectorTransform loads a transform saved by WriteVectorTransform
+//
+// The concrete type is chosen from the file header: PCA files load as
+// *PCAMatrix and random rotations as *RandomRotationMatrix. FAISS stores OPQ
+// as a plain linear transform, so it loads as *OPQMatrix with GetM() == 0;
+// the rotation itself is fully restored.
+//
+// Python equivalent: faiss.read_VectorTransform(filename)
+//
+// Example:
+//   pca, err := faiss.ReadVectorTransform("pca.bin")
+//   if err != nil {
+//       log.Fatal(err)
+//   }
+//   defer pca.Close()
+//   reduced, _ := pca.Apply(vectors)
+func ReadVectorTransform(path string) (VectorTransform, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to open transform file: %w", err)
+	}
+	var header [4]byte
+	_, err = io.ReadFull(f, header[:])
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to read transform header from %s: %w", path, err)
+	}
+
+	// Reject unsupported kinds before FAISS allocates anything
+	kind := string(header[:])
+	switch kind {
+	case "Pcam", "rrot", "LTra":
+	default:
+		return nil, fmt.Errorf("faiss: unsupported transform type %q in %s", kind, path)
+	}
+
+	ptr, dIn, dOut, isTrained, err := faissReadVectorTransform(path)
+	if err != nil {
+		return nil, fmt.Errorf("faiss: failed to read transform from %s: %w", path, err)
+	}
+
+	switch kind {
+	case "Pcam":
+		pca := &PCAMatrix{ptr: ptr, dIn: dIn, dOut: dOut, isTrained: isTrained}
+		runtime.SetFinalizer(pca, func(p *PCAMatrix) {
+			p.Close()
+		})
+		return pca, nil
+	case "rrot":
+		rr := &RandomRotationMatrix{ptr: ptr, dIn: dIn, dOut: dOut, isTrained: isTrained}
+		runtime.SetFinalizer(rr, func(r *RandomRotationMatrix) {
+			r.Close()
+		})
+		return rr, nil
+	default:
+		if dIn != dOut {
+			faiss_VectorTransform_free(ptr)
+			return nil, fmt.Errorf("faiss: linear transform %d->%d is not an OPQ rotation", dIn, dOut)
+		}
+		opq := &OPQMatrix{ptr: ptr, d: dIn, isTrained: isTrained}
+		runtime.SetFinalizer(opq, func(o *OPQMatrix) {
+			o.Close()
+		})
+		return opq, nil
+	}
+}
@@ -1,6 +1,12 @@
 package faiss
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -221,6 +227,196 @@ func TestPCAMatrix_Apply_InvalidDimension(t *testing.T) {
 	}
 }
 
+func TestPCAMatrix_ApplyInto(t *testing.T) {
+	pca, _ := NewPCAMatrix(16, 4)
+	defer pca.Close()
+
+	if err := pca.Train(generateVectors(200, 16)); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+
+	input := generateVectors(10, 16)
+	want, err := pca.Apply(input)
+	if err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	out := make([]float32, 10*4)
+	if err := pca.ApplyInto(input, out); err != nil {
+		t.Fatalf("ApplyInto() failed: %v", err)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Fatalf("ApplyInto()[%d] = %v, want %v", i, out[i], want[i])
+		}
+	}
+
+	if err := pca.ApplyInto(input, make([]float32, 5)); err == nil {
+		t.Error("ApplyInto() with wrong output size should error")
+	}
+}
+
+func TestPCAMatrix_ApplyStream(t *testing.T) {
+	pca, _ := NewPCAMatrix(16, 4)
+	defer pca.Close()
+
+	if err := pca.Train(generateVectors(200, 16)); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+
+	// 103 vectors in chunks of 10 leaves a partial last chunk
+	n := 103
+	input := generateVectors(n, 16)
+	want, err := pca.Apply(input)
+	if err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pca.ApplyStream(input, 10, &buf); err != nil {
+		t.Fatalf("ApplyStream() failed: %v", err)
+	}
+
+	if buf.Len() != n*(4+4*4) {
+		t.Fatalf("ApplyStream() wrote %d bytes, want %d", buf.Len(), n*(4+4*4))
+	}
+
+	for i := 0; i < n; i++ {
+		var dim int32
+		if err := binary.Read(&buf, binary.LittleEndian, &dim); err != nil {
+			t.Fatalf("failed to read dimension of vector %d: %v", i, err)
+		}
+		if dim != 4 {
+			t.Fatalf("vector %d dimension = %d, want 4", i, dim)
+		}
+		vec := make([]float32, 4)
+		if err := binary.Read(&buf, binary.LittleEndian, vec); err != nil {
+			t.Fatalf("failed to read vector %d: %v", i, err)
+		}
+		for j := range vec {
+			if vec[j] != want[i*4+j] {
+				t.Fatalf("vector %d[%d] = %v, want %v", i, j, vec[j], want[i*4+j])
+			}
+		}
+	}
+}
+
+func TestPCAMatrix_ApplyStream_InvalidChunkSize(t *testing.T) {
+	pca, _ := NewPCAMatrix(8, 4)
+	defer pca.Close()
+
+	pca.Train(generateVectors(100, 8))
+
+	var buf bytes.Buffer
+	if err := pca.ApplyStream(generateVectors(5, 8), 0, &buf); err == nil {
+		t.Error("ApplyStream() with chunk size 0 should error")
+	}
+}
+
+// ========================================
+// IncrementalPCA Tests
+// ========================================
+
+func TestIncrementalPCA_MatchesBatch(t *testing.T) {
+	dIn, dOut := 16, 4
+	n := 2000
+
+	// Data with a clear spectrum: each axis has a distinct variance and the
+	// axes are mixed so the components are correlated
+	rng := rand.New(rand.NewSource(42))
+	mix := make([]float32, dIn*dIn)
+	for i := range mix {
+		mix[i] = float32(rng.NormFloat64())
+	}
+	vectors := make([]float32, n*dIn)
+	latent := make([]float32, dIn)
+	for i := 0; i < n; i++ {
+		for j := range latent {
+			latent[j] = float32(rng.NormFloat64()) * float32(dIn-j)
+		}
+		for r := 0; r < dIn; r++ {
+			v := float32(3) // non-zero mean
+			for c := 0; c < dIn; c++ {
+				v += mix[r*dIn+c] * latent[c]
+			}
+			vectors[i*dIn+r] = v
+		}
+	}
+
+	batch, _ := NewPCAMatrix(dIn, dOut)
+	defer batch.Close()
+	if err := batch.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+
+	ipca, err := NewIncrementalPCA(dIn, dOut)
+	if err != nil {
+		t.Fatalf("NewIncrementalPCA() failed: %v", err)
+	}
+	for start := 0; start < n; start += 150 {
+		end := start + 150
+		if end > n {
+			end = n
+		}
+		if err := ipca.PartialFit(vectors[start*dIn : end*dIn]); err != nil {
+			t.Fatalf("PartialFit() failed: %v", err)
+		}
+	}
+	if ipca.NumSamples() != int64(n) {
+		t.Errorf("NumSamples() = %d, want %d", ipca.NumSamples(), n)
+	}
+
+	incremental, err := ipca.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize() failed: %v", err)
+	}
+	defer incremental.Close()
+
+	// Eigenvector signs are arbitrary, so compare projections back into the
+	// input space, which only depend on the spanned subspace
+	queries := vectors[:20*dIn]
+	project := func(pca *PCAMatrix) []float32 {
+		reduced, err := pca.Apply(queries)
+		if err != nil {
+			t.Fatalf("Apply() failed: %v", err)
+		}
+		back, err := pca.ReverseTransform(reduced)
+		if err != nil {
+			t.Fatalf("ReverseTransform() failed: %v", err)
+		}
+		return back
+	}
+	want := project(batch)
+	got := project(incremental)
+
+	for i := range want {
+		if !almostEqual(got[i], want[i], 1e-2*(1+abs32(want[i]))) {
+			t.Fatalf("projection[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestIncrementalPCA_InvalidInput(t *testing.T) {
+	if _, err := NewIncrementalPCA(8, 16); err == nil {
+		t.Error("NewIncrementalPCA(8, 16) should return error")
+	}
+
+	ipca, _ := NewIncrementalPCA(8, 4)
+	if err := ipca.PartialFit([]float32{1, 2, 3}); err == nil {
+		t.Error("PartialFit() with invalid length should return error")
+	}
+	if _, err := ipca.Finalize(); err == nil {
+		t.Error("Finalize() without data should return error")
+	}
+}
+
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
 // ========================================
 // PCAMatrix ReverseTransform Tests
 // ========================================
@@ -746,3 +942,197 @@ func TestPCA_DimensionalityReduction(t *testing.T) {
 		t.Errorf("Reduced vector length = %d, want %d", len(reduced), 4*10)
 	}
 }
+
+// ========================================
+// Transform Evaluation Tests
+// ========================================
+
+func TestEstimateReconstructionError(t *testing.T) {
+	dIn := 32
+	latent := 16
+	n := 2000
+	rng := rand.New(rand.NewSource(7))
+
+	// Vectors lie close to a 16-dimensional subspace of the 32-d space
+	basis := make([]float32, latent*dIn)
+	for i := range basis {
+		basis[i] = float32(rng.NormFloat64())
+	}
+	vectors := make([]float32, n*dIn)
+	for i := 0; i < n; i++ {
+		for l := 0; l < latent; l++ {
+			c := float32(rng.NormFloat64())
+			for j := 0; j < dIn; j++ {
+				vectors[i*dIn+j] += c * basis[l*dIn+j]
+			}
+		}
+		for j := 0; j < dIn; j++ {
+			vectors[i*dIn+j] += 0.01 * float32(rng.NormFloat64())
+		}
+	}
+
+	half, _ := NewPCAMatrix(dIn, dIn/2)
+	defer half.Close()
+	halfMSE, err := EstimateReconstructionError(half, vectors)
+	if err != nil {
+		t.Fatalf("EstimateReconstructionError(PCA-%d) failed: %v", dIn/2, err)
+	}
+	if !half.IsTrained() {
+		t.Error("EstimateReconstructionError() should train the transform")
+	}
+
+	few, _ := NewPCAMatrix(dIn, 2)
+	defer few.Close()
+	fewMSE, err := EstimateReconstructionError(few, vectors)
+	if err != nil {
+		t.Fatalf("EstimateReconstructionError(PCA-2) failed: %v", err)
+	}
+
+	t.Logf("MSE: PCA-%d = %.6f, PCA-2 = %.6f", dIn/2, halfMSE, fewMSE)
+	if halfMSE > 0.01 {
+		t.Errorf("PCA-%d MSE = %v, want < 0.01", dIn/2, halfMSE)
+	}
+	if fewMSE < 1 {
+		t.Errorf("PCA-2 MSE = %v, want > 1", fewMSE)
+	}
+}
+
+func TestEstimateReconstructionError_Invalid(t *testing.T) {
+	pca, _ := NewPCAMatrix(8, 4)
+	defer pca.Close()
+
+	if _, err := EstimateReconstructionError(nil, make([]float32, 8)); err == nil {
+		t.Error("Expected error for nil transform")
+	}
+	if _, err := EstimateReconstructionError(pca, nil); err == nil {
+		t.Error("Expected error for empty vectors")
+	}
+	if _, err := EstimateReconstructionError(pca, make([]float32, 7)); err == nil {
+		t.Error("Expected error for invalid vector length")
+	}
+}
+
+// ========================================
+// Transform Persistence Tests
+// ========================================
+
+func TestWriteReadVectorTransform_PCA(t *testing.T) {
+	dIn, dOut := 32, 8
+	vectors := generateVectors(1000, dIn)
+
+	pca, _ := NewPCAMatrix(dIn, dOut)
+	defer pca.Close()
+	if err := pca.Train(vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	want, err := pca.Apply(vectors[:10*dIn])
+	if err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "pca.bin")
+	if err := WriteVectorTransform(pca, path); err != nil {
+		t.Fatalf("WriteVectorTransform() failed: %v", err)
+	}
+
+	loaded, err := ReadVectorTransform(path)
+	if err != nil {
+		t.Fatalf("ReadVectorTransform() failed: %v", err)
+	}
+	defer loaded.Close()
+
+	if _, ok := loaded.(*PCAMatrix); !ok {
+		t.Fatalf("ReadVectorTransform() type = %T, want *PCAMatrix", loaded)
+	}
+	if loaded.DIn() != dIn || loaded.DOut() != dOut {
+		t.Errorf("dimensions = %d->%d, want %d->%d", loaded.DIn(), loaded.DOut(), dIn, dOut)
+	}
+	if !loaded.IsTrained() {
+		t.Error("loaded transform should be trained")
+	}
+
+	got, err := loaded.Apply(vectors[:10*dIn])
+	if err != nil {
+		t.Fatalf("Apply(loaded) failed: %v", err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Apply(loaded)[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteReadVectorTransform_Rotations(t *testing.T) {
+	d := 16
+	vectors := generateVectors(1000, d)
+	dir := t.TempDir()
+
+	rr, _ := NewRandomRotationMatrix(d, d)
+	defer rr.Close()
+	opq, _ := NewOPQMatrix(d, 4)
+	defer opq.Close()
+
+	tests := []struct {
+		name      string
+		transform VectorTransform
+	}{
+		{"RandomRotation", rr},
+		{"OPQ", opq},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.transform.Train(vectors); err != nil {
+				t.Fatalf("Train() failed: %v", err)
+			}
+			want, _ := tt.transform.Apply(vectors[:d])
+
+			path := filepath.Join(dir, tt.name+".bin")
+			if err := WriteVectorTransform(tt.transform, path); err != nil {
+				t.Fatalf("WriteVectorTransform() failed: %v", err)
+			}
+			loaded, err := ReadVectorTransform(path)
+			if err != nil {
+				t.Fatalf("ReadVectorTransform() failed: %v", err)
+			}
+			defer loaded.Close()
+
+			if got, want := fmt.Sprintf("%T", loaded), fmt.Sprintf("%T", tt.transform); got != want {
+				t.Errorf("ReadVectorTransform() type = %s, want %s", got, want)
+			}
+			got, err := loaded.Apply(vectors[:d])
+			if err != nil {
+				t.Fatalf("Apply(loaded) failed: %v", err)
+			}
+			for i := range want {
+				if !almostEqual(got[i], want[i], 1e-5) {
+					t.Fatalf("Apply(loaded)[%d] = %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteReadVectorTransform_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := WriteVectorTransform(nil, filepath.Join(dir, "nil.bin")); err == nil {
+		t.Error("Expected error for nil transform")
+	}
+
+	pca, _ := NewPCAMatrix(8, 4)
+	pca.Close()
+	if err := WriteVectorTransform(pca, filepath.Join(dir, "closed.bin")); err == nil {
+		t.Error("Expected error for closed transform")
+	}
+
+	if _, err := ReadVectorTransform(filepath.Join(dir, "missing.bin")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+
+	garbage := filepath.Join(dir, "garbage.bin")
+	os.WriteFile(garbage, []byte("not a transform"), 0o644)
+	if _, err := ReadVectorTransform(garbage); err == nil {
+		t.Error("Expected error for unknown transform header")
+	}
+}
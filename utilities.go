@@ -1,6 +1,8 @@
 package faiss
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -176,6 +178,33 @@ func Fvec2Bvec(fvec []float32) []uint8 {
 	return bvec
 }
 
+// BinarizeVectors converts a batch of float vectors to packed binary codes,
+// setting bit j of a code when component j is greater than threshold
+//
+// Each vector becomes d/8 bytes, with component j stored in byte j/8 at bit
+// j%8 (least significant bit first), which is the layout Fvec2Bvec produces
+// and IndexBinaryFlat expects. d must be a positive multiple of 8.
+//
+// Example:
+//   codes, _ := faiss.BinarizeVectors(embeddings, 256, 0.0)  // 32 bytes per vector
+func BinarizeVectors(vectors []float32, d int, threshold float32) ([]uint8, error) {
+	if d <= 0 || d%8 != 0 {
+		return nil, fmt.Errorf("faiss: dimension must be a positive multiple of 8, got %d", d)
+	}
+	if len(vectors)%d != 0 {
+		return nil, ErrInvalidVectors
+	}
+
+	codes := make([]uint8, len(vectors)/8)
+	for i, val := range vectors {
+		if val > threshold {
+			codes[i/8] |= 1 << uint(i%8)
+		}
+	}
+
+	return codes, nil
+}
+
 // BitstringHammingDistance computes Hamming distance between two binary strings
 //
 // Python equivalent: faiss.hamming
@@ -278,6 +307,582 @@ func CosineSimilarity(a, b []float32) (float32, error) {
 	return dotProduct / float32(math.Sqrt(float64(normA)*float64(normB))), nil
 }
 
+// CosineSimilarityFloat64 is CosineSimilarity with the dot product and norms
+// accumulated in float64
+//
+// Over many high-magnitude dimensions float32 sums lose enough precision for
+// near-ties to flip; use this variant for exact comparison tooling where
+// precision matters more than speed.
+func CosineSimilarityFloat64(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors must have same length")
+	}
+
+	dotProduct := 0.0
+	normA := 0.0
+	normB := 0.0
+
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dotProduct += x * y
+		normA += x * x
+		normB += y * y
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0, fmt.Errorf("cannot compute cosine similarity with zero vector")
+	}
+
+	return dotProduct / math.Sqrt(normA*normB), nil
+}
+
+// L2ToCosine converts a squared L2 distance between unit-norm vectors into
+// cosine similarity, clamped to [-1, 1]
+//
+// For unit vectors ||a-b||² = 2 - 2·cos(a,b), so cos = 1 - l2dist/2. The
+// result is meaningless if the vectors were not normalized (see NormalizeL2).
+//
+// Example:
+//   distances, labels, _ := l2Index.Search(normalizedQueries, 10)
+//   sim := faiss.L2ToCosine(distances[0])
+func L2ToCosine(l2dist float32) float32 {
+	return clampCosine(1 - l2dist/2)
+}
+
+// clampCosine clamps a similarity to [-1, 1] to absorb floating point error
+func clampCosine(sim float32) float32 {
+	if sim > 1 {
+		return 1
+	}
+	if sim < -1 {
+		return -1
+	}
+	return sim
+}
+
+// SearchCosine searches for the k most cosine-similar vectors
+//
+// The queries are L2-normalized (on a copy) before searching, and the
+// returned scores are cosine similarities clamped to [-1, 1], highest first.
+// The indexed vectors must have been normalized before they were added.
+//
+// For MetricInnerProduct indexes the scores are the inner products; for
+// MetricL2 indexes the squared distances are converted with L2ToCosine.
+//
+// Example:
+//   faiss.NormalizeL2(vectors, d)
+//   index.Add(vectors)
+//   similarities, labels, _ := faiss.SearchCosine(index, queries, 10)
+func SearchCosine(index Index, queries []float32, k int) (similarities []float32, labels []int64, err error) {
+	metric := index.MetricType()
+	if metric != MetricInnerProduct && metric != MetricL2 {
+		return nil, nil, fmt.Errorf("faiss: cosine search requires an InnerProduct or L2 index, got %s", metric)
+	}
+
+	normalized, err := NormalizeL2Copy(queries, index.D())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	similarities, labels, err = index.Search(normalized, k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, dist := range similarities {
+		if metric == MetricL2 {
+			similarities[i] = L2ToCosine(dist)
+		} else {
+			similarities[i] = clampCosine(dist)
+		}
+	}
+
+	return similarities, labels, nil
+}
+
+// SquaredToL2 converts squared L2 distances, as returned by MetricL2 indexes,
+// into Euclidean distances
+//
+// FAISS reports ||a-b||² to avoid a square root per result, which is easy to
+// compare by mistake against a plain distance threshold. Small negative
+// values from floating point error become 0, and the math.MaxFloat32 padding
+// FAISS uses for missing results (label -1) is kept as is.
+//
+// Example:
+//   distances, labels, _ := index.Search(queries, 10)
+//   euclidean := faiss.SquaredToL2(distances)
+func SquaredToL2(distances []float32) []float32 {
+	out := make([]float32, len(distances))
+	for i, dist := range distances {
+		switch {
+		case dist <= 0:
+			out[i] = 0
+		case dist >= math.MaxFloat32:
+			out[i] = dist
+		default:
+			out[i] = float32(math.Sqrt(float64(dist)))
+		}
+	}
+	return out
+}
+
+// SearchL2 searches a MetricL2 index and returns Euclidean (non-squared)
+// distances, nearest first
+//
+// Example:
+//   distances, labels, _ := faiss.SearchL2(index, queries, 10)
+//   if distances[0] < maxDistance { ... }
+func SearchL2(index Index, queries []float32, k int) (distances []float32, labels []int64, err error) {
+	if metric := index.MetricType(); metric != MetricL2 {
+		return nil, nil, fmt.Errorf("faiss: L2 search requires an L2 index, got %s", metric)
+	}
+
+	squared, labels, err := index.Search(queries, k)
+	if err != nil {
+		return nil, nil, err
+	}
+	return SquaredToL2(squared), labels, nil
+}
+
+// ========================================
+// Int32 ID Interop
+// ========================================
+
+// SearchInt32 searches the index and returns the labels as int32
+//
+// FAISS labels are int64. Instead of letting a cast silently wrap IDs above
+// math.MaxInt32 into negative values, SearchInt32 returns an error wrapping
+// ErrIDOverflow if any returned label does not fit. Missing results keep the
+// value -1.
+//
+// Example:
+//   distances, labels, err := faiss.SearchInt32(index, queries, 10)
+//   if errors.Is(err, faiss.ErrIDOverflow) {
+//       // the index holds IDs the int32 system can't represent
+//   }
+func SearchInt32(index Index, queries []float32, k int) (distances []float32, labels []int32, err error) {
+	if index == nil {
+		return nil, nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+
+	distances, labels64, err := index.Search(queries, k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labels = make([]int32, len(labels64))
+	for i, id := range labels64 {
+		if id > math.MaxInt32 || id < math.MinInt32 {
+			return nil, nil, fmt.Errorf("%w: label %d at position %d", ErrIDOverflow, id, i)
+		}
+		labels[i] = int32(id)
+	}
+	return distances, labels, nil
+}
+
+// AddWithIDsInt32 adds vectors with int32 IDs to an index that accepts
+// custom IDs (IndexIDMap, IVF indexes, or factory indexes with "IDMap,")
+//
+// Negative IDs are rejected since FAISS uses -1 for missing results. Paired
+// with SearchInt32, IDs round-trip without conversion code at the call site.
+//
+// Example:
+//   faiss.AddWithIDsInt32(index, vectors, []int32{10, 20, 30})
+func AddWithIDsInt32(index Index, vectors []float32, ids []int32) error {
+	if index == nil {
+		return fmt.Errorf("faiss: index cannot be nil")
+	}
+	withIDs, ok := index.(interface {
+		AddWithIDs(vectors []float32, ids []int64) error
+	})
+	if !ok {
+		return fmt.Errorf("faiss: index type %T does not support custom IDs", index)
+	}
+
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		if id < 0 {
+			return fmt.Errorf("faiss: ID %d at position %d must be non-negative", id, i)
+		}
+		ids64[i] = int64(id)
+	}
+	return withIDs.AddWithIDs(vectors, ids64)
+}
+
+// ========================================
+// Column-Major Input
+// ========================================
+
+// AddColumnMajor adds n vectors of dimension d stored column-major (structure
+// of arrays): component j of vector i is vectors[j*n+i]
+//
+// FAISS only reads row-major input and has no stride parameter, so the
+// vectors are transposed in batches of DefaultAddBatchSize rows. Memory use
+// is bounded by one batch instead of a full row-major copy, and the result
+// is the same as adding the row-major equivalent.
+//
+// Example:
+//   // columns[j] holds component j of every vector, concatenated
+//   faiss.AddColumnMajor(index, columns, n, d)
+func AddColumnMajor(index Index, vectors []float32, n, d int) error {
+	if err := checkColumnMajor(index, vectors, n, d); err != nil {
+		return err
+	}
+
+	batch := make([]float32, min(n, DefaultAddBatchSize)*d)
+	for i0 := 0; i0 < n; i0 += DefaultAddBatchSize {
+		rows := batch[:min(DefaultAddBatchSize, n-i0)*d]
+		transposeColumnMajor(rows, vectors, n, d, i0)
+		if err := index.Add(rows); err != nil {
+			return fmt.Errorf("faiss: failed to add vectors %d-%d: %w", i0, i0+len(rows)/d, err)
+		}
+	}
+	return nil
+}
+
+// SearchColumnMajor searches nq queries of dimension d stored column-major:
+// component j of query i is queries[j*nq+i]
+//
+// Results are laid out as for Search (row-major, k per query). See
+// AddColumnMajor.
+func SearchColumnMajor(index Index, queries []float32, nq, d, k int) (distances []float32, labels []int64, err error) {
+	if err := checkColumnMajor(index, queries, nq, d); err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]float32, nq*d)
+	transposeColumnMajor(rows, queries, nq, d, 0)
+	return index.Search(rows, k)
+}
+
+// checkColumnMajor validates column-major input of n vectors for index
+func checkColumnMajor(index Index, vectors []float32, n, d int) error {
+	if index == nil {
+		return fmt.Errorf("faiss: index cannot be nil")
+	}
+	if d <= 0 {
+		return ErrInvalidDimension
+	}
+	if d != index.D() {
+		return fmt.Errorf("faiss: vector dimension %d does not match index dimension %d: %w", d, index.D(), ErrDimensionMismatch)
+	}
+	if n < 0 || len(vectors) != n*d {
+		return fmt.Errorf("faiss: %d floats is not %d column-major vectors of dimension %d: %w", len(vectors), n, d, ErrInvalidVectors)
+	}
+	return nil
+}
+
+// transposeBlock is the tile size of transposeColumnMajor, small enough for
+// a tile of both layouts to stay in cache
+const transposeBlock = 64
+
+// transposeColumnMajor writes rows i0, i0+1, ... of the n-vector column-major
+// matrix src into the row-major dst, one row per d floats of dst
+func transposeColumnMajor(dst, src []float32, n, d, i0 int) {
+	rows := len(dst) / d
+	for ib := 0; ib < rows; ib += transposeBlock {
+		iEnd := min(ib+transposeBlock, rows)
+		for jb := 0; jb < d; jb += transposeBlock {
+			jEnd := min(jb+transposeBlock, d)
+			for i := ib; i < iEnd; i++ {
+				for j := jb; j < jEnd; j++ {
+					dst[i*d+j] = src[j*n+i0+i]
+				}
+			}
+		}
+	}
+}
+
+// ========================================
+// Training Sample
+// ========================================
+
+// sampleTrainingVectors draws a uniform random subset of the n = len(vectors)/d
+// vectors, keeping round(fraction*n) of them but never fewer than minN (or n,
+// whichever is smaller). The selected vectors keep their original order.
+func sampleTrainingVectors(vectors []float32, d int, fraction float64, minN int) ([]float32, error) {
+	if math.IsNaN(fraction) || fraction <= 0 || fraction > 1 {
+		return nil, fmt.Errorf("faiss: sample fraction must be in (0, 1], got %v", fraction)
+	}
+	if len(vectors) == 0 {
+		return nil, errors.New("faiss: cannot train on empty vectors")
+	}
+	if len(vectors)%d != 0 {
+		return nil, ErrInvalidVectors
+	}
+
+	n := len(vectors) / d
+	m := int(math.Round(fraction * float64(n)))
+	m = min(max(m, minN, 1), n)
+	if m == n {
+		return vectors, nil
+	}
+
+	// Selection sampling (Knuth's Algorithm S): one pass, no index permutation
+	sample := make([]float32, 0, m*d)
+	for i := 0; i < n && len(sample) < m*d; i++ {
+		remaining := m - len(sample)/d
+		if rand.Intn(n-i) < remaining {
+			sample = append(sample, vectors[i*d:(i+1)*d]...)
+		}
+	}
+	return sample, nil
+}
+
+// ========================================
+// Held-Out Evaluation
+// ========================================
+
+// TrainOnly trains index without adding any vectors
+//
+// Train never adds vectors by itself, but training and adding the same data
+// is so common that it is easy to search a held-out query set against an
+// index that already contains it, which inflates recall. TrainOnly makes the
+// intent explicit and adds guard rails: it refuses an index that already
+// holds vectors, since retraining would not reassign them, and checks that
+// nothing was added. Add the database afterwards, leaving out evaluation
+// queries with AddExcluding.
+//
+// Example:
+//   faiss.TrainOnly(index, trainingVectors)
+//   faiss.AddExcluding(index, database, heldOutRows)
+func TrainOnly(index Index, vectors []float32) error {
+	if index == nil {
+		return fmt.Errorf("faiss: index cannot be nil")
+	}
+	before := index.Ntotal()
+	if before > 0 {
+		return fmt.Errorf("faiss: cannot train an index that already holds %d vectors", before)
+	}
+	if err := index.Train(vectors); err != nil {
+		return err
+	}
+	if after := index.Ntotal(); after != before {
+		return fmt.Errorf("faiss: training added %d vectors to the index", after-before)
+	}
+	return nil
+}
+
+// AddExcluding adds every vector except the rows listed in excludeIDs
+//
+// excludeIDs are 0-based row positions in vectors, typically the queries
+// held out for evaluation, so they never show up in search results.
+// Duplicates are allowed; a position outside the batch is an error.
+//
+// labels has one entry per row of vectors: the label search results report
+// for that row, or -1 for an excluded row. Indexes that store custom IDs
+// (IndexIDMap, IVF and "IDMap," factory indexes) keep the ID a plain Add
+// would have given each vector, Ntotal() plus its row, and AddExcluding
+// fails without adding anything if one of those IDs is already in the
+// index. Other indexes number the kept vectors consecutively.
+//
+// Example:
+//   // Rows 0-99 are the evaluation queries
+//   labels, _ := faiss.AddExcluding(index, database, heldOut)
+//   _, results, _ := index.Search(database[:100*d], 10)
+//   // Ground truth computed on the full database holds row numbers;
+//   // labels[row] is the label the same vector has in index
+func AddExcluding(index Index, vectors []float32, excludeIDs []int64) (labels []int64, err error) {
+	if index == nil {
+		return nil, fmt.Errorf("faiss: index cannot be nil")
+	}
+	d := index.D()
+	if len(vectors)%d != 0 {
+		return nil, ErrInvalidVectors
+	}
+
+	n := len(vectors) / d
+	excluded := make(map[int64]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		if id < 0 || id >= int64(n) {
+			return nil, fmt.Errorf("faiss: excluded row %d is outside the %d vectors", id, n)
+		}
+		excluded[id] = true
+	}
+
+	start := index.Ntotal()
+	withIDs, ok := index.(interface {
+		AddWithIDs(vectors []float32, ids []int64) error
+	})
+	ptr, hasPtr := indexPointer(index)
+	keepRows := ok && hasPtr && storesIDs(ptr)
+
+	labels = make([]int64, n)
+	kept := make([]float32, 0, (n-len(excluded))*d)
+	ids := make([]int64, 0, n-len(excluded))
+	for i := 0; i < n; i++ {
+		if excluded[int64(i)] {
+			labels[i] = -1
+			continue
+		}
+		labels[i] = start + int64(len(ids))
+		if keepRows {
+			labels[i] = start + int64(i)
+		}
+		kept = append(kept, vectors[i*d:(i+1)*d]...)
+		ids = append(ids, labels[i])
+	}
+	if len(ids) == 0 {
+		return labels, nil
+	}
+
+	if !keepRows {
+		if err := index.Add(kept); err != nil {
+			return nil, err
+		}
+		return labels, nil
+	}
+	if err := checkIDsUnused(ptr, start, ids); err != nil {
+		return nil, err
+	}
+	if err := withIDs.AddWithIDs(kept, ids); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// checkIDsUnused returns an error if one of ids is already stored in the
+// ID-storing index behind ptr, which holds ntotal vectors
+func checkIDsUnused(ptr uintptr, ntotal int64, ids []int64) error {
+	if ntotal == 0 {
+		return nil
+	}
+	var stored []int64
+	if faissIndexIsIDMap(ptr) {
+		stored = faissIndexIDMapIDs(ptr)
+	} else {
+		var err error
+		if stored, err = faissIndexIVFStoredIDs(ptr, ntotal); err != nil {
+			return fmt.Errorf("faiss: failed to read stored IDs: %w", err)
+		}
+	}
+	adding := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		adding[id] = true
+	}
+	for _, id := range stored {
+		if adding[id] {
+			return fmt.Errorf("faiss: ID %d is already in the index", id)
+		}
+	}
+	return nil
+}
+
+// ========================================
+// Deduplication
+// ========================================
+
+// dedupBatchSize is the number of queries per range search in Deduplicate,
+// bounding the memory held by one batch of results
+const dedupBatchSize = 1024
+
+// Deduplicate removes exact or near-duplicate vectors before indexing
+//
+// Vectors are visited in order and the first of each group of duplicates is
+// kept, so unique holds the kept vectors in their original order and
+// keptIndices their positions in vectors. With radius 0 only bit-for-bit
+// equal vectors (treating -0 and +0 as equal) are duplicates. With radius > 0,
+// a vector is dropped if its squared L2 distance to an already kept vector is
+// below radius, as in RangeSearch; the search runs on an internal flat index,
+// so it is exact but costs O(n²·d).
+//
+// Example:
+//
+//	unique, kept, err := faiss.Deduplicate(vectors, 128, 1e-4)
+//	index.AddWithIDs(unique, kept) // IDs point back into the original data
+func Deduplicate(vectors []float32, d int, radius float32) (unique []float32, keptIndices []int64, err error) {
+	if d <= 0 {
+		return nil, nil, ErrInvalidDimension
+	}
+	if len(vectors)%d != 0 {
+		return nil, nil, fmt.Errorf("vectors length must be multiple of dimension %d: %w", d, ErrDimensionMismatch)
+	}
+	if radius < 0 || math.IsNaN(float64(radius)) {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidRadius, radius)
+	}
+	if err := validateInput(vectors, d); err != nil {
+		return nil, nil, err
+	}
+
+	n := len(vectors) / d
+	var keep []bool
+	if radius == 0 {
+		keep = exactDuplicates(vectors, d)
+	} else if keep, err = nearDuplicates(vectors, d, radius); err != nil {
+		return nil, nil, err
+	}
+
+	unique = make([]float32, 0, len(vectors))
+	keptIndices = make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		if keep[i] {
+			unique = append(unique, vectors[i*d:(i+1)*d]...)
+			keptIndices = append(keptIndices, int64(i))
+		}
+	}
+	return unique, keptIndices, nil
+}
+
+// exactDuplicates marks the first occurrence of each distinct vector
+func exactDuplicates(vectors []float32, d int) []bool {
+	n := len(vectors) / d
+	keep := make([]bool, n)
+	seen := make(map[string]struct{}, n)
+	key := make([]byte, 4*d)
+	for i := 0; i < n; i++ {
+		for j, v := range vectors[i*d : (i+1)*d] {
+			bits := math.Float32bits(v)
+			if v == 0 {
+				bits = 0 // -0 == +0
+			}
+			binary.LittleEndian.PutUint32(key[4*j:], bits)
+		}
+		if _, ok := seen[string(key)]; !ok {
+			seen[string(key)] = struct{}{}
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// nearDuplicates marks the vectors that are not within radius of an earlier
+// kept vector
+func nearDuplicates(vectors []float32, d int, radius float32) ([]bool, error) {
+	index, err := NewIndexFlatL2(d)
+	if err != nil {
+		return nil, err
+	}
+	defer index.Close()
+	if err := index.Add(vectors); err != nil {
+		return nil, err
+	}
+
+	n := len(vectors) / d
+	keep := make([]bool, n)
+	dropped := make([]bool, n)
+	for start := 0; start < n; start += dedupBatchSize {
+		end := min(start+dedupBatchSize, n)
+		result, err := index.RangeSearch(vectors[start*d:end*d], radius)
+		if err != nil {
+			return nil, err
+		}
+		for q := 0; q < end-start; q++ {
+			i := start + q
+			if dropped[i] {
+				continue
+			}
+			keep[i] = true
+			labels, _ := result.GetResults(q)
+			for _, j := range labels {
+				if int(j) > i {
+					dropped[j] = true
+				}
+			}
+		}
+	}
+	return keep, nil
+}
+
 // ========================================
 // Batch Operations
 // ========================================
@@ -285,10 +890,11 @@ func CosineSimilarity(a, b []float32) (float32, error) {
 // BatchL2Distance computes L2 distances for batches of vectors
 // queries and database should be flat arrays (n*d and m*d)
 //
-// Returns n×m matrix of distances
+// Returns n×m matrix of distances: every query against every database
+// vector. Use PairwiseL2 for the distances between aligned pairs.
 func BatchL2Distance(queries, database []float32, d int) ([]float32, error) {
 	if len(queries)%d != 0 || len(database)%d != 0 {
-		return nil, fmt.Errorf("vector lengths must be multiple of dimension")
+		return nil, fmt.Errorf("vector lengths must be multiple of dimension: %w", ErrDimensionMismatch)
 	}
 
 	nq := len(queries) / d
@@ -309,13 +915,77 @@ func BatchL2Distance(queries, database []float32, d int) ([]float32, error) {
 	return distances, nil
 }
 
+// BatchL2DistanceFloat64 is BatchL2Distance with each distance accumulated
+// and returned in float64
+//
+// It is slower than BatchL2Distance but keeps near-tie rankings stable for
+// very high-dimensional or large-magnitude vectors, where float32 sums round
+// away the differences between candidates.
+func BatchL2DistanceFloat64(queries, database []float32, d int) ([]float64, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if len(queries)%d != 0 || len(database)%d != 0 {
+		return nil, fmt.Errorf("vector lengths must be multiple of dimension: %w", ErrDimensionMismatch)
+	}
+
+	nq := len(queries) / d
+	nb := len(database) / d
+	distances := make([]float64, nq*nb)
+
+	for i := 0; i < nq; i++ {
+		for j := 0; j < nb; j++ {
+			sum := 0.0
+			for k := 0; k < d; k++ {
+				diff := float64(queries[i*d+k]) - float64(database[j*d+k])
+				sum += diff * diff
+			}
+			distances[i*nb+j] = math.Sqrt(sum)
+		}
+	}
+
+	return distances, nil
+}
+
+// PairwiseL2 computes the L2 (Euclidean) distance between a[i] and b[i] for
+// each pair of aligned vectors
+//
+// a and b must hold the same number n of d-dimensional vectors; the result
+// has n distances. Unlike BatchL2Distance it does not compare every vector of
+// a with every vector of b.
+//
+// Example:
+//   dists, _ := faiss.PairwiseL2(originals, reconstructed, d)
+func PairwiseL2(a, b []float32, d int) ([]float32, error) {
+	if d <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if len(a)%d != 0 || len(b)%d != 0 {
+		return nil, fmt.Errorf("vector lengths must be multiple of dimension: %w", ErrDimensionMismatch)
+	}
+	if len(a) != len(b) {
+		return nil, fmt.Errorf("faiss: %d vectors in a but %d in b: %w", len(a)/d, len(b)/d, ErrDimensionMismatch)
+	}
+
+	distances := make([]float32, len(a)/d)
+	for i := range distances {
+		sum := float32(0)
+		for j := i * d; j < (i+1)*d; j++ {
+			diff := a[j] - b[j]
+			sum += diff * diff
+		}
+		distances[i] = float32(math.Sqrt(float64(sum)))
+	}
+	return distances, nil
+}
+
 // BatchInnerProduct computes inner products for batches of vectors
 // queries and database should be flat arrays (n*d and m*d)
 //
 // Returns n×m matrix of inner products
 func BatchInnerProduct(queries, database []float32, d int) ([]float32, error) {
 	if len(queries)%d != 0 || len(database)%d != 0 {
-		return nil, fmt.Errorf("vector lengths must be multiple of dimension")
+		return nil, fmt.Errorf("vector lengths must be multiple of dimension: %w", ErrDimensionMismatch)
 	}
 
 	nq := len(queries) / d
@@ -341,12 +1011,89 @@ func BatchInnerProduct(queries, database []float32, d int) ([]float32, error) {
 
 // GetIndexDescription returns a human-readable description of an index
 //
+// The name is the FAISS class of the underlying object when it can be read
+// (e.g. "IndexIVFPQ" for an index loaded from disk), and the Go type
+// otherwise.
+//
 // Example:
 //   desc := faiss.GetIndexDescription(index)
-//   // "IndexFlatL2(d=128, ntotal=10000)"
+//   // "IndexFlatL2(d=128, ntotal=10000, metric=L2)"
 func GetIndexDescription(index Index) string {
-	return fmt.Sprintf("%T(d=%d, ntotal=%d, metric=%s)",
-		index, index.D(), index.Ntotal(), index.MetricType())
+	name := fmt.Sprintf("%T", index)
+	if ptr, ok := indexPointer(index); ok && ptr != 0 {
+		if className, err := indexClassName(ptr); err == nil {
+			name = className
+		}
+	}
+	return fmt.Sprintf("%s(d=%d, ntotal=%d, metric=%s)",
+		name, index.D(), index.Ntotal(), index.MetricType())
+}
+
+// IndexParams reports the configuration of a live index as structured data
+//
+// Common keys: "type", "d", "ntotal", "is_trained", "metric".
+// Depending on the index, it also reports:
+//   - IVF: "nlist", "nprobe" (read from the FAISS object, so SetNprobe is reflected)
+//   - HNSW: "efSearch", "efConstruction" and "M" (read from the FAISS object)
+//   - PQ and IVFPQ: "M" and "nbits" of the product quantizer
+//   - LSH: "nbits"
+//   - Factory-built indexes: "description"
+//   - Wrappers (IDMap, Refine, PreTransform): "base", the params of the
+//     wrapped index
+//
+// Example:
+//   params := faiss.IndexParams(index)
+//   log.Printf("index config: %v", params)  // map[d:128 metric:L2 nlist:1024 nprobe:16 ...]
+func IndexParams(index Index) map[string]interface{} {
+	params := map[string]interface{}{
+		"type":       fmt.Sprintf("%T", index),
+		"d":          index.D(),
+		"ntotal":     index.Ntotal(),
+		"is_trained": index.IsTrained(),
+		"metric":     index.MetricType().String(),
+	}
+
+	switch idx := index.(type) {
+	case *IndexLSH:
+		params["nbits"] = idx.nbits
+	case *IndexIDMap:
+		params["base"] = IndexParams(idx.baseIndex)
+	case *IndexRefine:
+		params["base"] = IndexParams(idx.baseIndex)
+		params["k_factor"] = idx.kFactor
+	case *IndexPreTransform:
+		params["base"] = IndexParams(idx.index)
+	case *IndexShards:
+		params["shards"] = len(idx.shards)
+	case *GenericIndex:
+		params["description"] = idx.description
+	}
+
+	ptr, ok := indexPointer(index)
+	if !ok || ptr == 0 {
+		return params
+	}
+	if nlist, err := faissIndexIVFGetNlist(ptr); err == nil {
+		params["nlist"] = nlist
+		if nprobe, err := faissIndexIVFGetNprobe(ptr); err == nil {
+			params["nprobe"] = nprobe
+		}
+	}
+	if M, nbits, err := faissIndexPQParams(ptr); err == nil {
+		params["M"] = M
+		params["nbits"] = nbits
+	}
+	if efSearch, err := faissIndexHNSWGetEfSearch(ptr); err == nil {
+		params["efSearch"] = efSearch
+		if efConstruction, err := faissIndexHNSWGetEfConstruction(ptr); err == nil {
+			params["efConstruction"] = efConstruction
+		}
+		if M, err := faissIndexHNSWGetM(ptr); err == nil {
+			params["M"] = M
+		}
+	}
+
+	return params
 }
 
 // IsIndexTrained checks if an index is trained
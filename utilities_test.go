@@ -1,7 +1,10 @@
 package faiss
 
 import (
+	"errors"
 	"math"
+	"math/big"
+	"math/rand"
 	"testing"
 )
 
@@ -182,6 +185,71 @@ func TestFvec2Bvec(t *testing.T) {
 	}
 }
 
+func TestBinarizeVectors(t *testing.T) {
+	d := 16
+	vectors := []float32{
+		// vector 0: only the first 8 components exceed 0.5
+		0.9, 0.6, 0.7, 0.8, 1.0, 0.51, 0.55, 0.99,
+		0.1, 0.2, 0.3, 0.4, 0.5, 0.0, -1, 0.45,
+		// vector 1: alternating components exceed 0.5
+		0.9, 0.1, 0.9, 0.1, 0.9, 0.1, 0.9, 0.1,
+		0.9, 0.1, 0.9, 0.1, 0.9, 0.1, 0.9, 0.1,
+	}
+
+	codes, err := BinarizeVectors(vectors, d, 0.5)
+	if err != nil {
+		t.Fatalf("BinarizeVectors() failed: %v", err)
+	}
+	want := []uint8{0xFF, 0x00, 0x55, 0x55}
+	if len(codes) != len(want) {
+		t.Fatalf("len(codes) = %d, want %d", len(codes), len(want))
+	}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("codes[%d] = %08b, want %08b", i, codes[i], want[i])
+		}
+	}
+
+	// With threshold 0 the result matches Fvec2Bvec
+	zero, _ := BinarizeVectors(vectors[:d], d, 0)
+	if got := Fvec2Bvec(vectors[:d]); BitstringHammingDistance(zero, got) != 0 {
+		t.Errorf("BinarizeVectors(threshold=0) = %v, want %v", zero, got)
+	}
+
+	// The codes are searchable in a binary flat index: each vector is its
+	// own nearest neighbor at Hamming distance 0
+	index, err := NewIndexBinaryFlat(d)
+	if err != nil {
+		t.Fatalf("NewIndexBinaryFlat() failed: %v", err)
+	}
+	defer index.Close()
+	if err := index.Add(codes); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	distances, labels, err := index.Search(codes, 2)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	for q := 0; q < 2; q++ {
+		if labels[q*2] != int64(q) || distances[q*2] != 0 {
+			t.Errorf("query %d: nearest = (%d, %d), want (%d, 0)", q, labels[q*2], distances[q*2], q)
+		}
+	}
+	if wantDist := int32(BitstringHammingDistance(codes[:2], codes[2:])); distances[1] != wantDist {
+		t.Errorf("distance between vectors = %d, want %d", distances[1], wantDist)
+	}
+}
+
+func TestBinarizeVectors_Invalid(t *testing.T) {
+	if _, err := BinarizeVectors(make([]float32, 12), 12, 0); err == nil {
+		t.Error("Expected error for dimension not a multiple of 8")
+	}
+	if _, err := BinarizeVectors(make([]float32, 12), 8, 0); err == nil {
+		t.Error("Expected error for invalid vector length")
+	}
+}
+
 func TestBitstringHammingDistance(t *testing.T) {
 	a := []uint8{0b10101010}
 	b := []uint8{0b11001100}
@@ -295,6 +363,282 @@ func TestCosineSimilarity(t *testing.T) {
 	}
 }
 
+func TestL2ToCosine(t *testing.T) {
+	// Unit vectors at 0, 90 and 180 degrees from (1, 0)
+	origin := []float32{1, 0}
+	tests := []struct {
+		name  string
+		other []float32
+		want  float32
+	}{
+		{"0 degrees", []float32{1, 0}, 1},
+		{"90 degrees", []float32{0, 1}, 0},
+		{"180 degrees", []float32{-1, 0}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l2, _ := L2Distance(origin, tt.other)
+			got := L2ToCosine(l2 * l2)
+			if !almostEqual(got, tt.want, 1e-6) {
+				t.Errorf("L2ToCosine(%v) = %v, want %v", l2*l2, got, tt.want)
+			}
+		})
+	}
+
+	// Rounding error outside [0, 4] is clamped
+	if got := L2ToCosine(-1e-6); got != 1 {
+		t.Errorf("L2ToCosine(-1e-6) = %v, want 1", got)
+	}
+	if got := L2ToCosine(4.00001); got != -1 {
+		t.Errorf("L2ToCosine(4.00001) = %v, want -1", got)
+	}
+}
+
+func TestSearchCosine(t *testing.T) {
+	d := 2
+	vectors := []float32{
+		1, 0,
+		0, 1,
+		-1, 0,
+	}
+	// Unnormalized query along (1, 0)
+	query := []float32{3, 0}
+	want := []float32{1, 0, -1}
+
+	for _, metric := range []MetricType{MetricInnerProduct, MetricL2} {
+		t.Run(metric.String(), func(t *testing.T) {
+			index, err := NewIndexFlat(d, metric)
+			if err != nil {
+				t.Fatalf("NewIndexFlat() failed: %v", err)
+			}
+			defer index.Close()
+			index.Add(vectors)
+
+			similarities, labels, err := SearchCosine(index, query, 3)
+			if err != nil {
+				t.Fatalf("SearchCosine() failed: %v", err)
+			}
+			for i := range want {
+				if labels[i] != int64(i) {
+					t.Errorf("labels[%d] = %d, want %d", i, labels[i], i)
+				}
+				if !almostEqual(similarities[i], want[i], 1e-5) {
+					t.Errorf("similarities[%d] = %v, want %v", i, similarities[i], want[i])
+				}
+				if similarities[i] > 1 || similarities[i] < -1 {
+					t.Errorf("similarities[%d] = %v, want within [-1, 1]", i, similarities[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSquaredToL2(t *testing.T) {
+	got := SquaredToL2([]float32{0, 4, 2, -1e-7, math.MaxFloat32})
+	want := []float32{0, 2, float32(math.Sqrt2), 0, math.MaxFloat32}
+	for i := range want {
+		if !almostEqual(got[i], want[i], 1e-6) {
+			t.Errorf("SquaredToL2()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSearchL2(t *testing.T) {
+	d := 16
+	vectors := generateVectors(200, d)
+	queries := generateVectors(5, d)
+	k := 4
+
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+	index.Add(vectors)
+
+	squared, wantLabels, _ := index.Search(queries, k)
+	distances, labels, err := SearchL2(index, queries, k)
+	if err != nil {
+		t.Fatalf("SearchL2() failed: %v", err)
+	}
+	for i := range squared {
+		if labels[i] != wantLabels[i] {
+			t.Errorf("labels[%d] = %d, want %d", i, labels[i], wantLabels[i])
+		}
+		want := float32(math.Sqrt(float64(squared[i])))
+		if !almostEqual(distances[i], want, 1e-5) {
+			t.Errorf("distances[%d] = %v, want sqrt(%v) = %v", i, distances[i], squared[i], want)
+		}
+	}
+
+	// The distance to the exact neighbor equals the Euclidean norm of the difference
+	var sum float64
+	for j := 0; j < d; j++ {
+		diff := float64(queries[j] - vectors[int(labels[0])*d+j])
+		sum += diff * diff
+	}
+	if !almostEqual(distances[0], float32(math.Sqrt(sum)), 1e-4) {
+		t.Errorf("distances[0] = %v, want %v", distances[0], math.Sqrt(sum))
+	}
+
+	ip, _ := NewIndexFlatIP(d)
+	defer ip.Close()
+	if _, _, err := SearchL2(ip, queries, k); err == nil {
+		t.Error("SearchL2() on an InnerProduct index should return error")
+	}
+}
+
+// ========================================
+// Int32 ID Interop Tests
+// ========================================
+
+func TestSearchInt32(t *testing.T) {
+	base, _ := NewIndexFlatL2(2)
+	defer base.Close()
+	index, err := NewIndexIDMap(base)
+	if err != nil {
+		t.Fatalf("NewIndexIDMap() failed: %v", err)
+	}
+	defer index.Close()
+
+	if err := AddWithIDsInt32(index, []float32{0, 0, 10, 10}, []int32{7, math.MaxInt32}); err != nil {
+		t.Fatalf("AddWithIDsInt32() failed: %v", err)
+	}
+
+	_, labels, err := SearchInt32(index, []float32{0, 0, 10, 10}, 1)
+	if err != nil {
+		t.Fatalf("SearchInt32() failed: %v", err)
+	}
+	if labels[0] != 7 || labels[1] != math.MaxInt32 {
+		t.Errorf("SearchInt32() labels = %v, want [7 %d]", labels, int32(math.MaxInt32))
+	}
+
+	// Missing results stay -1
+	_, labels, err = SearchInt32(index, []float32{0, 0}, 3)
+	if err != nil {
+		t.Fatalf("SearchInt32(k > ntotal) failed: %v", err)
+	}
+	if labels[2] != -1 {
+		t.Errorf("labels[2] = %d, want -1", labels[2])
+	}
+}
+
+func TestSearchInt32_Overflow(t *testing.T) {
+	base, _ := NewIndexFlatL2(2)
+	defer base.Close()
+	index, _ := NewIndexIDMap(base)
+	defer index.Close()
+
+	// 3 billion would wrap to a negative int32 with a plain cast
+	big := int64(3_000_000_000)
+	if err := index.AddWithIDs([]float32{1, 1}, []int64{big}); err != nil {
+		t.Fatalf("AddWithIDs() failed: %v", err)
+	}
+
+	_, labels, err := SearchInt32(index, []float32{1, 1}, 1)
+	if !errors.Is(err, ErrIDOverflow) {
+		t.Fatalf("SearchInt32() error = %v, want ErrIDOverflow", err)
+	}
+	if labels != nil {
+		t.Errorf("SearchInt32() labels = %v, want nil on overflow", labels)
+	}
+}
+
+func TestAddWithIDsInt32_Invalid(t *testing.T) {
+	flat, _ := NewIndexFlatL2(2)
+	defer flat.Close()
+	if err := AddWithIDsInt32(flat, []float32{1, 1}, []int32{1}); err == nil {
+		t.Error("Expected error for index without custom ID support")
+	}
+
+	base, _ := NewIndexFlatL2(2)
+	defer base.Close()
+	index, _ := NewIndexIDMap(base)
+	defer index.Close()
+	if err := AddWithIDsInt32(index, []float32{1, 1}, []int32{-5}); err == nil {
+		t.Error("Expected error for negative ID")
+	}
+	if err := AddWithIDsInt32(nil, []float32{1, 1}, []int32{1}); err == nil {
+		t.Error("Expected error for nil index")
+	}
+}
+
+// ========================================
+// Column-Major Input Tests
+// ========================================
+
+// toColumnMajor transposes n row-major vectors of dimension d
+func toColumnMajor(vectors []float32, n, d int) []float32 {
+	cols := make([]float32, n*d)
+	for i := 0; i < n; i++ {
+		for j := 0; j < d; j++ {
+			cols[j*n+i] = vectors[i*d+j]
+		}
+	}
+	return cols
+}
+
+func TestAddColumnMajor(t *testing.T) {
+	// Dimensions above and below the transpose tile; the second dataset
+	// spans two add batches
+	for _, tc := range []struct{ n, d int }{{2037, 100}, {DefaultAddBatchSize + 37, 3}} {
+		n, d := tc.n, tc.d
+		vectors := generateVectors(n, d)
+		queries := generateVectors(25, d)
+
+		rowIndex := mustCreateIndexFlatL2(t, d)
+		defer rowIndex.Close()
+		rowIndex.Add(vectors)
+		wantDist, wantLabels, _ := rowIndex.Search(queries, 5)
+
+		colIndex := mustCreateIndexFlatL2(t, d)
+		defer colIndex.Close()
+		if err := AddColumnMajor(colIndex, toColumnMajor(vectors, n, d), n, d); err != nil {
+			t.Fatalf("d=%d: AddColumnMajor() failed: %v", d, err)
+		}
+		if colIndex.Ntotal() != int64(n) {
+			t.Errorf("d=%d: Ntotal() = %d, want %d", d, colIndex.Ntotal(), n)
+		}
+
+		distances, labels, err := SearchColumnMajor(colIndex, toColumnMajor(queries, 25, d), 25, d, 5)
+		if err != nil {
+			t.Fatalf("d=%d: SearchColumnMajor() failed: %v", d, err)
+		}
+		for i := range labels {
+			if labels[i] != wantLabels[i] || distances[i] != wantDist[i] {
+				t.Fatalf("d=%d: result %d = (%d, %v), want (%d, %v)", d, i, labels[i], distances[i], wantLabels[i], wantDist[i])
+			}
+		}
+
+		// Stored vectors are the row-major originals
+		recons, _ := colIndex.(*IndexFlat).ReconstructN(0, int64(n))
+		for i := range recons {
+			if recons[i] != vectors[i] {
+				t.Fatalf("d=%d: stored component %d = %v, want %v", d, i, recons[i], vectors[i])
+			}
+		}
+	}
+}
+
+func TestAddColumnMajor_Invalid(t *testing.T) {
+	index := mustCreateIndexFlatL2(t, 4)
+	defer index.Close()
+
+	if err := AddColumnMajor(nil, make([]float32, 8), 2, 4); err == nil {
+		t.Error("Expected error for nil index")
+	}
+	if err := AddColumnMajor(index, make([]float32, 8), 4, 2); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("AddColumnMajor(d=2) error = %v, want ErrDimensionMismatch", err)
+	}
+	if err := AddColumnMajor(index, make([]float32, 9), 2, 4); !errors.Is(err, ErrInvalidVectors) {
+		t.Errorf("AddColumnMajor(9 floats) error = %v, want ErrInvalidVectors", err)
+	}
+	if _, _, err := SearchColumnMajor(index, make([]float32, 8), 3, 4, 1); !errors.Is(err, ErrInvalidVectors) {
+		t.Errorf("SearchColumnMajor(8 floats, nq=3) error = %v, want ErrInvalidVectors", err)
+	}
+	if err := AddColumnMajor(index, nil, 0, 4); err != nil || index.Ntotal() != 0 {
+		t.Errorf("AddColumnMajor(empty) = %v, Ntotal() = %d, want nil, 0", err, index.Ntotal())
+	}
+}
+
 func TestDistanceMismatchedLengths(t *testing.T) {
 	a := []float32{1.0, 2.0, 3.0}
 	b := []float32{4.0, 5.0}
@@ -315,6 +659,217 @@ func TestDistanceMismatchedLengths(t *testing.T) {
 	}
 }
 
+// ========================================
+// Held-Out Evaluation Tests
+// ========================================
+
+func TestTrainOnly(t *testing.T) {
+	d := 8
+	index := mustCreateGenericIndex(t, d, "IVF4,Flat")
+	defer index.Close()
+	vectors := generateVectors(500, d)
+
+	if err := TrainOnly(index, vectors); err != nil {
+		t.Fatalf("TrainOnly() failed: %v", err)
+	}
+	if !index.IsTrained() || index.Ntotal() != 0 {
+		t.Errorf("after TrainOnly: IsTrained() = %v, Ntotal() = %d, want true, 0", index.IsTrained(), index.Ntotal())
+	}
+
+	index.Add(vectors)
+	if err := TrainOnly(index, vectors); err == nil {
+		t.Error("Expected error for an index that already holds vectors")
+	}
+}
+
+func TestAddExcluding(t *testing.T) {
+	d, n := 8, 200
+	vectors := generateVectors(n, d)
+	heldOut := []int64{0, 5, 5, 99, 199}
+	isHeldOut := map[int64]bool{}
+	for _, id := range heldOut {
+		isHeldOut[id] = true
+	}
+	queries := make([]float32, 0, len(heldOut)*d)
+	for _, id := range heldOut {
+		queries = append(queries, vectors[id*int64(d):(id+1)*int64(d)]...)
+	}
+
+	for _, description := range []string{"Flat", "IDMap,Flat", "IVF4,Flat"} {
+		index := mustCreateGenericIndex(t, d, description)
+		if err := TrainOnly(index, vectors); err != nil {
+			t.Fatalf("%s: TrainOnly() failed: %v", description, err)
+		}
+		rowLabels, err := AddExcluding(index, vectors, heldOut)
+		if err != nil {
+			t.Fatalf("%s: AddExcluding() failed: %v", description, err)
+		}
+		if len(rowLabels) != n {
+			t.Fatalf("%s: len(labels) = %d, want %d", description, len(rowLabels), n)
+		}
+		for _, id := range heldOut {
+			if rowLabels[id] != -1 {
+				t.Errorf("%s: labels[%d] = %d, want -1 for a held-out row", description, id, rowLabels[id])
+			}
+		}
+		if want := int64(n - len(isHeldOut)); index.Ntotal() != want {
+			t.Errorf("%s: Ntotal() = %d, want %d", description, index.Ntotal(), want)
+		}
+		index.SetNprobe(4)
+
+		// Held-out vectors are not in the index, so none is its own match
+		distances, labels, err := index.Search(queries, n)
+		if err != nil {
+			t.Fatalf("%s: Search() failed: %v", description, err)
+		}
+		for i, dist := range distances {
+			if labels[i] >= 0 && dist == 0 {
+				t.Errorf("%s: held-out query %d found at distance 0 (label %d)", description, i/n, labels[i])
+				break
+			}
+		}
+
+		// Rows map to the labels search returns; indexes that store IDs
+		// keep the row numbers of the kept vectors
+		_, labels, _ = index.Search(vectors[7*d:8*d], 1)
+		if labels[0] != rowLabels[7] {
+			t.Errorf("%s: row 7 returned as %d, want labels[7] = %d", description, labels[0], rowLabels[7])
+		}
+		want := int64(7 - 2) // rows 0 and 5 come before row 7
+		if description != "Flat" {
+			want = 7
+		}
+		if rowLabels[7] != want {
+			t.Errorf("%s: labels[7] = %d, want %d", description, rowLabels[7], want)
+		}
+		index.Close()
+	}
+
+	index := mustCreateIndexFlatL2(t, d)
+	defer index.Close()
+	if _, err := AddExcluding(index, vectors, []int64{int64(n)}); err == nil {
+		t.Error("Expected error for excluded row out of range")
+	}
+	if _, err := AddExcluding(index, vectors[:d+1], nil); err == nil {
+		t.Error("Expected error for vectors not a multiple of d")
+	}
+}
+
+func TestAddExcluding_IDCollision(t *testing.T) {
+	d, n := 8, 200
+	vectors := generateVectors(n, d)
+
+	for _, description := range []string{"IDMap,Flat", "IVF4,Flat"} {
+		index := mustCreateGenericIndex(t, d, description)
+		if err := TrainOnly(index, vectors); err != nil {
+			t.Fatalf("%s: TrainOnly() failed: %v", description, err)
+		}
+		// Ntotal() is 1 afterwards, so AddExcluding would reuse ID 150
+		if err := index.(*GenericIndex).AddWithIDs(vectors[:d], []int64{150}); err != nil {
+			t.Fatalf("%s: AddWithIDs() failed: %v", description, err)
+		}
+
+		if _, err := AddExcluding(index, vectors, []int64{0}); err == nil {
+			t.Errorf("%s: AddExcluding() with a colliding ID should return error", description)
+		}
+		if index.Ntotal() != 1 {
+			t.Errorf("%s: Ntotal() = %d, want 1 after a rejected AddExcluding()", description, index.Ntotal())
+		}
+
+		// Excluding the colliding row leaves no collision
+		if _, err := AddExcluding(index, vectors, []int64{149}); err != nil {
+			t.Errorf("%s: AddExcluding() failed: %v", description, err)
+		}
+		index.Close()
+	}
+}
+
+// ========================================
+// Deduplication Tests
+// ========================================
+
+func TestDeduplicate(t *testing.T) {
+	d := 16
+	nBase := 200
+	base := generateVectors(nBase, d)
+
+	// Every 10th base vector appears again as an exact copy, every 7th as a
+	// slightly perturbed copy; the copies go after the originals
+	vectors := append([]float32{}, base...)
+	nExact := 0
+	for i := 0; i < nBase; i += 10 {
+		vectors = append(vectors, base[i*d:(i+1)*d]...)
+		nExact++
+	}
+	for i := 0; i < nBase; i += 7 {
+		v := append([]float32{}, base[i*d:(i+1)*d]...)
+		v[0] += 1e-3
+		vectors = append(vectors, v...)
+	}
+
+	t.Run("exact", func(t *testing.T) {
+		unique, kept, err := Deduplicate(vectors, d, 0)
+		if err != nil {
+			t.Fatalf("Deduplicate failed: %v", err)
+		}
+		// Only the exact copies collapse; perturbed copies are distinct
+		if dropped := len(vectors)/d - len(kept); dropped != nExact {
+			t.Errorf("dropped %d vectors, want %d", dropped, nExact)
+		}
+		if len(unique) != len(kept)*d {
+			t.Errorf("len(unique) = %d, want %d", len(unique), len(kept)*d)
+		}
+		for i := 0; i < nBase; i++ {
+			if kept[i] != int64(i) {
+				t.Fatalf("kept[%d] = %d, want originals kept in order", i, kept[i])
+			}
+		}
+	})
+
+	t.Run("near", func(t *testing.T) {
+		unique, kept, err := Deduplicate(vectors, d, 1e-4)
+		if err != nil {
+			t.Fatalf("Deduplicate failed: %v", err)
+		}
+		if len(kept) != nBase {
+			t.Fatalf("kept %d vectors, want %d", len(kept), nBase)
+		}
+		for i, idx := range kept {
+			if idx != int64(i) {
+				t.Fatalf("kept[%d] = %d, want %d", i, idx, i)
+			}
+		}
+		for i := range base {
+			if unique[i] != base[i] {
+				t.Fatalf("unique[%d] = %v, want %v", i, unique[i], base[i])
+			}
+		}
+	})
+
+	t.Run("signed zero", func(t *testing.T) {
+		negZero := float32(math.Copysign(0, -1))
+		_, kept, err := Deduplicate([]float32{0, 1, negZero, 1}, 2, 0)
+		if err != nil {
+			t.Fatalf("Deduplicate failed: %v", err)
+		}
+		if len(kept) != 1 {
+			t.Errorf("kept %v, want [0]", kept)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, _, err := Deduplicate(vectors[:d+1], d, 0); !errors.Is(err, ErrDimensionMismatch) {
+			t.Errorf("ragged input error = %v, want ErrDimensionMismatch", err)
+		}
+		if _, _, err := Deduplicate(vectors, d, -1); !errors.Is(err, ErrInvalidRadius) {
+			t.Errorf("negative radius error = %v, want ErrInvalidRadius", err)
+		}
+		if _, _, err := Deduplicate(vectors, 0, 0); !errors.Is(err, ErrInvalidDimension) {
+			t.Errorf("d=0 error = %v, want ErrInvalidDimension", err)
+		}
+	})
+}
+
 // ========================================
 // Batch Operations Tests
 // ========================================
@@ -335,6 +890,132 @@ func TestBatchL2Distance(t *testing.T) {
 	}
 }
 
+func TestBatchL2DistanceFloat64(t *testing.T) {
+	// Large-magnitude, high-dimensional vectors whose float32 sums round
+	d := 65536
+	rng := rand.New(rand.NewSource(42))
+	queries := make([]float32, d)
+	database := make([]float32, 2*d)
+	for k := 0; k < d; k++ {
+		queries[k] = 1e4 + rng.Float32()
+		database[k] = 1e4 + rng.Float32()
+		database[d+k] = -1e4 + rng.Float32()
+	}
+
+	// Exact reference with high-precision arithmetic
+	exact := func(x, y []float32) float64 {
+		sum := new(big.Float).SetPrec(256)
+		for k := range x {
+			diff := new(big.Float).SetPrec(256).Sub(big.NewFloat(float64(x[k])), big.NewFloat(float64(y[k])))
+			sum.Add(sum, diff.Mul(diff, diff))
+		}
+		f, _ := sum.Sqrt(sum).Float64()
+		return f
+	}
+
+	got64, err := BatchL2DistanceFloat64(queries, database, d)
+	if err != nil {
+		t.Fatalf("BatchL2DistanceFloat64 failed: %v", err)
+	}
+	got32, _ := BatchL2Distance(queries, database, d)
+
+	for j := 0; j < 2; j++ {
+		want := exact(queries, database[j*d:(j+1)*d])
+		err64 := math.Abs(got64[j]-want) / want
+		err32 := math.Abs(float64(got32[j])-want) / want
+		if err64 > 1e-12 {
+			t.Errorf("distance %d: float64 relative error = %g, want <= 1e-12", j, err64)
+		}
+		if err64 > err32 {
+			t.Errorf("distance %d: float64 relative error %g exceeds float32 error %g", j, err64, err32)
+		}
+	}
+
+	if _, err := BatchL2DistanceFloat64(queries, database, 0); err == nil {
+		t.Error("Expected error for d = 0")
+	}
+	if _, err := BatchL2DistanceFloat64(queries[:d-1], database, d); err == nil {
+		t.Error("Expected error for length not a multiple of d")
+	}
+}
+
+func TestCosineSimilarityFloat64(t *testing.T) {
+	d := 65536
+	rng := rand.New(rand.NewSource(7))
+	a := make([]float32, d)
+	b := make([]float32, d)
+	for k := range a {
+		a[k] = 1e4 + rng.Float32()
+		b[k] = 1e4 + rng.Float32()
+	}
+
+	// Exact reference with high-precision arithmetic
+	dot := new(big.Float).SetPrec(256)
+	normA := new(big.Float).SetPrec(256)
+	normB := new(big.Float).SetPrec(256)
+	for k := range a {
+		x, y := big.NewFloat(float64(a[k])), big.NewFloat(float64(b[k]))
+		dot.Add(dot, new(big.Float).SetPrec(256).Mul(x, y))
+		normA.Add(normA, new(big.Float).SetPrec(256).Mul(x, x))
+		normB.Add(normB, new(big.Float).SetPrec(256).Mul(y, y))
+	}
+	den := new(big.Float).SetPrec(256).Mul(normA, normB)
+	want, _ := new(big.Float).SetPrec(256).Quo(dot, den.Sqrt(den)).Float64()
+
+	got64, err := CosineSimilarityFloat64(a, b)
+	if err != nil {
+		t.Fatalf("CosineSimilarityFloat64 failed: %v", err)
+	}
+	got32, _ := CosineSimilarity(a, b)
+
+	err64 := math.Abs(got64 - want)
+	err32 := math.Abs(float64(got32) - want)
+	if err64 > 1e-12 {
+		t.Errorf("float64 error = %g, want <= 1e-12", err64)
+	}
+	if err64 > err32 {
+		t.Errorf("float64 error %g exceeds float32 error %g", err64, err32)
+	}
+	t.Logf("cosine error: float32 = %g, float64 = %g", err32, err64)
+
+	if _, err := CosineSimilarityFloat64(a, b[:1]); err == nil {
+		t.Error("Expected error for mismatched lengths")
+	}
+	if _, err := CosineSimilarityFloat64([]float32{0, 0}, []float32{1, 0}); err == nil {
+		t.Error("Expected error for zero vector")
+	}
+}
+
+func TestPairwiseL2(t *testing.T) {
+	d := 2
+	a := []float32{0, 0, 1, 1, -1, 2}
+	b := []float32{3, 4, 1, 1, 5, 10}
+
+	distances, err := PairwiseL2(a, b, d)
+	if err != nil {
+		t.Fatalf("PairwiseL2() failed: %v", err)
+	}
+	want := []float32{5, 0, 10}
+	if len(distances) != len(want) {
+		t.Fatalf("len(distances) = %d, want %d", len(distances), len(want))
+	}
+	for i := range want {
+		if distances[i] != want[i] {
+			t.Errorf("distances[%d] = %v, want %v", i, distances[i], want[i])
+		}
+	}
+
+	if _, err := PairwiseL2(a, b[:4], d); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("PairwiseL2() with different counts error = %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := PairwiseL2(a[:3], b[:3], d); err == nil {
+		t.Error("Expected error for length not a multiple of d")
+	}
+	if _, err := PairwiseL2(a, b, 0); err == nil {
+		t.Error("Expected error for d = 0")
+	}
+}
+
 func TestBatchInnerProduct(t *testing.T) {
 	d := 3
 	queries := []float32{1.0, 0.0, 0.0, 0.0, 1.0, 0.0} // 2 queries
@@ -410,3 +1091,90 @@ func TestGetIndexSize(t *testing.T) {
 		t.Errorf("Expected size %d, got %d", expectedSize, size)
 	}
 }
+
+func TestIndexParams_IVF(t *testing.T) {
+	d := 16
+	index, err := IndexFactory(d, "IVF8,PQ4x4", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer index.Close()
+
+	if err := index.SetNprobe(3); err != nil {
+		t.Fatalf("SetNprobe() failed: %v", err)
+	}
+
+	params := IndexParams(index)
+	want := map[string]interface{}{
+		"d":          d,
+		"ntotal":     int64(0),
+		"is_trained": false,
+		"metric":     "L2",
+		"nlist":      8,
+		"nprobe":     3,
+		"M":          4,
+		"nbits":      4,
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("IndexParams()[%q] = %v, want %v", key, params[key], value)
+		}
+	}
+
+	// Runtime changes are reflected
+	index.SetNprobe(5)
+	if got := IndexParams(index)["nprobe"]; got != 5 {
+		t.Errorf("IndexParams()[\"nprobe\"] after SetNprobe(5) = %v, want 5", got)
+	}
+	if _, ok := params["efSearch"]; ok {
+		t.Error("IndexParams() on IVF index should not report efSearch")
+	}
+}
+
+func TestIndexParams_HNSWAndWrappers(t *testing.T) {
+	hnsw, err := IndexFactory(8, "HNSW16", MetricL2)
+	if err != nil {
+		t.Fatalf("IndexFactory() failed: %v", err)
+	}
+	defer hnsw.Close()
+	hnsw.SetEfSearch(48)
+
+	params := IndexParams(hnsw)
+	if params["efSearch"] != 48 {
+		t.Errorf("IndexParams()[\"efSearch\"] = %v, want 48", params["efSearch"])
+	}
+	if params["M"] != 16 {
+		t.Errorf("IndexParams()[\"M\"] = %v, want 16", params["M"])
+	}
+	if _, ok := params["nlist"]; ok {
+		t.Error("IndexParams() on HNSW index should not report nlist")
+	}
+
+	pq, err := NewIndexPQ(8, 2, 6, MetricL2)
+	if err != nil {
+		t.Fatalf("NewIndexPQ() failed: %v", err)
+	}
+	defer pq.Close()
+	if params := IndexParams(pq); params["M"] != 2 || params["nbits"] != 6 {
+		t.Errorf("IndexParams() on PQ2x6 = M %v, nbits %v, want 2 and 6", params["M"], params["nbits"])
+	}
+
+	ivf, err := NewIndexIVFFlatAuto(8, 4, MetricInnerProduct)
+	if err != nil {
+		t.Fatalf("NewIndexIVFFlatAuto() failed: %v", err)
+	}
+	defer ivf.Close()
+	idmap, err := NewIndexIDMap(ivf)
+	if err != nil {
+		t.Fatalf("NewIndexIDMap() failed: %v", err)
+	}
+	defer idmap.Close()
+
+	base, ok := IndexParams(idmap)["base"].(map[string]interface{})
+	if !ok {
+		t.Fatal("IndexParams() on IDMap should report base params")
+	}
+	if base["nlist"] != 4 || base["metric"] != "InnerProduct" {
+		t.Errorf("base params = %v, want nlist 4 and metric InnerProduct", base)
+	}
+}